@@ -7,8 +7,9 @@ import (
 	"time"
 )
 
-func Setup(dbFile string) {
-	d, err := sql.Open("sqlite3", "./"+dbFile+"?_journal=WAL&_timeout=5000")
+// OpenDB connects to the SQLite file without touching its schema.
+func OpenDB(dbFile string) {
+	d, err := sql.Open("sqlite3", "./"+dbFile+"?_journal=WAL&_timeout=5000&_foreign_keys=on")
 	if err != nil {
 		panic(err)
 	}
@@ -22,11 +23,16 @@ func Setup(dbFile string) {
 	}
 
 	db.DB = d
+}
+
+func Setup(dbFile string) {
+	OpenDB(dbFile)
 
-	err = db.InitDB(dbFile)
+	if err := db.InitDB(); err != nil {
+		log.Fatal("Database migration failed: ", err)
+	}
 
-	err = db.SeedDB()
-	if err != nil {
+	if err := db.SeedDB(); err != nil {
 		log.Fatal("Database seeding failed: ", err)
 	}
 }
@@ -0,0 +1,162 @@
+// Package audio recognises MP3, FLAC and Ogg (Vorbis or Opus) files and
+// reads their length and tags: ID3v2 and ID3v1 for MP3, Vorbis comments for
+// the others. It does not decode audio.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	MP3  Format = "mp3"
+	FLAC Format = "flac"
+	Ogg  Format = "ogg"
+)
+
+var (
+	ErrUnsupported = errors.New("audio: unsupported format")
+	ErrMalformed   = errors.New("audio: malformed file")
+)
+
+// MIMEType is the Content-Type the format is served with.
+func (f Format) MIMEType() string {
+	switch f {
+	case MP3:
+		return "audio/mpeg"
+	case FLAC:
+		return "audio/flac"
+	case Ogg:
+		return "audio/ogg"
+	}
+	return "application/octet-stream"
+}
+
+// Metadata is what could be read from a file. Tags that are missing are
+// left empty, and Length is zero when it cannot be worked out.
+type Metadata struct {
+	Format      Format
+	Length      time.Duration
+	Title       string
+	Artist      string
+	TrackNumber int
+}
+
+// HeaderSize is how many bytes Detect needs to recognise a format.
+const HeaderSize = 4
+
+// Detect recognises a format from the first bytes of a file. It returns ""
+// when the format is not supported.
+func Detect(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return MP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		return MP3
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return FLAC
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return Ogg
+	}
+	return ""
+}
+
+// Parse reads the metadata of a file of the given size.
+func Parse(r io.ReaderAt, size int64) (Metadata, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return Metadata{}, ErrUnsupported
+	}
+
+	var meta Metadata
+	var err error
+	switch format := Detect(header); format {
+	case MP3:
+		meta, err = parseMP3(r, size)
+	case FLAC:
+		meta, err = parseFLAC(r)
+	case Ogg:
+		meta, err = parseOgg(r, size)
+	default:
+		return Metadata{}, ErrUnsupported
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = ErrMalformed
+	}
+	return meta, err
+}
+
+// applyVorbisComments reads a Vorbis comment block, as used by FLAC and
+// Ogg: a vendor string followed by KEY=value pairs, all length-prefixed in
+// little endian.
+func applyVorbisComments(meta *Metadata, block []byte) error {
+	field := func() (string, error) {
+		if len(block) < 4 {
+			return "", ErrMalformed
+		}
+		n := binary.LittleEndian.Uint32(block)
+		if uint64(n) > uint64(len(block)-4) {
+			return "", ErrMalformed
+		}
+		value := string(block[4 : 4+n])
+		block = block[4+n:]
+		return value, nil
+	}
+
+	if _, err := field(); err != nil {
+		return err
+	}
+	if len(block) < 4 {
+		return ErrMalformed
+	}
+	count := binary.LittleEndian.Uint32(block)
+	block = block[4:]
+
+	var albumArtist string
+	for range count {
+		comment, err := field()
+		if err != nil {
+			return err
+		}
+		key, value, ok := strings.Cut(comment, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			meta.Title = strings.TrimSpace(value)
+		case "ARTIST":
+			meta.Artist = strings.TrimSpace(value)
+		case "ALBUMARTIST":
+			albumArtist = strings.TrimSpace(value)
+		case "TRACKNUMBER":
+			meta.TrackNumber = trackNumber(value)
+		}
+	}
+	if meta.Artist == "" {
+		meta.Artist = albumArtist
+	}
+	return nil
+}
+
+// trackNumber reads "3" or "3/12" as 3.
+func trackNumber(value string) int {
+	number, _, _ := strings.Cut(strings.TrimSpace(value), "/")
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// samplesDuration is how long a number of samples plays at a sample rate.
+func samplesDuration(samples, sampleRate int64) time.Duration {
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
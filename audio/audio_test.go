@@ -0,0 +1,191 @@
+package audio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"goMusic/audio"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parse(t *testing.T, file []byte) audio.Metadata {
+	t.Helper()
+	meta, err := audio.Parse(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return meta
+}
+
+// id3v2 builds an ID3v2 tag of the given version from frame IDs and their
+// raw contents.
+func id3v2(version byte, frames ...string) []byte {
+	var body bytes.Buffer
+	for i := 0; i < len(frames); i += 2 {
+		body.WriteString(frames[i])
+		size := len(frames[i+1])
+		if version == 4 {
+			body.Write([]byte{byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)})
+		} else {
+			binary.Write(&body, binary.BigEndian, uint32(size))
+		}
+		body.Write([]byte{0, 0})
+		body.WriteString(frames[i+1])
+	}
+	body.Write(make([]byte, 32)) // padding
+
+	size := body.Len()
+	header := []byte{'I', 'D', '3', version, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body.Bytes()...)
+}
+
+// mpegFrames returns count MPEG 1 layer III frames at 128 kbit/s and
+// 44.1 kHz, each 417 bytes long.
+func mpegFrames(count int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, count)
+}
+
+func TestParseMP3(t *testing.T) {
+	utf16 := "\x01\xFF\xFEB\x00j\x00\xF6\x00r\x00k\x00"
+	file := append(id3v2(3, "TIT2", "\x00J\xF3ga", "TPE1", utf16, "TRCK", "\x003/12"), mpegFrames(100)...)
+
+	meta := parse(t, file)
+	want := audio.Metadata{Format: audio.MP3, Title: "Jóga", Artist: "Björk", TrackNumber: 3, Length: 2606250 * time.Microsecond}
+	if meta != want {
+		t.Errorf("got %+v, want %+v", meta, want)
+	}
+}
+
+func TestParseMP3TagPastEnd(t *testing.T) {
+	// The header claims a 256 MiB tag in a file of a few hundred bytes.
+	file := append([]byte{'I', 'D', '3', 4, 0, 0, 0x7F, 0x7F, 0x7F, 0x7F}, mpegFrames(1)...)
+	if _, err := audio.Parse(bytes.NewReader(file), int64(len(file))); !errors.Is(err, audio.ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+}
+
+func TestParseMP3Length(t *testing.T) {
+	tlen := append(id3v2(4, "TIT2", "\x03Yellow", "TLEN", "\x03266000"), mpegFrames(10)...)
+	if meta := parse(t, tlen); meta.Length != 266*time.Second || meta.Title != "Yellow" {
+		t.Errorf("TLEN: got %+v", meta)
+	}
+
+	xing := mpegFrames(10)
+	copy(xing[4+32:], "Xing\x00\x00\x00\x01\x00\x00\x03\xE8") // 1000 frames
+	if meta := parse(t, xing); meta.Length.Round(time.Millisecond) != 26122*time.Millisecond {
+		t.Errorf("Xing: got %v", meta.Length)
+	}
+}
+
+func TestParseID3v1(t *testing.T) {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:], "Shiver")
+	copy(tag[33:], "Coldplay")
+	tag[126] = 7
+	file := append(mpegFrames(10), tag...)
+
+	meta := parse(t, file)
+	if meta.Title != "Shiver" || meta.Artist != "Coldplay" || meta.TrackNumber != 7 {
+		t.Errorf("got %+v", meta)
+	}
+	if want := time.Duration(4170*8) * time.Second / 128000; meta.Length != want {
+		t.Errorf("the ID3v1 tag was counted as audio: got %v, want %v", meta.Length, want)
+	}
+}
+
+func vorbisComments(comments ...string) []byte {
+	var b bytes.Buffer
+	field := func(s string) {
+		binary.Write(&b, binary.LittleEndian, uint32(len(s)))
+		b.WriteString(s)
+	}
+	field("test encoder")
+	binary.Write(&b, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		field(c)
+	}
+	return b.Bytes()
+}
+
+func TestParseFLAC(t *testing.T) {
+	streamInfo := make([]byte, 34)
+	// 44.1 kHz, stereo, 16 bit, 441000 samples.
+	copy(streamInfo[10:], []byte{0x0A, 0xC4, 0x42, 0xF0, 0x00, 0x06, 0xBA, 0xA8})
+	comments := vorbisComments("title=Trouble", "ALBUMARTIST=Coldplay", "TRACKNUMBER=4/10")
+
+	var file bytes.Buffer
+	file.WriteString("fLaC")
+	file.Write([]byte{0, 0, 0, 34})
+	file.Write(streamInfo)
+	file.Write([]byte{0x80 | 4, 0, byte(len(comments) >> 8), byte(len(comments))})
+	file.Write(comments)
+
+	meta := parse(t, file.Bytes())
+	want := audio.Metadata{Format: audio.FLAC, Title: "Trouble", Artist: "Coldplay", TrackNumber: 4, Length: 10 * time.Second}
+	if meta != want {
+		t.Errorf("got %+v, want %+v", meta, want)
+	}
+
+	if _, err := audio.Parse(bytes.NewReader(file.Bytes()[:20]), 20); !errors.Is(err, audio.ErrMalformed) {
+		t.Errorf("truncated file: expected ErrMalformed, got %v", err)
+	}
+}
+
+// oggPage wraps packets in a single Ogg page.
+func oggPage(granule int64, packets ...[]byte) []byte {
+	var segments []byte
+	var body []byte
+	for _, p := range packets {
+		for n := len(p); ; n -= 255 {
+			segments = append(segments, byte(min(n, 255)))
+			if n < 255 {
+				break
+			}
+		}
+		body = append(body, p...)
+	}
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint64(header[6:], uint64(granule))
+	header[26] = byte(len(segments))
+	return append(append(header, segments...), body...)
+}
+
+func TestParseOgg(t *testing.T) {
+	ident := make([]byte, 30)
+	copy(ident, "\x01vorbis")
+	binary.LittleEndian.PutUint32(ident[12:], 48000)
+	// A long comment makes the packet span several lacing segments.
+	comments := append([]byte("\x03vorbis"), vorbisComments("TITLE=Sparks", "ARTIST=Coldplay", "COMMENT="+strings.Repeat("x", 600))...)
+
+	file := append(oggPage(0, ident), oggPage(0, comments)...)
+	file = append(file, oggPage(48000*90, make([]byte, 100))...)
+
+	meta := parse(t, file)
+	want := audio.Metadata{Format: audio.Ogg, Title: "Sparks", Artist: "Coldplay", Length: 90 * time.Second}
+	if meta != want {
+		t.Errorf("Vorbis: got %+v, want %+v", meta, want)
+	}
+
+	opusHead := []byte("OpusHead\x01\x02\x38\x01\x80\xBB\x00\x00\x00\x00\x00")
+	opusTags := append([]byte("OpusTags"), vorbisComments("TITLE=Clocks")...)
+	file = append(oggPage(0, opusHead, opusTags), oggPage(48000*5+312, make([]byte, 10))...)
+	if meta := parse(t, file); meta.Title != "Clocks" || meta.Length != 5*time.Second {
+		t.Errorf("Opus: got %+v", meta)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	file := []byte("RIFF\x00\x00\x00\x00WAVE")
+	if format := audio.Detect(file); format != "" {
+		t.Errorf("Detect: got %q", format)
+	}
+	if _, err := audio.Parse(bytes.NewReader(file), int64(len(file))); !errors.Is(err, audio.ErrUnsupported) {
+		t.Errorf("Parse: expected ErrUnsupported, got %v", err)
+	}
+}
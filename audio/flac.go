@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// parseFLAC walks the metadata blocks after the "fLaC" marker. STREAMINFO
+// gives the length and VORBIS_COMMENT the tags.
+func parseFLAC(r io.ReaderAt) (Metadata, error) {
+	meta := Metadata{Format: FLAC}
+	offset := int64(4)
+	for {
+		header := make([]byte, 4)
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return meta, err
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		offset += 4
+
+		if blockType == flacStreamInfo || blockType == flacVorbisComment {
+			block := make([]byte, length)
+			if _, err := r.ReadAt(block, offset); err != nil {
+				return meta, err
+			}
+			if blockType == flacStreamInfo {
+				if len(block) < 18 {
+					return meta, ErrMalformed
+				}
+				sampleRate := int64(block[10])<<12 | int64(block[11])<<4 | int64(block[12])>>4
+				samples := int64(block[13]&0x0F)<<32 | int64(binary.BigEndian.Uint32(block[14:18]))
+				if sampleRate > 0 {
+					meta.Length = samplesDuration(samples, sampleRate)
+				}
+			} else if err := applyVorbisComments(&meta, block); err != nil {
+				return meta, err
+			}
+		}
+
+		offset += length
+		if last {
+			return meta, nil
+		}
+	}
+}
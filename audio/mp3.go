@@ -0,0 +1,364 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	id3v2HeaderSize = 10
+	id3v1Size       = 128
+	// mpegSearchWindow is how far past the tags to look for the first frame.
+	mpegSearchWindow = 64 << 10
+)
+
+// parseMP3 reads an ID3v2 tag at the start of the file and an ID3v1 tag at
+// the end, preferring ID3v2. The length comes from the TLEN frame, a Xing
+// header, or failing those the bitrate of the first frame.
+func parseMP3(r io.ReaderAt, size int64) (Metadata, error) {
+	meta := Metadata{Format: MP3}
+	audioStart, audioEnd := int64(0), size
+
+	header := make([]byte, id3v2HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return meta, err
+	}
+	if bytes.HasPrefix(header, []byte("ID3")) {
+		tagSize, ok := syncsafe(header[6:10])
+		if !ok || id3v2HeaderSize+tagSize > size {
+			return meta, ErrMalformed
+		}
+		tag := make([]byte, tagSize)
+		if _, err := r.ReadAt(tag, id3v2HeaderSize); err != nil {
+			return meta, err
+		}
+		if err := applyID3v2(&meta, header[3], header[5], tag); err != nil {
+			return meta, err
+		}
+
+		audioStart = id3v2HeaderSize + tagSize
+		if header[5]&0x10 != 0 {
+			audioStart += id3v2HeaderSize // footer
+		}
+	}
+
+	if size-audioStart >= id3v1Size {
+		tag := make([]byte, id3v1Size)
+		if _, err := r.ReadAt(tag, size-id3v1Size); err != nil {
+			return meta, err
+		}
+		if bytes.HasPrefix(tag, []byte("TAG")) {
+			applyID3v1(&meta, tag)
+			audioEnd -= id3v1Size
+		}
+	}
+
+	if meta.Length == 0 {
+		length, err := mpegLength(r, audioStart, audioEnd)
+		if err != nil {
+			return meta, err
+		}
+		meta.Length = length
+	}
+	return meta, nil
+}
+
+// applyID3v2 reads the frames of an ID3v2.2, 2.3 or 2.4 tag. Compressed and
+// encrypted frames are skipped.
+func applyID3v2(meta *Metadata, version, flags byte, tag []byte) error {
+	if version < 2 || version > 4 {
+		return nil
+	}
+	if flags&0x80 != 0 && version < 4 {
+		tag = deunsynchronise(tag)
+	}
+	if flags&0x40 != 0 && version > 2 {
+		if len(tag) < 4 {
+			return ErrMalformed
+		}
+		size := int64(binary.BigEndian.Uint32(tag)) + 4
+		if version == 4 {
+			size, _ = syncsafe(tag[:4])
+		}
+		if size > int64(len(tag)) {
+			return ErrMalformed
+		}
+		tag = tag[size:]
+	}
+
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	var albumArtist string
+	for len(tag) >= headerSize && tag[0] != 0 {
+		id := string(tag[:idSize])
+		var size int64
+		var frameFlags byte
+		switch version {
+		case 2:
+			size = int64(tag[3])<<16 | int64(tag[4])<<8 | int64(tag[5])
+		case 3:
+			size = int64(binary.BigEndian.Uint32(tag[4:8]))
+			if tag[9]&0xC0 != 0 {
+				frameFlags = 0x0C // compressed or encrypted
+			}
+		case 4:
+			var ok bool
+			if size, ok = syncsafe(tag[4:8]); !ok {
+				return ErrMalformed
+			}
+			frameFlags = tag[9]
+		}
+		if size > int64(len(tag)-headerSize) {
+			return ErrMalformed
+		}
+		data := tag[headerSize : headerSize+int(size)]
+		tag = tag[headerSize+int(size):]
+
+		if frameFlags&0x0C != 0 {
+			continue
+		}
+		if frameFlags&0x02 != 0 {
+			data = deunsynchronise(data)
+		}
+		if frameFlags&0x01 != 0 && len(data) >= 4 {
+			data = data[4:] // data length indicator
+		}
+
+		switch id {
+		case "TIT2", "TT2":
+			meta.Title = id3Text(data)
+		case "TPE1", "TP1":
+			meta.Artist = id3Text(data)
+		case "TPE2", "TP2":
+			albumArtist = id3Text(data)
+		case "TRCK", "TRK":
+			meta.TrackNumber = trackNumber(id3Text(data))
+		case "TLEN", "TLE":
+			if ms, err := strconv.Atoi(id3Text(data)); err == nil && ms > 0 {
+				meta.Length = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if meta.Artist == "" {
+		meta.Artist = albumArtist
+	}
+	return nil
+}
+
+// applyID3v1 fills in whatever the ID3v2 tag left empty.
+func applyID3v1(meta *Metadata, tag []byte) {
+	field := func(b []byte) string {
+		text, _, _ := bytes.Cut(b, []byte{0})
+		return strings.TrimSpace(latin1(text))
+	}
+	if meta.Title == "" {
+		meta.Title = field(tag[3:33])
+	}
+	if meta.Artist == "" {
+		meta.Artist = field(tag[33:63])
+	}
+	// ID3v1.1 keeps the track number in the last byte of the comment.
+	if meta.TrackNumber == 0 && tag[125] == 0 {
+		meta.TrackNumber = int(tag[126])
+	}
+}
+
+// id3Text decodes a text frame: an encoding byte followed by one or more
+// NUL separated strings, of which the first is returned.
+func id3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var text string
+	switch data[0] {
+	case 0:
+		text = latin1(data[1:])
+	case 1:
+		text = utf16Text(data[1:], true)
+	case 2:
+		text = utf16Text(data[1:], false)
+	default:
+		text = string(data[1:])
+	}
+	text, _, _ = strings.Cut(text, "\x00")
+	return strings.TrimSpace(text)
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// utf16Text decodes UTF-16, big endian unless a byte order mark says
+// otherwise.
+func utf16Text(b []byte, bom bool) string {
+	order := binary.ByteOrder(binary.BigEndian)
+	if bom && len(b) >= 2 {
+		if b[0] == 0xFF && b[1] == 0xFE {
+			order = binary.LittleEndian
+		}
+		if (b[0] == 0xFF && b[1] == 0xFE) || (b[0] == 0xFE && b[1] == 0xFF) {
+			b = b[2:]
+		}
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// deunsynchronise undoes the ID3 unsynchronisation scheme, which inserts a
+// zero byte after every 0xFF.
+func deunsynchronise(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+// syncsafe reads a 28-bit integer stored in the low seven bits of four
+// bytes.
+func syncsafe(b []byte) (int64, bool) {
+	var n int64
+	for _, c := range b[:4] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int64(c)
+	}
+	return n, true
+}
+
+// mpegFrame is the part of an MPEG audio frame header that matters for the
+// length.
+type mpegFrame struct {
+	version         int // 1, 2, or 25 for MPEG 2.5
+	layer           int
+	bitrate         int // bits per second
+	sampleRate      int
+	samplesPerFrame int
+	size            int
+	mono            bool
+}
+
+var (
+	// Bitrates in kbit/s by bitrate index, for MPEG 1 layers I-III and
+	// MPEG 2 and 2.5 layer I and layers II-III.
+	mpeg1Bitrates = [3][16]int{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	}
+	mpeg2Bitrates = [2][16]int{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mpegSampleRates = map[int][3]int{
+		1:  {44100, 48000, 32000},
+		2:  {22050, 24000, 16000},
+		25: {11025, 12000, 8000},
+	}
+)
+
+// parseMPEGFrame decodes a four byte frame header.
+func parseMPEGFrame(h []byte) (mpegFrame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+	versionBits, layerBits := (h[1]>>3)&3, (h[1]>>1)&3
+	bitrateIndex, rateIndex := h[2]>>4, (h[2]>>2)&3
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	f := mpegFrame{version: map[byte]int{0: 25, 2: 2, 3: 1}[versionBits], layer: int(4 - layerBits), mono: h[3]>>6 == 3}
+	if f.version == 1 {
+		f.bitrate = mpeg1Bitrates[f.layer-1][bitrateIndex] * 1000
+	} else {
+		f.bitrate = mpeg2Bitrates[min(f.layer-1, 1)][bitrateIndex] * 1000
+	}
+	f.sampleRate = mpegSampleRates[f.version][rateIndex]
+
+	padding := int(h[2]>>1) & 1
+	switch {
+	case f.layer == 1:
+		f.samplesPerFrame = 384
+		f.size = (12*f.bitrate/f.sampleRate + padding) * 4
+	case f.layer == 3 && f.version != 1:
+		f.samplesPerFrame = 576
+		f.size = 72*f.bitrate/f.sampleRate + padding
+	default:
+		f.samplesPerFrame = 1152
+		f.size = 144*f.bitrate/f.sampleRate + padding
+	}
+	return f, true
+}
+
+// mpegLength finds the first frame of the audio between start and end. A
+// Xing or Info header in it gives the frame count of a VBR file; otherwise
+// the file is assumed to have a constant bitrate.
+func mpegLength(r io.ReaderAt, start, end int64) (time.Duration, error) {
+	window := make([]byte, min(mpegSearchWindow, max(end-start, 0)))
+	n, err := r.ReadAt(window, start)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	window = window[:n]
+
+	for i := 0; i+4 <= len(window); i++ {
+		frame, ok := parseMPEGFrame(window[i:])
+		if !ok {
+			continue
+		}
+		// A real frame is followed by another one, unless it is the last.
+		if next := i + frame.size; next+4 <= len(window) {
+			if _, ok := parseMPEGFrame(window[next:]); !ok {
+				continue
+			}
+		}
+
+		if frames := xingFrames(window[i:], frame); frames > 0 {
+			return samplesDuration(frames*int64(frame.samplesPerFrame), int64(frame.sampleRate)), nil
+		}
+		audioSize := end - start - int64(i)
+		return time.Duration(float64(audioSize) * 8 / float64(frame.bitrate) * float64(time.Second)), nil
+	}
+	return 0, nil
+}
+
+// xingFrames reads the frame count from a Xing or Info header, which VBR
+// encoders write in place of the first frame's audio.
+func xingFrames(b []byte, frame mpegFrame) int64 {
+	if frame.layer != 3 {
+		return 0
+	}
+	sideInfo := 32
+	switch {
+	case frame.version == 1 && frame.mono, frame.version != 1 && !frame.mono:
+		sideInfo = 17
+	case frame.version != 1 && frame.mono:
+		sideInfo = 9
+	}
+
+	offset := 4 + sideInfo
+	if len(b) < offset+12 {
+		return 0
+	}
+	id := string(b[offset : offset+4])
+	if id != "Xing" && id != "Info" {
+		return 0
+	}
+	if binary.BigEndian.Uint32(b[offset+4:])&1 == 0 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint32(b[offset+8:]))
+}
@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	oggPageHeaderSize = 27
+	// oggMaxPageSize is the largest a page can be: a header, 255 segment
+	// lengths and 255 segments of 255 bytes.
+	oggMaxPageSize = oggPageHeaderSize + 255 + 255*255
+	// oggPacketLimit bounds the header packets; comment packets can be large
+	// because some taggers embed cover art in them.
+	oggPacketLimit = 16 << 20
+)
+
+// parseOgg reads the identification and comment headers, the first two
+// packets of the stream, and works out the length from the granule position
+// of the last page.
+func parseOgg(r io.ReaderAt, size int64) (Metadata, error) {
+	meta := Metadata{Format: Ogg}
+	packets, err := oggHeaderPackets(r, 2)
+	if err != nil {
+		return meta, err
+	}
+	ident, comments := packets[0], packets[1]
+
+	var sampleRate, preSkip int64
+	switch {
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 16:
+		sampleRate = int64(binary.LittleEndian.Uint32(ident[12:16]))
+		if !bytes.HasPrefix(comments, []byte("\x03vorbis")) {
+			return meta, ErrMalformed
+		}
+		comments = comments[7:]
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 12:
+		// Opus granule positions always count samples at 48 kHz.
+		sampleRate = 48000
+		preSkip = int64(binary.LittleEndian.Uint16(ident[10:12]))
+		if !bytes.HasPrefix(comments, []byte("OpusTags")) {
+			return meta, ErrMalformed
+		}
+		comments = comments[8:]
+	default:
+		return meta, ErrUnsupported
+	}
+
+	if err := applyVorbisComments(&meta, comments); err != nil {
+		return meta, err
+	}
+
+	granule, err := oggLastGranule(r, size)
+	if err != nil {
+		return meta, err
+	}
+	if granule > preSkip && sampleRate > 0 {
+		meta.Length = samplesDuration(granule-preSkip, sampleRate)
+	}
+	return meta, nil
+}
+
+// oggHeaderPackets reassembles the first count packets from the pages at
+// the start of the file. A packet ends with a segment shorter than 255
+// bytes and may continue across pages.
+func oggHeaderPackets(r io.ReaderAt, count int) ([][]byte, error) {
+	var packets [][]byte
+	var packet []byte
+	offset := int64(0)
+	for len(packets) < count {
+		header := make([]byte, oggPageHeaderSize)
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(header, []byte("OggS")) {
+			return nil, ErrMalformed
+		}
+		segments := make([]byte, header[26])
+		if _, err := r.ReadAt(segments, offset+oggPageHeaderSize); err != nil {
+			return nil, err
+		}
+		offset += oggPageHeaderSize + int64(len(segments))
+
+		bodySize := 0
+		for _, n := range segments {
+			bodySize += int(n)
+		}
+		body := make([]byte, bodySize)
+		if _, err := r.ReadAt(body, offset); err != nil {
+			return nil, err
+		}
+		offset += int64(bodySize)
+
+		for _, n := range segments {
+			packet = append(packet, body[:n]...)
+			body = body[n:]
+			if len(packet) > oggPacketLimit {
+				return nil, ErrMalformed
+			}
+			if n < 255 {
+				packets = append(packets, packet)
+				packet = nil
+				if len(packets) == count {
+					break
+				}
+			}
+		}
+	}
+	return packets, nil
+}
+
+// oggLastGranule finds the last page in the file and returns its granule
+// position, the number of samples decoded by the end of it. It returns 0
+// when no page is found.
+func oggLastGranule(r io.ReaderAt, size int64) (int64, error) {
+	start := max(0, size-oggMaxPageSize)
+	tail := make([]byte, size-start)
+	if _, err := r.ReadAt(tail, start); err != nil {
+		return 0, err
+	}
+
+	for end := len(tail); end > 0; {
+		i := bytes.LastIndex(tail[:end], []byte("OggS"))
+		if i < 0 {
+			break
+		}
+		if i+14 <= len(tail) {
+			granule := int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+			if granule >= 0 {
+				return granule, nil
+			}
+		}
+		end = i
+	}
+	return 0, nil
+}
@@ -2,36 +2,88 @@ package authentication
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"goMusic/constants"
+	"goMusic/problem"
 	"net/http"
-	"os"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+const (
+	// AccessTokenTTL is how long an access token is accepted. Clients trade
+	// their refresh token for a new one when it runs out.
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a refresh token can be exchanged.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type Claims struct {
-	UserID int `json:"user_id"`
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// Denylist reports access tokens that were revoked before they expired,
+// either by their ID or because every token the user was issued before a
+// point in time has been revoked.
+type Denylist interface {
+	IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error)
+}
+
+var (
+	keys     *KeySet
+	denylist Denylist
+)
+
+// UseKeys sets the keys tokens are signed and verified with.
+func UseKeys(k *KeySet) {
+	keys = k
+}
+
+// UseDenylist makes AuthMiddleware reject the tokens d reports as revoked.
+func UseDenylist(d Denylist) {
+	denylist = d
+}
+
+// GenerateToken issues a signed access token. Each token gets a random ID
+// (the jti claim) so it can be revoked on its own.
+func GenerateToken(userID int, role string) (string, error) {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTKey())
+	if keys == nil {
+		return "", ErrNoKeys
+	}
+	key := keys.current()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
 }
 
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Error(w, r, http.StatusUnauthorized, problem.Unauthorized, "An access token is required.")
 			return
 		}
 
@@ -40,22 +92,84 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			tokenString = authHeader[7:]
 		}
 
+		if keys == nil {
+			problem.InternalError(w, r, ErrNoKeys)
+			return
+		}
+
 		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			return getJWTKey(), nil
-		})
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.verificationKey)
 
 		if err != nil || !token.Valid {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Error(w, r, http.StatusUnauthorized, problem.Unauthorized, "The access token is invalid or has expired.")
 			return
 		}
 
+		if denylist != nil {
+			var issuedAt time.Time
+			if claims.IssuedAt != nil {
+				issuedAt = claims.IssuedAt.Time
+			}
+			revoked, err := denylist.IsRevoked(r.Context(), claims.ID, claims.UserID, issuedAt)
+			if err != nil {
+				problem.InternalError(w, r, err)
+				return
+			}
+			if revoked {
+				problem.Error(w, r, http.StatusUnauthorized, problem.Unauthorized, "The access token has been revoked.")
+				return
+			}
+		}
+
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, "userID", claims.UserID)
+		ctx = context.WithValue(ctx, "role", claims.Role)
+		ctx = context.WithValue(ctx, "claims", claims)
 		next(w, r.WithContext(ctx))
 	}
 }
 
-func getJWTKey() []byte {
-	return []byte(os.Getenv("JWT_SECRET_KEY"))
+// RequireRole only lets requests through when the token's role is one of
+// roles. It reads the role set by AuthMiddleware, so it must be wrapped by it.
+func RequireRole(roles ...constants.Role) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			if !slices.Contains(roles, constants.Role(role)) {
+				problem.Error(w, r, http.StatusForbidden, problem.Forbidden, "Your role does not allow this request.")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// NewRefreshToken returns an opaque refresh token for the client and the hash
+// to store in its place.
+func NewRefreshToken() (token string, hash string, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the stored form of a refresh token. The tokens are
+// random, so a fast hash is enough to keep a database leak from exposing them.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewFamilyID returns an ID for a new chain of rotated refresh tokens.
+func NewFamilyID() (string, error) {
+	return randomToken(16)
+}
+
+func randomToken(size int) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
 }
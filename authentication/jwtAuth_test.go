@@ -1,10 +1,13 @@
 package authentication
 
 import (
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"goMusic/constants"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -12,19 +15,32 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// useTestKeys signs and verifies tokens with a fresh Ed25519 key for the
+// duration of the test.
+func useTestKeys(t *testing.T) *KeySet {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestKey(t, dir, "test", "EdDSA", time.Now())
+	set, err := LoadKeySet(dir, DefaultGracePeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	UseKeys(set)
+	t.Cleanup(func() { UseKeys(nil) })
+	return set
+}
+
 func TestGenerateToken(t *testing.T) {
-	const testSecret = "test-secret-key"
-	os.Setenv("JWT_SECRET_KEY", testSecret)
-	defer os.Unsetenv("JWT_SECRET_KEY")
+	set := useTestKeys(t)
 
 	userID := 1
 
-	token, err := GenerateToken(userID)
+	token, err := GenerateToken(userID, "editor")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 	t.Logf("Generated token: %s", token)
 
-	expirationTime := time.Now().Add(24 * time.Hour)
+	expirationTime := time.Now().Add(AccessTokenTTL)
 	claims := &Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -32,10 +48,10 @@ func TestGenerateToken(t *testing.T) {
 		},
 	}
 	parsedToken, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(testSecret), nil
+		return set.current().private.Public(), nil
 	})
 
 	if err != nil {
@@ -44,26 +60,41 @@ func TestGenerateToken(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.True(t, parsedToken.Valid)
+	assert.Equal(t, "test", parsedToken.Header["kid"])
 	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, "editor", claims.Role)
+	assert.NotEmpty(t, claims.ID)
 
 	expiresAt := claims.ExpiresAt.Time
-	expectedExpiry := time.Now().Add(24 * time.Hour)
+	expectedExpiry := time.Now().Add(AccessTokenTTL)
 	timeDiff := expectedExpiry.Sub(expiresAt)
-	assert.Less(t, timeDiff.Abs(), 5*time.Minute) // Allow 5 minute tolerance
+	assert.Less(t, timeDiff.Abs(), time.Minute) // Allow 1 minute tolerance
+
+	other, err := GenerateToken(userID, "editor")
+	assert.NoError(t, err)
+	otherClaims := &Claims{}
+	_, err = jwt.ParseWithClaims(other, otherClaims, set.verificationKey)
+	assert.NoError(t, err)
+	assert.NotEqual(t, claims.ID, otherClaims.ID)
+}
+
+func TestGenerateTokenWithoutKeys(t *testing.T) {
+	_, err := GenerateToken(1, "viewer")
+	assert.ErrorIs(t, err, ErrNoKeys)
 }
 
 func TestAuthMiddleware(t *testing.T) {
-	os.Setenv("JWT_SECRET_KEY", "test-secret-key")
-	defer os.Unsetenv("JWT_SECRET_KEY")
+	set := useTestKeys(t)
 
 	t.Run("Valid token", func(t *testing.T) {
 		userID := 456
-		token, err := GenerateToken(userID)
+		token, err := GenerateToken(userID, "admin")
 		assert.NoError(t, err)
 
 		nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctxUserID := r.Context().Value("userID")
 			assert.Equal(t, userID, ctxUserID)
+			assert.Equal(t, "admin", r.Context().Value("role"))
 			w.WriteHeader(http.StatusOK)
 		})
 
@@ -111,8 +142,10 @@ func TestAuthMiddleware(t *testing.T) {
 			},
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+		key := set.current()
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+		tokenString, err := token.SignedString(key.private)
 		assert.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/", nil)
@@ -127,9 +160,27 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
+	t.Run("HMAC token", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1})
+		token.Header["kid"] = set.current().ID
+		tokenString, err := token.SignedString([]byte(set.current().private.Public().(ed25519.PublicKey)))
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		rr := httptest.NewRecorder()
+
+		middleware := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("Handler should not be called with a symmetric token")
+		})
+		middleware.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
 	t.Run("Token without Bearer prefix", func(t *testing.T) {
 		userID := 123
-		token, err := GenerateToken(userID)
+		token, err := GenerateToken(userID, "viewer")
 		assert.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/", nil)
@@ -148,3 +199,88 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 	})
 }
+
+func TestRequireRole(t *testing.T) {
+	useTestKeys(t)
+
+	handler := AuthMiddleware(RequireRole(constants.Editor, constants.Admin)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		role string
+		want int
+	}{
+		{"admin", http.StatusNoContent},
+		{"editor", http.StatusNoContent},
+		{"viewer", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run("role "+tt.role, func(t *testing.T) {
+			token, err := GenerateToken(1, tt.role)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("DELETE", "/bands/1", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+type fakeDenylist struct {
+	tokenIDs map[string]bool
+	err      error
+}
+
+func (d fakeDenylist) IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	return d.tokenIDs[tokenID], d.err
+}
+
+func TestAuthMiddlewareDenylist(t *testing.T) {
+	set := useTestKeys(t)
+	defer UseDenylist(nil)
+
+	revoked, err := GenerateToken(1, "viewer")
+	assert.NoError(t, err)
+	valid, err := GenerateToken(1, "viewer")
+	assert.NoError(t, err)
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(revoked, claims, set.verificationKey)
+	assert.NoError(t, err)
+
+	handler := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(token string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	UseDenylist(fakeDenylist{tokenIDs: map[string]bool{claims.ID: true}})
+	assert.Equal(t, http.StatusUnauthorized, serve(revoked))
+	assert.Equal(t, http.StatusOK, serve(valid))
+
+	UseDenylist(fakeDenylist{err: errors.New("database is locked")})
+	assert.Equal(t, http.StatusInternalServerError, serve(valid))
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, hash)
+	assert.Equal(t, hash, HashRefreshToken(token))
+
+	other, _, err := NewRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -0,0 +1,259 @@
+package authentication
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultGracePeriod is how long a retired key keeps verifying tokens after
+// a newer key is added, unless configured otherwise.
+const DefaultGracePeriod = 24 * time.Hour
+
+// ErrNoKeys is returned when the key directory holds no usable private key.
+var ErrNoKeys = errors.New("no signing keys configured")
+
+// SigningKey is one private key from the key directory. Its ID is the file
+// name without the .pem extension and is sent as the kid header.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	// Added is when the key file was written, which is when the key before
+	// it was retired.
+	Added time.Time
+}
+
+// KeySet holds the keys loaded from a directory of PEM encoded RSA or Ed25519
+// private keys. The key whose file name sorts last signs new tokens; older
+// keys only verify, and stop doing so once the grace period after their
+// successor was added has passed.
+type KeySet struct {
+	mu    sync.RWMutex
+	dir   string
+	grace time.Duration
+	keys  []*SigningKey
+}
+
+// LoadKeySet reads every *.pem file in dir. It fails when the directory
+// cannot be read, a key cannot be parsed, or there are no keys at all.
+func LoadKeySet(dir string, grace time.Duration) (*KeySet, error) {
+	set := &KeySet{dir: dir, grace: grace}
+	if err := set.Reload(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Reload re-reads the key directory, picking up rotated keys without a
+// restart. The current keys are kept when the directory is unusable.
+func (s *KeySet) Reload() error {
+	if s.dir == "" {
+		return ErrNoKeys
+	}
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	var keys []*SigningKey
+	for _, path := range paths {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%w in %s", ErrNoKeys, s.dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	return nil
+}
+
+func readKeyFile(path string) (*SigningKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM file")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		ID:    strings.TrimSuffix(filepath.Base(path), ".pem"),
+		Added: info.ModTime(),
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Method, key.private = jwt.SigningMethodRS256, private
+	case ed25519.PrivateKey:
+		key.Method, key.private = jwt.SigningMethodEdDSA, private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// current returns the key new tokens are signed with.
+func (s *KeySet) current() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[len(s.keys)-1]
+}
+
+// active returns the keys that still verify tokens at the given time.
+func (s *KeySet) active(now time.Time) []*SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []*SigningKey
+	for i, key := range s.keys {
+		if i == len(s.keys)-1 || now.Before(s.keys[i+1].Added.Add(s.grace)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// verificationKey is a jwt.Keyfunc that picks the public key named by the
+// token's kid header, rejecting retired keys and mismatched algorithms.
+func (s *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.active(time.Now()) {
+		if key.ID != kid {
+			continue
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+		}
+		return key.private.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// JWK is the public half of a signing key in RFC 7517 form.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys that currently verify tokens.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.active(time.Now()) {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// ServeJWKS publishes the verification keys so other services can check
+// goMusic tokens.
+func (s *KeySet) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(s.JWKS())
+}
+
+// GenerateKeyFile writes a new private key to dir, named after the current
+// time so it sorts after the existing keys and becomes the signing key.
+// alg is "EdDSA" or "RS256".
+func GenerateKeyFile(dir, alg string) (string, error) {
+	var private any
+	var err error
+	switch alg {
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		return "", fmt.Errorf("unsupported algorithm %q: must be EdDSA or RS256", alg)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z")+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", err
+	}
+	return path, file.Close()
+}
@@ -0,0 +1,164 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestKey writes a private key named id to dir, dated added.
+func writeTestKey(t *testing.T, dir, id, alg string, added time.Time) {
+	t.Helper()
+	var private any
+	if alg == "RS256" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private = key
+	} else {
+		_, private, _ = ed25519.GenerateKey(rand.Reader)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, id+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, added, added); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header["kid"].(string)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	t.Run("no directory", func(t *testing.T) {
+		_, err := LoadKeySet("", DefaultGracePeriod)
+		assert.ErrorIs(t, err, ErrNoKeys)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		_, err := LoadKeySet(t.TempDir(), DefaultGracePeriod)
+		assert.ErrorIs(t, err, ErrNoKeys)
+	})
+
+	t.Run("unparseable key", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "bad.pem"), []byte("not a key"), 0o600)
+		_, err := LoadKeySet(dir, DefaultGracePeriod)
+		assert.Error(t, err)
+	})
+
+	t.Run("generated keys", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateKeyFile(dir, "EdDSA")
+		assert.NoError(t, err)
+		_, err = GenerateKeyFile(dir, "HS256")
+		assert.Error(t, err)
+
+		set, err := LoadKeySet(dir, DefaultGracePeriod)
+		assert.NoError(t, err)
+		assert.Equal(t, "EdDSA", set.current().Method.Alg())
+	})
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeTestKey(t, dir, "2024-01", "RS256", now.Add(-72*time.Hour))
+	writeTestKey(t, dir, "2024-02", "EdDSA", now.Add(-48*time.Hour))
+
+	set, err := LoadKeySet(dir, 24*time.Hour)
+	assert.NoError(t, err)
+	UseKeys(set)
+	defer UseKeys(nil)
+
+	// Past its grace period, 2024-01 is neither published nor accepted.
+	assert.Len(t, set.JWKS().Keys, 1)
+
+	oldToken, err := GenerateToken(1, "viewer")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-02", kid(t, oldToken))
+
+	writeTestKey(t, dir, "2024-03", "RS256", now)
+	assert.NoError(t, set.Reload())
+
+	newToken, err := GenerateToken(1, "viewer")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03", kid(t, newToken))
+
+	for _, token := range []string{oldToken, newToken} {
+		_, err := jwt.ParseWithClaims(token, &Claims{}, set.verificationKey)
+		assert.NoError(t, err, "tokens signed by keys within the grace period should verify")
+	}
+
+	jwks := set.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: "2024-02", Use: "sig", Alg: "EdDSA", Curve: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	assert.NotEmpty(t, jwks.Keys[1].N)
+
+	// Once the grace period after 2024-03 was added runs out, 2024-02 retires.
+	os.Chtimes(filepath.Join(dir, "2024-03.pem"), now.Add(-25*time.Hour), now.Add(-25*time.Hour))
+	assert.NoError(t, set.Reload())
+	_, err = jwt.ParseWithClaims(oldToken, &Claims{}, set.verificationKey)
+	assert.Error(t, err)
+	_, err = jwt.ParseWithClaims(newToken, &Claims{}, set.verificationKey)
+	assert.NoError(t, err)
+}
+
+func TestVerificationKeyRejectsMismatchedTokens(t *testing.T) {
+	set := useTestKeys(t)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{UserID: 1})
+	unknown.Header["kid"] = "missing"
+	tokenString, _ := unknown.SignedString(set.current().private)
+	_, err := jwt.ParseWithClaims(tokenString, &Claims{}, set.verificationKey)
+	assert.ErrorContains(t, err, "unknown signing key")
+
+	dir := t.TempDir()
+	writeTestKey(t, dir, "test", "RS256", time.Now())
+	rsaKeys, _ := LoadKeySet(dir, DefaultGracePeriod)
+	wrongAlg := jwt.NewWithClaims(jwt.SigningMethodRS256, &Claims{UserID: 1})
+	wrongAlg.Header["kid"] = "test"
+	tokenString, _ = wrongAlg.SignedString(rsaKeys.current().private)
+	_, err = jwt.ParseWithClaims(tokenString, &Claims{}, set.verificationKey)
+	assert.ErrorContains(t, err, "unexpected signing method")
+}
+
+func TestServeJWKS(t *testing.T) {
+	set := useTestKeys(t)
+
+	rr := httptest.NewRecorder()
+	set.ServeJWKS(rr, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.True(t, strings.Contains(rr.Body.String(), `"kid":"test"`))
+	assert.False(t, strings.Contains(rr.Body.String(), `"d"`), "private key material must not be published")
+}
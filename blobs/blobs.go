@@ -0,0 +1,142 @@
+// Package blobs stores files on local disk under the SHA-256 of their
+// content, so uploading the same file twice keeps one copy.
+package blobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Blob identifies stored content by its hex SHA-256 digest.
+type Blob struct {
+	Digest string
+	Size   int64
+}
+
+// Store keeps blobs in dir, fanned out into subdirectories named after the
+// first two characters of the digest. Uploads are written to dir/tmp first
+// and only moved into place once committed.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create starts a new blob. The caller must Commit or Abort the writer.
+func (s *Store) Create() (*Writer, error) {
+	file, err := os.CreateTemp(filepath.Join(s.dir, "tmp"), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{store: s, file: file, hash: sha256.New()}, nil
+}
+
+// Put stores everything r yields.
+func (s *Store) Put(r io.Reader) (Blob, error) {
+	w, err := s.Create()
+	if err != nil {
+		return Blob{}, err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Abort()
+		return Blob{}, err
+	}
+	return w.Commit()
+}
+
+// Open opens a committed blob for reading.
+func (s *Store) Open(digest string) (*os.File, error) {
+	if !validDigest(digest) {
+		return nil, ErrNotFound
+	}
+	file, err := os.Open(s.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *Store) path(digest string) string {
+	return filepath.Join(s.dir, digest[:2], digest)
+}
+
+// Writer hashes content as it is written to a temporary file. What has been
+// written so far can be read back with ReadAt, so uploads can be inspected
+// before they are committed.
+type Writer struct {
+	store *Store
+	file  *os.File
+	hash  hash.Hash
+	size  int64
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) ReadAt(p []byte, off int64) (int, error) {
+	return w.file.ReadAt(p, off)
+}
+
+func (w *Writer) Size() int64 {
+	return w.size
+}
+
+// Commit moves the content into the store. If the same content is already
+// stored the upload is discarded.
+func (w *Writer) Commit() (Blob, error) {
+	blob := Blob{Digest: hex.EncodeToString(w.hash.Sum(nil)), Size: w.size}
+	if err := w.file.Sync(); err != nil {
+		w.Abort()
+		return Blob{}, err
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return Blob{}, err
+	}
+
+	path := w.store.path(blob.Digest)
+	if _, err := os.Stat(path); err == nil {
+		os.Remove(w.file.Name())
+		return blob, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		os.Remove(w.file.Name())
+		return Blob{}, err
+	}
+	if err := os.Rename(w.file.Name(), path); err != nil {
+		os.Remove(w.file.Name())
+		return Blob{}, err
+	}
+	return blob, nil
+}
+
+// Abort throws the upload away.
+func (w *Writer) Abort() error {
+	w.file.Close()
+	return os.Remove(w.file.Name())
+}
+
+func validDigest(digest string) bool {
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package blobs_test
+
+import (
+	"errors"
+	"goMusic/blobs"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func put(t *testing.T, store *blobs.Store, content string) blobs.Blob {
+	t.Helper()
+	blob, err := store.Put(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := blobs.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := put(t, store, "hello")
+	if blob.Digest != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" || blob.Size != 5 {
+		t.Fatalf("unexpected blob %+v", blob)
+	}
+	if again := put(t, store, "hello"); again != blob {
+		t.Errorf("same content stored as %+v", again)
+	}
+
+	file, err := store.Open(blob.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "hello" {
+		t.Errorf("read back %q", content)
+	}
+
+	if leftovers, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+	for _, digest := range []string{"../../etc/passwd", strings.Repeat("0", 64)} {
+		if _, err := store.Open(digest); !errors.Is(err, blobs.ErrNotFound) {
+			t.Errorf("Open(%q): expected ErrNotFound, got %v", digest, err)
+		}
+	}
+}
+
+func TestAbort(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := blobs.NewStore(dir)
+
+	w, err := store.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("partial"))
+	buf := make([]byte, 4)
+	if _, err := w.ReadAt(buf, 3); err != nil || string(buf) != "tial" {
+		t.Errorf("ReadAt returned %q, %v", buf, err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if leftovers, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(leftovers) != 0 {
+		t.Errorf("aborted upload left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,192 @@
+// Package charts ranks songs and albums by popularity. Plays, purchases and
+// ratings all count, each worth less the longer ago it happened, and the
+// charts of each finished day are stored so they can be looked up later.
+package charts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"math"
+	"slices"
+	"time"
+)
+
+// Size is how many entries a chart has.
+const Size = 100
+
+// What each event is worth before it decays. A rating is worth its share of
+// five stars of ratingWeight.
+const (
+	playWeight     = 1
+	purchaseWeight = 5
+	ratingWeight   = 3
+)
+
+// Config sets how fast events decay: after one half-life an event is worth
+// half as much, after two a quarter, and so on. Each period has its own.
+type Config struct {
+	DailyHalfLife  time.Duration
+	WeeklyHalfLife time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{DailyHalfLife: 12 * time.Hour, WeeklyHalfLife: 72 * time.Hour}
+}
+
+func (c Config) halfLife(period string) time.Duration {
+	if period == models.ChartDaily {
+		return c.DailyHalfLife
+	}
+	return c.WeeklyHalfLife
+}
+
+// periodDays is how many days each period covers.
+var periodDays = map[string]int{models.ChartDaily: 1, models.ChartWeekly: 7}
+
+// Window returns the time range a chart covers: the period ending with its
+// UTC date, up to but not including midnight after it.
+func Window(period, date string) (from, to time.Time, err error) {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	days, ok := periodDays[period]
+	if !ok {
+		return time.Time{}, time.Time{}, errors.New("unknown chart period " + period)
+	}
+	to = day.AddDate(0, 0, 1)
+	return to.AddDate(0, 0, -days), to, nil
+}
+
+// Rank scores each song or album by its events as they stood at to, and
+// returns the Size best, highest score first and then by ID. It depends
+// only on its arguments, so the same events always give the same chart.
+func Rank(events []models.ChartEvent, to time.Time, halfLife time.Duration) []models.ChartEntry {
+	scores := map[int]float64{}
+	for _, event := range events {
+		weight := float64(playWeight)
+		switch event.Type {
+		case models.EventPurchase:
+			weight = purchaseWeight
+		case models.EventRating:
+			weight = ratingWeight * float64(event.Rating) / 5
+		}
+		age := to.Sub(event.At).Hours() / halfLife.Hours()
+		scores[event.ItemId] += weight * math.Pow(0.5, age)
+	}
+
+	entries := make([]models.ChartEntry, 0, len(scores))
+	for itemID, score := range scores {
+		entries = append(entries, models.ChartEntry{ItemId: itemID, Score: math.Round(score*1e4) / 1e4})
+	}
+	slices.SortFunc(entries, func(a, b models.ChartEntry) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ItemId, b.ItemId))
+	})
+	entries = entries[:min(len(entries), Size)]
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+	return entries
+}
+
+// Build computes the chart of kind for the period ending on date, with each
+// entry's position in the chart for the period before: the stored one if
+// there is one, otherwise as computed now.
+func Build(ctx context.Context, store *repositories.Store, config Config, kind, period, date string) (models.Chart, error) {
+	entries, err := rank(ctx, store, config, kind, period, date)
+	if err != nil {
+		return models.Chart{}, err
+	}
+
+	day, _ := time.Parse(time.DateOnly, date)
+	previousDate := day.AddDate(0, 0, -periodDays[period]).Format(time.DateOnly)
+	var previous []models.ChartEntry
+	stored, err := store.Charts.GetChart(ctx, kind, period, previousDate)
+	switch {
+	case err == nil:
+		previous = stored.Entries
+	case errors.Is(err, repositories.ErrNotFound):
+		if previous, err = rank(ctx, store, config, kind, period, previousDate); err != nil {
+			return models.Chart{}, err
+		}
+	default:
+		return models.Chart{}, err
+	}
+
+	positions := map[int]int{}
+	for _, entry := range previous {
+		positions[entry.ItemId] = entry.Position
+	}
+	for i, entry := range entries {
+		if position, ok := positions[entry.ItemId]; ok {
+			entries[i].PreviousPosition = &position
+		}
+	}
+	return models.Chart{Kind: kind, Period: period, Date: date, Entries: entries}, nil
+}
+
+func rank(ctx context.Context, store *repositories.Store, config Config, kind, period, date string) ([]models.ChartEntry, error) {
+	from, to, err := Window(period, date)
+	if err != nil {
+		return nil, err
+	}
+	events, err := store.Charts.Events(ctx, kind, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return Rank(events, to, config.halfLife(period)), nil
+}
+
+// Get returns the chart for date. Charts of days before today are stored
+// the first time they are asked for and read back from then on; today's is
+// still changing, so it is built afresh each time and reported provisional.
+func Get(ctx context.Context, store *repositories.Store, config Config, kind, period, date, today string) (chart models.Chart, provisional bool, err error) {
+	if date >= today {
+		chart, err = Build(ctx, store, config, kind, period, date)
+		return chart, true, err
+	}
+
+	chart, err = store.Charts.GetChart(ctx, kind, period, date)
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return chart, false, err
+	}
+	if chart, err = Build(ctx, store, config, kind, period, date); err != nil {
+		return models.Chart{}, false, err
+	}
+	err = store.Charts.SaveChart(ctx, chart)
+	if errors.Is(err, repositories.ErrConflict) {
+		// Stored concurrently; return that one so everyone sees the same.
+		chart, err = store.Charts.GetChart(ctx, kind, period, date)
+	}
+	return chart, false, err
+}
+
+// Schedule stores yesterday's charts straight away and then checks every
+// hour for a newly finished day, until ctx is cancelled. Failures are passed
+// to onError and retried on the next check.
+func Schedule(ctx context.Context, store *repositories.Store, config Config, onError func(error)) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			today := models.Today()
+			day, _ := time.Parse(time.DateOnly, today)
+			yesterday := day.AddDate(0, 0, -1).Format(time.DateOnly)
+			for _, kind := range []string{models.ChartSongs, models.ChartAlbums} {
+				for _, period := range []string{models.ChartDaily, models.ChartWeekly} {
+					if _, _, err := Get(ctx, store, config, kind, period, yesterday, today); err != nil && ctx.Err() == nil {
+						onError(err)
+					}
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,155 @@
+package charts_test
+
+import (
+	"context"
+	"goMusic/charts"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	from, to, err := charts.Window(models.ChartWeekly, "2026-10-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 9, 25, 0, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Errorf("from = %v, want %v", from, want)
+	}
+	if want := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC); !to.Equal(want) {
+		t.Errorf("to = %v, want %v", to, want)
+	}
+	if _, _, err := charts.Window("monthly", "2026-10-01"); err == nil {
+		t.Error("unknown period: want an error")
+	}
+}
+
+func TestRank(t *testing.T) {
+	end := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	events := []models.ChartEvent{
+		// One half-life before the end: worth half.
+		{ItemId: 1, Type: models.EventPlay, At: end.Add(-12 * time.Hour)},
+		{ItemId: 1, Type: models.EventPlay, At: end.Add(-12 * time.Hour)},
+		// Two half-lives: a quarter of a purchase.
+		{ItemId: 2, Type: models.EventPurchase, At: end.Add(-24 * time.Hour)},
+		// Four stars are 4/5 of a rating.
+		{ItemId: 3, Type: models.EventRating, At: end, Rating: 4},
+		{ItemId: 4, Type: models.EventPlay, At: end.Add(-12 * time.Hour)},
+	}
+	got := charts.Rank(events, end, 12*time.Hour)
+	want := []models.ChartEntry{
+		{Position: 1, ItemId: 3, Score: 2.4},
+		{Position: 2, ItemId: 2, Score: 1.25},
+		{Position: 3, ItemId: 1, Score: 1},
+		{Position: 4, ItemId: 4, Score: 0.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	// Ties are broken by ID, whatever order the events come in.
+	tied := []models.ChartEvent{
+		{ItemId: 9, Type: models.EventPlay, At: end},
+		{ItemId: 5, Type: models.EventPlay, At: end},
+	}
+	if got := charts.Rank(tied, end, time.Hour); got[0].ItemId != 5 || got[1].ItemId != 9 {
+		t.Errorf("tied = %+v, want 5 before 9", got)
+	}
+}
+
+// seedPlays creates songs and records one play of each given song at each
+// given time.
+func seedPlays(t *testing.T, store *repositories.Store, plays map[string][]time.Time) map[string]int {
+	t.Helper()
+	ctx := context.Background()
+	userID, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+	songs := map[string]int{}
+	for _, title := range []string{"Airbag", "Lucky", "Reckoner"} {
+		songs[title], _ = store.Songs.Create(ctx, models.Song{Title: title, Length: 240, Price: 99})
+	}
+	var batch []models.Play
+	for title, times := range plays {
+		id := songs[title]
+		for _, at := range times {
+			batch = append(batch, models.Play{UserId: userID, SongId: &id, PlayedAt: at, Duration: 240})
+		}
+	}
+	if _, err := store.Plays.Record(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	return songs
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	store := repositories.NewMemoryStore()
+	at := func(date string, hour int) time.Time {
+		day, _ := time.Parse(time.DateOnly, date)
+		return day.Add(time.Duration(hour) * time.Hour)
+	}
+	songs := seedPlays(t, store, map[string][]time.Time{
+		"Airbag":   {at("2026-09-30", 10), at("2026-09-30", 11), at("2026-10-01", 9)},
+		"Lucky":    {at("2026-09-30", 12), at("2026-10-01", 20), at("2026-10-01", 21)},
+		"Reckoner": {at("2026-10-01", 22)},
+	})
+	config := charts.DefaultConfig()
+
+	chart, err := charts.Build(ctx, store, config, models.ChartSongs, models.ChartDaily, "2026-10-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, two := 1, 2
+	want := models.Chart{Kind: models.ChartSongs, Period: models.ChartDaily, Date: "2026-10-01", Entries: []models.ChartEntry{
+		{Position: 1, ItemId: songs["Lucky"], Score: 1.6346, PreviousPosition: &two},
+		{Position: 2, ItemId: songs["Reckoner"], Score: 0.8909},
+		{Position: 3, ItemId: songs["Airbag"], Score: 0.4204, PreviousPosition: &one},
+	}}
+	if !reflect.DeepEqual(chart, want) {
+		t.Errorf("got %+v\nwant %+v", chart, want)
+	}
+
+	again, _ := charts.Build(ctx, store, config, models.ChartSongs, models.ChartDaily, "2026-10-01")
+	if !reflect.DeepEqual(again, chart) {
+		t.Errorf("building again gave %+v, want the same chart", again)
+	}
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	store := repositories.NewMemoryStore()
+	day := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	songs := seedPlays(t, store, map[string][]time.Time{"Airbag": {day}})
+	config := charts.DefaultConfig()
+
+	chart, provisional, err := charts.Get(ctx, store, config, models.ChartSongs, models.ChartWeekly, "2026-10-01", "2026-10-05")
+	if err != nil || provisional {
+		t.Fatalf("past chart: provisional %v, %v", provisional, err)
+	}
+	if len(chart.Entries) != 1 || chart.Entries[0].ItemId != songs["Airbag"] {
+		t.Errorf("entries = %+v", chart.Entries)
+	}
+	if _, err := store.Charts.GetChart(ctx, models.ChartSongs, models.ChartWeekly, "2026-10-01"); err != nil {
+		t.Errorf("past chart wasn't stored: %v", err)
+	}
+
+	// Later plays in the week don't change the stored chart.
+	lucky := songs["Lucky"]
+	userID := 1
+	if _, err := store.Plays.Record(ctx, []models.Play{{UserId: userID, SongId: &lucky, PlayedAt: day, Duration: 240}}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _, _ := charts.Get(ctx, store, config, models.ChartSongs, models.ChartWeekly, "2026-10-01", "2026-10-05")
+	if !reflect.DeepEqual(stored, chart) {
+		t.Errorf("stored chart changed to %+v", stored)
+	}
+
+	today, provisional, err := charts.Get(ctx, store, config, models.ChartSongs, models.ChartWeekly, "2026-10-01", "2026-10-01")
+	if err != nil || !provisional {
+		t.Fatalf("today's chart: provisional %v, %v", provisional, err)
+	}
+	if len(today.Entries) != 2 {
+		t.Errorf("today's entries = %+v, want both songs", today.Entries)
+	}
+}
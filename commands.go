@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goMusic/authentication"
+	"goMusic/constants"
+	"goMusic/db"
+	"goMusic/exporter"
+	"goMusic/importer"
+	"goMusic/models"
+	"goMusic/recommend"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+)
+
+const usage = `usage:
+  goMusic                        start the API server
+  goMusic migrate up             apply all pending migrations
+  goMusic migrate down [steps]   revert the last migration (or the last N)
+  goMusic migrate status         list migrations and whether they are applied
+  goMusic user role NAME ROLE    set a user's role to admin, moderator, editor or viewer
+  goMusic key generate [ALG]     add a signing key (EdDSA or RS256) to JWT_KEY_DIR
+  goMusic import [-dry-run] FILE import artists, bands, albums and songs from a .csv or .jsonl file
+  goMusic export [flags]         write the catalog as json, csv or ndjson, or with -album ID or
+                                 -playlist ID a tracklist as xspf or m3u8, to -o FILE or stdout;
+                                 -format overrides the file's extension and -base-url prefixes
+                                 track locations
+  goMusic recommend rebuild      recompute the song similarities behind the recommendations now`
+
+// runCommand handles the subcommands that run instead of the server.
+func runCommand(out io.Writer, dbFile string, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(out, dbFile, args[1:])
+	case "user":
+		return runUser(out, dbFile, args[1:])
+	case "key":
+		return runKey(out, args[1:])
+	case "import":
+		return runImport(out, dbFile, args[1:])
+	case "export":
+		return runExport(out, dbFile, args[1:])
+	case "recommend":
+		return runRecommend(out, dbFile, args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(out, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(out io.Writer, dbFile string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	OpenDB(dbFile)
+	defer CloseDB()
+
+	migrations, err := db.Migrations()
+	if err != nil {
+		return err
+	}
+	migrator := db.NewMigrator(db.DB, migrations)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "database is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(out, "no migrations to revert")
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, s.AppliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+// runUser manages accounts from the command line, which is how the first
+// admin is created.
+func runUser(out io.Writer, dbFile string, args []string) error {
+	if len(args) != 3 || args[0] != "role" {
+		return errors.New(usage)
+	}
+
+	role, err := constants.ParseRole(args[2])
+	if err != nil {
+		return fmt.Errorf("invalid role %q: must be admin, moderator, editor or viewer", args[2])
+	}
+
+	OpenDB(dbFile)
+	defer CloseDB()
+
+	ctx := context.Background()
+	users := repositories.NewSQLiteStore(db.DB).Users
+	user, err := users.GetByUsername(ctx, args[1])
+	if errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("no user named %q", args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := users.UpdateRole(ctx, user.Id, role); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s is now %s\n", user.Username, role)
+	return nil
+}
+
+// runKey creates signing keys. The new key signs tokens once the server is
+// restarted or sent SIGHUP; the previous one keeps verifying for the grace
+// period.
+func runKey(out io.Writer, args []string) error {
+	if len(args) == 0 || len(args) > 2 || args[0] != "generate" {
+		return errors.New(usage)
+	}
+
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return errors.New("JWT_KEY_DIR is not set")
+	}
+	alg := "EdDSA"
+	if len(args) == 2 {
+		alg = args[1]
+	}
+
+	path, err := authentication.GenerateKeyFile(dir, alg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %s key %s\n", alg, path)
+	return nil
+}
+
+// runImport creates the catalog records in an import file, all in one
+// transaction. Every problem with the file is listed first, and with
+// -dry-run nothing is written.
+func runImport(out io.Writer, dbFile string, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dryRun := flags.Bool("dry-run", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	path := flags.Arg(0)
+	format, ok := importer.FormatFor(filepath.Ext(path))
+	if !ok {
+		return fmt.Errorf("cannot tell the format of %s: name it .csv, .jsonl or .ndjson", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	batch, problems, err := importer.Read(file, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, p := range problems {
+		if p.Field == "" {
+			fmt.Fprintf(out, "%s:%d: %s\n", path, p.Line, p.Message)
+		} else {
+			fmt.Fprintf(out, "%s:%d: %s %s\n", path, p.Line, p.Field, p.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found; nothing was imported", len(problems))
+	}
+
+	counts := fmt.Sprintf("%d artists, %d bands, %d albums and %d songs",
+		len(batch.Artists), len(batch.Bands), len(batch.Albums), len(batch.Songs))
+	if *dryRun {
+		fmt.Fprintln(out, "would import", counts)
+		return nil
+	}
+
+	OpenDB(dbFile)
+	defer CloseDB()
+
+	if err := repositories.NewSQLiteStore(db.DB).Imports.Import(context.Background(), batch); err != nil {
+		return fmt.Errorf("importing %s: %w", path, err)
+	}
+	fmt.Fprintln(out, "imported", counts)
+	return nil
+}
+
+func runExport(out io.Writer, dbFile string, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	path := flags.String("o", "", "")
+	formatName := flags.String("format", "", "")
+	albumID := flags.Int("album", 0, "")
+	playlistID := flags.Int("playlist", 0, "")
+	baseURL := flags.String("base-url", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || (*albumID != 0 && *playlistID != 0) {
+		return errors.New(usage)
+	}
+
+	allowed := exporter.CatalogFormats
+	if *albumID != 0 || *playlistID != 0 {
+		allowed = exporter.TracklistFormats
+	}
+	format := exporter.Format(*formatName)
+	if format == "" && *path != "" {
+		format, _ = exporter.FormatFor(filepath.Ext(*path))
+	}
+	if format == "" {
+		format = allowed[0]
+	}
+	if !slices.Contains(allowed, format) {
+		return fmt.Errorf("cannot export this as %q", format)
+	}
+
+	OpenDB(dbFile)
+	defer CloseDB()
+	store := repositories.NewSQLiteStore(db.DB)
+	ctx := context.Background()
+
+	// A tracklist is loaded before the output file is created, so a missing
+	// album or playlist leaves no empty file behind.
+	var list models.Tracklist
+	var err error
+	switch {
+	case *albumID != 0:
+		var album models.Album
+		if album, err = store.Albums.GetByID(ctx, *albumID); err == nil {
+			list, err = viewModels.GetAlbumTracklist(ctx, store, album)
+		}
+	case *playlistID != 0:
+		var playlist models.Playlist
+		if playlist, err = store.Playlists.GetByID(ctx, *playlistID); err == nil {
+			list, err = viewModels.GetPlaylistTracklist(ctx, store, playlist)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("loading the tracklist: %w", err)
+	}
+
+	w := out
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *albumID != 0 || *playlistID != 0 {
+		err = exporter.WriteTracklist(w, list, format, *baseURL)
+	} else {
+		err = exportCatalog(ctx, w, store, format)
+	}
+	if err != nil && *path != "" {
+		os.Remove(*path)
+	}
+	return err
+}
+
+func exportCatalog(ctx context.Context, w io.Writer, store *repositories.Store, format exporter.Format) error {
+	encoder, err := exporter.NewEncoder(w, format)
+	if err != nil {
+		return err
+	}
+	if err := store.Exports.Export(ctx, encoder.Encode); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// runRecommend rebuilds the similarity matrix without waiting for the
+// server's next scheduled rebuild.
+func runRecommend(out io.Writer, dbFile string, args []string) error {
+	if len(args) != 1 || args[0] != "rebuild" {
+		return errors.New(usage)
+	}
+
+	OpenDB(dbFile)
+	defer CloseDB()
+	if err := recommend.Rebuild(context.Background(), repositories.NewSQLiteStore(db.DB)); err != nil {
+		return fmt.Errorf("rebuilding recommendations: %w", err)
+	}
+	fmt.Fprintln(out, "recommendations rebuilt")
+	return nil
+}
@@ -0,0 +1,35 @@
+package constants
+
+import "errors"
+
+// Role controls what a user may change. Viewers can only read, moderators
+// can also edit and delete anyone's reviews, editors can change the catalog
+// and admins can do everything, including managing users.
+type Role string
+
+const (
+	Admin     Role = "admin"
+	Moderator Role = "moderator"
+	Editor    Role = "editor"
+	Viewer    Role = "viewer"
+)
+
+func (r Role) String() string {
+	return string(r)
+}
+
+func ParseRole(s string) (Role, error) {
+	switch s {
+	case "admin":
+		return Admin, nil
+	case "moderator":
+		return Moderator, nil
+	case "editor":
+		return Editor, nil
+	case "viewer":
+		return Viewer, nil
+
+	default:
+		return "", errors.New("invalid role value")
+	}
+}
@@ -0,0 +1,28 @@
+package constants
+
+import "errors"
+
+// Visibility controls who can see a playlist. Public playlists can be read by
+// every user, private ones only by their owner and collaborators.
+type Visibility string
+
+const (
+	Public  Visibility = "public"
+	Private Visibility = "private"
+)
+
+func (v Visibility) String() string {
+	return string(v)
+}
+
+func ParseVisibility(s string) (Visibility, error) {
+	switch s {
+	case "public":
+		return Public, nil
+	case "private":
+		return Private, nil
+
+	default:
+		return "", errors.New("invalid visibility value")
+	}
+}
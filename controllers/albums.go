@@ -1,30 +1,39 @@
 package controllers
 
 import (
-	"goMusic/authentication"
 	"goMusic/services"
 	"net/http"
 	"strconv"
 )
 
-func RegisterAlbumRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /albums", services.GetAlbums)
+func RegisterAlbumRoutes(mux Router, albums *services.AlbumService) {
+	mux.HandleFunc("GET /albums", albums.GetAlbums)
 	mux.HandleFunc("GET /albums/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id, _ := strconv.Atoi(r.PathValue("id"))
-		services.GetAlbumByID(w, r, id)
+		albums.GetAlbumByID(w, r, id)
+	})
+	mux.HandleFunc("GET /albums/{id}/export", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		albums.ExportAlbum(w, r, id)
 	})
 
-	mux.HandleFunc("POST /albums", authentication.AuthMiddleware(services.PostAlbum))
-	mux.HandleFunc("PUT /albums/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("POST /albums", editorsOnly(albums.PostAlbum))
+	mux.HandleFunc("PUT /albums/{id}", editorsOnly(
+		func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.Atoi(r.PathValue("id"))
+			albums.UpdateAlbumByID(w, r, id)
+		},
+	))
+	mux.HandleFunc("DELETE /albums/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.UpdateAlbumByID(w, r, id)
+			albums.DeleteAlbumByID(w, r, id)
 		},
 	))
-	mux.HandleFunc("DELETE /albums/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("PUT /albums/{id}/songs", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.DeleteAlbumByID(w, id)
+			albums.SetAlbumSongs(w, r, id)
 		},
 	))
 }
@@ -1,30 +1,29 @@
 package controllers
 
 import (
-	"goMusic/authentication"
 	"goMusic/services"
 	"net/http"
 	"strconv"
 )
 
-func RegisterArtistRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /artists", services.GetArtists)
+func RegisterArtistRoutes(mux Router, artists *services.ArtistService) {
+	mux.HandleFunc("GET /artists", artists.GetArtists)
 	mux.HandleFunc("GET /artists/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id, _ := strconv.Atoi(r.PathValue("id"))
-		services.GetArtistByID(w, r, id)
+		artists.GetArtistByID(w, r, id)
 	})
 
-	mux.HandleFunc("POST /artists", authentication.AuthMiddleware(services.PostArtist))
-	mux.HandleFunc("PUT /artists/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("POST /artists", editorsOnly(artists.PostArtist))
+	mux.HandleFunc("PUT /artists/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.UpdateArtistByID(w, r, id)
+			artists.UpdateArtistByID(w, r, id)
 		},
 	))
-	mux.HandleFunc("DELETE /artists/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("DELETE /artists/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.DeleteArtistByID(w, id)
+			artists.DeleteArtistByID(w, r, id)
 		},
 	))
 }
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+func RegisterAudioRoutes(mux Router, audio *services.AudioService) {
+	mux.HandleFunc("GET /songs/{id}/audio", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		audio.StreamAudio(w, r, id)
+	})
+	mux.HandleFunc("POST /songs/{id}/audio", editorsOnly(
+		func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.Atoi(r.PathValue("id"))
+			audio.UploadAudio(w, r, id)
+		},
+	))
+}
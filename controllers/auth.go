@@ -4,42 +4,25 @@ import (
 	"goMusic/authentication"
 	"goMusic/services"
 	"net/http"
+	"strconv"
 )
 
-func RegisterAuthRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		RegisterUser(w, r)
-	})
+func RegisterAuthRoutes(mux Router, users *services.UserService) {
+	mux.HandleFunc("POST /register", users.RegisterUser)
+	mux.HandleFunc("POST /login", users.LoginUser)
+	mux.HandleFunc("POST /token/refresh", users.RefreshToken)
+	mux.HandleFunc("POST /logout", authentication.AuthMiddleware(users.Logout))
+	mux.HandleFunc("POST /logout/all", authentication.AuthMiddleware(users.LogoutAll))
 
-	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		LoginUser(w, r)
-	})
+	mux.HandleFunc("GET /profile", authentication.AuthMiddleware(users.GetProfile))
 
-	mux.HandleFunc("/profile", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		GetProfile(w, r)
+	mux.HandleFunc("GET /users", adminsOnly(users.GetUsers))
+	mux.HandleFunc("PUT /users/{id}/role", adminsOnly(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		users.UpdateUserRole(w, r, id)
 	}))
 }
 
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
-	services.RegisterUser(w, r)
-}
-
-func LoginUser(w http.ResponseWriter, r *http.Request) {
-	services.LoginUser(w, r)
-}
-
-func GetProfile(w http.ResponseWriter, r *http.Request) {
-	services.GetProfile(w, r)
+func RegisterKeyRoutes(mux Router, keys *authentication.KeySet) {
+	mux.HandleFunc("GET /.well-known/jwks.json", keys.ServeJWKS)
 }
@@ -1,30 +1,29 @@
 package controllers
 
 import (
-	"goMusic/authentication"
 	"goMusic/services"
 	"net/http"
 	"strconv"
 )
 
-func RegisterBandRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /bands", services.GetBands)
+func RegisterBandRoutes(mux Router, bands *services.BandService) {
+	mux.HandleFunc("GET /bands", bands.GetBands)
 	mux.HandleFunc("GET /bands/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id, _ := strconv.Atoi(r.PathValue("id"))
-		services.GetBandByID(w, r, id)
+		bands.GetBandByID(w, r, id)
 	})
 
-	mux.HandleFunc("POST /bands", authentication.AuthMiddleware(services.PostBand))
-	mux.HandleFunc("PUT /bands/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("POST /bands", editorsOnly(bands.PostBand))
+	mux.HandleFunc("PUT /bands/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.UpdateBandByID(w, r, id)
+			bands.UpdateBandByID(w, r, id)
 		},
 	))
-	mux.HandleFunc("DELETE /bands/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("DELETE /bands/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.DeleteBandByID(w, id)
+			bands.DeleteBandByID(w, r, id)
 		},
 	))
 }
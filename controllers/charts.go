@@ -0,0 +1,9 @@
+package controllers
+
+import "goMusic/services"
+
+// RegisterChartRoutes registers the popularity charts, which are public.
+func RegisterChartRoutes(mux Router, charts *services.ChartService) {
+	mux.HandleFunc("GET /charts/songs", charts.GetSongChart)
+	mux.HandleFunc("GET /charts/albums", charts.GetAlbumChart)
+}
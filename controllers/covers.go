@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+func RegisterCoverRoutes(mux Router, covers *services.CoverService) {
+	mux.HandleFunc("GET /albums/{id}/cover/{variant}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		covers.GetCover(w, r, id, r.PathValue("variant"))
+	})
+	mux.HandleFunc("PUT /albums/{id}/cover", editorsOnly(
+		func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.Atoi(r.PathValue("id"))
+			covers.PutCover(w, r, id)
+		},
+	))
+}
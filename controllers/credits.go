@@ -0,0 +1,14 @@
+package controllers
+
+import "goMusic/services"
+
+// RegisterCreditRoutes registers the routes that credit artists and bands
+// on albums and songs, and list what each of them is credited on.
+func RegisterCreditRoutes(mux Router, credits *services.CreditService) {
+	mux.HandleFunc("GET /artists/{id}/credits", withID(credits.GetArtistCredits))
+	mux.HandleFunc("GET /bands/{id}/credits", withID(credits.GetBandCredits))
+
+	mux.HandleFunc("POST /albums/{id}/credits", editorWithID(credits.AddAlbumCredit))
+	mux.HandleFunc("POST /songs/{id}/credits", editorWithID(credits.AddSongCredit))
+	mux.HandleFunc("DELETE /credits/{id}", editorWithID(credits.DeleteCredit))
+}
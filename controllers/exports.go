@@ -0,0 +1,9 @@
+package controllers
+
+import "goMusic/services"
+
+// RegisterExportRoutes registers the catalog export. It reads the whole
+// catalog, so like the import it is kept to editors.
+func RegisterExportRoutes(mux Router, exports *services.ExportService) {
+	mux.HandleFunc("GET /export", editorsOnly(exports.GetExport))
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import "goMusic/services"
+
+func RegisterGenreRoutes(mux Router, genres *services.GenreService) {
+	mux.HandleFunc("GET /genres", genres.GetGenres)
+	mux.HandleFunc("GET /genres/{id}", withID(genres.GetGenreByID))
+	mux.HandleFunc("GET /genres/{id}/albums", withID(genres.GetGenreAlbums))
+	mux.HandleFunc("GET /genres/{id}/songs", withID(genres.GetGenreSongs))
+
+	mux.HandleFunc("POST /genres", editorsOnly(genres.PostGenre))
+	mux.HandleFunc("PUT /genres/{id}", editorWithID(genres.UpdateGenreByID))
+	mux.HandleFunc("DELETE /genres/{id}", editorWithID(genres.DeleteGenreByID))
+
+	mux.HandleFunc("PUT /albums/{id}/genres", editorWithID(genres.SetAlbumGenres))
+	mux.HandleFunc("PUT /songs/{id}/genres", editorWithID(genres.SetSongGenres))
+}
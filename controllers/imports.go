@@ -0,0 +1,8 @@
+package controllers
+
+import "goMusic/services"
+
+// RegisterImportRoutes registers the bulk catalog import.
+func RegisterImportRoutes(mux Router, imports *services.ImportService) {
+	mux.HandleFunc("POST /import", editorsOnly(imports.PostImport))
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import "goMusic/services"
+
+func RegisterLabelRoutes(mux Router, labels *services.LabelService) {
+	mux.HandleFunc("GET /labels", labels.GetLabels)
+	mux.HandleFunc("GET /labels/{id}", withID(labels.GetLabelByID))
+	mux.HandleFunc("GET /labels/{id}/albums", withID(labels.GetLabelAlbums))
+
+	mux.HandleFunc("POST /labels", editorsOnly(labels.PostLabel))
+	mux.HandleFunc("PUT /labels/{id}", editorWithID(labels.UpdateLabelByID))
+	mux.HandleFunc("DELETE /labels/{id}", editorWithID(labels.DeleteLabelByID))
+}
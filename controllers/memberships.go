@@ -0,0 +1,14 @@
+package controllers
+
+import "goMusic/services"
+
+// RegisterMembershipRoutes registers the routes that record which artists
+// played in which bands, and when.
+func RegisterMembershipRoutes(mux Router, memberships *services.MembershipService) {
+	mux.HandleFunc("GET /bands/{id}/members", withID(memberships.GetBandMembers))
+	mux.HandleFunc("GET /artists/{id}/bands", withID(memberships.GetArtistBands))
+
+	mux.HandleFunc("POST /bands/{id}/members", editorWithID(memberships.AddBandMember))
+	mux.HandleFunc("PUT /memberships/{id}", editorWithID(memberships.UpdateMembership))
+	mux.HandleFunc("DELETE /memberships/{id}", editorWithID(memberships.DeleteMembership))
+}
@@ -0,0 +1,741 @@
+package controllers
+
+import (
+	"encoding/json"
+	"goMusic/authentication"
+	"goMusic/constants"
+	"goMusic/models"
+	"goMusic/openapi"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// RegisterDocsRoutes serves the OpenAPI document and a Swagger UI for it.
+func RegisterDocsRoutes(mux Router) {
+	document, _ := json.Marshal(APISpec().Document())
+
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	})
+	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("GET /docs/{file...}", httpSwagger.Handler(httpSwagger.URL("/openapi.json")))
+}
+
+// APISpec documents every route registered by RegisterRoutes, keyed by its
+// pattern. A test fails when the two drift apart.
+func APISpec() openapi.Spec {
+	operations := map[string]openapi.Operation{
+		"POST /register": {
+			Summary: "Register a new user", Tags: []string{"Authentication"},
+			Request: viewModels.RegisterRequest{}, Response: viewModels.AuthResponse{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict},
+		},
+		"POST /login": {
+			Summary: "Log in", Tags: []string{"Authentication"},
+			Request: viewModels.LoginRequest{}, Response: viewModels.AuthResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		"POST /token/refresh": {
+			Summary: "Exchange a refresh token for a new token pair", Tags: []string{"Authentication"},
+			Description: "Each refresh token can be used once. Reusing one revokes every token from the same login.",
+			Request:     viewModels.RefreshRequest{}, Response: viewModels.AuthResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		"POST /logout": {
+			Summary: "Revoke the current token", Tags: []string{"Authentication"}, Auth: openapi.Authenticated,
+			Description: "Also revokes the refresh token from the same login when it is given.",
+			Request:     viewModels.RefreshRequest{}, OptionalRequest: true, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest},
+		},
+		"POST /logout/all": {
+			Summary: "Revoke every token issued to the user", Tags: []string{"Authentication"}, Auth: openapi.Authenticated,
+			Status: http.StatusNoContent,
+		},
+		"GET /profile": {
+			Summary: "Get the authenticated user", Tags: []string{"Users"}, Auth: openapi.Authenticated,
+			Response: models.User{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /users": {
+			Summary: "List users and their roles", Tags: []string{"Users"}, Auth: constants.Admin.String(),
+			Response: []models.User{},
+		},
+		"PUT /users/{id}/role": {
+			Summary: "Change a user's role", Tags: []string{"Users"}, Auth: constants.Admin.String(),
+			Request: viewModels.UpdateRoleRequest{}, Response: models.User{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"GET /.well-known/jwks.json": {
+			Summary: "Public keys for verifying tokens", Tags: []string{"Authentication"},
+			Response: authentication.JWKS{},
+		},
+		"GET /search": {
+			Summary: "Search albums, artists, bands and songs", Tags: []string{"Search"},
+			Query: []openapi.Param{
+				{Name: "q", Description: "Words to match, each as a prefix", Schema: &openapi.Schema{Type: "string"}, Required: true},
+				{Name: "limit", Description: "Maximum number of results (1-50, default 20)", Schema: &openapi.Schema{Type: "integer"}},
+			},
+			Response: []viewModels.SearchResultViewModel{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /openapi.json": {
+			Summary: "This OpenAPI document", Tags: []string{"Documentation"},
+		},
+		"GET /docs": {
+			Summary: "Redirect to the Swagger UI", Tags: []string{"Documentation"}, Status: http.StatusMovedPermanently,
+		},
+		"GET /docs/{file...}": {
+			Summary: "Swagger UI", Tags: []string{"Documentation"},
+		},
+	}
+
+	maps.Copy(operations, playlistOperations())
+	maps.Copy(operations, orderOperations())
+	maps.Copy(operations, audioOperations())
+	maps.Copy(operations, coverOperations())
+	maps.Copy(operations, genreOperations())
+	maps.Copy(operations, tagOperations())
+	maps.Copy(operations, creditOperations())
+	maps.Copy(operations, membershipOperations())
+	maps.Copy(operations, importOperations())
+	maps.Copy(operations, exportOperations())
+	maps.Copy(operations, reviewOperations())
+	maps.Copy(operations, playOperations())
+	maps.Copy(operations, recommendationOperations())
+	maps.Copy(operations, chartOperations())
+	maps.Copy(operations, labelOperations())
+	maps.Copy(operations, catalogOperations("/albums", "Albums", "album", collectionParams(repositories.AlbumQuery),
+		models.Album{}, query.Page[viewModels.AlbumViewModel]{}, viewModels.DetailedAlbumViewModel{}))
+	maps.Copy(operations, withAsOf("/artists", catalogOperations("/artists", "Artists", "artist", collectionParams(repositories.ArtistQuery),
+		models.Artist{}, query.Page[viewModels.ArtistViewModel]{}, viewModels.ArtistViewModel{})))
+	maps.Copy(operations, withAsOf("/bands", catalogOperations("/bands", "Bands", "band", collectionParams(repositories.BandQuery),
+		models.Band{}, query.Page[viewModels.BandViewModel]{}, viewModels.BandViewModel{})))
+	maps.Copy(operations, catalogOperations("/songs", "Songs", "song", collectionParams(repositories.SongQuery),
+		models.Song{}, query.Page[viewModels.SongViewModel]{}, viewModels.DetailedSongViewModel{}))
+
+	return openapi.Spec{
+		Title: "goMusic API", Version: "1.0.0", Operations: operations,
+		Error: problem.Problem{}, ErrorContentType: problem.ContentType,
+	}
+}
+
+// catalogOperations documents the five routes every catalog collection has.
+func catalogOperations(path, tag, noun string, params []openapi.Param, model, page, detail any) map[string]openapi.Operation {
+	editor := constants.Editor.String()
+	return map[string]openapi.Operation{
+		"GET " + path: {
+			Summary: "List " + strings.ToLower(tag), Tags: []string{tag}, Query: params,
+			Response: page, Errors: []int{http.StatusBadRequest},
+		},
+		"GET " + path + "/{id}": {
+			Summary: "Get a " + noun, Tags: []string{tag},
+			Response: detail, Errors: []int{http.StatusNotFound},
+		},
+		"POST " + path: {
+			Summary: "Create a " + noun, Tags: []string{tag}, Auth: editor,
+			Request: model, Status: http.StatusCreated, Errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+		},
+		"PUT " + path + "/{id}": {
+			Summary: "Update a " + noun, Tags: []string{tag}, Auth: editor,
+			Request: model, Response: model, Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+		"DELETE " + path + "/{id}": {
+			Summary: "Delete a " + noun, Tags: []string{tag}, Auth: editor,
+			Status: http.StatusNoContent, Errors: []int{http.StatusNotFound},
+		},
+	}
+}
+
+// withAsOf documents the as_of parameter on the reads of a collection whose
+// age and status are computed from its dates.
+func withAsOf(path string, operations map[string]openapi.Operation) map[string]openapi.Operation {
+	asOf := openapi.Param{Name: "as_of", Description: "Compute age and status on this YYYY-MM-DD date instead of today", Schema: &openapi.Schema{Type: "string", Format: "date"}}
+	for _, key := range []string{"GET " + path, "GET " + path + "/{id}"} {
+		op := operations[key]
+		op.Query = append(slices.Clone(op.Query), asOf)
+		if !slices.Contains(op.Errors, http.StatusBadRequest) {
+			op.Errors = append([]int{http.StatusBadRequest}, op.Errors...)
+		}
+		operations[key] = op
+	}
+	return operations
+}
+
+// playlistOperations documents the playlist routes. Playlists a user cannot
+// see answer 404 rather than 403.
+func playlistOperations() map[string]openapi.Operation {
+	tags := []string{"Playlists"}
+	auth := openapi.Authenticated
+	detail := viewModels.DetailedPlaylistViewModel{}
+	return map[string]openapi.Operation{
+		"GET /playlists": {
+			Summary: "List the playlists you own or collaborate on", Tags: tags, Auth: auth,
+			Response: []viewModels.PlaylistViewModel{},
+		},
+		"GET /playlists/invitations": {
+			Summary: "List the playlists you have been invited to", Tags: tags, Auth: auth,
+			Response: []viewModels.PlaylistViewModel{},
+		},
+		"POST /playlists": {
+			Summary: "Create a playlist", Tags: tags, Auth: auth,
+			Description: "New playlists are private unless visibility is public.",
+			Request:     viewModels.PlaylistRequest{}, Response: detail, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest},
+		},
+		"GET /playlists/{id}": {
+			Summary: "Get a playlist and its tracks", Tags: tags, Auth: auth,
+			Response: detail, Errors: []int{http.StatusNotFound},
+		},
+		"PUT /playlists/{id}": {
+			Summary: "Update a playlist", Tags: tags, Auth: auth, Description: "Only the owner can update a playlist.",
+			Request: viewModels.PlaylistRequest{}, Response: detail,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		},
+		"DELETE /playlists/{id}": {
+			Summary: "Delete a playlist", Tags: tags, Auth: auth, Description: "Only the owner can delete a playlist.",
+			Status: http.StatusNoContent, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+		},
+		"POST /playlists/{id}/tracks": {
+			Summary: "Add a song to a playlist", Tags: tags, Auth: auth,
+			Description: "The song is inserted at position, or appended when position is 0 or past the end.",
+			Request:     viewModels.AddTrackRequest{}, Response: detail, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+		"PUT /playlists/{id}/tracks": {
+			Summary: "Reorder a playlist's tracks", Tags: tags, Auth: auth,
+			Description: "order lists every current position in the new order. It is rejected with a 409 when it does not match the playlist's tracks.",
+			Request:     viewModels.ReorderTracksRequest{}, Response: detail,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+		},
+		"POST /playlists/{id}/tracks/move": {
+			Summary: "Move a track to another position", Tags: tags, Auth: auth,
+			Request: viewModels.MoveTrackRequest{}, Response: detail,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		},
+		"DELETE /playlists/{id}/tracks/{position}": {
+			Summary: "Remove a track from a playlist", Tags: tags, Auth: auth,
+			Status: http.StatusNoContent, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+		},
+		"POST /playlists/{id}/collaborators": {
+			Summary: "Invite a collaborator", Tags: tags, Auth: auth,
+			Description: "Only the owner can invite users. They can change the tracks once they accept.",
+			Request:     viewModels.InviteRequest{}, Response: detail, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"DELETE /playlists/{id}/collaborators/{user_id}": {
+			Summary: "Remove a collaborator", Tags: tags, Auth: auth,
+			Description: "The owner can remove anyone. Other users can remove themselves to decline an invitation or leave a playlist.",
+			Status:      http.StatusNoContent, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+		},
+		"POST /playlists/{id}/invitation": {
+			Summary: "Accept an invitation to collaborate", Tags: tags, Auth: auth,
+			Response: detail, Errors: []int{http.StatusNotFound},
+		},
+	}
+}
+
+// audioOperations documents the upload and streaming of song audio.
+func audioOperations() map[string]openapi.Operation {
+	tags := []string{"Songs"}
+	return map[string]openapi.Operation{
+		"GET /songs/{id}/audio": {
+			Summary: "Stream a song's audio", Tags: tags,
+			Description: "Supports Range requests for seeking. The ETag is the file's SHA-256 digest.",
+			Response:    openapi.Binary{}, ContentType: "audio/*",
+			Errors: []int{http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable},
+		},
+		"POST /songs/{id}/audio": {
+			Summary: "Upload a song's audio", Tags: tags, Auth: constants.Editor.String(),
+			Description: "Accepts MP3, FLAC and Ogg files and replaces any earlier upload. The song's length is taken from the file. " +
+				"Its title, artist and track number tags are returned as suggestions; with apply=true the title is applied and the matching artist or band is credited as the performer.",
+			Query:   []openapi.Param{{Name: "apply", Description: "Apply the title and artist tags to the song", Schema: &openapi.Schema{Type: "boolean"}}},
+			Request: audioUpload{}, RequestContentType: "multipart/form-data",
+			Response: viewModels.AudioUploadViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge,
+				http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+		},
+	}
+}
+
+// audioUpload is the multipart form POST /songs/{id}/audio reads.
+type audioUpload struct {
+	File openapi.Binary `json:"file" validate:"required"`
+}
+
+// coverOperations documents the upload and serving of album covers.
+func coverOperations() map[string]openapi.Operation {
+	tags := []string{"Albums"}
+	return map[string]openapi.Operation{
+		"GET /albums/{id}/cover/{variant}": {
+			Summary: "Get an album's cover", Tags: tags,
+			Description: "variant is original, large, medium or small. The ETag is the image's SHA-256 digest. " +
+				"The URLs in an album's cover_urls carry a version and may be cached indefinitely.",
+			Query:    []openapi.Param{{Name: "v", Description: "Version from cover_urls", Schema: &openapi.Schema{Type: "string"}}},
+			Response: openapi.Binary{}, ContentType: "image/*",
+			Errors: []int{http.StatusNotFound},
+		},
+		"PUT /albums/{id}/cover": {
+			Summary: "Upload an album's cover", Tags: tags, Auth: constants.Editor.String(),
+			Description: "Accepts a JPEG or PNG between 200 and 4096 pixels wide and high, and replaces any earlier cover. " +
+				"Thumbnails fitting 600, 300 and 100 pixel squares are made from it.",
+			Request: openapi.Binary{}, RequestContentType: "image/*",
+			Response: viewModels.DetailedAlbumViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge,
+				http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+		},
+	}
+}
+
+// genreOperations documents the genre tree and the genres of albums and
+// songs.
+// labelOperations documents the record label routes.
+func labelOperations() map[string]openapi.Operation {
+	tags := []string{"Labels"}
+	editor := constants.Editor.String()
+	return map[string]openapi.Operation{
+		"GET /labels": {
+			Summary: "List labels", Tags: tags, Query: collectionParams(repositories.LabelQuery),
+			Response: query.Page[viewModels.LabelViewModel]{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /labels/{id}": {
+			Summary: "Get a label", Tags: tags,
+			Response: viewModels.LabelViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /labels/{id}/albums": {
+			Summary: "List a label's albums", Tags: tags,
+			Description: "Ordered by release date, albums without one last.",
+			Response:    []viewModels.AlbumViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"POST /labels": {
+			Summary: "Create a label", Tags: tags, Auth: editor,
+			Request: models.Label{}, Response: viewModels.LabelViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest},
+		},
+		"PUT /labels/{id}": {
+			Summary: "Update a label", Tags: tags, Auth: editor,
+			Request: models.Label{}, Response: viewModels.LabelViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"DELETE /labels/{id}": {
+			Summary: "Delete a label", Tags: tags, Auth: editor,
+			Description: "The label's albums are kept without a label.",
+			Status:      http.StatusNoContent, Errors: []int{http.StatusNotFound},
+		},
+	}
+}
+
+func genreOperations() map[string]openapi.Operation {
+	tags := []string{"Genres"}
+	editor := constants.Editor.String()
+	return map[string]openapi.Operation{
+		"GET /genres": {
+			Summary: "List genres", Tags: tags,
+			Description: "Every genre, ordered by name. Subgenres carry their parent_id.",
+			Response:    []viewModels.GenreViewModel{},
+		},
+		"GET /genres/{id}": {
+			Summary: "Get a genre", Tags: tags,
+			Response: viewModels.DetailedGenreViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /genres/{id}/albums": {
+			Summary: "List a genre's albums", Tags: tags,
+			Description: "Includes the albums of every subgenre.",
+			Response:    []viewModels.AlbumViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /genres/{id}/songs": {
+			Summary: "List a genre's songs", Tags: tags,
+			Description: "Includes the songs of every subgenre.",
+			Response:    []viewModels.SongViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"POST /genres": {
+			Summary: "Create a genre", Tags: tags, Auth: editor,
+			Request: models.Genre{}, Response: viewModels.DetailedGenreViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"PUT /genres/{id}": {
+			Summary: "Update a genre", Tags: tags, Auth: editor,
+			Description: "A genre cannot be moved under itself or one of its subgenres.",
+			Request:     models.Genre{}, Response: viewModels.DetailedGenreViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"DELETE /genres/{id}": {
+			Summary: "Delete a genre", Tags: tags, Auth: editor,
+			Description: "Genres with subgenres cannot be deleted.",
+			Status:      http.StatusNoContent, Errors: []int{http.StatusNotFound, http.StatusConflict},
+		},
+		"PUT /albums/{id}/genres": {
+			Summary: "Set an album's genres", Tags: []string{"Albums"}, Auth: editor,
+			Request: viewModels.GenresRequest{}, Response: viewModels.DetailedAlbumViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+		"PUT /songs/{id}/genres": {
+			Summary: "Set a song's genres", Tags: []string{"Songs"}, Auth: editor,
+			Request: viewModels.GenresRequest{}, Response: viewModels.DetailedSongViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+	}
+}
+
+// tagOperations documents tag counts and tagging every kind of catalog
+// entry.
+func tagOperations() map[string]openapi.Operation {
+	operations := map[string]openapi.Operation{
+		"GET /tags": {
+			Summary: "List tags", Tags: []string{"Tags"},
+			Description: "Tags in use with the number of entries carrying each, most used first.",
+			Query: []openapi.Param{{Name: "type", Description: "Count only album, song, artist or band tags",
+				Schema: &openapi.Schema{Type: "string", Enum: []string{models.TagAlbum, models.TagSong, models.TagArtist, models.TagBand}}}},
+			Response: []viewModels.TagCountViewModel{}, Errors: []int{http.StatusBadRequest},
+		},
+	}
+	for path, entityType := range taggedCollections {
+		tags := []string{"Tags"}
+		operations["POST "+path+"/{id}/tags"] = openapi.Operation{
+			Summary: "Tag a " + entityType, Tags: tags, Auth: openapi.Authenticated,
+			Description: "Tag names are trimmed and lower-cased. Responds with the " + entityType + "'s tags.",
+			Request:     viewModels.TagRequest{}, Response: []string{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		}
+		operations["DELETE "+path+"/{id}/tags/{name}"] = openapi.Operation{
+			Summary: "Remove a tag from a " + entityType, Tags: tags, Auth: constants.Editor.String(),
+			Description: "Responds with the " + entityType + "'s remaining tags.",
+			Response:    []string{}, Errors: []int{http.StatusNotFound},
+		}
+	}
+	return operations
+}
+
+// creditOperations documents crediting artists and bands on albums and
+// songs, the discography of each artist and band, and setting album tracks.
+func creditOperations() map[string]openapi.Operation {
+	tags := []string{"Credits"}
+	editor := constants.Editor.String()
+	roles := "Roles are performer, featured, composer, lyricist and producer."
+	return map[string]openapi.Operation{
+		"GET /artists/{id}/credits": {
+			Summary: "List what an artist is credited on", Tags: tags,
+			Response: []viewModels.CreditedWorkViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /bands/{id}/credits": {
+			Summary: "List what a band is credited on", Tags: tags,
+			Response: []viewModels.CreditedWorkViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"POST /albums/{id}/credits": {
+			Summary: "Credit an artist or band on an album", Tags: tags, Auth: editor,
+			Description: "Give either artist_id or band_id. " + roles + " Responds with the album's credits.",
+			Request:     viewModels.CreditRequest{}, Response: []viewModels.CreditViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"POST /songs/{id}/credits": {
+			Summary: "Credit an artist or band on a song", Tags: tags, Auth: editor,
+			Description: "Give either artist_id or band_id. " + roles + " Responds with the song's credits.",
+			Request:     viewModels.CreditRequest{}, Response: []viewModels.CreditViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"DELETE /credits/{id}": {
+			Summary: "Remove a credit", Tags: tags, Auth: editor,
+			Status: http.StatusNoContent, Errors: []int{http.StatusNotFound},
+		},
+		"PUT /albums/{id}/songs": {
+			Summary: "Set an album's tracks", Tags: []string{"Albums"}, Auth: editor,
+			Request: viewModels.AlbumSongsRequest{}, Response: viewModels.DetailedAlbumViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+	}
+}
+
+// membershipOperations documents the band membership routes. Memberships
+// are listed founding members first, then in the order they joined.
+func membershipOperations() map[string]openapi.Operation {
+	tags := []string{"Memberships"}
+	editor := constants.Editor.String()
+	dates := "A missing date_joined means since the band formed and a missing date_left means still a member."
+	return map[string]openapi.Operation{
+		"GET /bands/{id}/members": {
+			Summary: "List a band's members", Tags: tags,
+			Query:    []openapi.Param{{Name: "at", Description: "Only the members on this YYYY-MM-DD date", Schema: &openapi.Schema{Type: "string", Format: "date"}}},
+			Response: []viewModels.BandMemberViewModel{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"GET /artists/{id}/bands": {
+			Summary: "List the bands an artist has played in", Tags: tags,
+			Response: []viewModels.ArtistBandViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"POST /bands/{id}/members": {
+			Summary: "Add an artist to a band", Tags: tags, Auth: editor,
+			Description: dates + " Responds with every member of the band.",
+			Request:     viewModels.MembershipRequest{}, Response: []viewModels.BandMemberViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+		"PUT /memberships/{id}": {
+			Summary: "Change a membership", Tags: tags, Auth: editor,
+			Description: dates + " Responds with every member of the band.",
+			Request:     viewModels.MembershipRequest{}, Response: []viewModels.BandMemberViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+		},
+		"DELETE /memberships/{id}": {
+			Summary: "Remove a membership", Tags: tags, Auth: editor,
+			Status: http.StatusNoContent, Errors: []int{http.StatusNotFound},
+		},
+	}
+}
+
+// importOperations documents the bulk catalog import.
+func importOperations() map[string]openapi.Operation {
+	return map[string]openapi.Operation{
+		"POST /import": {
+			Summary: "Import artists, bands, albums and songs", Tags: []string{"Import"}, Auth: constants.Editor.String(),
+			Description: "Accepts CSV (text/csv) with a header line or JSON Lines (application/jsonl), one record per line. " +
+				"The type column is artist, band, album or song. Albums and songs name their artist or band, and songs their album, " +
+				"by first and last name, band name or album title as given elsewhere in the file. " +
+				"Every record is created in one transaction; if any line has a problem nothing is created and each problem is listed with its line.",
+			Query:   []openapi.Param{{Name: "dry_run", Description: "Check the file and report what would be created without writing anything", Schema: &openapi.Schema{Type: "boolean"}}},
+			Request: openapi.Binary{}, RequestContentType: "text/csv",
+			Response: viewModels.ImportReport{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+		},
+	}
+}
+
+// exportOperations documents the catalog export and the album and playlist
+// tracklist exports.
+func exportOperations() map[string]openapi.Operation {
+	tags := []string{"Export"}
+	tracklistFormat := openapi.Param{Name: "format", Description: "xspf (the default) or m3u8", Schema: &openapi.Schema{Type: "string"}}
+	tracklist := "Each track's location is its audio stream, /songs/{id}/audio, relative to this server."
+	return map[string]openapi.Operation{
+		"GET /export": {
+			Summary: "Export the whole catalog", Tags: tags, Auth: constants.Editor.String(),
+			Description: "Streams every artist, band, membership, genre, album and song, and the tracks, credits, genres and tags that link them, referring to each other by ID. " +
+				"json is one object with an array per kind of record; ndjson is one object per line with a type field; " +
+				"csv has a type column and every other column, left empty where a kind of record doesn't have it.",
+			Query:    []openapi.Param{{Name: "format", Description: "json (the default), csv or ndjson", Schema: &openapi.Schema{Type: "string"}}},
+			Response: openapi.Binary{}, ContentType: "application/json",
+			Errors: []int{http.StatusBadRequest},
+		},
+		"GET /albums/{id}/export": {
+			Summary: "Export an album's tracklist", Tags: tags, Description: tracklist,
+			Query:    []openapi.Param{tracklistFormat},
+			Response: openapi.Binary{}, ContentType: "application/xspf+xml",
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"GET /playlists/{id}/export": {
+			Summary: "Export a playlist's tracks", Tags: tags, Auth: openapi.Authenticated, Description: tracklist,
+			Query:    []openapi.Param{tracklistFormat},
+			Response: openapi.Binary{}, ContentType: "application/xspf+xml",
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+	}
+}
+
+// reviewOperations documents the album and song reviews.
+func reviewOperations() map[string]openapi.Operation {
+	tags := []string{"Reviews"}
+	auth := openapi.Authenticated
+	params := collectionParams(repositories.ReviewQuery)
+	page := query.Page[viewModels.ReviewViewModel]{}
+	once := "Each user can review an album or song once; posting again is rejected with a 409. " +
+		"The album's or song's average_rating and rating_count include the new rating straight away."
+	author := "Only the author, moderators and admins can change a review."
+	return map[string]openapi.Operation{
+		"GET /albums/{id}/reviews": {
+			Summary: "List an album's reviews", Tags: tags, Query: params,
+			Response: page, Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"POST /albums/{id}/reviews": {
+			Summary: "Review an album", Tags: tags, Auth: auth, Description: once,
+			Request: viewModels.ReviewRequest{}, Response: viewModels.ReviewViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		"GET /songs/{id}/reviews": {
+			Summary: "List a song's reviews", Tags: tags, Query: params,
+			Response: page, Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		"POST /songs/{id}/reviews": {
+			Summary: "Review a song", Tags: tags, Auth: auth, Description: once,
+			Request: viewModels.ReviewRequest{}, Response: viewModels.ReviewViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		"PUT /reviews/{id}": {
+			Summary: "Update a review", Tags: tags, Auth: auth, Description: author,
+			Request: viewModels.ReviewRequest{}, Response: viewModels.ReviewViewModel{},
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		},
+		"DELETE /reviews/{id}": {
+			Summary: "Delete a review", Tags: tags, Auth: auth, Description: author,
+			Status: http.StatusNoContent, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+		},
+	}
+}
+
+// playOperations documents the listening history and the statistics drawn
+// from it. Durations are in seconds.
+func playOperations() map[string]openapi.Operation {
+	tags := []string{"Listening"}
+	auth := openapi.Authenticated
+	window := openapi.Param{Name: "window", Description: "day, week or month for the last 24 hours, 7 days or 30 days, or all (the default)", Schema: &openapi.Schema{Type: "string"}}
+	limit := openapi.Param{Name: "limit", Description: "Maximum number of results (1-50, default 10)", Schema: &openapi.Schema{Type: "integer"}}
+	return map[string]openapi.Operation{
+		"POST /me/plays": {
+			Summary: "Record plays", Tags: tags, Auth: auth,
+			Description: "Submit up to 500 plays at once, for instance those recorded offline. " +
+				"Give each a client_id so a retried batch is only counted once: plays whose client_id you already submitted are reported as duplicates. " +
+				"If any song doesn't exist nothing is recorded.",
+			Request: viewModels.PlayBatchRequest{}, Response: viewModels.PlayBatchViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+		},
+		"GET /me/stats/top-songs": {
+			Summary: "Your most played songs", Tags: tags, Auth: auth, Query: []openapi.Param{window, limit},
+			Response: viewModels.TopListViewModel[viewModels.TopSongViewModel]{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /me/stats/top-artists": {
+			Summary: "The artists and bands you played most", Tags: tags, Auth: auth, Query: []openapi.Param{window, limit},
+			Description: "A play counts for every artist and band credited as performer on the song. Each item is an artist or a band, as named by type.",
+			Response:    viewModels.TopListViewModel[viewModels.TopArtistViewModel]{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /me/stats/listening-time": {
+			Summary: "How long you listened", Tags: tags, Auth: auth, Query: []openapi.Param{window},
+			Description: "Totals for the window and for each UTC day in it with plays.",
+			Response:    viewModels.ListeningTimeViewModel{}, Errors: []int{http.StatusBadRequest},
+		},
+	}
+}
+
+// recommendationOperations documents the song suggestions.
+func recommendationOperations() map[string]openapi.Operation {
+	tags := []string{"Recommendations"}
+	limit := openapi.Param{Name: "limit", Description: "Maximum number of results (1-50, default 20)", Schema: &openapi.Schema{Type: "integer"}}
+	rebuilt := " The similarities are recomputed on a schedule, so recent plays take a while to count."
+	return map[string]openapi.Operation{
+		"GET /me/recommendations": {
+			Summary: "Songs you might like", Tags: tags, Auth: openapi.Authenticated, Query: []openapi.Param{limit},
+			Description: "Songs you haven't played or bought, ranked by how often they are listened to by the same users as the songs you play most. " +
+				"Songs sharing a performer or genre with your favourites fill in when there is too little listening to go on. Empty until you play or buy something." + rebuilt,
+			Response: []viewModels.RecommendationViewModel{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /songs/{id}/similar": {
+			Summary: "Songs like this one", Tags: tags, Query: []openapi.Param{limit},
+			Description: "Songs listened to by the same users, topped up with songs sharing a performer or genre." + rebuilt,
+			Response:    []viewModels.RecommendationViewModel{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+	}
+}
+
+// chartOperations documents the popularity charts.
+func chartOperations() map[string]openapi.Operation {
+	tags := []string{"Charts"}
+	query := []openapi.Param{
+		{Name: "period", Description: "daily for the UTC day, or weekly (the default) for the 7 days ending on it", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "date", Description: "Date of the chart, formatted like 2006-01-02; today by default", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "limit", Description: "Maximum number of entries (1-100, default 20)", Schema: &openapi.Schema{Type: "integer"}},
+	}
+	scoring := "Plays, purchases and ratings in the period all count, each worth less the longer before the end of the period it happened. " +
+		"Each entry gives its position in the chart for the period before: the previous day, or the week before for weekly charts. " +
+		"Charts of past days are kept once first asked for, so they stay the same; today's is provisional and changes until the day is over."
+	return map[string]openapi.Operation{
+		"GET /charts/songs": {
+			Summary: "Most popular songs", Tags: tags, Query: query, Description: scoring,
+			Response: viewModels.ChartViewModel[viewModels.BasicSongViewModel]{}, Errors: []int{http.StatusBadRequest},
+		},
+		"GET /charts/albums": {
+			Summary: "Most popular albums", Tags: tags, Query: query,
+			Description: scoring + " Playing a song counts for every album it is on.",
+			Response:    viewModels.ChartViewModel[viewModels.BasicAlbumViewModel]{}, Errors: []int{http.StatusBadRequest},
+		},
+	}
+}
+
+// orderOperations documents the cart, checkout, order and library routes.
+// Amounts are in cents.
+func orderOperations() map[string]openapi.Operation {
+	tags := []string{"Orders"}
+	auth := openapi.Authenticated
+	return map[string]openapi.Operation{
+		"GET /me/cart": {
+			Summary: "Get your cart", Tags: tags, Auth: auth,
+			Description: "Items are priced at the current catalog prices. Covered items are already owned or come with an album in the cart, and cost nothing.",
+			Response:    viewModels.CartViewModel{},
+		},
+		"POST /me/cart/items": {
+			Summary: "Add an album or a song to your cart", Tags: tags, Auth: auth,
+			Request: viewModels.CartItemRequest{}, Response: viewModels.CartViewModel{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		"DELETE /me/cart/items/{id}": {
+			Summary: "Remove an item from your cart", Tags: tags, Auth: auth,
+			Status: http.StatusNoContent, Errors: []int{http.StatusNotFound},
+		},
+		"POST /checkout": {
+			Summary: "Buy everything in your cart", Tags: tags, Auth: auth,
+			Description: "Charges the catalog prices at checkout and adds the items, and the songs of any albums, to your library. An empty cart is a 409.",
+			Response:    viewModels.OrderViewModel{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict},
+		},
+		"GET /me/orders": {
+			Summary: "List your orders, newest first", Tags: tags, Auth: auth,
+			Response: []viewModels.OrderViewModel{},
+		},
+		"GET /me/orders/{id}": {
+			Summary: "Get one of your orders", Tags: tags, Auth: auth,
+			Response: viewModels.OrderViewModel{}, Errors: []int{http.StatusNotFound},
+		},
+		"GET /me/library": {
+			Summary: "List the albums and songs you own", Tags: tags, Auth: auth,
+			Response: viewModels.LibraryViewModel{},
+		},
+	}
+}
+
+// collectionParams documents the pagination, sorting and filtering
+// parameters a collection's query spec accepts.
+func collectionParams[T any](spec query.Spec[T]) []openapi.Param {
+	var sortable []string
+	params := []openapi.Param{
+		{Name: "limit", Description: "Page size (1-" + strconv.Itoa(query.MaxLimit) + ", default " + strconv.Itoa(query.DefaultLimit) + ")", Schema: &openapi.Schema{Type: "integer"}},
+		{Name: "cursor", Description: "next_cursor from the previous page", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(spec.Fields)) {
+		field := spec.Fields[name]
+		if field.Sortable {
+			sortable = append(sortable, name)
+		}
+		for _, op := range field.Ops {
+			param := openapi.Param{Name: name, Description: "Equal to", Schema: kindSchema(field.Kind)}
+			switch op {
+			case query.Gte:
+				param.Name, param.Description = name+"_gte", "At least"
+			case query.Lte:
+				param.Name, param.Description = name+"_lte", "At most"
+			}
+			params = append(params, param)
+		}
+	}
+
+	return append(params, openapi.Param{
+		Name:        "sort",
+		Description: "Comma separated fields, prefixed with - for descending order: " + strings.Join(sortable, ", "),
+		Schema:      &openapi.Schema{Type: "string"},
+	})
+}
+
+func kindSchema(kind query.Kind) *openapi.Schema {
+	switch kind {
+	case query.Int:
+		return &openapi.Schema{Type: "integer"}
+	case query.Float:
+		return &openapi.Schema{Type: "number"}
+	case query.Bool:
+		return &openapi.Schema{Type: "boolean"}
+	default:
+		return &openapi.Schema{Type: "string"}
+	}
+}
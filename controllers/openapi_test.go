@@ -0,0 +1,96 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"goMusic/charts"
+	"goMusic/controllers"
+	"goMusic/repositories"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// routeRecorder collects the patterns routes are registered with.
+type routeRecorder []string
+
+func (r *routeRecorder) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	*r = append(*r, pattern)
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	var routes routeRecorder
+	controllers.RegisterRoutes(&routes, repositories.NewMemoryStore(), nil, nil, charts.DefaultConfig())
+	operations := controllers.APISpec().Operations
+
+	registered := map[string]bool{}
+	for _, pattern := range routes {
+		registered[pattern] = true
+		if !strings.Contains(pattern, " ") {
+			t.Errorf("route %q has no method, so it cannot be documented as one operation", pattern)
+		}
+		if _, ok := operations[pattern]; !ok {
+			t.Errorf("route %q is missing from the OpenAPI document", pattern)
+		}
+	}
+	for pattern := range operations {
+		if !registered[pattern] {
+			t.Errorf("the OpenAPI document describes %q, which is not registered", pattern)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	mux := http.NewServeMux()
+	controllers.RegisterDocsRoutes(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var document struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &document); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+	if document.OpenAPI != "3.0.3" {
+		t.Errorf("wrong OpenAPI version %q", document.OpenAPI)
+	}
+	if _, ok := document.Paths["/songs/{id}"]["put"]; !ok {
+		t.Errorf("songs are not documented: %v", document.Paths["/songs/{id}"])
+	}
+
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(rr.Body.String(), -1) {
+		if _, ok := document.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("reference to undefined schema %s", ref[1])
+		}
+	}
+	for _, name := range []string{"Album", "AlbumViewModelPage", "DetailedSongViewModel", "AuthResponse", "Problem", "FieldError"} {
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	mux := http.NewServeMux()
+	controllers.RegisterDocsRoutes(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/docs/index.html", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "/openapi.json") {
+		t.Error("Swagger UI does not load the OpenAPI document")
+	}
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+// RegisterOrderRoutes registers the cart, checkout, order history and
+// library routes. They all act on the logged in user.
+func RegisterOrderRoutes(mux Router, orders *services.OrderService) {
+	mux.HandleFunc("GET /me/cart", authentication.AuthMiddleware(orders.GetCart))
+	mux.HandleFunc("POST /me/cart/items", authentication.AuthMiddleware(orders.AddToCart))
+	mux.HandleFunc("DELETE /me/cart/items/{id}", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		orders.RemoveFromCart(w, r, id)
+	}))
+	mux.HandleFunc("POST /checkout", authentication.AuthMiddleware(orders.Checkout))
+
+	mux.HandleFunc("GET /me/orders", authentication.AuthMiddleware(orders.GetOrders))
+	mux.HandleFunc("GET /me/orders/{id}", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		orders.GetOrderByID(w, r, id)
+	}))
+	mux.HandleFunc("GET /me/library", authentication.AuthMiddleware(orders.GetLibrary))
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+// RegisterPlaylistRoutes registers the playlist routes. They all need a
+// logged in user; the service decides what each user may see and change.
+func RegisterPlaylistRoutes(mux Router, playlists *services.PlaylistService) {
+	withID := func(handle func(w http.ResponseWriter, r *http.Request, id int) bool) http.HandlerFunc {
+		return authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.Atoi(r.PathValue("id"))
+			handle(w, r, id)
+		})
+	}
+
+	mux.HandleFunc("GET /playlists", authentication.AuthMiddleware(playlists.GetPlaylists))
+	mux.HandleFunc("GET /playlists/invitations", authentication.AuthMiddleware(playlists.GetInvitations))
+	mux.HandleFunc("POST /playlists", authentication.AuthMiddleware(playlists.PostPlaylist))
+	mux.HandleFunc("GET /playlists/{id}", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		playlists.GetPlaylistByID(w, r, id)
+	}))
+	mux.HandleFunc("GET /playlists/{id}/export", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		playlists.ExportPlaylist(w, r, id)
+	}))
+	mux.HandleFunc("PUT /playlists/{id}", withID(playlists.UpdatePlaylistByID))
+	mux.HandleFunc("DELETE /playlists/{id}", withID(playlists.DeletePlaylistByID))
+
+	mux.HandleFunc("POST /playlists/{id}/tracks", withID(playlists.AddTrack))
+	mux.HandleFunc("PUT /playlists/{id}/tracks", withID(playlists.ReorderTracks))
+	mux.HandleFunc("POST /playlists/{id}/tracks/move", withID(playlists.MoveTrack))
+	mux.HandleFunc("DELETE /playlists/{id}/tracks/{position}", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		position, _ := strconv.Atoi(r.PathValue("position"))
+		playlists.RemoveTrack(w, r, id, position)
+	}))
+
+	mux.HandleFunc("POST /playlists/{id}/collaborators", withID(playlists.InviteCollaborator))
+	mux.HandleFunc("DELETE /playlists/{id}/collaborators/{user_id}", authentication.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		userID, _ := strconv.Atoi(r.PathValue("user_id"))
+		playlists.RemoveCollaborator(w, r, id, userID)
+	}))
+	mux.HandleFunc("POST /playlists/{id}/invitation", withID(playlists.AcceptInvitation))
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/services"
+)
+
+// RegisterPlayRoutes registers the listening history and statistics routes.
+// They all act on the logged in user.
+func RegisterPlayRoutes(mux Router, plays *services.PlayService) {
+	mux.HandleFunc("POST /me/plays", authentication.AuthMiddleware(plays.PostPlays))
+	mux.HandleFunc("GET /me/stats/top-songs", authentication.AuthMiddleware(plays.GetTopSongs))
+	mux.HandleFunc("GET /me/stats/top-artists", authentication.AuthMiddleware(plays.GetTopArtists))
+	mux.HandleFunc("GET /me/stats/listening-time", authentication.AuthMiddleware(plays.GetListeningTime))
+}
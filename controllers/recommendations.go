@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+// RegisterRecommendationRoutes registers the song suggestions. Similar songs
+// are public; recommendations are for the logged in user.
+func RegisterRecommendationRoutes(mux Router, recommendations *services.RecommendationService) {
+	mux.HandleFunc("GET /me/recommendations", authentication.AuthMiddleware(recommendations.GetRecommendations))
+	mux.HandleFunc("GET /songs/{id}/similar", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		recommendations.GetSimilarSongs(w, r, id)
+	})
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/services"
+)
+
+// RegisterReviewRoutes registers the album and song reviews. Anyone can
+// read them; writing one needs a logged in user, and the service decides
+// who may change it.
+func RegisterReviewRoutes(mux Router, reviews *services.ReviewService) {
+	mux.HandleFunc("GET /albums/{id}/reviews", withID(reviews.GetAlbumReviews))
+	mux.HandleFunc("POST /albums/{id}/reviews", authentication.AuthMiddleware(withID(reviews.PostAlbumReview)))
+	mux.HandleFunc("GET /songs/{id}/reviews", withID(reviews.GetSongReviews))
+	mux.HandleFunc("POST /songs/{id}/reviews", authentication.AuthMiddleware(withID(reviews.PostSongReview)))
+	mux.HandleFunc("PUT /reviews/{id}", authentication.AuthMiddleware(withID(reviews.UpdateReview)))
+	mux.HandleFunc("DELETE /reviews/{id}", authentication.AuthMiddleware(withID(reviews.DeleteReview)))
+}
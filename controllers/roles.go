@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/constants"
+	"net/http"
+	"strconv"
+)
+
+// editorsOnly guards routes that change the catalog.
+func editorsOnly(next http.HandlerFunc) http.HandlerFunc {
+	return authentication.AuthMiddleware(authentication.RequireRole(constants.Editor, constants.Admin)(next))
+}
+
+// withID passes the {id} path value to handle.
+func withID(handle func(w http.ResponseWriter, r *http.Request, id int)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		handle(w, r, id)
+	}
+}
+
+// editorWithID is withID for the editorsOnly routes that change the catalog.
+func editorWithID(handle func(w http.ResponseWriter, r *http.Request, id int) bool) http.HandlerFunc {
+	return editorsOnly(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		handle(w, r, id)
+	})
+}
+
+// adminsOnly guards user management routes.
+func adminsOnly(next http.HandlerFunc) http.HandlerFunc {
+	return authentication.AuthMiddleware(authentication.RequireRole(constants.Admin)(next))
+}
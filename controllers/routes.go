@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/blobs"
+	"goMusic/charts"
+	"goMusic/repositories"
+	"goMusic/services"
+	"net/http"
+)
+
+// Router is what routes are registered on: the server's *http.ServeMux, or a
+// recorder when tests compare the routes with the OpenAPI document.
+type Router interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// RegisterRoutes registers every route the server answers. Uploaded files
+// are kept in files, and chartConfig sets how fast plays and purchases stop
+// counting towards the charts.
+func RegisterRoutes(mux Router, store *repositories.Store, keys *authentication.KeySet, files *blobs.Store, chartConfig charts.Config) {
+	RegisterAuthRoutes(mux, services.NewUserService(store))
+	RegisterKeyRoutes(mux, keys)
+	RegisterAlbumRoutes(mux, services.NewAlbumService(store))
+	RegisterArtistRoutes(mux, services.NewArtistService(store))
+	RegisterBandRoutes(mux, services.NewBandService(store))
+	RegisterSongRoutes(mux, services.NewSongService(store))
+	RegisterAudioRoutes(mux, services.NewAudioService(store, files))
+	RegisterCoverRoutes(mux, services.NewCoverService(store, files))
+	RegisterGenreRoutes(mux, services.NewGenreService(store))
+	RegisterLabelRoutes(mux, services.NewLabelService(store))
+	RegisterTagRoutes(mux, services.NewTagService(store))
+	RegisterCreditRoutes(mux, services.NewCreditService(store))
+	RegisterMembershipRoutes(mux, services.NewMembershipService(store))
+	RegisterImportRoutes(mux, services.NewImportService(store))
+	RegisterExportRoutes(mux, services.NewExportService(store))
+	RegisterReviewRoutes(mux, services.NewReviewService(store))
+	RegisterSearchRoutes(mux, services.NewSearchService(store))
+	RegisterPlaylistRoutes(mux, services.NewPlaylistService(store))
+	RegisterOrderRoutes(mux, services.NewOrderService(store))
+	RegisterPlayRoutes(mux, services.NewPlayService(store))
+	RegisterRecommendationRoutes(mux, services.NewRecommendationService(store))
+	RegisterChartRoutes(mux, services.NewChartService(store, chartConfig))
+	RegisterDocsRoutes(mux)
+}
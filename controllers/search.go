@@ -0,0 +1,7 @@
+package controllers
+
+import "goMusic/services"
+
+func RegisterSearchRoutes(mux Router, search *services.SearchService) {
+	mux.HandleFunc("GET /search", search.Search)
+}
@@ -1,30 +1,29 @@
 package controllers
 
 import (
-	"goMusic/authentication"
 	"goMusic/services"
 	"net/http"
 	"strconv"
 )
 
-func RegisterSongRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /songs", services.GetSongs)
+func RegisterSongRoutes(mux Router, songs *services.SongService) {
+	mux.HandleFunc("GET /songs", songs.GetSongs)
 	mux.HandleFunc("GET /songs/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id, _ := strconv.Atoi(r.PathValue("id"))
-		services.GetSongByID(w, id)
+		songs.GetSongByID(w, r, id)
 	})
 
-	mux.HandleFunc("POST /songs", authentication.AuthMiddleware(services.PostSong))
-	mux.HandleFunc("PUT /songs/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("POST /songs", editorsOnly(songs.PostSong))
+	mux.HandleFunc("PUT /songs/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.UpdateSongByID(w, r, id)
+			songs.UpdateSongByID(w, r, id)
 		},
 	))
-	mux.HandleFunc("DELETE /songs/{id}", authentication.AuthMiddleware(
+	mux.HandleFunc("DELETE /songs/{id}", editorsOnly(
 		func(w http.ResponseWriter, r *http.Request) {
 			id, _ := strconv.Atoi(r.PathValue("id"))
-			services.DeleteSongByID(w, id)
+			songs.DeleteSongByID(w, r, id)
 		},
 	))
 }
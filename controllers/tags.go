@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"goMusic/authentication"
+	"goMusic/models"
+	"goMusic/services"
+	"net/http"
+	"strconv"
+)
+
+// taggedCollections maps each catalog collection to the kind of entry it
+// holds.
+var taggedCollections = map[string]string{
+	"/albums":  models.TagAlbum,
+	"/songs":   models.TagSong,
+	"/artists": models.TagArtist,
+	"/bands":   models.TagBand,
+}
+
+// RegisterTagRoutes registers the tag routes. Any logged in user may tag an
+// entry; removing tags is left to editors.
+func RegisterTagRoutes(mux Router, tags *services.TagService) {
+	mux.HandleFunc("GET /tags", tags.GetTags)
+
+	for path, entityType := range taggedCollections {
+		mux.HandleFunc("POST "+path+"/{id}/tags", authentication.AuthMiddleware(
+			func(w http.ResponseWriter, r *http.Request) {
+				id, _ := strconv.Atoi(r.PathValue("id"))
+				tags.AddTag(w, r, entityType, id)
+			},
+		))
+		mux.HandleFunc("DELETE "+path+"/{id}/tags/{name}", editorsOnly(
+			func(w http.ResponseWriter, r *http.Request) {
+				id, _ := strconv.Atoi(r.PathValue("id"))
+				tags.RemoveTag(w, r, entityType, id, r.PathValue("name"))
+			},
+		))
+	}
+}
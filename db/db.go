@@ -1,124 +1,22 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
 
-func InitDB(filepath string) error {
-	var err error
-	DB, err = sql.Open("sqlite3", filepath)
-	if err != nil {
-		return err
-	}
-
-	_, err = DB.Exec("PRAGMA foreign_keys = ON")
+// InitDB applies any pending schema migrations to DB.
+func InitDB() error {
+	migrations, err := Migrations()
 	if err != nil {
 		return err
 	}
 
-	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS users (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        username TEXT UNIQUE NOT NULL,
-        password TEXT NOT NULL,
-        email TEXT UNIQUE NOT NULL,
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    )`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS sexes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS titles (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS artists (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		first_name TEXT NOT NULL,
-		last_name TEXT NOT NULL,
-		nationality TEXT NOT NULL,
-		birth_date DATE NOT NULL,
-		age INT NOT NULL,
-		alive BOOLEAN NOT NULL,
-		sex_id INT NOT NULL,
-		title_id INT NOT NULL,
-		band_id INT,
-		FOREIGN KEY (sex_id) REFERENCES sexes(id),
-	    FOREIGN KEY (title_id) REFERENCES titles(id),
-	    FOREIGN KEY (band_id) REFERENCES bands(id)
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS bands (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		nationality TEXT,
-		number_of_members INT NOT NULL,
-		date_formed DATE NOT NULL,
-		age INT,
-		active BOOLEAN
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS albums (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		price REAL NOT NULL,
-	    artist_id INT,
-	    band_id INT,
-	    FOREIGN KEY (artist_id) REFERENCES artists(id),
-	    FOREIGN KEY (band_id) REFERENCES bands(id)
-	)`)
-
-	_, err = DB.Exec(`
-	 CREATE TABLE IF NOT EXISTS songs (
-	  id INTEGER PRIMARY KEY AUTOINCREMENT,
-	  title TEXT NOT NULL,
-	  length INT NOT NULL,
-	  price REAL NOT NULL,
-	  album_id INT,
-	  artist_id INT,
-	  band_id INT,
-	  FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
-	  FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
-	  FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS album_songs (
-		album_id INTEGER NOT NULL,
-		song_id INTEGER NOT NULL,
-		PRIMARY KEY (album_id, song_id),
-		FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
-		FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS artist_songs (
-		artist_id INTEGER NOT NULL,
-		song_id INTEGER NOT NULL,
-		PRIMARY KEY (artist_id, song_id),
-		FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
-		FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
-	)`)
-
-	_, err = DB.Exec(`
-	CREATE TABLE IF NOT EXISTS band_songs (
-		band_id INTEGER NOT NULL,
-		song_id INTEGER NOT NULL,
-		PRIMARY KEY (band_id, song_id),
-		FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE,
-		FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
-	)`)
-
+	_, err = NewMigrator(DB, migrations).Up(context.Background())
 	return err
 }
 
@@ -162,23 +60,24 @@ func SeedDB() error {
 			}
 		}
 
+		date := func(d string) *string { return &d }
+
 		bands := []struct {
-			ID              int
-			Name            string
-			Nationality     string
-			NumberOfMembers int
-			DateFormed      string
-			Age             int
-			Active          bool
+			ID            int
+			Name          string
+			Nationality   string
+			DateFormed    string
+			DisbandedDate *string
 		}{
-			{1, "Pink Floyd", "British", 5, "1965-01-01", 59, false},
-			{2, "The Beatles", "British", 4, "1960-08-01", 64, false},
-			{3, "Radiohead", "British", 5, "1985-09-01", 39, true},
+			{1, "Pink Floyd", "British", "1965-01-01", date("2014-11-10")},
+			{2, "The Beatles", "British", "1960-08-01", date("1970-04-10")},
+			{3, "Radiohead", "British", "1985-09-01", nil},
+			{4, "The Smile", "British", "2020-05-01", nil},
 		}
 		for _, b := range bands {
 			_, err := DB.Exec(
-				"INSERT INTO bands (id, name, nationality, number_of_members, date_formed, age, active) VALUES (?, ?, ?, ?, ?, ?, ?)",
-				b.ID, b.Name, b.Nationality, b.NumberOfMembers, b.DateFormed, b.Age, b.Active)
+				"INSERT INTO bands (id, name, nationality, date_formed, disbanded_date) VALUES (?, ?, ?, ?, ?)",
+				b.ID, b.Name, b.Nationality, b.DateFormed, b.DisbandedDate)
 			if err != nil {
 				return err
 			}
@@ -190,122 +89,134 @@ func SeedDB() error {
 			LastName    string
 			Nationality string
 			BirthDate   string
-			Age         int
-			Alive       bool
+			DeathDate   *string
 			SexID       int
 			TitleID     int
-			BandID      *int
 		}{
-			{1, "John", "Coltrane", "American", "1926-09-23", 40, false, 1, 1, nil},
-			{2, "Gerry", "Mulligan", "American", "1927-04-06", 68, false, 1, 1, nil},
-			{3, "Sarah", "Vaughan", "American", "1924-03-27", 66, false, 2, 2, nil},
-			{4, "Thom", "Yorke", "British", "1968-10-07", 56, true, 1, 1, intPtr(3)},
+			{1, "John", "Coltrane", "American", "1926-09-23", date("1967-07-17"), 1, 1},
+			{2, "Gerry", "Mulligan", "American", "1927-04-06", date("1996-01-20"), 1, 1},
+			{3, "Sarah", "Vaughan", "American", "1924-03-27", date("1990-04-03"), 2, 2},
+			{4, "Thom", "Yorke", "British", "1968-10-07", nil, 1, 1},
+			{5, "Jonny", "Greenwood", "British", "1971-11-05", nil, 1, 1},
 		}
 		for _, a := range artists {
 			_, err := DB.Exec(
-				"INSERT INTO artists (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
-				a.ID, a.FirstName, a.LastName, a.Nationality, a.BirthDate, a.Age, a.Alive, a.SexID, a.TitleID, a.BandID)
+				"INSERT INTO artists (id, first_name, last_name, nationality, birth_date, death_date, sex_id, title_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				a.ID, a.FirstName, a.LastName, a.Nationality, a.BirthDate, a.DeathDate, a.SexID, a.TitleID)
 			if err != nil {
 				return err
 			}
 		}
 
-		albums := []struct {
-			ID       int
-			Title    string
-			Price    float64
-			ArtistID *int
-			BandID   *int
+		memberships := []struct {
+			ArtistID int
+			BandID   int
+			Role     string
 		}{
-			{1, "Blue Train", 56.99, intPtr(1), nil},
-			{2, "Jeru", 17.99, intPtr(2), nil},
-			{3, "Sarah Vaughan and Clifford Brown", 39.99, intPtr(3), nil},
-			{4, "OK Computer", 45.99, nil, intPtr(3)},
-			{5, "Abbey Road", 42.99, nil, intPtr(2)},
+			{4, 3, "vocals, guitar, piano"},
+			{5, 3, "guitar, keyboards"},
+			{4, 4, "vocals, guitar, bass"},
+			{5, 4, "guitar, bass, piano"},
 		}
-		for _, a := range albums {
+		for _, m := range memberships {
 			_, err := DB.Exec(
-				"INSERT INTO albums (id, title, price, artist_id, band_id) VALUES (?, ?, ?, ?, ?)",
-				a.ID, a.Title, a.Price, a.ArtistID, a.BandID)
+				"INSERT INTO band_memberships (artist_id, band_id, role) VALUES (?, ?, ?)",
+				m.ArtistID, m.BandID, m.Role)
 			if err != nil {
 				return err
 			}
 		}
 
-		songs := []struct {
-			ID       int
-			Title    string
-			Length   int
-			Price    float64
-			AlbumID  *int
-			ArtistID *int
-			BandID   *int
+		albums := []struct {
+			ID    int
+			Title string
+			Price int64
 		}{
-			{1, "Blue Train", 543, 9.99, intPtr(1), intPtr(1), nil},
-			{2, "Lazy Bird", 434, 8.99, intPtr(1), intPtr(1), nil},
-			{3, "Jeru", 294, 5.99, intPtr(2), intPtr(2), nil},
-			{4, "Paranoid Android", 387, 7.99, intPtr(4), nil, intPtr(3)},
-			{5, "Karma Police", 264, 5.99, intPtr(4), nil, intPtr(3)},
-			{6, "Come Together", 259, 6.99, intPtr(5), nil, intPtr(2)},
+			{1, "Blue Train", 5699},
+			{2, "Jeru", 1799},
+			{3, "Sarah Vaughan and Clifford Brown", 3999},
+			{4, "OK Computer", 4599},
+			{5, "Abbey Road", 4299},
 		}
-		for _, s := range songs {
+		for _, a := range albums {
 			_, err := DB.Exec(
-				"INSERT INTO songs (id, title, length, price, album_id, artist_id, band_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-				s.ID, s.Title, s.Length, s.Price, s.AlbumID, s.ArtistID, s.BandID)
+				"INSERT INTO albums (id, title, price) VALUES (?, ?, ?)",
+				a.ID, a.Title, a.Price)
 			if err != nil {
 				return err
 			}
 		}
 
-		albumSongs := []struct {
-			AlbumID int
-			SongID  int
+		songs := []struct {
+			ID     int
+			Title  string
+			Length int
+			Price  int64
 		}{
-			{1, 1},
-			{1, 2},
-			{2, 3},
-			{4, 4},
-			{4, 5},
-			{5, 6},
+			{1, "Blue Train", 543, 999},
+			{2, "Lazy Bird", 434, 899},
+			{3, "Jeru", 294, 599},
+			{4, "Paranoid Android", 387, 799},
+			{5, "Karma Police", 264, 599},
+			{6, "Come Together", 259, 699},
 		}
-		for _, as := range albumSongs {
+		for _, s := range songs {
 			_, err := DB.Exec(
-				"INSERT INTO album_songs (album_id, song_id) VALUES (?, ?)",
-				as.AlbumID, as.SongID)
+				"INSERT INTO songs (id, title, length, price) VALUES (?, ?, ?, ?)",
+				s.ID, s.Title, s.Length, s.Price)
 			if err != nil {
 				return err
 			}
 		}
 
-		artistSongs := []struct {
-			ArtistID int
+		albumSongs := []struct {
+			AlbumID  int
 			SongID   int
+			Position int
 		}{
-			{1, 1},
-			{1, 2},
-			{2, 3},
+			{1, 1, 1},
+			{1, 2, 2},
+			{2, 3, 1},
+			{4, 4, 1},
+			{4, 5, 2},
+			{5, 6, 1},
 		}
-		for _, as := range artistSongs {
+		for _, as := range albumSongs {
 			_, err := DB.Exec(
-				"INSERT INTO artist_songs (artist_id, song_id) VALUES (?, ?)",
-				as.ArtistID, as.SongID)
+				"INSERT INTO album_songs (album_id, song_id, position) VALUES (?, ?, ?)",
+				as.AlbumID, as.SongID, as.Position)
 			if err != nil {
 				return err
 			}
 		}
 
-		bandSongs := []struct {
-			BandID int
-			SongID int
+		credits := []struct {
+			AlbumID  *int
+			SongID   *int
+			ArtistID *int
+			BandID   *int
+			Role     string
 		}{
-			{3, 4},
-			{3, 5},
-			{2, 6},
-		}
-		for _, bs := range bandSongs {
+			{intPtr(1), nil, intPtr(1), nil, "performer"},
+			{intPtr(2), nil, intPtr(2), nil, "performer"},
+			{intPtr(3), nil, intPtr(3), nil, "performer"},
+			{intPtr(4), nil, nil, intPtr(3), "performer"},
+			{intPtr(5), nil, nil, intPtr(2), "performer"},
+			{nil, intPtr(1), intPtr(1), nil, "performer"},
+			{nil, intPtr(1), intPtr(1), nil, "composer"},
+			{nil, intPtr(2), intPtr(1), nil, "performer"},
+			{nil, intPtr(3), intPtr(2), nil, "performer"},
+			{nil, intPtr(3), intPtr(2), nil, "composer"},
+			{nil, intPtr(4), nil, intPtr(3), "performer"},
+			{nil, intPtr(4), intPtr(4), nil, "lyricist"},
+			{nil, intPtr(5), nil, intPtr(3), "performer"},
+			{nil, intPtr(5), intPtr(4), nil, "lyricist"},
+			{nil, intPtr(6), nil, intPtr(2), "performer"},
+		}
+		for _, c := range credits {
 			_, err := DB.Exec(
-				"INSERT INTO band_songs (band_id, song_id) VALUES (?, ?)",
-				bs.BandID, bs.SongID)
+				"INSERT INTO credits (album_id, song_id, artist_id, band_id, role) VALUES (?, ?, ?, ?, ?)",
+				c.AlbumID, c.SongID, c.ArtistID, c.BandID, c.Role)
 			if err != nil {
 				return err
 			}
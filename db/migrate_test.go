@@ -0,0 +1,339 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	d, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	d.SetMaxOpenConns(1)
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func tableExists(t *testing.T, d *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	err := d.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	require.NoError(t, err)
+	return count > 0
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+	assert.Equal(t, "initial_schema", migrations[0].Name)
+}
+
+func TestMigratorUpDownStatus(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	migrator := NewMigrator(d, migrations)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.True(t, tableExists(t, d, "albums"))
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "a second run should be a no-op")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied)
+		assert.NotEmpty(t, s.AppliedAt)
+	}
+
+	reverted, err := migrator.Down(ctx, len(migrations))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+	assert.False(t, tableExists(t, d, "albums"))
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.False(t, s.Applied)
+	}
+}
+
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrator := NewMigrator(d, []Migration{
+		{Version: 1, Name: "widgets", Up: "CREATE TABLE widgets (id INTEGER PRIMARY KEY);", Down: "DROP TABLE widgets;"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE gadgets (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);", Down: "DROP TABLE gadgets;"},
+	})
+
+	applied, err := migrator.Up(ctx)
+	assert.Error(t, err)
+	assert.Len(t, applied, 1)
+	assert.True(t, tableExists(t, d, "widgets"))
+	assert.False(t, tableExists(t, d, "gadgets"), "partial migration must be rolled back")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+}
+
+func TestMigratorRejectsForeignKeyViolations(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrator := NewMigrator(d, []Migration{{
+		Version: 1,
+		Name:    "orphans",
+		Up: `CREATE TABLE parents (id INTEGER PRIMARY KEY);
+			CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INT REFERENCES parents(id));
+			INSERT INTO children (id, parent_id) VALUES (1, 42);`,
+		Down: "DROP TABLE children; DROP TABLE parents;",
+	}})
+
+	_, err := migrator.Up(ctx)
+	assert.ErrorContains(t, err, "foreign key violation")
+	assert.False(t, tableExists(t, d, "children"))
+}
+
+func TestLoadMigrationsValidatesFiles(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{
+		"m/0001_only_up.up.sql": {Data: []byte("SELECT 1;")},
+	}, "m")
+	assert.ErrorContains(t, err, "needs both an up and a down file")
+
+	_, err = loadMigrations(fstest.MapFS{
+		"m/first.up.sql": {Data: []byte("SELECT 1;")},
+	}, "m")
+	assert.Error(t, err)
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"m/0002_second.up.sql":   {Data: []byte("SELECT 2;")},
+		"m/0002_second.down.sql": {Data: []byte("SELECT -2;")},
+		"m/0001_first.up.sql":    {Data: []byte("SELECT 1;")},
+		"m/0001_first.down.sql":  {Data: []byte("SELECT -1;")},
+	}, "m")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, []int{migrations[0].Version, migrations[1].Version})
+}
+
+func TestCreditsMigrationMovesOwnership(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	const credits = 10
+	require.Equal(t, "credits", migrations[credits-1].Name)
+
+	_, err = NewMigrator(d, migrations[:credits-1]).Up(ctx)
+	require.NoError(t, err)
+	_, err = d.Exec(`
+		INSERT INTO sexes (id, name) VALUES (1, 'Female');
+		INSERT INTO titles (id, name) VALUES (1, 'Ms.');
+		INSERT INTO bands (id, name, number_of_members, date_formed) VALUES (1, 'Band', 3, '2000-01-01');
+		INSERT INTO artists (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id)
+			VALUES (1, 'Ann', 'Artist', 'British', '1980-01-01', 44, 1, 1, 1);
+		INSERT INTO albums (id, title, price, artist_id) VALUES (1, 'Album', 999, 1);
+		INSERT INTO songs (id, title, length, price, album_id, band_id) VALUES (1, 'Song', 200, 99, 1, 1);
+		INSERT INTO artist_songs (artist_id, song_id) VALUES (1, 1);`)
+	require.NoError(t, err)
+
+	migrator := NewMigrator(d, migrations[:credits])
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	rows, err := d.Query(`SELECT IFNULL(album_id, 0), IFNULL(song_id, 0), IFNULL(artist_id, 0), IFNULL(band_id, 0), role
+		FROM credits ORDER BY album_id IS NULL, artist_id IS NULL`)
+	require.NoError(t, err)
+	var got [][5]any
+	for rows.Next() {
+		var albumID, songID, artistID, bandID int
+		var role string
+		require.NoError(t, rows.Scan(&albumID, &songID, &artistID, &bandID, &role))
+		got = append(got, [5]any{albumID, songID, artistID, bandID, role})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][5]any{
+		{1, 0, 1, 0, "performer"},
+		{0, 1, 1, 0, "performer"},
+		{0, 1, 0, 1, "performer"},
+	}, got)
+
+	var position int
+	require.NoError(t, d.QueryRow("SELECT position FROM album_songs WHERE album_id = 1 AND song_id = 1").Scan(&position))
+	assert.Equal(t, 1, position)
+	assert.False(t, tableExists(t, d, "artist_songs"))
+
+	_, err = d.Exec("UPDATE songs SET title = 'Renamed' WHERE id = 1")
+	require.NoError(t, err)
+	var indexed int
+	require.NoError(t, d.QueryRow("SELECT COUNT(*) FROM search_index WHERE search_index MATCH 'Renamed'").Scan(&indexed))
+	assert.Equal(t, 1, indexed, "search triggers must survive the rebuild")
+
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	var artistID, bandID, albumID int
+	require.NoError(t, d.QueryRow("SELECT artist_id FROM albums WHERE id = 1").Scan(&artistID))
+	require.NoError(t, d.QueryRow("SELECT album_id, band_id FROM songs WHERE id = 1").Scan(&albumID, &bandID))
+	assert.Equal(t, []int{1, 1, 1}, []int{artistID, albumID, bandID})
+	assert.False(t, tableExists(t, d, "credits"))
+}
+
+func TestBandMembershipsMigrationMovesBandIDs(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	const memberships = 11
+	require.Equal(t, "band_memberships", migrations[memberships-1].Name)
+
+	_, err = NewMigrator(d, migrations[:memberships-1]).Up(ctx)
+	require.NoError(t, err)
+	_, err = d.Exec(`
+		INSERT INTO sexes (id, name) VALUES (1, 'Female');
+		INSERT INTO titles (id, name) VALUES (1, 'Ms.');
+		INSERT INTO bands (id, name, number_of_members, date_formed) VALUES (1, 'Band', 4, '2000-01-01');
+		INSERT INTO artists (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id)
+			VALUES (1, 'Ann', 'Artist', 'British', '1980-01-01', 44, 1, 1, 1, 1),
+			       (2, 'Bob', 'Solo', 'British', '1981-01-01', 43, 1, 1, 1, NULL);`)
+	require.NoError(t, err)
+
+	migrator := NewMigrator(d, migrations[:memberships])
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var artistID, bandID int
+	var dateJoined, dateLeft sql.NullString
+	require.NoError(t, d.QueryRow("SELECT artist_id, band_id, date_joined, date_left FROM band_memberships").
+		Scan(&artistID, &bandID, &dateJoined, &dateLeft))
+	assert.Equal(t, []int{1, 1}, []int{artistID, bandID})
+	assert.False(t, dateJoined.Valid || dateLeft.Valid, "a moved membership is current with unknown dates")
+
+	_, err = d.Exec("UPDATE bands SET name = 'Renamed' WHERE id = 1")
+	require.NoError(t, err)
+	var indexed int
+	require.NoError(t, d.QueryRow("SELECT COUNT(*) FROM search_index WHERE search_index MATCH 'Renamed'").Scan(&indexed))
+	assert.Equal(t, 1, indexed, "search triggers must survive the rebuild")
+
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	var members int
+	var band sql.NullInt64
+	require.NoError(t, d.QueryRow("SELECT band_id FROM artists WHERE id = 1").Scan(&band))
+	require.NoError(t, d.QueryRow("SELECT number_of_members FROM bands WHERE id = 1").Scan(&members))
+	assert.Equal(t, int64(1), band.Int64)
+	assert.Equal(t, 1, members, "the count is taken from the memberships")
+	assert.False(t, tableExists(t, d, "band_memberships"))
+}
+
+func TestLifeDatesMigrationEstimatesEndDates(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	const lifeDates = 12
+	require.Equal(t, "life_dates", migrations[lifeDates-1].Name)
+
+	_, err = NewMigrator(d, migrations[:lifeDates-1]).Up(ctx)
+	require.NoError(t, err)
+	_, err = d.Exec(`
+		INSERT INTO sexes (id, name) VALUES (1, 'Male');
+		INSERT INTO titles (id, name) VALUES (1, 'Mr.');
+		INSERT INTO artists (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id)
+			VALUES (1, 'John', 'Coltrane', 'American', '1926-09-23', 40, 0, 1, 1),
+			       (2, 'Thom', 'Yorke', 'British', '1968-10-07', 56, 1, 1, 1);
+		INSERT INTO bands (id, name, nationality, date_formed, age, active)
+			VALUES (1, 'The Beatles', 'British', '1960-08-01', 9, 0),
+			       (2, 'Radiohead', 'British', '1985-09-01', 39, 1),
+			       (3, 'Unknown', 'British', '1990-01-01', NULL, 0);`)
+	require.NoError(t, err)
+
+	migrator := NewMigrator(d, migrations[:lifeDates])
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var coltrane, yorke, beatles, radiohead, unknown, today sql.NullString
+	require.NoError(t, d.QueryRow("SELECT death_date FROM artists WHERE id = 1").Scan(&coltrane))
+	require.NoError(t, d.QueryRow("SELECT death_date FROM artists WHERE id = 2").Scan(&yorke))
+	require.NoError(t, d.QueryRow("SELECT disbanded_date FROM bands WHERE id = 1").Scan(&beatles))
+	require.NoError(t, d.QueryRow("SELECT disbanded_date FROM bands WHERE id = 2").Scan(&radiohead))
+	require.NoError(t, d.QueryRow("SELECT disbanded_date FROM bands WHERE id = 3").Scan(&unknown))
+	require.NoError(t, d.QueryRow("SELECT date('now')").Scan(&today))
+	assert.Equal(t, "1966-09-23", coltrane.String, "the estimate keeps the stored age")
+	assert.Equal(t, "1969-08-01", beatles.String)
+	assert.Equal(t, today.String, unknown.String)
+	assert.False(t, yorke.Valid || radiohead.Valid, "the living and active have no end date")
+
+	var columns int
+	require.NoError(t, d.QueryRow("SELECT COUNT(*) FROM pragma_table_info('artists') WHERE name IN ('age', 'alive')").Scan(&columns))
+	assert.Zero(t, columns)
+
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	var age int
+	var alive bool
+	require.NoError(t, d.QueryRow("SELECT age, alive FROM artists WHERE id = 1").Scan(&age, &alive))
+	assert.Equal(t, 40, age)
+	assert.False(t, alive)
+	require.NoError(t, d.QueryRow("SELECT age, active FROM bands WHERE id = 2").Scan(&age, &alive))
+	assert.True(t, alive)
+	assert.GreaterOrEqual(t, age, 41, "the age is recomputed as of the rollback")
+}
+
+func TestOrdersMigrationMovesPricesToCents(t *testing.T) {
+	d := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	const orders = 6
+	require.Equal(t, "orders", migrations[orders-1].Name)
+
+	_, err = NewMigrator(d, migrations[:orders-1]).Up(ctx)
+	require.NoError(t, err)
+	// 0.29 * 100 is 28.999999999999996 as a double.
+	_, err = d.Exec(`
+		INSERT INTO albums (id, title, price) VALUES (1, 'OK Computer', 9.99);
+		INSERT INTO songs (id, title, length, price) VALUES (1, 'Airbag', 284, 0.29);`)
+	require.NoError(t, err)
+
+	migrator := NewMigrator(d, migrations[:orders])
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var album, song int64
+	require.NoError(t, d.QueryRow("SELECT price FROM albums WHERE id = 1").Scan(&album))
+	require.NoError(t, d.QueryRow("SELECT price FROM songs WHERE id = 1").Scan(&song))
+	assert.Equal(t, []int64{999, 29}, []int64{album, song})
+	_, err = d.Exec("UPDATE songs SET price = -1 WHERE id = 1")
+	assert.Error(t, err, "the new column keeps its CHECK constraint")
+
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	var price float64
+	require.NoError(t, d.QueryRow("SELECT price FROM albums WHERE id = 1").Scan(&price))
+	assert.Equal(t, 9.99, price)
+}
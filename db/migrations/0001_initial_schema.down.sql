@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS band_songs;
+DROP TABLE IF EXISTS artist_songs;
+DROP TABLE IF EXISTS album_songs;
+DROP TABLE IF EXISTS songs;
+DROP TABLE IF EXISTS albums;
+DROP TABLE IF EXISTS artists;
+DROP TABLE IF EXISTS bands;
+DROP TABLE IF EXISTS titles;
+DROP TABLE IF EXISTS sexes;
+DROP TABLE IF EXISTS users;
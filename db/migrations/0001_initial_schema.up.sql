@@ -0,0 +1,93 @@
+-- Baseline schema. The statements use IF NOT EXISTS so databases created
+-- before migrations were introduced are adopted without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    password TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sexes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS titles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS artists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    nationality TEXT NOT NULL,
+    birth_date DATE NOT NULL,
+    age INT NOT NULL,
+    alive BOOLEAN NOT NULL,
+    sex_id INT NOT NULL,
+    title_id INT NOT NULL,
+    band_id INT,
+    FOREIGN KEY (sex_id) REFERENCES sexes(id),
+    FOREIGN KEY (title_id) REFERENCES titles(id),
+    FOREIGN KEY (band_id) REFERENCES bands(id)
+);
+
+CREATE TABLE IF NOT EXISTS bands (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nationality TEXT,
+    number_of_members INT NOT NULL,
+    date_formed DATE NOT NULL,
+    age INT,
+    active BOOLEAN
+);
+
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    price REAL NOT NULL,
+    artist_id INT,
+    band_id INT,
+    FOREIGN KEY (artist_id) REFERENCES artists(id),
+    FOREIGN KEY (band_id) REFERENCES bands(id)
+);
+
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    length INT NOT NULL,
+    price REAL NOT NULL,
+    album_id INT,
+    artist_id INT,
+    band_id INT,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS album_songs (
+    album_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    PRIMARY KEY (album_id, song_id),
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS artist_songs (
+    artist_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    PRIMARY KEY (artist_id, song_id),
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS band_songs (
+    band_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    PRIMARY KEY (band_id, song_id),
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
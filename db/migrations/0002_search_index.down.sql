@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS albums_search_insert;
+DROP TRIGGER IF EXISTS albums_search_update;
+DROP TRIGGER IF EXISTS albums_search_delete;
+DROP TRIGGER IF EXISTS artists_search_insert;
+DROP TRIGGER IF EXISTS artists_search_update;
+DROP TRIGGER IF EXISTS artists_search_delete;
+DROP TRIGGER IF EXISTS bands_search_insert;
+DROP TRIGGER IF EXISTS bands_search_update;
+DROP TRIGGER IF EXISTS bands_search_delete;
+DROP TRIGGER IF EXISTS songs_search_insert;
+DROP TRIGGER IF EXISTS songs_search_update;
+DROP TRIGGER IF EXISTS songs_search_delete;
+
+DROP TABLE IF EXISTS search_index;
@@ -0,0 +1,60 @@
+-- Full-text index over the catalog. go-sqlite3 only compiles FTS5 in with the
+-- sqlite_fts5 build tag, which every build of this module needs.
+-- The rowid encodes the row as id * 4 + type (0 album, 1 artist, 2 band,
+-- 3 song) so triggers can find their entry without scanning the index.
+
+CREATE VIRTUAL TABLE search_index USING fts5(
+    kind UNINDEXED,
+    name,
+    prefix='2 3',
+    tokenize='unicode61 remove_diacritics 1'
+);
+
+INSERT INTO search_index (rowid, kind, name) SELECT id * 4, 'album', title FROM albums;
+INSERT INTO search_index (rowid, kind, name) SELECT id * 4 + 1, 'artist', first_name || ' ' || last_name FROM artists;
+INSERT INTO search_index (rowid, kind, name) SELECT id * 4 + 2, 'band', name FROM bands;
+INSERT INTO search_index (rowid, kind, name) SELECT id * 4 + 3, 'song', title FROM songs;
+
+CREATE TRIGGER albums_search_insert AFTER INSERT ON albums BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_update AFTER UPDATE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_delete AFTER DELETE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+END;
+
+CREATE TRIGGER artists_search_insert AFTER INSERT ON artists BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_update AFTER UPDATE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_delete AFTER DELETE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+END;
+
+CREATE TRIGGER bands_search_insert AFTER INSERT ON bands BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_update AFTER UPDATE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_delete AFTER DELETE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+END;
+
+CREATE TRIGGER songs_search_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_update AFTER UPDATE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+END;
@@ -0,0 +1,3 @@
+-- Every user gets a role. Existing and newly registered users are viewers
+-- until an admin promotes them.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'viewer' CHECK (role IN ('admin', 'editor', 'viewer'));
@@ -0,0 +1,26 @@
+-- Refresh tokens are stored as SHA-256 hashes. Every login starts a family,
+-- and each refresh replaces the token with a new one in the same family, so a
+-- reused token reveals a leak and the whole family can be revoked.
+CREATE TABLE refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    family_id TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    revoked_at INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Access tokens revoked before they expire, by jti. Rows can be dropped once
+-- expires_at has passed.
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+
+-- Access tokens issued before this time (unix seconds) are rejected.
+ALTER TABLE users ADD COLUMN tokens_revoked_at INTEGER;
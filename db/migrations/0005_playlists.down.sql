@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS playlist_collaborators;
+DROP TRIGGER IF EXISTS playlist_songs_renumber;
+DROP TABLE IF EXISTS playlist_songs;
+DROP TABLE IF EXISTS playlists;
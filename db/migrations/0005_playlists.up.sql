@@ -0,0 +1,45 @@
+-- Playlists belong to the user who created them. Private playlists are only
+-- visible to the owner and collaborators who accepted an invitation.
+CREATE TABLE playlists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'private')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX playlists_user_id ON playlists (user_id);
+
+-- Tracks have explicit positions, numbered from 1 without gaps. A song can
+-- appear more than once, so rows have their own ID.
+CREATE TABLE playlist_songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    playlist_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    FOREIGN KEY (playlist_id) REFERENCES playlists(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX playlist_songs_position ON playlist_songs (playlist_id, position);
+
+-- Closes the gap left by a removed track, including one removed because its
+-- song was deleted from the catalog.
+CREATE TRIGGER playlist_songs_renumber AFTER DELETE ON playlist_songs BEGIN
+    UPDATE playlist_songs SET position = position - 1
+    WHERE playlist_id = old.playlist_id AND position > old.position;
+END;
+
+-- Invited users become collaborators once accepted_at is set.
+CREATE TABLE playlist_collaborators (
+    playlist_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    accepted_at INTEGER,
+    PRIMARY KEY (playlist_id, user_id),
+    FOREIGN KEY (playlist_id) REFERENCES playlists(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX playlist_collaborators_user_id ON playlist_collaborators (user_id);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS owned_songs;
+DROP TABLE IF EXISTS owned_albums;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS cart_items;
+
+ALTER TABLE albums ADD COLUMN price_real REAL NOT NULL DEFAULT 0;
+UPDATE albums SET price_real = price / 100.0;
+ALTER TABLE albums DROP COLUMN price;
+ALTER TABLE albums RENAME COLUMN price_real TO price;
+
+ALTER TABLE songs ADD COLUMN price_real REAL NOT NULL DEFAULT 0;
+UPDATE songs SET price_real = price / 100.0;
+ALTER TABLE songs DROP COLUMN price;
+ALTER TABLE songs RENAME COLUMN price_real TO price;
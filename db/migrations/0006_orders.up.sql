@@ -0,0 +1,76 @@
+-- Money is stored in integer minor units (cents) so totals are exact. That
+-- includes catalog prices, which are rounded to the nearest cent. SQLite can
+-- only add NOT NULL columns with a default, and the new column takes the old
+-- one's name.
+ALTER TABLE albums ADD COLUMN price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0);
+UPDATE albums SET price_cents = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE albums DROP COLUMN price;
+ALTER TABLE albums RENAME COLUMN price_cents TO price;
+
+ALTER TABLE songs ADD COLUMN price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0);
+UPDATE songs SET price_cents = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE songs DROP COLUMN price;
+ALTER TABLE songs RENAME COLUMN price_cents TO price;
+
+-- Each cart row holds either an album or a song.
+CREATE TABLE cart_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    album_id INTEGER,
+    song_id INTEGER,
+    added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((album_id IS NULL) <> (song_id IS NULL)),
+    UNIQUE (user_id, album_id),
+    UNIQUE (user_id, song_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX orders_user_id ON orders (user_id);
+
+-- Line items keep the title and price at checkout, so the history survives
+-- price changes and deleted catalog entries.
+CREATE TABLE order_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    item_type TEXT NOT NULL CHECK (item_type IN ('album', 'song')),
+    album_id INTEGER,
+    song_id INTEGER,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE SET NULL,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX order_items_order_id ON order_items (order_id);
+
+-- What each user owns. Buying an album grants its songs as well.
+CREATE TABLE owned_albums (
+    user_id INTEGER NOT NULL,
+    album_id INTEGER NOT NULL,
+    order_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, album_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE TABLE owned_songs (
+    user_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    order_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, song_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- The audio file of a song lives in the blob store under its SHA-256
+-- digest. The tags read from it are kept so they can be suggested again.
+CREATE TABLE song_audio (
+    song_id INTEGER PRIMARY KEY,
+    digest TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('mp3', 'flac', 'ogg')),
+    size INTEGER NOT NULL,
+    length INTEGER NOT NULL DEFAULT 0,
+    title TEXT NOT NULL DEFAULT '',
+    artist TEXT NOT NULL DEFAULT '',
+    track_number INTEGER NOT NULL DEFAULT 0,
+    uploaded_at INTEGER NOT NULL,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- Each album cover is kept as the uploaded original and a few thumbnails,
+-- all in the blob store. variant is 'original' or the thumbnail's name.
+CREATE TABLE album_covers (
+    album_id INTEGER NOT NULL,
+    variant TEXT NOT NULL,
+    digest TEXT NOT NULL,
+    mime_type TEXT NOT NULL CHECK (mime_type IN ('image/jpeg', 'image/png')),
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    uploaded_at INTEGER NOT NULL,
+    PRIMARY KEY (album_id, variant),
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS band_tags;
+DROP TABLE IF EXISTS artist_tags;
+DROP TABLE IF EXISTS song_tags;
+DROP TABLE IF EXISTS album_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS song_genres;
+DROP TABLE IF EXISTS album_genres;
+DROP TABLE IF EXISTS genres;
@@ -0,0 +1,89 @@
+-- Performer credits go back to the columns and join tables. Other roles have
+-- nowhere to go and are lost. Where several artists or bands perform on an
+-- album or song the column keeps the lowest id.
+CREATE TABLE albums_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    artist_id INT,
+    band_id INT,
+    FOREIGN KEY (artist_id) REFERENCES artists(id),
+    FOREIGN KEY (band_id) REFERENCES bands(id)
+);
+INSERT INTO albums_old (id, title, price, artist_id, band_id)
+SELECT id, title, price,
+    (SELECT MIN(artist_id) FROM credits WHERE credits.album_id = albums.id AND role = 'performer'),
+    (SELECT MIN(band_id) FROM credits WHERE credits.album_id = albums.id AND role = 'performer')
+FROM albums;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'albums') WHERE name = 'albums_old';
+DROP TABLE albums;
+ALTER TABLE albums_old RENAME TO albums;
+
+CREATE TABLE songs_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    length INT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    album_id INT,
+    artist_id INT,
+    band_id INT,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE
+);
+INSERT INTO songs_old (id, title, length, price, album_id, artist_id, band_id)
+SELECT id, title, length, price,
+    (SELECT MIN(album_id) FROM album_songs WHERE album_songs.song_id = songs.id),
+    (SELECT MIN(artist_id) FROM credits WHERE credits.song_id = songs.id AND role = 'performer'),
+    (SELECT MIN(band_id) FROM credits WHERE credits.song_id = songs.id AND role = 'performer')
+FROM songs;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'songs') WHERE name = 'songs_old';
+DROP TABLE songs;
+ALTER TABLE songs_old RENAME TO songs;
+
+CREATE TABLE artist_songs (
+    artist_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    PRIMARY KEY (artist_id, song_id),
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+INSERT INTO artist_songs (artist_id, song_id)
+SELECT artist_id, song_id FROM credits WHERE artist_id IS NOT NULL AND song_id IS NOT NULL AND role = 'performer';
+
+CREATE TABLE band_songs (
+    band_id INTEGER NOT NULL,
+    song_id INTEGER NOT NULL,
+    PRIMARY KEY (band_id, song_id),
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+INSERT INTO band_songs (band_id, song_id)
+SELECT band_id, song_id FROM credits WHERE band_id IS NOT NULL AND song_id IS NOT NULL AND role = 'performer';
+
+DROP TABLE credits;
+
+DROP INDEX album_songs_position;
+ALTER TABLE album_songs DROP COLUMN position;
+
+CREATE TRIGGER albums_search_insert AFTER INSERT ON albums BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_update AFTER UPDATE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_delete AFTER DELETE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+END;
+
+CREATE TRIGGER songs_search_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_update AFTER UPDATE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+END;
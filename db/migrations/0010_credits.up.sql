@@ -0,0 +1,113 @@
+-- Credits replace albums.artist_id/band_id, songs.artist_id/band_id and the
+-- artist_songs/band_songs tables with one model: an artist or band credited
+-- on an album or song with a role. Exactly one of album_id and song_id is
+-- set, and exactly one of artist_id and band_id.
+CREATE TABLE credits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    album_id INTEGER,
+    song_id INTEGER,
+    artist_id INTEGER,
+    band_id INTEGER,
+    role TEXT NOT NULL CHECK (role IN ('performer', 'composer', 'lyricist', 'producer', 'featured')),
+    CHECK ((album_id IS NULL) <> (song_id IS NULL)),
+    CHECK ((artist_id IS NULL) <> (band_id IS NULL)),
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE
+);
+
+-- NULLs are distinct in a unique index, so the missing ids count as 0.
+CREATE UNIQUE INDEX credits_unique ON credits (
+    IFNULL(album_id, 0), IFNULL(song_id, 0), IFNULL(artist_id, 0), IFNULL(band_id, 0), role
+);
+CREATE INDEX credits_album_id ON credits (album_id);
+CREATE INDEX credits_song_id ON credits (song_id);
+CREATE INDEX credits_artist_id ON credits (artist_id);
+CREATE INDEX credits_band_id ON credits (band_id);
+
+-- Everything recorded so far is a performer credit. References to rows that
+-- no longer exist were never enforced on the old columns and are dropped.
+INSERT OR IGNORE INTO credits (album_id, artist_id, role)
+SELECT id, artist_id, 'performer' FROM albums
+WHERE EXISTS (SELECT 1 FROM artists WHERE artists.id = albums.artist_id);
+INSERT OR IGNORE INTO credits (album_id, band_id, role)
+SELECT id, band_id, 'performer' FROM albums
+WHERE EXISTS (SELECT 1 FROM bands WHERE bands.id = albums.band_id);
+INSERT OR IGNORE INTO credits (song_id, artist_id, role)
+SELECT id, artist_id, 'performer' FROM songs
+WHERE EXISTS (SELECT 1 FROM artists WHERE artists.id = songs.artist_id);
+INSERT OR IGNORE INTO credits (song_id, band_id, role)
+SELECT id, band_id, 'performer' FROM songs
+WHERE EXISTS (SELECT 1 FROM bands WHERE bands.id = songs.band_id);
+INSERT OR IGNORE INTO credits (song_id, artist_id, role)
+SELECT song_id, artist_id, 'performer' FROM artist_songs
+WHERE EXISTS (SELECT 1 FROM songs WHERE songs.id = artist_songs.song_id)
+AND EXISTS (SELECT 1 FROM artists WHERE artists.id = artist_songs.artist_id);
+INSERT OR IGNORE INTO credits (song_id, band_id, role)
+SELECT song_id, band_id, 'performer' FROM band_songs
+WHERE EXISTS (SELECT 1 FROM songs WHERE songs.id = band_songs.song_id)
+AND EXISTS (SELECT 1 FROM bands WHERE bands.id = band_songs.band_id);
+
+-- album_songs becomes the only record of which songs are on an album.
+INSERT OR IGNORE INTO album_songs (album_id, song_id)
+SELECT album_id, id FROM songs
+WHERE EXISTS (SELECT 1 FROM albums WHERE albums.id = songs.album_id);
+
+-- Tracks keep their order in position. Existing tracks are numbered in song
+-- ID order, which is how they were listed before.
+ALTER TABLE album_songs ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+UPDATE album_songs SET position = (
+    SELECT COUNT(*) FROM album_songs o
+    WHERE o.album_id = album_songs.album_id AND o.song_id <= album_songs.song_id
+);
+CREATE INDEX album_songs_position ON album_songs (album_id, position);
+
+DROP TABLE artist_songs;
+DROP TABLE band_songs;
+
+-- SQLite cannot drop columns that have foreign keys, so albums and songs
+-- are rebuilt without them. Dropping a table drops its triggers, so the
+-- search index triggers from 0002 are recreated.
+CREATE TABLE albums_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0)
+);
+INSERT INTO albums_new (id, title, price) SELECT id, title, price FROM albums;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'albums') WHERE name = 'albums_new';
+DROP TABLE albums;
+ALTER TABLE albums_new RENAME TO albums;
+
+CREATE TABLE songs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    length INT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0)
+);
+INSERT INTO songs_new (id, title, length, price) SELECT id, title, length, price FROM songs;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'songs') WHERE name = 'songs_new';
+DROP TABLE songs;
+ALTER TABLE songs_new RENAME TO songs;
+
+CREATE TRIGGER albums_search_insert AFTER INSERT ON albums BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_update AFTER UPDATE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_delete AFTER DELETE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+END;
+
+CREATE TRIGGER songs_search_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_update AFTER UPDATE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 3, 'song', new.title);
+END;
+CREATE TRIGGER songs_search_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+END;
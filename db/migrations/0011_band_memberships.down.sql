@@ -0,0 +1,72 @@
+-- Current memberships go back to artists.band_id, keeping the lowest band id
+-- for artists in several bands, and number_of_members is stored again as
+-- the count at the time of the rollback. Past memberships, roles and dates
+-- are lost.
+CREATE TABLE artists_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    nationality TEXT NOT NULL,
+    birth_date DATE NOT NULL,
+    age INT NOT NULL,
+    alive BOOLEAN NOT NULL,
+    sex_id INT NOT NULL,
+    title_id INT NOT NULL,
+    band_id INT,
+    FOREIGN KEY (sex_id) REFERENCES sexes(id),
+    FOREIGN KEY (title_id) REFERENCES titles(id),
+    FOREIGN KEY (band_id) REFERENCES bands(id)
+);
+INSERT INTO artists_old (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id)
+SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id,
+    (SELECT MIN(band_id) FROM band_memberships m WHERE m.artist_id = artists.id
+        AND (m.date_joined IS NULL OR m.date_joined <= date('now'))
+        AND (m.date_left IS NULL OR m.date_left > date('now')))
+FROM artists;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'artists') WHERE name = 'artists_old';
+DROP TABLE artists;
+ALTER TABLE artists_old RENAME TO artists;
+
+CREATE TABLE bands_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nationality TEXT,
+    number_of_members INT NOT NULL,
+    date_formed DATE NOT NULL,
+    age INT,
+    active BOOLEAN
+);
+INSERT INTO bands_old (id, name, nationality, number_of_members, date_formed, age, active)
+SELECT id, name, nationality,
+    (SELECT COUNT(DISTINCT artist_id) FROM band_memberships m WHERE m.band_id = bands.id
+        AND (m.date_joined IS NULL OR m.date_joined <= date('now'))
+        AND (m.date_left IS NULL OR m.date_left > date('now'))),
+    date_formed, age, active
+FROM bands;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'bands') WHERE name = 'bands_old';
+DROP TABLE bands;
+ALTER TABLE bands_old RENAME TO bands;
+
+DROP TABLE band_memberships;
+
+CREATE TRIGGER artists_search_insert AFTER INSERT ON artists BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_update AFTER UPDATE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_delete AFTER DELETE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+END;
+
+CREATE TRIGGER bands_search_insert AFTER INSERT ON bands BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_update AFTER UPDATE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_delete AFTER DELETE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+END;
@@ -0,0 +1,80 @@
+-- Band memberships replace artists.band_id, which could only hold one current
+-- band, with a history: an artist plays in a band in a role between two
+-- dates. A missing date_joined means since the band formed, a missing
+-- date_left means to this day. Dates are YYYY-MM-DD, so they compare as text.
+CREATE TABLE band_memberships (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    artist_id INTEGER NOT NULL,
+    band_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT '',
+    date_joined TEXT,
+    date_left TEXT,
+    CHECK (date_joined IS NULL OR date_left IS NULL OR date_left >= date_joined),
+    FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE,
+    FOREIGN KEY (band_id) REFERENCES bands(id) ON DELETE CASCADE
+);
+CREATE INDEX band_memberships_artist_id ON band_memberships (artist_id);
+CREATE INDEX band_memberships_band_id ON band_memberships (band_id);
+
+-- Each artist's band is a current membership with no known dates or role.
+INSERT INTO band_memberships (artist_id, band_id)
+SELECT id, band_id FROM artists
+WHERE EXISTS (SELECT 1 FROM bands WHERE bands.id = artists.band_id);
+
+-- The rebuilt tables drop artists.band_id and bands.number_of_members, which
+-- is now counted from the current memberships. Dropping a table drops its
+-- triggers, so the search index triggers from 0002 are recreated.
+CREATE TABLE artists_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    nationality TEXT NOT NULL,
+    birth_date DATE NOT NULL,
+    age INT NOT NULL,
+    alive BOOLEAN NOT NULL,
+    sex_id INT NOT NULL,
+    title_id INT NOT NULL,
+    FOREIGN KEY (sex_id) REFERENCES sexes(id),
+    FOREIGN KEY (title_id) REFERENCES titles(id)
+);
+INSERT INTO artists_new (id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id)
+SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id FROM artists;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'artists') WHERE name = 'artists_new';
+DROP TABLE artists;
+ALTER TABLE artists_new RENAME TO artists;
+
+CREATE TABLE bands_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nationality TEXT,
+    date_formed DATE NOT NULL,
+    age INT,
+    active BOOLEAN
+);
+INSERT INTO bands_new (id, name, nationality, date_formed, age, active)
+SELECT id, name, nationality, date_formed, age, active FROM bands;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'bands') WHERE name = 'bands_new';
+DROP TABLE bands;
+ALTER TABLE bands_new RENAME TO bands;
+
+CREATE TRIGGER artists_search_insert AFTER INSERT ON artists BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_update AFTER UPDATE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 1, 'artist', new.first_name || ' ' || new.last_name);
+END;
+CREATE TRIGGER artists_search_delete AFTER DELETE ON artists BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+END;
+
+CREATE TRIGGER bands_search_insert AFTER INSERT ON bands BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_update AFTER UPDATE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4 + 2, 'band', new.name);
+END;
+CREATE TRIGGER bands_search_delete AFTER DELETE ON bands BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+END;
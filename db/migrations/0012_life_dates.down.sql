@@ -0,0 +1,20 @@
+-- The stored columns come back holding the values as of the rollback, and
+-- the dates are lost. SQLite can only add NOT NULL columns with a default.
+ALTER TABLE artists ADD COLUMN age INT NOT NULL DEFAULT 0;
+ALTER TABLE artists ADD COLUMN alive BOOLEAN NOT NULL DEFAULT 1;
+ALTER TABLE bands ADD COLUMN age INT;
+ALTER TABLE bands ADD COLUMN active BOOLEAN;
+
+UPDATE artists SET
+    age = MAX(0, CAST(strftime('%Y', IFNULL(MIN(death_date, date('now')), date('now'))) AS INTEGER)
+        - CAST(strftime('%Y', birth_date) AS INTEGER)
+        - (strftime('%m-%d', IFNULL(MIN(death_date, date('now')), date('now'))) < strftime('%m-%d', birth_date))),
+    alive = death_date IS NULL OR death_date > date('now');
+UPDATE bands SET
+    age = MAX(0, CAST(strftime('%Y', IFNULL(MIN(disbanded_date, date('now')), date('now'))) AS INTEGER)
+        - CAST(strftime('%Y', date_formed) AS INTEGER)
+        - (strftime('%m-%d', IFNULL(MIN(disbanded_date, date('now')), date('now'))) < strftime('%m-%d', date_formed))),
+    active = disbanded_date IS NULL OR disbanded_date > date('now');
+
+ALTER TABLE artists DROP COLUMN death_date;
+ALTER TABLE bands DROP COLUMN disbanded_date;
@@ -0,0 +1,18 @@
+-- Age and alive/active are derived from dates when they are read, so the
+-- stored values, which went stale as time passed, are dropped. Artists gain
+-- a death date and bands a disbanded date; NULL means still alive or active.
+ALTER TABLE artists ADD COLUMN death_date TEXT;
+ALTER TABLE bands ADD COLUMN disbanded_date TEXT;
+
+-- The real dates are unknown. Artists and bands recorded as no longer alive
+-- or active get the earliest date that keeps their stored age, never later
+-- than today, so they keep their status. Bands without an age end today.
+UPDATE artists SET death_date = MIN(date(birth_date, '+' || age || ' years'), date('now'))
+WHERE NOT alive;
+UPDATE bands SET disbanded_date = IFNULL(MIN(date(date_formed, '+' || age || ' years'), date('now')), date('now'))
+WHERE NOT active;
+
+ALTER TABLE artists DROP COLUMN age;
+ALTER TABLE artists DROP COLUMN alive;
+ALTER TABLE bands DROP COLUMN age;
+ALTER TABLE bands DROP COLUMN active;
@@ -0,0 +1,25 @@
+DROP TRIGGER reviews_rating_insert;
+DROP TRIGGER reviews_rating_update;
+DROP TRIGGER reviews_rating_delete;
+DROP TABLE reviews;
+
+ALTER TABLE albums DROP COLUMN rating_count;
+ALTER TABLE albums DROP COLUMN rating_sum;
+ALTER TABLE songs DROP COLUMN rating_count;
+ALTER TABLE songs DROP COLUMN rating_sum;
+
+-- Moderators lose the role and become viewers.
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    password TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    role TEXT NOT NULL DEFAULT 'viewer' CHECK (role IN ('admin', 'editor', 'viewer')),
+    tokens_revoked_at INTEGER
+);
+INSERT INTO users_new (id, username, password, email, created_at, role, tokens_revoked_at)
+SELECT id, username, password, email, created_at, IIF(role = 'moderator', 'viewer', role), tokens_revoked_at FROM users;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'users') WHERE name = 'users_new';
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
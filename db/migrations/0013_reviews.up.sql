@@ -0,0 +1,58 @@
+-- Moderators can edit and delete anyone's reviews. SQLite cannot change a
+-- CHECK constraint, so users is rebuilt with the new role allowed.
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    password TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    role TEXT NOT NULL DEFAULT 'viewer' CHECK (role IN ('admin', 'moderator', 'editor', 'viewer')),
+    tokens_revoked_at INTEGER
+);
+INSERT INTO users_new (id, username, password, email, created_at, role, tokens_revoked_at)
+SELECT id, username, password, email, created_at, role, tokens_revoked_at FROM users;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'users') WHERE name = 'users_new';
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+-- A review rates an album or song from 1 to 5, with optional text. Exactly
+-- one of album_id and song_id is set, and each user reviews an item once.
+CREATE TABLE reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    album_id INTEGER,
+    song_id INTEGER,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    CHECK ((album_id IS NULL) <> (song_id IS NULL)),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- NULLs are distinct in a unique index, so the missing id counts as 0.
+CREATE UNIQUE INDEX reviews_unique ON reviews (user_id, IFNULL(album_id, 0), IFNULL(song_id, 0));
+CREATE INDEX reviews_album_id ON reviews (album_id);
+CREATE INDEX reviews_song_id ON reviews (song_id);
+
+-- The number and sum of each album's and song's ratings are kept up to date
+-- by triggers, so listing them never has to aggregate the reviews.
+ALTER TABLE albums ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE albums ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE songs ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE songs ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+
+CREATE TRIGGER reviews_rating_insert AFTER INSERT ON reviews BEGIN
+    UPDATE albums SET rating_count = rating_count + 1, rating_sum = rating_sum + new.rating WHERE id = new.album_id;
+    UPDATE songs SET rating_count = rating_count + 1, rating_sum = rating_sum + new.rating WHERE id = new.song_id;
+END;
+CREATE TRIGGER reviews_rating_update AFTER UPDATE OF rating ON reviews BEGIN
+    UPDATE albums SET rating_sum = rating_sum - old.rating + new.rating WHERE id = new.album_id;
+    UPDATE songs SET rating_sum = rating_sum - old.rating + new.rating WHERE id = new.song_id;
+END;
+CREATE TRIGGER reviews_rating_delete AFTER DELETE ON reviews BEGIN
+    UPDATE albums SET rating_count = rating_count - 1, rating_sum = rating_sum - old.rating WHERE id = old.album_id;
+    UPDATE songs SET rating_count = rating_count - 1, rating_sum = rating_sum - old.rating WHERE id = old.song_id;
+END;
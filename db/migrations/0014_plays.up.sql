@@ -0,0 +1,20 @@
+-- Each row is one play of a song reported by a client. duration is how many
+-- seconds were listened to, which can be less than the song's length.
+-- Clients submitting plays recorded offline give each one a client_id, so a
+-- retried batch doesn't count them twice; NULLs are distinct, so plays
+-- without one are never deduplicated. Plays of deleted songs keep counting
+-- towards listening time.
+CREATE TABLE plays (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    song_id INTEGER,
+    played_at INTEGER NOT NULL,
+    duration INTEGER NOT NULL CHECK (duration >= 0),
+    client_id TEXT,
+    UNIQUE (user_id, client_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX plays_user_id_played_at ON plays (user_id, played_at);
+CREATE INDEX plays_song_id ON plays (song_id);
@@ -0,0 +1,14 @@
+-- The item-to-item similarity matrix behind the recommendations: for each
+-- song, the songs most often listened to by the same users, with their cosine
+-- similarity from 0 to 1. A background job replaces the whole table on a
+-- schedule, so it may lag behind the latest plays.
+CREATE TABLE song_similarity (
+    song_id INTEGER NOT NULL,
+    similar_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    PRIMARY KEY (song_id, similar_id),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    FOREIGN KEY (similar_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX song_similarity_similar_id ON song_similarity (similar_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chart_entries;
+DROP TABLE IF EXISTS charts;
@@ -0,0 +1,23 @@
+-- Chart snapshots. A chart ranks the songs or albums by their decayed
+-- score over the day (daily) or the seven days (weekly) up to the end of
+-- its UTC date. Once a date is over its charts are stored, so later changes
+-- to plays, orders or reviews don't rewrite history.
+CREATE TABLE charts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('songs', 'albums')),
+    period TEXT NOT NULL CHECK (period IN ('daily', 'weekly')),
+    chart_date TEXT NOT NULL,
+    UNIQUE (kind, period, chart_date)
+);
+
+-- item_id is a song or album ID, depending on the chart's kind. It has no
+-- foreign key so past charts keep their positions when an entry is deleted.
+CREATE TABLE chart_entries (
+    chart_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    item_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    previous_position INTEGER,
+    PRIMARY KEY (chart_id, position),
+    FOREIGN KEY (chart_id) REFERENCES charts(id) ON DELETE CASCADE
+);
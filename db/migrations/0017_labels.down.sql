@@ -0,0 +1,46 @@
+-- SQLite cannot drop a column with a foreign key, so albums is rebuilt
+-- without the release metadata. Dropping a table drops its triggers, so the
+-- search index triggers are recreated, and the rating triggers on reviews,
+-- which update albums, are dropped while it is missing.
+DROP TRIGGER reviews_rating_insert;
+DROP TRIGGER reviews_rating_update;
+DROP TRIGGER reviews_rating_delete;
+
+CREATE TABLE albums_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    rating_count INTEGER NOT NULL DEFAULT 0,
+    rating_sum INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO albums_old (id, title, price, rating_count, rating_sum)
+SELECT id, title, price, rating_count, rating_sum FROM albums;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'albums') WHERE name = 'albums_old';
+DROP TABLE albums;
+ALTER TABLE albums_old RENAME TO albums;
+
+CREATE TRIGGER albums_search_insert AFTER INSERT ON albums BEGIN
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_update AFTER UPDATE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+    INSERT INTO search_index (rowid, kind, name) VALUES (new.id * 4, 'album', new.title);
+END;
+CREATE TRIGGER albums_search_delete AFTER DELETE ON albums BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4;
+END;
+
+CREATE TRIGGER reviews_rating_insert AFTER INSERT ON reviews BEGIN
+    UPDATE albums SET rating_count = rating_count + 1, rating_sum = rating_sum + new.rating WHERE id = new.album_id;
+    UPDATE songs SET rating_count = rating_count + 1, rating_sum = rating_sum + new.rating WHERE id = new.song_id;
+END;
+CREATE TRIGGER reviews_rating_update AFTER UPDATE OF rating ON reviews BEGIN
+    UPDATE albums SET rating_sum = rating_sum - old.rating + new.rating WHERE id = new.album_id;
+    UPDATE songs SET rating_sum = rating_sum - old.rating + new.rating WHERE id = new.song_id;
+END;
+CREATE TRIGGER reviews_rating_delete AFTER DELETE ON reviews BEGIN
+    UPDATE albums SET rating_count = rating_count - 1, rating_sum = rating_sum - old.rating WHERE id = old.album_id;
+    UPDATE songs SET rating_count = rating_count - 1, rating_sum = rating_sum - old.rating WHERE id = old.song_id;
+END;
+
+DROP TABLE labels;
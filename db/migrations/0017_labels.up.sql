@@ -0,0 +1,17 @@
+-- Record labels, and release metadata for albums. An album is released on
+-- at most one label; deleting the label leaves its albums without one.
+CREATE TABLE labels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    country TEXT
+);
+
+ALTER TABLE albums ADD COLUMN label_id INTEGER REFERENCES labels(id) ON DELETE SET NULL;
+ALTER TABLE albums ADD COLUMN release_date TEXT;
+ALTER TABLE albums ADD COLUMN catalog_number TEXT;
+ALTER TABLE albums ADD COLUMN barcode TEXT;
+ALTER TABLE albums ADD COLUMN format TEXT CHECK (format IN ('LP', 'CD', 'digital'));
+
+CREATE INDEX albums_label ON albums (label_id, release_date);
+-- A UPC or EAN identifies one release. NULLs don't collide.
+CREATE UNIQUE INDEX albums_barcode ON albums (barcode);
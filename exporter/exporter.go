@@ -0,0 +1,244 @@
+// Package exporter writes catalog exports and tracklists. The catalog is
+// encoded one record at a time, as JSON, newline-delimited JSON or CSV, so
+// an export of any size is never held in memory. Album and playlist
+// tracklists are written as XSPF or M3U8 playlist files.
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"goMusic/models"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of an export.
+type Format string
+
+const (
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+	XSPF   Format = "xspf"
+	M3U8   Format = "m3u8"
+)
+
+// CatalogFormats can encode the whole catalog; TracklistFormats can encode
+// an album or playlist.
+var (
+	CatalogFormats   = []Format{JSON, CSV, NDJSON}
+	TracklistFormats = []Format{XSPF, M3U8}
+)
+
+// FormatFor returns the format of a file from its extension, such as
+// ".csv".
+func FormatFor(ext string) (Format, bool) {
+	switch strings.ToLower(ext) {
+	case ".json":
+		return JSON, true
+	case ".ndjson", ".jsonl":
+		return NDJSON, true
+	case ".csv":
+		return CSV, true
+	case ".xspf":
+		return XSPF, true
+	case ".m3u8", ".m3u":
+		return M3U8, true
+	}
+	return "", false
+}
+
+// ContentType is the media type of a file in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json"
+	case NDJSON:
+		return "application/x-ndjson"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XSPF:
+		return "application/xspf+xml"
+	case M3U8:
+		return "application/vnd.apple.mpegurl"
+	}
+	return "application/octet-stream"
+}
+
+// Encoder writes the records of a catalog export. Records must arrive kind
+// by kind in the order of models.ExportTypes, as repositories export them.
+type Encoder interface {
+	Encode(record models.ExportRecord) error
+	// Close finishes the export. It does not close the underlying writer.
+	Close() error
+}
+
+// NewEncoder returns an encoder writing the catalog to w in one of the
+// CatalogFormats.
+func NewEncoder(w io.Writer, format Format) (Encoder, error) {
+	switch format {
+	case JSON:
+		return &jsonEncoder{w: bufio.NewWriter(w)}, nil
+	case NDJSON:
+		return &ndjsonEncoder{w: bufio.NewWriter(w)}, nil
+	case CSV:
+		return newCSVEncoder(w)
+	}
+	return nil, fmt.Errorf("cannot export the catalog as %q", format)
+}
+
+// columns checks a record against models.ExportColumns.
+func columns(record models.ExportRecord) ([]string, error) {
+	names, ok := models.ExportColumns[record.Type]
+	if !ok || len(names) != len(record.Values) {
+		return nil, fmt.Errorf("malformed %q export record", record.Type)
+	}
+	return names, nil
+}
+
+// writeObject writes the record's values as a JSON object, in column
+// order, starting with the given fields.
+func writeObject(w *bufio.Writer, record models.ExportRecord, names []string, leading string) error {
+	w.WriteByte('{')
+	w.WriteString(leading)
+	for i, name := range names {
+		value, err := json.Marshal(record.Values[i])
+		if err != nil {
+			return err
+		}
+		if i > 0 || leading != "" {
+			w.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		w.Write(key)
+		w.WriteByte(':')
+		w.Write(value)
+	}
+	return w.WriteByte('}')
+}
+
+// jsonEncoder writes one object with an array for each kind of record,
+// such as {"artists": [...], "bands": [...], ...}. Every kind is present,
+// even when it has no records.
+type jsonEncoder struct {
+	w *bufio.Writer
+	// opened counts the arrays started so far; the last one is still open.
+	opened int
+	empty  bool
+}
+
+func (e *jsonEncoder) Encode(record models.ExportRecord) error {
+	names, err := columns(record)
+	if err != nil {
+		return err
+	}
+	kind := slices.Index(models.ExportTypes, record.Type)
+	if kind+1 < e.opened {
+		return fmt.Errorf("%q export record after the %ss", record.Type, models.ExportTypes[e.opened-1])
+	}
+	e.openUntil(kind + 1)
+	if !e.empty {
+		e.w.WriteByte(',')
+	}
+	e.empty = false
+	return writeObject(e.w, record, names, "")
+}
+
+// openUntil closes the open array and starts the arrays up to the nth kind.
+func (e *jsonEncoder) openUntil(n int) {
+	for e.opened < n {
+		if e.opened == 0 {
+			e.w.WriteByte('{')
+		} else {
+			e.w.WriteString("],")
+		}
+		e.w.WriteString(`"` + models.ExportTypes[e.opened] + `s":[`)
+		e.opened++
+		e.empty = true
+	}
+}
+
+func (e *jsonEncoder) Close() error {
+	e.openUntil(len(models.ExportTypes))
+	e.w.WriteString("]}\n")
+	return e.w.Flush()
+}
+
+// ndjsonEncoder writes one object per line, with the kind of record in its
+// "type" field.
+type ndjsonEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *ndjsonEncoder) Encode(record models.ExportRecord) error {
+	names, err := columns(record)
+	if err != nil {
+		return err
+	}
+	if err := writeObject(e.w, record, names, `"type":`+strconv.Quote(record.Type)); err != nil {
+		return err
+	}
+	return e.w.WriteByte('\n')
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// csvEncoder writes one row per record under a header with a "type" column
+// and every column any kind of record has. A row leaves the columns its
+// kind doesn't have empty, and so are missing values.
+type csvEncoder struct {
+	w *csv.Writer
+	// positions holds where each kind's columns go in a row.
+	positions map[string][]int
+	header    []string
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), positions: map[string][]int{}, header: []string{"type"}}
+	for _, kind := range models.ExportTypes {
+		for _, name := range models.ExportColumns[kind] {
+			position := slices.Index(e.header, name)
+			if position < 0 {
+				position = len(e.header)
+				e.header = append(e.header, name)
+			}
+			e.positions[kind] = append(e.positions[kind], position)
+		}
+	}
+	return e, e.w.Write(e.header)
+}
+
+func (e *csvEncoder) Encode(record models.ExportRecord) error {
+	if _, err := columns(record); err != nil {
+		return err
+	}
+	row := make([]string, len(e.header))
+	row[0] = record.Type
+	for i, value := range record.Values {
+		if value != nil {
+			row[e.positions[record.Type][i]] = formatValue(value)
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,137 @@
+package exporter_test
+
+import (
+	"goMusic/exporter"
+	"goMusic/models"
+	"strings"
+	"testing"
+)
+
+var records = []models.ExportRecord{
+	{Type: "band", Values: []any{1, "Radiohead", "British", "1985-09-01", nil}},
+	{Type: "label", Values: []any{1, "Parlophone", "United Kingdom"}},
+	{Type: "album", Values: []any{1, "OK Computer", 999, 1, "1997-05-21", "NODATA 02", nil, "CD"}},
+	{Type: "album", Values: []any{2, "Kid A, Remastered", 1250, nil, nil, nil, nil, nil}},
+	{Type: "tag", Values: []any{"album", 2, "electronic"}},
+}
+
+func encode(t *testing.T, format exporter.Format) string {
+	t.Helper()
+	var out strings.Builder
+	encoder, err := exporter.NewEncoder(&out, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestEncode(t *testing.T) {
+	tests := map[exporter.Format]string{
+		exporter.JSON: `{"artists":[],"bands":[{"id":1,"name":"Radiohead","nationality":"British","date_formed":"1985-09-01","disbanded_date":null}],` +
+			`"memberships":[],"genres":[],"labels":[{"id":1,"name":"Parlophone","country":"United Kingdom"}],` +
+			`"albums":[{"id":1,"title":"OK Computer","price":999,"label_id":1,"release_date":"1997-05-21","catalog_number":"NODATA 02","barcode":null,"format":"CD"},` +
+			`{"id":2,"title":"Kid A, Remastered","price":1250,"label_id":null,"release_date":null,"catalog_number":null,"barcode":null,"format":null}],` +
+			`"songs":[],"tracks":[],"credits":[],"album_genres":[],"song_genres":[],"tags":[{"entity_type":"album","entity_id":2,"name":"electronic"}]}` + "\n",
+		exporter.NDJSON: `{"type":"band","id":1,"name":"Radiohead","nationality":"British","date_formed":"1985-09-01","disbanded_date":null}` + "\n" +
+			`{"type":"label","id":1,"name":"Parlophone","country":"United Kingdom"}` + "\n" +
+			`{"type":"album","id":1,"title":"OK Computer","price":999,"label_id":1,"release_date":"1997-05-21","catalog_number":"NODATA 02","barcode":null,"format":"CD"}` + "\n" +
+			`{"type":"album","id":2,"title":"Kid A, Remastered","price":1250,"label_id":null,"release_date":null,"catalog_number":null,"barcode":null,"format":null}` + "\n" +
+			`{"type":"tag","entity_type":"album","entity_id":2,"name":"electronic"}` + "\n",
+	}
+	for format, want := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			if got := encode(t, format); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		lines := strings.Split(encode(t, exporter.CSV), "\n")
+		header := "type,id,first_name,last_name,nationality,birth_date,death_date,sex_id,title_id,name,date_formed,disbanded_date," +
+			"artist_id,band_id,role,date_joined,date_left,parent_id,country,title,price,label_id,release_date,catalog_number,barcode,format," +
+			"length,album_id,song_id,position,genre_id,entity_type,entity_id"
+		if lines[0] != header {
+			t.Errorf("header = %s", lines[0])
+		}
+		if want := "album,1,,,,,,,,,,,,,,,,,,OK Computer,999,1,1997-05-21,NODATA 02,,CD,,,,,,,"; lines[3] != want {
+			t.Errorf("album row = %s, want %s", lines[3], want)
+		}
+		if want := "album,2,,,,,,,,,,,,,,,,,,\"Kid A, Remastered\",1250,,,,,,,,,,,,"; lines[4] != want {
+			t.Errorf("album row = %s, want %s", lines[4], want)
+		}
+		if want := "tag,,,,,,,,,electronic,,,,,,,,,,,,,,,,,,,,,,album,2"; lines[5] != want {
+			t.Errorf("tag row = %s, want %s", lines[5], want)
+		}
+	})
+}
+
+func TestEncodeRejectsBadRecords(t *testing.T) {
+	tests := map[string][]models.ExportRecord{
+		"out of order":  {{Type: "label", Values: []any{1, "Parlophone", nil}}, {Type: "band", Values: []any{1, "Radiohead", "British", "1985-09-01", nil}}},
+		"unknown type":  {{Type: "playlist", Values: []any{1}}},
+		"wrong columns": {{Type: "album", Values: []any{1}}},
+	}
+	for name, records := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoder, _ := exporter.NewEncoder(&strings.Builder{}, exporter.JSON)
+			var err error
+			for _, record := range records {
+				if err = encoder.Encode(record); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestWriteTracklist(t *testing.T) {
+	list := models.Tracklist{
+		Title:   "Songs & Stories\nvol. 1",
+		Creator: "alice",
+		Tracks: []models.TracklistTrack{
+			{SongId: 3, Title: "Airbag", Creator: "Radiohead", Album: "OK Computer", Length: 284, TrackNum: 1},
+			{SongId: 7, Title: "Untitled", Length: 0, TrackNum: 2},
+		},
+	}
+
+	var xspf strings.Builder
+	if err := exporter.WriteTracklist(&xspf, list, exporter.XSPF, "https://music.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<playlist xmlns="http://xspf.org/ns/0/" version="1">`,
+		"<title>Songs &amp; Stories&#xA;vol. 1</title>",
+		"<location>https://music.example.com/songs/3/audio</location>",
+		"<duration>284000</duration>",
+		"<track>\n      <location>https://music.example.com/songs/7/audio</location>\n      <title>Untitled</title>\n      <trackNum>2</trackNum>\n    </track>",
+	} {
+		if !strings.Contains(xspf.String(), want) {
+			t.Errorf("XSPF is missing %q:\n%s", want, xspf.String())
+		}
+	}
+
+	var m3u8 strings.Builder
+	if err := exporter.WriteTracklist(&m3u8, list, exporter.M3U8, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXTM3U\n#PLAYLIST:Songs & Stories vol. 1\n#EXTINF:284,Radiohead - Airbag\n/songs/3/audio\n#EXTINF:0,Untitled\n/songs/7/audio\n"
+	if m3u8.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", m3u8.String(), want)
+	}
+
+	if err := exporter.WriteTracklist(&m3u8, list, exporter.CSV, ""); err == nil {
+		t.Error("expected an error for a catalog format")
+	}
+}
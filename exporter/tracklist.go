@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"goMusic/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteTracklist writes an album or playlist to w in one of the
+// TracklistFormats. Each track's location is its audio stream under
+// baseURL, which may be empty for paths relative to the server root.
+func WriteTracklist(w io.Writer, list models.Tracklist, format Format, baseURL string) error {
+	switch format {
+	case XSPF:
+		return writeXSPF(w, list, baseURL)
+	case M3U8:
+		return writeM3U8(w, list, baseURL)
+	}
+	return fmt.Errorf("cannot export a tracklist as %q", format)
+}
+
+func trackLocation(baseURL string, track models.TracklistTrack) string {
+	return strings.TrimSuffix(baseURL, "/") + "/songs/" + strconv.Itoa(track.SongId) + "/audio"
+}
+
+// xspfPlaylist is an XSPF (https://xspf.org) document. Its elements are
+// declared in the order the specification requires.
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int         `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Creator string      `xml:"creator,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	TrackNum int    `xml:"trackNum,omitempty"`
+	// Duration is in milliseconds.
+	Duration int `xml:"duration,omitempty"`
+}
+
+func writeXSPF(w io.Writer, list models.Tracklist, baseURL string) error {
+	doc := xspfPlaylist{Version: 1, Title: list.Title, Creator: list.Creator, Tracks: []xspfTrack{}}
+	for _, track := range list.Tracks {
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: trackLocation(baseURL, track),
+			Title:    track.Title,
+			Creator:  track.Creator,
+			Album:    track.Album,
+			TrackNum: track.TrackNum,
+			Duration: track.Length * 1000,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeM3U8 writes an extended M3U playlist in UTF-8, giving each track's
+// length and "creator - title" before its location.
+func writeM3U8(w io.Writer, list models.Tracklist, baseURL string) error {
+	// Every directive is one line, so line breaks in names are dropped.
+	oneLine := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+	b := bufio.NewWriter(w)
+	b.WriteString("#EXTM3U\n")
+	if list.Title != "" {
+		b.WriteString("#PLAYLIST:" + oneLine.Replace(list.Title) + "\n")
+	}
+	for _, track := range list.Tracks {
+		name := track.Title
+		if track.Creator != "" {
+			name = track.Creator + " - " + name
+		}
+		b.WriteString("#EXTINF:" + strconv.Itoa(track.Length) + "," + oneLine.Replace(name) + "\n")
+		b.WriteString(trackLocation(baseURL, track) + "\n")
+	}
+	return b.Flush()
+}
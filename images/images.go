@@ -0,0 +1,75 @@
+// Package images makes thumbnails with the standard library alone, by
+// averaging the source pixels each thumbnail pixel covers.
+package images
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit scales width and height down to fit within a square of the given
+// size, keeping the aspect ratio. Images that already fit are not enlarged.
+func Fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// RGBA converts an image to RGBA so it can be resized repeatedly without
+// going through the image.Image interface for every pixel.
+func RGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, src, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Thumbnail shrinks src to fit within a square of the given size.
+func Thumbnail(src *image.RGBA, size int) *image.RGBA {
+	width, height := Fit(src.Rect.Dx(), src.Rect.Dy(), size)
+	return Resize(src, width, height)
+}
+
+// Resize scales src to width by height. Every destination pixel is the
+// average of the block of source pixels it covers, which keeps detail when
+// shrinking; when enlarging it repeats the nearest pixel.
+func Resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Rect.Dx(), src.Rect.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8((r + n/2) / n)
+			d[1] = uint8((g + n/2) / n)
+			d[2] = uint8((b + n/2) / n)
+			d[3] = uint8((a + n/2) / n)
+		}
+	}
+	return dst
+}
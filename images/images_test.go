@@ -0,0 +1,50 @@
+package images_test
+
+import (
+	"goMusic/images"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		wantW, wantH        int
+	}{
+		{1000, 1000, 300, 300, 300},
+		{1200, 600, 300, 300, 150},
+		{600, 1200, 300, 150, 300},
+		{200, 100, 300, 200, 100},
+		{3000, 2, 300, 300, 1},
+	}
+	for _, tt := range tests {
+		if w, h := images.Fit(tt.width, tt.height, tt.size); w != tt.wantW || h != tt.wantH {
+			t.Errorf("Fit(%d, %d, %d) = %d, %d; want %d, %d", tt.width, tt.height, tt.size, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// Alternating black and white columns average to grey.
+	src := image.NewNRGBA(image.Rect(10, 10, 410, 210))
+	for y := 10; y < 210; y++ {
+		for x := 10; x < 410; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := images.Thumbnail(images.RGBA(src), 100)
+	if thumb.Rect != image.Rect(0, 0, 100, 50) {
+		t.Fatalf("wrong size %v", thumb.Rect)
+	}
+	for _, p := range []image.Point{{0, 0}, {50, 25}, {99, 49}} {
+		if c := thumb.RGBAAt(p.X, p.Y); c != (color.RGBA{128, 128, 128, 255}) {
+			t.Errorf("pixel %v is %v, want grey", p, c)
+		}
+	}
+}
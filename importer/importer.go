@@ -0,0 +1,294 @@
+// Package importer reads catalog import files: CSV or JSON Lines with one
+// artist, band, album or song on each line. Albums and songs name the
+// artists, bands and albums elsewhere in the file that they belong to, and
+// every row is checked with the same rules as the API.
+package importer
+
+import (
+	"cmp"
+	"fmt"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/validation"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of an import file.
+type Format string
+
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+)
+
+// FormatFor returns the format of a file from its extension, such as
+// ".csv", or a media type, such as "application/x-ndjson".
+func FormatFor(name string) (Format, bool) {
+	switch strings.ToLower(name) {
+	case ".csv", "text/csv":
+		return CSV, true
+	case ".jsonl", ".ndjson", "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return JSONLines, true
+	}
+	return "", false
+}
+
+// Types lists the kinds of row, in the order they are created.
+var Types = []string{"artist", "band", "album", "song"}
+
+// Columns lists the columns each kind of row uses besides "type". Artists
+// are referred to by "first_name last_name", bands by name and albums by
+// title, so those must be unique within a file.
+var Columns = map[string][]string{
+	"artist": {"first_name", "last_name", "nationality", "birth_date", "death_date", "sex_id", "title_id"},
+	"band":   {"name", "nationality", "date_formed", "disbanded_date"},
+	"album":  {"title", "price", "release_date", "catalog_number", "barcode", "format", "artist", "band"},
+	"song":   {"title", "length", "price", "album", "artist", "band"},
+}
+
+// Read parses an import file and checks every row. The problems found are
+// returned as field errors naming their line, in line order, and the batch
+// must not be written unless there are none. err is only set when the file
+// can't be read at all.
+func Read(r io.Reader, format Format) (models.CatalogImport, []problem.FieldError, error) {
+	var rows []row
+	var problems []problem.FieldError
+	var err error
+	switch format {
+	case CSV:
+		rows, problems, err = readCSV(r)
+	case JSONLines:
+		rows, problems, err = readJSONLines(r)
+	default:
+		err = fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return models.CatalogImport{}, nil, err
+	}
+
+	b := &builder{problems: problems, names: map[string]map[string]int{}, lines: map[string]map[string]int{}}
+	for _, row := range rows {
+		b.add(row)
+	}
+	for _, ref := range b.refs {
+		b.resolve(ref)
+	}
+
+	slices.SortStableFunc(b.problems, func(x, y problem.FieldError) int { return cmp.Compare(x.Line, y.Line) })
+	return b.batch, b.problems, nil
+}
+
+// builder turns rows into a batch, collecting every problem on the way.
+type builder struct {
+	batch    models.CatalogImport
+	problems []problem.FieldError
+	// names holds the batch index of each artist, band and album by natural
+	// key, and lines the line each was defined on.
+	names map[string]map[string]int
+	lines map[string]map[string]int
+	// refs are resolved once every row has been read, so rows may refer to
+	// rows further down the file.
+	refs []reference
+}
+
+// reference is a column naming another row, and how to record the index of
+// the row it names.
+type reference struct {
+	line   int
+	column string
+	name   string
+	set    func(index int)
+}
+
+func (b *builder) fail(line int, field, rule, message string) {
+	b.problems = append(b.problems, problem.FieldError{Line: line, Field: field, Rule: rule, Message: message})
+}
+
+func (b *builder) add(r row) {
+	kind := strings.ToLower(r.fields["type"])
+	f := &fields{row: r, b: b, used: map[string]bool{"type": true}, bad: map[string]bool{}}
+
+	switch kind {
+	case "artist":
+		artist := models.Artist{
+			FirstName:   f.text("first_name"),
+			LastName:    f.text("last_name"),
+			Nationality: f.text("nationality"),
+			BirthDate:   f.text("birth_date"),
+			DeathDate:   f.optional("death_date"),
+			SexId:       f.id("sex_id"),
+			TitleId:     f.id("title_id"),
+		}
+		if f.validate(&artist) && artist.DeathDate != nil && *artist.DeathDate < artist.BirthDate {
+			b.fail(r.line, "death_date", "gtefield", "must not be before birth_date")
+		}
+		b.name(r.line, "artist", "first_name", artist.FirstName+" "+artist.LastName, len(b.batch.Artists))
+		b.batch.Artists = append(b.batch.Artists, artist)
+
+	case "band":
+		band := models.Band{
+			Name:          f.text("name"),
+			Nationality:   f.text("nationality"),
+			DateFormed:    f.text("date_formed"),
+			DisbandedDate: f.optional("disbanded_date"),
+		}
+		if f.validate(&band) && band.DisbandedDate != nil && *band.DisbandedDate < band.DateFormed {
+			b.fail(r.line, "disbanded_date", "gtefield", "must not be before date_formed")
+		}
+		b.name(r.line, "band", "name", band.Name, len(b.batch.Bands))
+		b.batch.Bands = append(b.batch.Bands, band)
+
+	case "album":
+		i := len(b.batch.Albums)
+		album := models.Album{
+			Title:         f.text("title"),
+			Price:         int64(f.integer("price")),
+			ReleaseDate:   f.optional("release_date"),
+			CatalogNumber: f.optional("catalog_number"),
+			Barcode:       f.optional("barcode"),
+			Format:        f.optional("format"),
+		}
+		f.validate(&album)
+		b.name(r.line, "album", "title", album.Title, i)
+		b.batch.Albums = append(b.batch.Albums, models.ImportedAlbum{Album: album})
+		f.ref("artist", func(index int) { b.batch.Albums[i].Artist = &index })
+		f.ref("band", func(index int) { b.batch.Albums[i].Band = &index })
+
+	case "song":
+		i := len(b.batch.Songs)
+		song := models.Song{Title: f.text("title"), Length: f.integer("length"), Price: int64(f.integer("price"))}
+		f.validate(&song)
+		b.batch.Songs = append(b.batch.Songs, models.ImportedSong{Song: song})
+		f.ref("album", func(index int) { b.batch.Songs[i].Album = &index })
+		f.ref("artist", func(index int) { b.batch.Songs[i].Artist = &index })
+		f.ref("band", func(index int) { b.batch.Songs[i].Band = &index })
+
+	case "":
+		b.fail(r.line, "type", "required", "is required")
+		return
+	default:
+		b.fail(r.line, "type", "oneof", "must be one of "+strings.Join(Types, ", "))
+		return
+	}
+	f.unused(kind)
+}
+
+// name records the natural key of a row so other rows can refer to it.
+func (b *builder) name(line int, kind, field, key string, index int) {
+	if b.names[kind] == nil {
+		b.names[kind] = map[string]int{}
+		b.lines[kind] = map[string]int{}
+	}
+	if first, ok := b.lines[kind][key]; ok {
+		b.fail(line, field, "unique", fmt.Sprintf("names the same %s as line %d", kind, first))
+		return
+	}
+	b.names[kind][key] = index
+	b.lines[kind][key] = line
+}
+
+func (b *builder) resolve(ref reference) {
+	index, ok := b.names[ref.column][ref.name]
+	if !ok {
+		b.fail(ref.line, ref.column, "reference", "does not match a"+article(ref.column)+" "+ref.column+" in this file")
+		return
+	}
+	ref.set(index)
+}
+
+func article(kind string) string {
+	if strings.IndexByte("aeiou", kind[0]) >= 0 {
+		return "n"
+	}
+	return ""
+}
+
+// fields hands out the values of one row, remembering which columns were
+// used so the rest can be reported, and which could not be parsed so they
+// are not reported twice.
+type fields struct {
+	row
+	b    *builder
+	used map[string]bool
+	bad  map[string]bool
+}
+
+func (f *fields) text(column string) string {
+	f.used[column] = true
+	return f.row.fields[column]
+}
+
+func (f *fields) optional(column string) *string {
+	if value := f.text(column); value != "" {
+		return &value
+	}
+	return nil
+}
+
+func (f *fields) integer(column string) int {
+	value := f.text(column)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		f.bad[column] = true
+		f.b.fail(f.line, column, "numeric", "must be a whole number")
+	}
+	return n
+}
+
+func (f *fields) id(column string) *int {
+	if f.row.fields[column] == "" {
+		f.used[column] = true
+		return nil
+	}
+	n := f.integer(column)
+	return &n
+}
+
+func (f *fields) ref(column string, set func(index int)) {
+	if name := f.text(column); name != "" {
+		f.b.refs = append(f.b.refs, reference{line: f.line, column: column, name: name, set: set})
+	}
+}
+
+// validate checks a record with the API's rules, reporting whether it
+// passed.
+func (f *fields) validate(v any) bool {
+	err := validation.ValidateStruct(v)
+	if err == nil {
+		return len(f.bad) == 0
+	}
+	for _, fe := range validation.FieldErrors(err) {
+		if !f.bad[fe.Field] {
+			fe.Line = f.line
+			f.b.problems = append(f.b.problems, fe)
+		}
+	}
+	return false
+}
+
+// unused reports the columns a row has a value for but doesn't use.
+func (f *fields) unused(kind string) {
+	var extra []string
+	for column := range f.row.fields {
+		if !f.used[column] {
+			extra = append(extra, column)
+		}
+	}
+	slices.Sort(extra)
+	for _, column := range extra {
+		message := "is not a known column"
+		for _, other := range Types {
+			if slices.Contains(Columns[other], column) {
+				message = "does not apply to a" + article(kind) + " " + kind
+				break
+			}
+		}
+		f.b.fail(f.line, column, "unknown", message)
+	}
+}
@@ -0,0 +1,121 @@
+package importer_test
+
+import (
+	"goMusic/importer"
+	"goMusic/problem"
+	"strings"
+	"testing"
+)
+
+const catalogCSV = `type,first_name,last_name,nationality,birth_date,sex_id,title_id,name,date_formed,title,price,length,album,artist,band
+song,,,,,,,,,Airbag,99,284,OK Computer,,Radiohead
+album,,,,,,,,,OK Computer,999,,,,Radiohead
+band,,,British,,,,Radiohead,1985-09-01,,,,,,
+artist,Thom,Yorke,British,1968-10-07,1,1,,,,,,,,
+song,,,,,,,,,Hearing Damage,99,260,,Thom Yorke,
+`
+
+func TestReadResolvesReferencesAnywhereInTheFile(t *testing.T) {
+	jsonLines := `{"type": "song", "title": "Airbag", "price": 99, "length": 284, "album": "OK Computer", "band": "Radiohead"}
+{"type": "album", "title": "OK Computer", "price": 999, "band": "Radiohead"}
+
+{"type": "band", "name": "Radiohead", "nationality": "British", "date_formed": "1985-09-01", "disbanded_date": null}
+{"type": "artist", "first_name": "Thom", "last_name": "Yorke", "nationality": "British", "birth_date": "1968-10-07", "sex_id": 1, "title_id": 1}
+{"type": "song", "title": "Hearing Damage", "price": 99, "length": 260, "artist": "Thom Yorke"}
+`
+	for format, text := range map[importer.Format]string{importer.CSV: catalogCSV, importer.JSONLines: jsonLines} {
+		t.Run(string(format), func(t *testing.T) {
+			batch, problems, err := importer.Read(strings.NewReader(text), format)
+			if err != nil || len(problems) != 0 {
+				t.Fatalf("Read = %+v, %v", problems, err)
+			}
+			if len(batch.Artists) != 1 || len(batch.Bands) != 1 || len(batch.Albums) != 1 || len(batch.Songs) != 2 {
+				t.Fatalf("wrong batch: %+v", batch)
+			}
+
+			airbag, damage := batch.Songs[0], batch.Songs[1]
+			if airbag.Song.Length != 284 || airbag.Album == nil || *airbag.Album != 0 || airbag.Band == nil || airbag.Artist != nil {
+				t.Errorf("Airbag should be on the album by the band: %+v", airbag)
+			}
+			if damage.Artist == nil || *damage.Artist != 0 || damage.Album != nil {
+				t.Errorf("Hearing Damage should be by the artist: %+v", damage)
+			}
+			if album := batch.Albums[0]; album.Album.Price != 999 || album.Band == nil || *album.Band != 0 {
+				t.Errorf("wrong album: %+v", album)
+			}
+			if artist := batch.Artists[0]; artist.SexId == nil || *artist.SexId != 1 || artist.DeathDate != nil {
+				t.Errorf("wrong artist: %+v", artist)
+			}
+		})
+	}
+}
+
+func TestReadReportsEveryProblem(t *testing.T) {
+	text := `type,first_name,last_name,nationality,birth_date,death_date,sex_id,title_id,name,date_formed,title,price,length,album,band,label
+artist,Thom,Yorke,British,1968-10-07,,1,1,,,,,,,,
+artist,Thom,Yorke,British,1968,1960-01-01,1,1,,,,,,,,
+artist,Ed,Young,British,1990-01-01,1980-01-01,1,1,,,,,,,,
+band,,,British,,,,,Radiohead,1985-09-01,,,,,,EMI
+album,,,,,,,,,,Kid A,cheap,,,Radiohead,
+song,,,,,,,,,,Idioteque,99,309,Kid B,,
+song,,,,,,,,Radiohead,,Motion Picture Soundtrack,99,419,Kid A,,
+record,,,,,,,,,,,,,,,
+,,,,,,,,,,,,,,,
+too,few
+`
+	_, problems, err := importer.Read(strings.NewReader(text), importer.CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []problem.FieldError{
+		{Line: 3, Field: "birth_date", Rule: "datetime"},
+		{Line: 3, Field: "first_name", Rule: "unique"},
+		{Line: 4, Field: "death_date", Rule: "gtefield"},
+		{Line: 5, Field: "label", Rule: "unknown"},
+		{Line: 6, Field: "price", Rule: "numeric"},
+		{Line: 7, Field: "album", Rule: "reference"},
+		{Line: 8, Field: "name", Rule: "unknown"},
+		{Line: 9, Field: "type", Rule: "oneof"},
+		{Line: 10, Field: "type", Rule: "required"},
+		{Line: 11, Field: "", Rule: "columns"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %+v", len(problems), len(want), problems)
+	}
+	for i, p := range problems {
+		if p.Line != want[i].Line || p.Field != want[i].Field || p.Rule != want[i].Rule || p.Message == "" {
+			t.Errorf("problem %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+}
+
+func TestReadRejectsUnreadableFiles(t *testing.T) {
+	tests := map[string]struct {
+		format importer.Format
+		text   string
+	}{
+		"empty CSV":      {importer.CSV, ""},
+		"bad quoting":    {importer.CSV, "type,title\nsong,\"Airbag\n"},
+		"long JSON line": {importer.JSONLines, `{"title": "` + strings.Repeat("a", 2<<20) + `"}`},
+		"unknown format": {"xml", "<catalog/>"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := importer.Read(strings.NewReader(tt.text), tt.format); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	for name, want := range map[string]importer.Format{
+		".csv": importer.CSV, "text/csv": importer.CSV, ".JSONL": importer.JSONLines, "application/x-ndjson": importer.JSONLines,
+		".json": "", "application/json": "",
+	} {
+		if got, ok := importer.FormatFor(name); got != want || ok != (want != "") {
+			t.Errorf("FormatFor(%q) = %q, %v", name, got, ok)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goMusic/problem"
+	"io"
+	"strings"
+)
+
+// maxLineLength bounds a single line of a JSON Lines file.
+const maxLineLength = 1 << 20
+
+// row is one record of an import file with its non-empty fields by column
+// name.
+type row struct {
+	line   int
+	fields map[string]string
+}
+
+// readCSV reads a CSV file whose first line names the columns. Records with
+// the wrong number of fields are reported and skipped; quoting errors stop
+// the read because the rest of the file can't be trusted.
+func readCSV(r io.Reader) ([]row, []problem.FieldError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	}
+
+	var rows []row
+	var problems []problem.FieldError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, problems, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			problems = append(problems, problem.FieldError{
+				Line: line, Rule: "columns",
+				Message: fmt.Sprintf("has %d fields but the header has %d", len(record), len(header)),
+			})
+			continue
+		}
+		fields := map[string]string{}
+		for i, name := range header {
+			if value := strings.TrimSpace(record[i]); value != "" {
+				fields[name] = value
+			}
+		}
+		rows = append(rows, row{line: line, fields: fields})
+	}
+}
+
+// readJSONLines reads a file with one JSON object per line. Strings,
+// numbers and booleans are all kept as text; nulls are left out. Lines that
+// are not objects are reported and skipped, and blank lines are ignored.
+func readJSONLines(r io.Reader) ([]row, []problem.FieldError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLineLength)
+
+	var rows []row
+	var problems []problem.FieldError
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(text, &values); err != nil || values == nil {
+			problems = append(problems, problem.FieldError{Line: line, Rule: "json", Message: "is not a JSON object"})
+			continue
+		}
+		fields := map[string]string{}
+		for name, raw := range values {
+			if string(raw) == "null" {
+				continue
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				value = string(raw)
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				fields[strings.ToLower(name)] = value
+			}
+		}
+		rows = append(rows, row{line: line, fields: fields})
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return nil, nil, fmt.Errorf("lines can be at most %d bytes long", maxLineLength)
+	}
+	return rows, problems, scanner.Err()
+}
@@ -1,28 +1,154 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"goMusic/authentication"
+	"goMusic/blobs"
+	"goMusic/charts"
 	"goMusic/controllers"
+	"goMusic/recommend"
+	"goMusic/repositories"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	if os.Getenv("JWT_SECRET_KEY") == "" {
-		os.Setenv("JWT_SECRET_KEY", "secret")
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Stdout, "music.db", os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	keys, err := loadKeys()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start:", err)
+		os.Exit(1)
+	}
+	authentication.UseKeys(keys)
+	reloadKeysOnHangup(keys)
+
+	files, err := openBlobStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start:", err)
+		os.Exit(1)
+	}
+
+	interval, err := recommendationInterval()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start:", err)
+		os.Exit(1)
+	}
+
+	chartConfig, err := loadChartConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start:", err)
+		os.Exit(1)
 	}
 
 	Setup("music.db")
 	defer CloseDB()
 
+	store := repositories.NewSQLiteStore(GetDB())
+	authentication.UseDenylist(store.Tokens)
+	recommend.Schedule(context.Background(), store, interval, func(err error) {
+		fmt.Fprintln(os.Stderr, "rebuilding recommendations:", err)
+	})
+	charts.Schedule(context.Background(), store, chartConfig, func(err error) {
+		fmt.Fprintln(os.Stderr, "storing charts:", err)
+	})
 	mux := http.NewServeMux()
 
-	controllers.RegisterAuthRoutes(mux)
-	controllers.RegisterAlbumRoutes(mux)
-	controllers.RegisterArtistRoutes(mux)
-	controllers.RegisterBandRoutes(mux)
-	controllers.RegisterSongRoutes(mux)
+	controllers.RegisterRoutes(mux, store, keys, files, chartConfig)
 
 	fmt.Println("Server starting on :8082")
 	http.ListenAndServe("localhost:8082", mux)
 }
+
+// loadKeys reads the signing keys from JWT_KEY_DIR. Retired keys keep
+// verifying tokens for JWT_KEY_GRACE_PERIOD, a Go duration such as "24h".
+func loadKeys() (*authentication.KeySet, error) {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return nil, errors.New("JWT_KEY_DIR is not set; create a key with `goMusic key generate`")
+	}
+
+	grace := authentication.DefaultGracePeriod
+	if text := os.Getenv("JWT_KEY_GRACE_PERIOD"); text != "" {
+		var err error
+		if grace, err = time.ParseDuration(text); err != nil || grace < 0 {
+			return nil, fmt.Errorf("invalid JWT_KEY_GRACE_PERIOD %q", text)
+		}
+	}
+
+	return authentication.LoadKeySet(dir, grace)
+}
+
+// openBlobStore opens the directory uploaded files are kept in, BLOB_DIR or
+// ./uploads by default.
+func openBlobStore() (*blobs.Store, error) {
+	dir := os.Getenv("BLOB_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	return blobs.NewStore(dir)
+}
+
+// recommendationInterval reads how often the song similarities behind the
+// recommendations are rebuilt from RECOMMENDATION_INTERVAL, a Go duration
+// such as "30m". It defaults to an hour.
+func recommendationInterval() (time.Duration, error) {
+	text := os.Getenv("RECOMMENDATION_INTERVAL")
+	if text == "" {
+		return time.Hour, nil
+	}
+	interval, err := time.ParseDuration(text)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid RECOMMENDATION_INTERVAL %q", text)
+	}
+	return interval, nil
+}
+
+// loadChartConfig reads how fast events stop counting towards the charts
+// from CHART_DAILY_HALF_LIFE and CHART_WEEKLY_HALF_LIFE, Go durations such as
+// "12h". Each defaults to charts.DefaultConfig.
+func loadChartConfig() (charts.Config, error) {
+	config := charts.DefaultConfig()
+	for name, halfLife := range map[string]*time.Duration{
+		"CHART_DAILY_HALF_LIFE":  &config.DailyHalfLife,
+		"CHART_WEEKLY_HALF_LIFE": &config.WeeklyHalfLife,
+	} {
+		text := os.Getenv(name)
+		if text == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(text)
+		if err != nil || duration <= 0 {
+			return charts.Config{}, fmt.Errorf("invalid %s %q", name, text)
+		}
+		*halfLife = duration
+	}
+	return config, nil
+}
+
+// reloadKeysOnHangup re-reads the key directory on SIGHUP so a rotated key
+// takes over without a restart.
+func reloadKeysOnHangup(keys *authentication.KeySet) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			if err := keys.Reload(); err != nil {
+				fmt.Fprintln(os.Stderr, "reloading keys:", err)
+				continue
+			}
+			fmt.Println("Signing keys reloaded")
+		}
+	}()
+}
@@ -1,9 +1,15 @@
 package models
 
+// Album is both the stored album and the body of POST and PUT /albums. Price
+// is in cents. The release metadata is optional: LabelId is the label it was
+// released on, Barcode its UPC-A or EAN-13, and Format LP, CD or digital.
 type Album struct {
-	Id       int     `json:"id" validate:"min=0"`
-	Title    string  `json:"title" validate:"required,min=1,max=100"`
-	Price    float64 `json:"price" validate:"required,min=0"`
-	ArtistId *int    `json:"artist_id,omitempty"`
-	BandId   *int    `json:"band_id,omitempty"`
+	Id            int     `json:"id" validate:"min=0"`
+	Title         string  `json:"title" validate:"required,min=1,max=100"`
+	Price         int64   `json:"price" validate:"required,min=0"`
+	LabelId       *int    `json:"label_id,omitempty" validate:"omitempty,min=1"`
+	ReleaseDate   *string `json:"release_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	CatalogNumber *string `json:"catalog_number,omitempty" validate:"omitempty,min=1,max=50"`
+	Barcode       *string `json:"barcode,omitempty" validate:"omitempty,barcode"`
+	Format        *string `json:"format,omitempty" validate:"omitempty,oneof=LP CD digital"`
 }
@@ -1,14 +1,26 @@
 package models
 
+// Artist is both the stored artist and the body of POST and PUT /artists.
+// A nil DeathDate means the artist is alive.
 type Artist struct {
-	Id          int    `json:"id"`
-	FirstName   string `json:"first_name" validate:"required,min=1,max=100"`
-	LastName    string `json:"last_name" validate:"required,min=1,max=100"`
-	Nationality string `json:"nationality" validate:"required,min=1,max=100"`
-	BirthDate   string `json:"birth_date" validate:"required"`
-	Age         int    `json:"age" validate:"required,min=0,max=150"`
-	Alive       bool   `json:"alive"`
-	SexId       *int   `json:"sex_id,omitempty" validate:"validSex"`
-	TitleId     *int   `json:"title_id,omitempty" validate:"validTitle"`
-	BandId      *int   `json:"band_id,omitempty"`
+	Id          int     `json:"id"`
+	FirstName   string  `json:"first_name" validate:"required,min=1,max=100"`
+	LastName    string  `json:"last_name" validate:"required,min=1,max=100"`
+	Nationality string  `json:"nationality" validate:"required,min=1,max=100"`
+	BirthDate   string  `json:"birth_date" validate:"required,datetime=2006-01-02"`
+	DeathDate   *string `json:"death_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	SexId       *int    `json:"sex_id,omitempty" validate:"validSex"`
+	TitleId     *int    `json:"title_id,omitempty" validate:"validTitle"`
+}
+
+// AgeOn is the artist's age on the given date, or at their death if that
+// came first.
+func (a Artist) AgeOn(date string) int {
+	return yearsBetween(a.BirthDate, until(date, a.DeathDate))
+}
+
+// AliveOn reports whether the artist had been born by the given date and
+// had not died. DeathDate is the first day they were not alive.
+func (a Artist) AliveOn(date string) bool {
+	return a.BirthDate <= date && (a.DeathDate == nil || *a.DeathDate > date)
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// SongAudio is the audio file uploaded for a song, stored in the blob store
+// under Digest, and the tags that were read from it. Length is in seconds.
+type SongAudio struct {
+	SongId      int
+	Digest      string
+	Format      string
+	Size        int64
+	Length      int
+	Title       string
+	Artist      string
+	TrackNumber int
+	UploadedAt  time.Time
+}
+
+// AudioUpload is everything an upload writes: the audio record and, when its
+// tags are applied, the song's new fields and the performers to credit.
+type AudioUpload struct {
+	Audio   SongAudio
+	Song    *Song
+	Credits []Credit
+}
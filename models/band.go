@@ -1,11 +1,25 @@
 package models
 
+// Band is both the stored band and the body of POST and PUT /bands.
+// NumberOfMembers is counted from the current memberships and ignored on
+// writes. A nil DisbandedDate means the band is still together.
 type Band struct {
-	Id              int    `json:"id"`
-	Name            string `json:"name" validate:"required,min=1,max=100"`
-	Nationality     string `json:"nationality" validate:"required,min=1,max=100"`
-	NumberOfMembers int    `json:"number_of_members" validate:"required,min=1,max=500"`
-	DateFormed      string `json:"date_formed" validate:"required,min=1,max=100"`
-	Age             int    `json:"age" validate:"required,min=0,max=150"`
-	Active          bool   `json:"active"`
+	Id              int     `json:"id"`
+	Name            string  `json:"name" validate:"required,min=1,max=100"`
+	Nationality     string  `json:"nationality" validate:"required,min=1,max=100"`
+	NumberOfMembers int     `json:"number_of_members"`
+	DateFormed      string  `json:"date_formed" validate:"required,datetime=2006-01-02"`
+	DisbandedDate   *string `json:"disbanded_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// AgeOn is how many years the band had been together on the given date, or
+// when it disbanded if that came first.
+func (b Band) AgeOn(date string) int {
+	return yearsBetween(b.DateFormed, until(date, b.DisbandedDate))
+}
+
+// ActiveOn reports whether the band had formed by the given date and had
+// not disbanded. DisbandedDate is the first day it was not together.
+func (b Band) ActiveOn(date string) bool {
+	return b.DateFormed <= date && (b.DisbandedDate == nil || *b.DisbandedDate > date)
 }
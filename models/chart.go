@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// The kinds of chart.
+const (
+	ChartSongs  = "songs"
+	ChartAlbums = "albums"
+)
+
+// The chart periods: a daily chart covers its date, a weekly chart the seven
+// days ending on it.
+const (
+	ChartDaily  = "daily"
+	ChartWeekly = "weekly"
+)
+
+// The events a chart score is built from.
+const (
+	EventPlay     = "play"
+	EventPurchase = "purchase"
+	EventRating   = "rating"
+)
+
+// Chart is the ranking of songs or albums for a period ending on Date, a
+// UTC date formatted 2006-01-02.
+type Chart struct {
+	Kind    string
+	Period  string
+	Date    string
+	Entries []ChartEntry
+}
+
+// ChartEntry is a song or album's place in a chart. PreviousPosition is its
+// place in the chart for the period before, or nil if it wasn't in it.
+type ChartEntry struct {
+	Position         int
+	ItemId           int
+	Score            float64
+	PreviousPosition *int
+}
+
+// ChartEvent is something that makes a song or album more popular. Rating
+// is only set for ratings.
+type ChartEvent struct {
+	ItemId int
+	Type   string
+	At     time.Time
+	Rating int
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CoverImage is one variant of an album's cover: the uploaded original or
+// one of its thumbnails, stored in the blob store under Digest.
+type CoverImage struct {
+	AlbumId    int
+	Variant    string
+	Digest     string
+	MIMEType   string
+	Width      int
+	Height     int
+	UploadedAt time.Time
+}
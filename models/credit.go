@@ -0,0 +1,24 @@
+package models
+
+// The roles an artist or band can be credited with.
+const (
+	RolePerformer = "performer"
+	RoleComposer  = "composer"
+	RoleLyricist  = "lyricist"
+	RoleProducer  = "producer"
+	RoleFeatured  = "featured"
+)
+
+// CreditRoles lists every role, in the order they are displayed.
+var CreditRoles = []string{RolePerformer, RoleFeatured, RoleComposer, RoleLyricist, RoleProducer}
+
+// Credit records that an artist or band worked on an album or song. Exactly
+// one of AlbumId and SongId is set, and exactly one of ArtistId and BandId.
+type Credit struct {
+	Id       int
+	AlbumId  *int
+	SongId   *int
+	ArtistId *int
+	BandId   *int
+	Role     string
+}
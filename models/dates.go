@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Dates are stored and exchanged as YYYY-MM-DD strings, which compare in
+// date order.
+
+// Today is the current date. It is UTC, like SQLite's date('now').
+func Today() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// yearsBetween counts the whole years from one date to another, or 0 when
+// either is not a valid date or to comes first.
+func yearsBetween(from, to string) int {
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return 0
+	}
+
+	years := end.Year() - start.Year()
+	if end.Month() < start.Month() || (end.Month() == start.Month() && end.Day() < start.Day()) {
+		years--
+	}
+	return max(years, 0)
+}
+
+// until is the earlier of date and an optional end date.
+func until(date string, end *string) string {
+	if end != nil && *end < date {
+		return *end
+	}
+	return date
+}
@@ -0,0 +1,52 @@
+package models
+
+// ExportTypes lists the kinds of record in a catalog export, in the order
+// they are written. Records only refer to records of earlier kinds, except
+// genres, which may refer to a parent genre further down.
+var ExportTypes = []string{
+	"artist", "band", "membership", "genre", "label", "album", "song",
+	"track", "credit", "album_genre", "song_genre", "tag",
+}
+
+// ExportColumns lists the values of each kind of export record, in order.
+// Tracks put a song on an album at a position; tags attach the named tag to the entity of
+// entity_type (album, song, artist or band) with entity_id.
+var ExportColumns = map[string][]string{
+	"artist":      {"id", "first_name", "last_name", "nationality", "birth_date", "death_date", "sex_id", "title_id"},
+	"band":        {"id", "name", "nationality", "date_formed", "disbanded_date"},
+	"membership":  {"id", "artist_id", "band_id", "role", "date_joined", "date_left"},
+	"genre":       {"id", "name", "parent_id"},
+	"label":       {"id", "name", "country"},
+	"album":       {"id", "title", "price", "label_id", "release_date", "catalog_number", "barcode", "format"},
+	"song":        {"id", "title", "length", "price"},
+	"track":       {"album_id", "song_id", "position"},
+	"credit":      {"id", "album_id", "song_id", "artist_id", "band_id", "role"},
+	"album_genre": {"album_id", "genre_id"},
+	"song_genre":  {"song_id", "genre_id"},
+	"tag":         {"entity_type", "entity_id", "name"},
+}
+
+// ExportRecord is one record of a catalog export: the values of
+// ExportColumns[Type], in order. Missing values are nil.
+type ExportRecord struct {
+	Type   string
+	Values []any
+}
+
+// Tracklist is an album or playlist as exported to a playlist file.
+type Tracklist struct {
+	Title   string
+	Creator string
+	Tracks  []TracklistTrack
+}
+
+// TracklistTrack is one song of a tracklist. Creator names the song's
+// performers and Length is in seconds.
+type TracklistTrack struct {
+	SongId   int
+	Title    string
+	Creator  string
+	Album    string
+	Length   int
+	TrackNum int
+}
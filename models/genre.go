@@ -0,0 +1,9 @@
+package models
+
+// Genre classifies albums and songs. Genres form a tree: a genre with a
+// ParentId is a subgenre of that genre.
+type Genre struct {
+	Id       int    `json:"id" validate:"min=0"`
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	ParentId *int   `json:"parent_id,omitempty" validate:"omitempty,min=1"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// CatalogImport is a batch of new catalog records that are created
+// together. Albums and songs refer to the artists, bands and albums of the
+// same batch by their index in its slices.
+type CatalogImport struct {
+	Artists []Artist
+	Bands   []Band
+	Albums  []ImportedAlbum
+	Songs   []ImportedSong
+}
+
+// ImportedAlbum is an album of a CatalogImport and the artist or band, if
+// any, credited as its performer.
+type ImportedAlbum struct {
+	Album  Album
+	Artist *int
+	Band   *int
+}
+
+// ImportedSong is a song of a CatalogImport, the album it is on and the
+// artist or band, if any, credited as its performer.
+type ImportedSong struct {
+	Song   Song
+	Album  *int
+	Artist *int
+	Band   *int
+}
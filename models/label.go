@@ -0,0 +1,9 @@
+package models
+
+// Label is a record label, both as stored and as the body of POST and PUT
+// /labels. Albums name the label they were released on.
+type Label struct {
+	Id      int     `json:"id"`
+	Name    string  `json:"name" validate:"required,min=1,max=100"`
+	Country *string `json:"country,omitempty" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// BandMembership records that an artist played in a band, in a role such as
+// "guitar" or "lead vocals", between two YYYY-MM-DD dates. A nil DateJoined
+// means since the band formed and a nil DateLeft means to this day.
+type BandMembership struct {
+	Id         int
+	ArtistId   int
+	BandId     int
+	Role       string
+	DateJoined *string
+	DateLeft   *string
+}
+
+// ActiveOn reports whether the artist was in the band on the given
+// YYYY-MM-DD date. DateLeft is the first day they no longer were.
+func (m BandMembership) ActiveOn(date string) bool {
+	return (m.DateJoined == nil || *m.DateJoined <= date) && (m.DateLeft == nil || *m.DateLeft > date)
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// The kinds of item that can be bought.
+const (
+	ItemAlbum = "album"
+	ItemSong  = "song"
+)
+
+// CartItem is an album or a song waiting to be bought. Exactly one of
+// AlbumId and SongId is set.
+type CartItem struct {
+	Id      int
+	UserId  int
+	AlbumId *int
+	SongId  *int
+}
+
+// Order is a completed purchase. Amounts are in minor units (cents).
+type Order struct {
+	Id        int
+	UserId    int
+	Total     int64
+	CreatedAt time.Time
+	Items     []OrderItem
+}
+
+// OrderItem records what was bought and what it cost at checkout. AlbumId
+// and SongId become nil if the catalog entry is deleted later.
+type OrderItem struct {
+	Type    string
+	AlbumId *int
+	SongId  *int
+	Title   string
+	Price   int64
+}
+
+// Library lists the albums and songs a user owns.
+type Library struct {
+	AlbumIds []int
+	SongIds  []int
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Play is one listen to a song. Duration is the number of seconds listened
+// to. SongId is nil once the song has been deleted from the catalog, and
+// ClientId, when the client gave one, identifies the play among the user's
+// others so it is only recorded once.
+type Play struct {
+	Id       int
+	UserId   int
+	SongId   *int
+	PlayedAt time.Time
+	Duration int
+	ClientId *string
+}
+
+// SongPlays counts a user's plays of one song.
+type SongPlays struct {
+	SongId  int
+	Plays   int
+	Seconds int
+}
+
+// PerformerPlays counts a user's plays of the songs an artist or band is
+// credited as performing. Exactly one of ArtistId and BandId is set.
+type PerformerPlays struct {
+	ArtistId *int
+	BandId   *int
+	Plays    int
+	Seconds  int
+}
+
+// ListeningDay totals a user's plays on one UTC day, formatted 2006-01-02.
+type ListeningDay struct {
+	Date    string
+	Plays   int
+	Seconds int
+}
@@ -0,0 +1,25 @@
+package models
+
+type Playlist struct {
+	Id          int    `json:"id"`
+	UserId      int    `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+// PlaylistTrack places a song at a position in a playlist, counting from 1.
+type PlaylistTrack struct {
+	PlaylistId int
+	SongId     int
+	Position   int
+}
+
+// PlaylistCollaborator is a user invited to edit a playlist's tracks. The
+// invitation is pending until Accepted.
+type PlaylistCollaborator struct {
+	PlaylistId int
+	UserId     int
+	Username   string
+	Accepted   bool
+}
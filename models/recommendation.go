@@ -0,0 +1,17 @@
+package models
+
+// SimilarSong is a song listened to by the same users as another, with
+// their cosine similarity from 0 to 1.
+type SimilarSong struct {
+	SongId int
+	Score  float64
+}
+
+// Recommendation is a suggested song with a score, higher meaning a better
+// match, and the reason it was suggested, such as "because you played
+// Karma Police".
+type Recommendation struct {
+	SongId int
+	Score  float64
+	Reason string
+}
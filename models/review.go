@@ -0,0 +1,37 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Review is a user's rating of an album or song from 1 to 5, with optional
+// text. Exactly one of AlbumId and SongId is set, and a user reviews each
+// album or song at most once. Username is the author's, for display.
+type Review struct {
+	Id        int
+	UserId    int
+	Username  string
+	AlbumId   *int
+	SongId    *int
+	Rating    int
+	Body      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Rating is the running count and sum of an album's or song's ratings.
+type Rating struct {
+	Count int
+	Sum   int
+}
+
+// Average is the mean rating to two decimal places, or nil when there are
+// no ratings.
+func (r Rating) Average() *float64 {
+	if r.Count == 0 {
+		return nil
+	}
+	average := math.Round(float64(r.Sum)/float64(r.Count)*100) / 100
+	return &average
+}
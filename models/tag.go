@@ -0,0 +1,15 @@
+package models
+
+// The kinds of catalog entry that can be tagged.
+const (
+	TagAlbum  = "album"
+	TagSong   = "song"
+	TagArtist = "artist"
+	TagBand   = "band"
+)
+
+// TagCount is a tag and the number of entries it is attached to.
+type TagCount struct {
+	Name  string
+	Count int
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	Id        int
+	UserId    int
+	TokenHash string
+	FamilyId  string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,364 @@
+// Package openapi builds an OpenAPI 3 document from descriptions of the
+// registered routes, deriving request and response schemas from the Go types
+// the handlers decode and encode.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Access levels for Operation.Auth.
+const (
+	Public = ""
+	// Authenticated operations need a valid token but no particular role.
+	Authenticated = "authenticated"
+)
+
+// Operation describes one route. Request and Response are zero values of the
+// body types, e.g. models.Album{}; nil means the route has no such body.
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Auth is Public, Authenticated or the name of the role required.
+	Auth     string
+	Query    []Param
+	Request  any
+	Response any
+	// OptionalRequest marks the request body as optional.
+	OptionalRequest bool
+	// RequestContentType of the request, when it is not JSON, e.g.
+	// multipart/form-data.
+	RequestContentType string
+	// ContentType of the response, when it is not JSON.
+	ContentType string
+	// Status is the success status, http.StatusOK when zero.
+	Status int
+	// Errors lists the error statuses the route can answer with, besides
+	// the 401 and 403 implied by Auth.
+	Errors []int
+}
+
+// Binary is raw file content, such as an uploaded file or a download. It is
+// documented as a binary string.
+type Binary []byte
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Description string
+	Schema      *Schema
+	Required    bool
+}
+
+// Spec is a set of operations keyed by the pattern they are registered with
+// on the ServeMux, e.g. "GET /albums/{id}".
+type Spec struct {
+	Title      string
+	Version    string
+	Operations map[string]Operation
+	// Error is the body every error response has, served as
+	// ErrorContentType. Error responses have no body when it is nil.
+	Error            any
+	ErrorContentType string
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*document `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// document is an operation object as it appears in the OpenAPI document.
+type document struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type body struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+// Schema is the subset of JSON Schema the generated document uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+const bearerAuth = "bearerAuth"
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Document renders the spec. Struct types become shared component schemas
+// named after the Go type.
+func (s Spec) Document() Document {
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: s.Title, Version: s.Version},
+		Paths:   map[string]map[string]*document{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	schemas := schemaBuilder{components: doc.Components.Schemas}
+
+	var errorContent map[string]mediaType
+	if s.Error != nil {
+		errorContent = map[string]mediaType{s.ErrorContentType: {Schema: schemas.of(reflect.TypeOf(s.Error))}}
+	}
+
+	for pattern, op := range s.Operations {
+		method, path, _ := strings.Cut(pattern, " ")
+		// ServeMux wildcards like {file...} are plain parameters in OpenAPI.
+		path = strings.ReplaceAll(path, "...}", "}")
+
+		out := &document{
+			Summary:     op.Summary,
+			Description: op.Description,
+			OperationID: operationID(method, path),
+			Tags:        op.Tags,
+			Responses:   map[string]response{},
+		}
+
+		for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+			schema := &Schema{Type: "string"}
+			if match[1] == "id" || match[1] == "position" || strings.HasSuffix(match[1], "_id") {
+				schema = &Schema{Type: "integer"}
+			}
+			out.Parameters = append(out.Parameters, parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+		}
+		for _, param := range op.Query {
+			out.Parameters = append(out.Parameters, parameter{
+				Name: param.Name, In: "query", Description: param.Description, Required: param.Required, Schema: param.Schema,
+			})
+		}
+
+		if op.Request != nil {
+			contentType := op.RequestContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			out.RequestBody = &body{Required: !op.OptionalRequest, Content: map[string]mediaType{
+				contentType: {Schema: schemas.of(reflect.TypeOf(op.Request))},
+			}}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := response{Description: http.StatusText(status)}
+		if op.Response != nil {
+			contentType := op.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success.Content = map[string]mediaType{contentType: {Schema: schemas.of(reflect.TypeOf(op.Response))}}
+		}
+		out.Responses[strconv.Itoa(status)] = success
+
+		errors := slices.Clone(op.Errors)
+		if op.Auth != Public {
+			out.Security = []map[string][]string{{bearerAuth: {}}}
+			errors = append(errors, http.StatusUnauthorized)
+			if op.Auth != Authenticated {
+				out.Description = strings.TrimSpace(out.Description + "\n\nRequires the " + op.Auth + " role.")
+				errors = append(errors, http.StatusForbidden)
+			}
+		}
+		for _, code := range errors {
+			out.Responses[strconv.Itoa(code)] = response{Description: http.StatusText(code), Content: errorContent}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*document{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = out
+	}
+
+	return doc
+}
+
+// operationID turns "GET /albums/{id}" into "getAlbumsById".
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		if param, ok := strings.CutPrefix(segment, "{"); ok {
+			segment = "by-" + strings.TrimSuffix(param, "}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return r == '.' || r == '-' || r == '_'
+		}) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+type schemaBuilder struct {
+	components map[string]*Schema
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	binaryType = reflect.TypeOf(Binary(nil))
+)
+
+// of returns the schema for t, adding struct types to the components and
+// referring to them by name.
+func (b schemaBuilder) of(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == binaryType {
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.of(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.of(t.Elem())}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, done := b.components[name]; !done {
+			schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			b.components[name] = schema
+			b.addFields(schema, t)
+			sort.Strings(schema.Required)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// interface{} and anything else can hold any JSON value.
+		return &Schema{}
+	}
+}
+
+// addFields adds the properties encoding/json would write for t, following
+// embedded structs. Fields with a required validation rule are required.
+func (b schemaBuilder) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = b.of(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+}
+
+// schemaName names a component after its Go type. Instances of generic types
+// are named after their type arguments, so query.Page[viewModels.AlbumViewModel]
+// becomes AlbumViewModelPage.
+func schemaName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if !generic {
+		return name
+	}
+
+	var prefix string
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		prefix += arg[strings.LastIndex(arg, ".")+1:]
+	}
+	return prefix + name
+}
@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type track struct {
+	base
+	Title    string    `json:"title" validate:"required,min=1"`
+	AlbumID  *int      `json:"album_id,omitempty"`
+	Secret   string    `json:"-"`
+	Released time.Time `json:"released"`
+	Extra    any       `json:"extra"`
+	hidden   bool
+}
+
+func TestSchemas(t *testing.T) {
+	b := schemaBuilder{components: map[string]*Schema{}}
+
+	ref := b.of(reflect.TypeOf(page[track]{}))
+	if ref.Ref != "#/components/schemas/trackpage" {
+		t.Fatalf("wrong reference for a generic type: %+v", ref)
+	}
+
+	schema := b.components["track"]
+	if schema == nil {
+		t.Fatalf("track schema missing: %v", b.components)
+	}
+
+	want := map[string]Schema{
+		"id":       {Type: "integer"},
+		"title":    {Type: "string"},
+		"album_id": {Type: "integer", Nullable: true},
+		"released": {Type: "string", Format: "date-time"},
+		"extra":    {},
+	}
+	if len(schema.Properties) != len(want) {
+		t.Errorf("wrong properties: %v", schema.Properties)
+	}
+	for name, expected := range want {
+		if got := schema.Properties[name]; got == nil || !reflect.DeepEqual(*got, expected) {
+			t.Errorf("property %s: got %+v want %+v", name, got, expected)
+		}
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"title"}) {
+		t.Errorf("wrong required fields: %v", schema.Required)
+	}
+}
+
+type upload struct {
+	File Binary `json:"file" validate:"required"`
+}
+
+type failure struct {
+	Code string `json:"code"`
+}
+
+func TestDocument(t *testing.T) {
+	doc := Spec{Title: "test", Version: "1", Error: failure{}, ErrorContentType: "application/problem+json", Operations: map[string]Operation{
+		"GET /tracks/{id}": {Summary: "Get a track", Response: track{}, Errors: []int{http.StatusNotFound}},
+		"DELETE /tracks/{id}": {
+			Summary: "Delete a track", Auth: "editor", Status: http.StatusNoContent,
+		},
+		"PUT /tracks/{id}/file": {Summary: "Upload a track", Request: upload{}, RequestContentType: "multipart/form-data"},
+		"GET /tracks/{id}/file": {Summary: "Download a track", Response: Binary{}, ContentType: "audio/*"},
+	}}.Document()
+
+	get := doc.Paths["/tracks/{id}"]["get"]
+	if get == nil || get.OperationID != "getTracksById" || len(get.Security) != 0 {
+		t.Fatalf("wrong get operation: %+v", get)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].In != "path" || get.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("wrong path parameters: %+v", get.Parameters)
+	}
+	if _, ok := get.Responses["404"]; !ok || get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/track" {
+		t.Errorf("wrong responses: %+v", get.Responses)
+	}
+
+	if ref := get.Responses["404"].Content["application/problem+json"].Schema; ref == nil || ref.Ref != "#/components/schemas/failure" {
+		t.Errorf("error response does not use the error schema: %+v", get.Responses["404"])
+	}
+
+	del := doc.Paths["/tracks/{id}"]["delete"]
+	if len(del.Security) != 1 {
+		t.Errorf("protected operation has no security requirement")
+	}
+	for _, code := range []string{"204", "401", "403"} {
+		if _, ok := del.Responses[code]; !ok {
+			t.Errorf("delete is missing a %s response: %+v", code, del.Responses)
+		}
+	}
+
+	put := doc.Paths["/tracks/{id}/file"]["put"]
+	if put.RequestBody == nil || put.RequestBody.Content["multipart/form-data"].Schema == nil {
+		t.Fatalf("wrong upload request body: %+v", put.RequestBody)
+	}
+	if file := doc.Components.Schemas["upload"].Properties["file"]; file == nil || !reflect.DeepEqual(*file, Schema{Type: "string", Format: "binary"}) {
+		t.Errorf("wrong schema for a file field: %+v", file)
+	}
+	download := doc.Paths["/tracks/{id}/file"]["get"].Responses["200"].Content["audio/*"].Schema
+	if download == nil || download.Format != "binary" {
+		t.Errorf("wrong schema for a download: %+v", download)
+	}
+}
@@ -0,0 +1,86 @@
+// Package problem writes error responses as RFC 7807 problem details, so
+// every endpoint fails with the same application/problem+json shape.
+package problem
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// Codes identify the kind of problem for clients. They are part of the API:
+// add new ones rather than changing existing ones.
+const (
+	InvalidRequest      = "invalid_request"
+	ValidationFailed    = "validation_failed"
+	InvalidQuery        = "invalid_query"
+	Unauthorized        = "unauthorized"
+	InvalidCredentials  = "invalid_credentials"
+	InvalidRefreshToken = "invalid_refresh_token"
+	RefreshTokenReused  = "refresh_token_reused"
+	Forbidden           = "forbidden"
+	NotFound            = "not_found"
+	Conflict            = "conflict"
+	InvalidReference    = "invalid_reference"
+	UnsupportedMedia    = "unsupported_media_type"
+	PayloadTooLarge     = "payload_too_large"
+	InvalidAudio        = "invalid_audio"
+	InvalidImage        = "invalid_image"
+	Internal            = "internal_error"
+)
+
+// FieldError describes one field that failed validation. Field is the JSON
+// name and Rule the validation rule that failed, e.g. "required". Line is
+// only set for uploaded files, and is the line the field is on.
+type FieldError struct {
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details object with a machine-readable code
+// and, for validation failures, the fields at fault.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Code     string       `json:"code"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// New returns a problem whose title is the standard text for status.
+func New(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// Write sends p as the response, using the request path as its instance.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a problem with the given status, code and detail.
+func Error(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
+
+// InternalError logs err and responds with a 500 that does not reveal it.
+func InternalError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	Write(w, r, New(http.StatusInternalServerError, Internal, "An unexpected error occurred."))
+}
@@ -0,0 +1,43 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Error(rr, httptest.NewRequest("GET", "/albums/7", nil), http.StatusNotFound, NotFound, "album not found")
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("wrong content type %q", contentType)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{Type: "about:blank", Title: "Not Found", Status: 404, Code: NotFound, Detail: "album not found", Instance: "/albums/7"}
+	if p.Type != want.Type || p.Title != want.Title || p.Status != want.Status || p.Code != want.Code || p.Detail != want.Detail || p.Instance != want.Instance {
+		t.Errorf("wrong problem: got %+v want %+v", p, want)
+	}
+}
+
+func TestInternalErrorHidesDetails(t *testing.T) {
+	rr := httptest.NewRecorder()
+	InternalError(rr, httptest.NewRequest("GET", "/albums", nil), errors.New("no such table: albums"))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(rr.Body.String(), "no such table") {
+		t.Errorf("response leaks the error: %s", rr.Body.String())
+	}
+}
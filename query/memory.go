@@ -0,0 +1,104 @@
+package query
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Apply filters, sorts and pages a slice in memory with the same semantics
+// as the SQL built by Select.
+func (s Spec[T]) Apply(items []T, q Query) Page[T] {
+	var matched []T
+	for _, item := range items {
+		if s.matches(item, q.Filters) {
+			matched = append(matched, item)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b T) int {
+		for _, sort := range q.Sort {
+			value := s.Fields[sort.Field].Value
+			if c := compare(value(a), value(b), sort.Desc); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	page := matched
+	if q.After != nil {
+		page = slices.DeleteFunc(slices.Clone(matched), func(item T) bool {
+			return !s.after(item, q)
+		})
+	}
+
+	return s.Paginate(page[:min(len(page), q.Limit+1)], q, len(matched))
+}
+
+func (s Spec[T]) matches(item T, filters []Filter) bool {
+	for _, filter := range filters {
+		if matches := s.Fields[filter.Field].Matches; matches != nil {
+			if !matches(item, filter.Value) {
+				return false
+			}
+			continue
+		}
+		value := s.Fields[filter.Field].Value(item)
+		if value == nil {
+			return false
+		}
+		c := compare(value, filter.Value, false)
+		switch {
+		case filter.Op == Eq && c != 0,
+			filter.Op == Gte && c < 0,
+			filter.Op == Lte && c > 0:
+			return false
+		}
+	}
+	return true
+}
+
+// after reports whether an item sorts strictly after the cursor.
+func (s Spec[T]) after(item T, q Query) bool {
+	for i, sort := range q.Sort {
+		if c := compare(s.Fields[sort.Field].Value(item), q.After[i], sort.Desc); c != 0 {
+			return c > 0
+		}
+	}
+	return false
+}
+
+// compare orders two values of the same kind. Nil sorts first, like NULL in
+// SQLite.
+func compare(a, b any, desc bool) int {
+	var c int
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		c = -1
+	case b == nil:
+		c = 1
+	default:
+		switch a := a.(type) {
+		case int:
+			c = cmp.Compare(a, b.(int))
+		case float64:
+			c = cmp.Compare(a, b.(float64))
+		case string:
+			c = cmp.Compare(a, b.(string))
+		case bool:
+			c = cmp.Compare(boolInt(a), boolInt(b.(bool)))
+		}
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,290 @@
+// Package query parses the pagination, sorting and filtering parameters
+// accepted by the collection endpoints and applies them either as SQL or to
+// an in-memory slice, so every repository pages its results the same way.
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Kind is the type of a field's values, used to parse parameters and cursors.
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	String
+	Bool
+)
+
+// Op is a filter comparison. Eq is written as ?field=, the others as
+// ?field_gte= and ?field_lte=.
+type Op string
+
+const (
+	Eq  Op = "eq"
+	Gte Op = "gte"
+	Lte Op = "lte"
+)
+
+// Field describes a column that can be sorted or filtered on. Value extracts
+// the same value from a model so results can be filtered, sorted and given a
+// cursor without going back to the database.
+//
+// A field can instead stand for a relationship no single column holds, such
+// as the artists credited on an album. Its Condition is the SQL an Eq filter
+// becomes, with the value bound to its one ?, and Matches does the same in
+// memory. Such fields can't be sorted on.
+//
+// Args are bound to the ? placeholders in Column, in order, wherever the
+// column is used, for expressions that depend on a value such as a date.
+type Field[T any] struct {
+	Column    string
+	Args      []any
+	Kind      Kind
+	Sortable  bool
+	Ops       []Op
+	Value     func(T) any
+	Condition string
+	Matches   func(item T, value any) bool
+}
+
+// Spec lists the fields a collection accepts, keyed by parameter name. Every
+// spec must have an "id" field, which breaks ties so cursors are stable.
+type Spec[T any] struct {
+	Fields map[string]Field[T]
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type Filter struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// Query is a parsed set of collection parameters.
+type Query struct {
+	Limit   int
+	Sort    []Sort
+	Filters []Filter
+	// After holds the sort values of the last item of the previous page.
+	After []any
+}
+
+// Page is the envelope returned by every collection endpoint.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// WithItems returns a page with the same cursor and total but different
+// items, typically the view models built from the original ones.
+func WithItems[T, U any](page Page[T], items []U) Page[U] {
+	return Page[U]{Items: items, NextCursor: page.NextCursor, Total: page.Total}
+}
+
+// ErrInvalid wraps every error caused by a bad parameter.
+var ErrInvalid = errors.New("invalid query")
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+// Parse reads limit, cursor, sort and filter parameters. Unknown sort fields,
+// malformed values and cursors from a different sort order are rejected.
+func (s Spec[T]) Parse(values url.Values) (Query, error) {
+	q := Query{Limit: DefaultLimit}
+
+	if text := values.Get("limit"); text != "" {
+		limit, err := strconv.Atoi(text)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Query{}, invalid("limit must be between 1 and %d", MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	if text := values.Get("sort"); text != "" {
+		for _, name := range strings.Split(text, ",") {
+			sort := Sort{Field: name}
+			if rest, ok := strings.CutPrefix(name, "-"); ok {
+				sort = Sort{Field: rest, Desc: true}
+			}
+			if field, ok := s.Fields[sort.Field]; !ok || !field.Sortable {
+				return Query{}, invalid("cannot sort by %q", sort.Field)
+			}
+			q.Sort = append(q.Sort, sort)
+		}
+	}
+	q.Sort = s.withTieBreak(q.Sort)
+
+	for _, name := range slices.Sorted(maps.Keys(s.Fields)) {
+		field := s.Fields[name]
+		for _, op := range field.Ops {
+			param := name
+			if op != Eq {
+				param += "_" + string(op)
+			}
+			text, ok := values[param]
+			if !ok || len(text) == 0 {
+				continue
+			}
+			value, err := parseValue(field.Kind, text[0])
+			if err != nil {
+				return Query{}, invalid("%s: %v", param, err)
+			}
+			q.Filters = append(q.Filters, Filter{Field: name, Op: op, Value: value})
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := s.decodeCursor(cursor, q.Sort)
+		if err != nil {
+			return Query{}, err
+		}
+		q.After = after
+	}
+
+	return q, nil
+}
+
+// WithMatches returns a copy of the spec whose named field is matched in
+// memory by matches, letting a store bind a relationship field to its data.
+func (s Spec[T]) WithMatches(name string, matches func(item T, value any) bool) Spec[T] {
+	fields := maps.Clone(s.Fields)
+	field := fields[name]
+	field.Matches = matches
+	fields[name] = field
+	return Spec[T]{Fields: fields}
+}
+
+// withTieBreak appends an ascending id sort unless id is already sorted on.
+func (s Spec[T]) withTieBreak(sorts []Sort) []Sort {
+	for _, sort := range sorts {
+		if sort.Field == "id" {
+			return sorts
+		}
+	}
+	return append(sorts, Sort{Field: "id"})
+}
+
+func parseValue(kind Kind, text string) (any, error) {
+	switch kind {
+	case Int:
+		return strconv.Atoi(text)
+	case Float:
+		return strconv.ParseFloat(text, 64)
+	case Bool:
+		return strconv.ParseBool(text)
+	default:
+		return text, nil
+	}
+}
+
+type cursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+func sortKey(sorts []Sort) string {
+	names := make([]string, len(sorts))
+	for i, sort := range sorts {
+		names[i] = sort.Field
+		if sort.Desc {
+			names[i] = "-" + sort.Field
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (s Spec[T]) encodeCursor(item T, sorts []Sort) string {
+	c := cursor{Sort: sortKey(sorts)}
+	for _, sort := range sorts {
+		c.Values = append(c.Values, s.Fields[sort.Field].Value(item))
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (s Spec[T]) decodeCursor(text string, sorts []Sort) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, invalid("malformed cursor")
+	}
+
+	var c struct {
+		Sort   string            `json:"s"`
+		Values []json.RawMessage `json:"v"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) != len(sorts) {
+		return nil, invalid("malformed cursor")
+	}
+	if c.Sort != sortKey(sorts) {
+		return nil, invalid("cursor does not match sort order")
+	}
+
+	values := make([]any, len(sorts))
+	for i, sort := range sorts {
+		if values[i], err = decodeValue(s.Fields[sort.Field].Kind, c.Values[i]); err != nil {
+			return nil, invalid("malformed cursor")
+		}
+	}
+	return values, nil
+}
+
+// decodeValue reads one cursor value. Nullable fields encode NULL as null.
+func decodeValue(kind Kind, raw json.RawMessage) (any, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	var err error
+	switch kind {
+	case Int:
+		var v int
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case Float:
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case Bool:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	default:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+// Paginate trims a result fetched with Limit+1 rows down to a page and sets
+// the cursor when more rows follow.
+func (s Spec[T]) Paginate(items []T, q Query, total int) Page[T] {
+	page := Page[T]{Items: items, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(items) > q.Limit {
+		page.Items = items[:q.Limit]
+		page.NextCursor = s.encodeCursor(page.Items[q.Limit-1], q.Sort)
+	}
+	return page
+}
@@ -0,0 +1,219 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+type track struct {
+	ID     int
+	Title  string
+	Price  float64
+	Single bool
+	Label  *int
+}
+
+var trackQuery = Spec[track]{Fields: map[string]Field[track]{
+	"id":     {Column: "id", Kind: Int, Sortable: true, Value: func(t track) any { return t.ID }},
+	"title":  {Column: "title", Kind: String, Sortable: true, Ops: []Op{Eq}, Value: func(t track) any { return t.Title }},
+	"price":  {Column: "price", Kind: Float, Sortable: true, Ops: []Op{Gte, Lte}, Value: func(t track) any { return t.Price }},
+	"single": {Column: "single", Kind: Bool, Ops: []Op{Eq}, Value: func(t track) any { return t.Single }},
+	"label": {Column: "label_id", Kind: Int, Ops: []Op{Eq}, Value: func(t track) any {
+		if t.Label == nil {
+			return nil
+		}
+		return *t.Label
+	}},
+}}
+
+func parse(t *testing.T, raw string) Query {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := trackQuery.Parse(values)
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %v", raw, err)
+	}
+	return q
+}
+
+func TestParseRejectsInvalidParameters(t *testing.T) {
+	tests := map[string]string{
+		"limit too large":     "limit=1000",
+		"limit not a number":  "limit=ten",
+		"unknown sort column": "sort=secret",
+		"unsortable column":   "sort=single",
+		"bad float filter":    "price_gte=cheap",
+		"bad bool filter":     "single=maybe",
+		"malformed cursor":    "cursor=!!!",
+	}
+
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, _ := url.ParseQuery(raw)
+			if _, err := trackQuery.Parse(values); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	q := parse(t, "")
+	if q.Limit != DefaultLimit || len(q.Sort) != 1 || q.Sort[0] != (Sort{Field: "id"}) {
+		t.Errorf("unexpected defaults: %+v", q)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	q := parse(t, "sort=-price,title&price_gte=1.5&single=true&limit=10")
+	q.After = []any{2.5, "Yellow", 7}
+
+	clauses, args := trackQuery.Select(q)
+
+	want := " WHERE price >= ? AND single = ? AND " +
+		"(((price < ? OR price IS NULL)) OR (price = ? AND title > ?) OR (price = ? AND title = ? AND id > ?))" +
+		" ORDER BY price DESC, title, id LIMIT ?"
+	if clauses != want {
+		t.Errorf("wrong clauses:\n got %s\nwant %s", clauses, want)
+	}
+
+	wantArgs := []any{1.5, true, 2.5, 2.5, "Yellow", 2.5, "Yellow", 7, 11}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("wrong args: got %v want %v", args, wantArgs)
+	}
+	for i := range args {
+		if args[i] != wantArgs[i] {
+			t.Errorf("arg %d: got %v want %v", i, args[i], wantArgs[i])
+		}
+	}
+
+	where, _ := trackQuery.Where(q)
+	if where != " WHERE price >= ? AND single = ?" {
+		t.Errorf("count query should ignore the cursor: got %s", where)
+	}
+}
+
+func TestSelectBindsColumnArgs(t *testing.T) {
+	dated := Spec[track]{Fields: map[string]Field[track]{
+		"id":  trackQuery.Fields["id"],
+		"age": {Column: "age(?)", Args: []any{"1990-01-01"}, Kind: Int, Sortable: true, Ops: []Op{Gte}, Value: func(t track) any { return t.ID }},
+	}}
+	values, _ := url.ParseQuery("sort=-age&age_gte=20&limit=5")
+	q, err := dated.Parse(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.After = []any{30, 4}
+
+	clauses, args := dated.Select(q)
+	want := " WHERE age(?) >= ? AND (((age(?) < ? OR age(?) IS NULL)) OR (age(?) = ? AND id > ?)) ORDER BY age(?) DESC, id LIMIT ?"
+	if clauses != want {
+		t.Errorf("wrong clauses:\n got %s\nwant %s", clauses, want)
+	}
+	day := "1990-01-01"
+	wantArgs := []any{day, 20, day, 30, day, day, 30, 4, day, 6}
+	if fmt.Sprint(args) != fmt.Sprint(wantArgs) {
+		t.Errorf("wrong args: got %v want %v", args, wantArgs)
+	}
+}
+
+func TestSelectWithNullCursor(t *testing.T) {
+	q := parse(t, "sort=title&limit=10")
+	q.After = []any{nil, 3}
+	clauses, args := trackQuery.Select(q)
+	want := " WHERE ((title IS NOT NULL) OR (title IS NULL AND id > ?)) ORDER BY title, id LIMIT ?"
+	if clauses != want || len(args) != 2 || args[0] != 3 {
+		t.Errorf("wrong clauses:\n got %s %v\nwant %s", clauses, args, want)
+	}
+
+	q = parse(t, "sort=-title&limit=10")
+	q.After = []any{nil, 3}
+	clauses, _ = trackQuery.Select(q)
+	want = " WHERE ((0) OR (title IS NULL AND id > ?)) ORDER BY title DESC, id LIMIT ?"
+	if clauses != want {
+		t.Errorf("wrong clauses:\n got %s\nwant %s", clauses, want)
+	}
+}
+
+func TestCursorKeepsNull(t *testing.T) {
+	label := 1
+	labelled := Spec[track]{Fields: map[string]Field[track]{
+		"id":    trackQuery.Fields["id"],
+		"label": {Column: "label_id", Kind: Int, Sortable: true, Value: trackQuery.Fields["label"].Value},
+	}}
+	tracks := []track{{ID: 1, Label: &label}, {ID: 2}, {ID: 3}}
+	values, _ := url.ParseQuery("sort=label&limit=1")
+	q, _ := labelled.Parse(values)
+	page := labelled.Apply(tracks, q)
+
+	values.Set("cursor", page.NextCursor)
+	q, err := labelled.Parse(values)
+	if err != nil || len(q.After) != 2 || q.After[0] != nil {
+		t.Fatalf("cursor after a NULL = %v, %v", q.After, err)
+	}
+	if page = labelled.Apply(tracks, q); page.Items[0].ID != 3 {
+		t.Errorf("second page = %+v", page.Items)
+	}
+}
+
+func TestApplyPagesThroughResults(t *testing.T) {
+	label := 1
+	tracks := []track{
+		{ID: 1, Title: "Yellow", Price: 1.29, Label: &label},
+		{ID: 2, Title: "Trouble", Price: 0.99},
+		{ID: 3, Title: "Creep", Price: 1.29, Single: true, Label: &label},
+		{ID: 4, Title: "Clocks", Price: 1.99},
+		{ID: 5, Title: "Fix You", Price: 1.29},
+	}
+
+	var got []int
+	raw := "sort=-price,title&limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		page := trackQuery.Apply(tracks, parse(t, raw))
+		if page.Total != 5 {
+			t.Errorf("total should count every match: got %d", page.Total)
+		}
+		for _, item := range page.Items {
+			got = append(got, item.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		raw = "sort=-price,title&limit=2&cursor=" + page.NextCursor
+	}
+
+	want := []int{4, 3, 5, 1, 2}
+	if len(got) != len(want) {
+		t.Fatalf("wrong order: got %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("wrong order: got %v want %v", got, want)
+		}
+	}
+
+	filtered := trackQuery.Apply(tracks, parse(t, "label=1&price_lte=1.5"))
+	if filtered.Total != 2 || filtered.Items[0].ID != 1 || filtered.Items[1].ID != 3 {
+		t.Errorf("wrong filtered page: %+v", filtered)
+	}
+}
+
+func TestCursorMustMatchSort(t *testing.T) {
+	page := trackQuery.Apply([]track{{ID: 1}, {ID: 2}}, parse(t, "limit=1&sort=title"))
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	values, _ := url.ParseQuery("sort=-price&cursor=" + page.NextCursor)
+	if _, err := trackQuery.Parse(values); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a cursor from another sort, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+	"slices"
+	"strings"
+)
+
+var sqlOps = map[Op]string{Eq: "=", Gte: ">=", Lte: "<="}
+
+// Where builds the filter conditions, without the cursor, for counting.
+func (s Spec[T]) Where(q Query) (string, []any) {
+	conditions, args := s.filters(q)
+	return joinConditions(conditions), args
+}
+
+// Select builds the WHERE, ORDER BY and LIMIT clauses for one page. It asks
+// for Limit+1 rows so Paginate can tell whether another page follows.
+func (s Spec[T]) Select(q Query) (string, []any) {
+	conditions, args := s.filters(q)
+	if q.After != nil {
+		keyset, keysetArgs := s.keyset(q)
+		conditions = append(conditions, keyset)
+		args = append(args, keysetArgs...)
+	}
+
+	order := make([]string, len(q.Sort))
+	for i, sort := range q.Sort {
+		field := s.Fields[sort.Field]
+		order[i] = field.Column
+		if sort.Desc {
+			order[i] += " DESC"
+		}
+		args = append(args, field.Args...)
+	}
+
+	args = append(args, q.Limit+1)
+	return joinConditions(conditions) + " ORDER BY " + strings.Join(order, ", ") + " LIMIT ?", args
+}
+
+func (s Spec[T]) filters(q Query) ([]string, []any) {
+	var conditions []string
+	var args []any
+	for _, filter := range q.Filters {
+		field := s.Fields[filter.Field]
+		if field.Condition != "" {
+			conditions = append(conditions, field.Condition)
+		} else {
+			conditions = append(conditions, field.Column+" "+sqlOps[filter.Op]+" ?")
+			args = append(args, field.Args...)
+		}
+		args = append(args, filter.Value)
+	}
+	return conditions, args
+}
+
+// keyset expands the row comparison against the cursor into
+// (a > ?) OR (a = ? AND b > ?) ..., flipping the comparison for descending
+// columns. NULLs sort first, as SQLite orders them ascending, so a NULL
+// cursor value is compared with IS NULL and IS NOT NULL instead.
+func (s Spec[T]) keyset(q Query) (string, []any) {
+	var alternatives []string
+	var args []any
+	for i, sort := range q.Sort {
+		var terms []string
+		for j := 0; j < i; j++ {
+			term, termArgs := equal(s.Fields[q.Sort[j].Field], q.After[j])
+			terms = append(terms, term)
+			args = append(args, termArgs...)
+		}
+		term, termArgs := beyond(s.Fields[sort.Field], q.After[i], sort.Desc)
+		terms = append(terms, term)
+		args = append(args, termArgs...)
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// equal matches the rows whose column holds value.
+func equal[T any](field Field[T], value any) (string, []any) {
+	if value == nil {
+		return field.Column + " IS NULL", field.Args
+	}
+	return field.Column + " = ?", append(slices.Clone(field.Args), value)
+}
+
+// beyond matches the rows whose column sorts strictly after value.
+func beyond[T any](field Field[T], value any, desc bool) (string, []any) {
+	column, args := field.Column, field.Args
+	switch {
+	case value == nil && desc:
+		// NULLs come last in descending order.
+		return "0", nil
+	case value == nil:
+		return column + " IS NOT NULL", args
+	case desc:
+		return "(" + column + " < ? OR " + column + " IS NULL)", slices.Concat(args, []any{value}, args)
+	default:
+		return column + " > ?", append(slices.Clone(args), value)
+	}
+}
+
+func joinConditions(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,254 @@
+package recommend
+
+import (
+	"cmp"
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"maps"
+	"math"
+	"slices"
+)
+
+const (
+	// maxSeeds is how many of a user's most played songs their
+	// recommendations start from.
+	maxSeeds = 50
+	// fallbackSeeds is how many of them the fallback looks beyond, since it
+	// takes a few queries per song.
+	fallbackSeeds = 5
+	// maxGenreSongs caps how many songs of one genre the fallback considers.
+	maxGenreSongs = 100
+
+	// The fallback scores a shared performer and a shared genre the same as
+	// a weak co-listening signal, so real similarities rank first.
+	performerScore = 0.1
+	genreScore     = 0.05
+)
+
+// candidate is a song being considered, with its score so far and the
+// reason for the largest contribution to it.
+type candidate struct {
+	score float64
+	best  float64
+	// reason is the reason to give for best.
+	reason string
+}
+
+type candidates map[int]*candidate
+
+func (c candidates) add(songID int, score float64, reason string) {
+	if c[songID] == nil {
+		c[songID] = &candidate{}
+	}
+	c[songID].score += score
+	if score > c[songID].best {
+		c[songID].best, c[songID].reason = score, reason
+	}
+}
+
+// top returns the limit best candidates, highest score first and then by
+// song ID so the order is stable.
+func (c candidates) top(limit int) []models.Recommendation {
+	recommendations := make([]models.Recommendation, 0, len(c))
+	for songID, candidate := range c {
+		recommendations = append(recommendations, models.Recommendation{
+			SongId: songID, Score: math.Round(candidate.score*1e4) / 1e4, Reason: candidate.reason,
+		})
+	}
+	slices.SortFunc(recommendations, func(a, b models.Recommendation) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.SongId, b.SongId))
+	})
+	return recommendations[:min(len(recommendations), limit)]
+}
+
+// Similar returns up to limit songs like the given one: those listened to by
+// the same users, topped up with songs sharing a performer or a genre.
+func Similar(ctx context.Context, store *repositories.Store, song models.Song, limit int) ([]models.Recommendation, error) {
+	similar, err := store.Recommendations.GetSimilar(ctx, []int{song.Id})
+	if err != nil {
+		return nil, err
+	}
+	found := candidates{}
+	for _, neighbour := range similar[song.Id] {
+		found.add(neighbour.SongId, neighbour.Score, "listeners of "+song.Title+" also played this")
+	}
+
+	if len(found) < limit {
+		related, err := relatedSongs(ctx, store, []int{song.Id})
+		if err != nil {
+			return nil, err
+		}
+		fallback := candidates{}
+		for _, relation := range related {
+			if relation.songID == song.Id || found[relation.songID] != nil {
+				continue
+			}
+			reason := "also by " + relation.name
+			if relation.genre {
+				reason = "also in " + relation.name
+			}
+			fallback.add(relation.songID, relation.score, reason)
+		}
+		maps.Copy(found, fallback)
+	}
+	return found.top(limit), nil
+}
+
+// ForUser returns up to limit songs the user hasn't played or bought, scored
+// by their similarity to the songs the user has, weighted by how often they
+// played each. When that finds too few, songs sharing a performer or genre
+// with their favourites are added.
+func ForUser(ctx context.Context, store *repositories.Store, userID int, limit int) ([]models.Recommendation, error) {
+	listened, err := store.Recommendations.Listened(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seeds := mostPlayed(listened, maxSeeds)
+	if len(seeds) == 0 {
+		return []models.Recommendation{}, nil
+	}
+
+	songs, err := store.Songs.GetByIDs(ctx, seeds)
+	if err != nil {
+		return nil, err
+	}
+	reasons := map[int]string{}
+	for _, song := range songs {
+		reasons[song.Id] = "because you played " + song.Title
+		if listened[song.Id] == 0 {
+			reasons[song.Id] = "because you bought " + song.Title
+		}
+	}
+	// Playing a song again counts for less each time.
+	weight := func(songID int) float64 {
+		return 1 + math.Log(float64(max(listened[songID], 1)))
+	}
+
+	similar, err := store.Recommendations.GetSimilar(ctx, seeds)
+	if err != nil {
+		return nil, err
+	}
+	found := candidates{}
+	for _, seed := range seeds {
+		for _, neighbour := range similar[seed] {
+			if _, heard := listened[neighbour.SongId]; !heard {
+				found.add(neighbour.SongId, weight(seed)*neighbour.Score, reasons[seed])
+			}
+		}
+	}
+
+	if len(found) < limit {
+		related, err := relatedSongs(ctx, store, seeds[:min(len(seeds), fallbackSeeds)])
+		if err != nil {
+			return nil, err
+		}
+		fallback := candidates{}
+		for _, relation := range related {
+			if _, heard := listened[relation.songID]; heard || found[relation.songID] != nil {
+				continue
+			}
+			fallback.add(relation.songID, weight(relation.seed)*relation.score, "because you like "+relation.name)
+		}
+		maps.Copy(found, fallback)
+	}
+	return found.top(limit), nil
+}
+
+// mostPlayed returns up to limit of the listened songs, most played first.
+func mostPlayed(listened map[int]int, limit int) []int {
+	var songIDs []int
+	for songID := range listened {
+		songIDs = append(songIDs, songID)
+	}
+	slices.SortFunc(songIDs, func(a, b int) int {
+		return cmp.Or(cmp.Compare(listened[b], listened[a]), cmp.Compare(a, b))
+	})
+	return songIDs[:min(len(songIDs), limit)]
+}
+
+// relation is a song sharing a performer or genre, named name, with a seed
+// song.
+type relation struct {
+	seed   int
+	songID int
+	name   string
+	genre  bool
+	score  float64
+}
+
+// relatedSongs finds the songs sharing a performing artist or band, or a
+// genre, with each seed.
+func relatedSongs(ctx context.Context, store *repositories.Store, seeds []int) ([]relation, error) {
+	var related []relation
+
+	credits, err := store.Credits.GetBySongIDs(ctx, seeds)
+	if err != nil {
+		return nil, err
+	}
+	for _, seed := range seeds {
+		for _, credit := range credits[seed] {
+			if credit.Role != models.RolePerformer {
+				continue
+			}
+			name, others, err := performerSongs(ctx, store, credit)
+			if err != nil {
+				return nil, err
+			}
+			for _, other := range others {
+				related = append(related, relation{seed: seed, songID: other, name: name, score: performerScore})
+			}
+		}
+	}
+
+	genres, err := store.Genres.GetBySongIDs(ctx, seeds)
+	if err != nil {
+		return nil, err
+	}
+	for _, seed := range seeds {
+		for _, genre := range genres[seed] {
+			songIDs, err := store.Genres.GetSongIDs(ctx, []int{genre.Id})
+			if err != nil {
+				return nil, err
+			}
+			for _, other := range songIDs[:min(len(songIDs), maxGenreSongs)] {
+				related = append(related, relation{seed: seed, songID: other, name: genre.Name, genre: true, score: genreScore})
+			}
+		}
+	}
+	return related, nil
+}
+
+// performerSongs returns the name of the artist or band a credit names, and
+// the songs it performs.
+func performerSongs(ctx context.Context, store *repositories.Store, credit models.Credit) (string, []int, error) {
+	var name string
+	var credits []models.Credit
+	if credit.ArtistId != nil {
+		artist, err := store.Artists.GetByID(ctx, *credit.ArtistId)
+		if err != nil {
+			return "", nil, err
+		}
+		name = artist.FirstName + " " + artist.LastName
+		if credits, err = store.Credits.GetByArtistID(ctx, artist.Id); err != nil {
+			return "", nil, err
+		}
+	} else {
+		band, err := store.Bands.GetByID(ctx, *credit.BandId)
+		if err != nil {
+			return "", nil, err
+		}
+		name = band.Name
+		if credits, err = store.Credits.GetByBandID(ctx, band.Id); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var songIDs []int
+	for _, other := range credits {
+		if other.SongId != nil && other.Role == models.RolePerformer {
+			songIDs = append(songIDs, *other.SongId)
+		}
+	}
+	return name, songIDs, nil
+}
@@ -0,0 +1,126 @@
+package recommend_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/recommend"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+)
+
+func TestSimilarities(t *testing.T) {
+	listens := map[int][]int{
+		1: {10, 20, 30},
+		2: {10, 20},
+		3: {20, 40},
+	}
+	got := recommend.Similarities(listens, 2)
+
+	// 10 and 20 share two listeners out of 2 and 3: 2/sqrt(6).
+	want := map[int][]models.SimilarSong{
+		10: {{SongId: 20, Score: 0.8165}, {SongId: 30, Score: 0.7071}},
+		20: {{SongId: 10, Score: 0.8165}, {SongId: 30, Score: 0.5774}},
+		30: {{SongId: 10, Score: 0.7071}, {SongId: 20, Score: 0.5774}},
+		40: {{SongId: 20, Score: 0.5774}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+// seedListening builds a catalog of songs by two bands and has users play
+// them, returning the store and the song IDs by title.
+func seedListening(t *testing.T) (*repositories.Store, map[string]int) {
+	t.Helper()
+	ctx := context.Background()
+	store := repositories.NewMemoryStore()
+
+	radiohead, _ := store.Bands.Create(ctx, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"})
+	portishead, _ := store.Bands.Create(ctx, models.Band{Name: "Portishead", Nationality: "British", DateFormed: "1991-01-01"})
+	songs := map[string]int{}
+	for title, band := range map[string]int{
+		"Karma Police": radiohead, "Airbag": radiohead, "Reckoner": radiohead,
+		"Roads": portishead, "Glory Box": portishead,
+	} {
+		id, _ := store.Songs.Create(ctx, models.Song{Title: title, Length: 240, Price: 99})
+		if _, err := store.Credits.Create(ctx, models.Credit{SongId: &id, BandId: &band, Role: models.RolePerformer}); err != nil {
+			t.Fatal(err)
+		}
+		songs[title] = id
+	}
+
+	listen := func(username string, titles ...string) int {
+		userID, _ := store.Users.Create(ctx, models.User{Username: username, Password: "hash", Email: username + "@example.com"})
+		var plays []models.Play
+		for _, title := range titles {
+			id := songs[title]
+			plays = append(plays, models.Play{UserId: userID, SongId: &id, Duration: 240})
+		}
+		if _, err := store.Plays.Record(ctx, plays); err != nil {
+			t.Fatal(err)
+		}
+		return userID
+	}
+	listen("alice", "Karma Police", "Karma Police", "Roads")
+	listen("bob", "Karma Police", "Roads", "Glory Box")
+	listen("carol", "Airbag")
+
+	if err := recommend.Rebuild(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	return store, songs
+}
+
+func TestForUser(t *testing.T) {
+	store, songs := seedListening(t)
+	ctx := context.Background()
+	dave, _ := store.Users.Create(ctx, models.User{Username: "dave", Password: "hash", Email: "dave@example.com"})
+	karma := songs["Karma Police"]
+	if _, err := store.Plays.Record(ctx, []models.Play{{UserId: dave, SongId: &karma, Duration: 240}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := recommend.ForUser(ctx, store, dave, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Roads is co-listened with Karma Police by two users, Glory Box by one;
+	// the other Radiohead songs only come from the shared band.
+	want := []models.Recommendation{
+		{SongId: songs["Roads"], Score: 1, Reason: "because you played Karma Police"},
+		{SongId: songs["Glory Box"], Score: 0.7071, Reason: "because you played Karma Police"},
+		{SongId: songs["Airbag"], Score: 0.1, Reason: "because you like Radiohead"},
+		{SongId: songs["Reckoner"], Score: 0.1, Reason: "because you like Radiohead"},
+	}
+	if songs["Reckoner"] < songs["Airbag"] {
+		want[2], want[3] = want[3], want[2]
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if got, _ := recommend.ForUser(ctx, store, 99, 10); len(got) != 0 {
+		t.Errorf("a user without plays got %+v", got)
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	store, songs := seedListening(t)
+	ctx := context.Background()
+	song, _ := store.Songs.GetByID(ctx, songs["Karma Police"])
+
+	got, err := recommend.Similar(ctx, store, song, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %+v", got)
+	}
+	if got[0].SongId != songs["Roads"] || got[0].Reason != "listeners of Karma Police also played this" {
+		t.Errorf("best match = %+v", got[0])
+	}
+	if got[2].Reason != "also by Radiohead" {
+		t.Errorf("fallback = %+v", got[2])
+	}
+}
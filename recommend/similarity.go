@@ -0,0 +1,89 @@
+// Package recommend suggests songs from what users listen to. Songs are
+// similar when the same users play or own them (item-to-item collaborative
+// filtering); where there is too little listening to go on, songs sharing a
+// performer or a genre are suggested instead.
+package recommend
+
+import (
+	"cmp"
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// Neighbours is how many similar songs are kept for each song.
+	Neighbours = 20
+	// maxUserSongs caps how many of a user's most played songs count
+	// towards similarity, since the pairs grow with its square.
+	maxUserSongs = 500
+)
+
+// Similarities computes the cosine similarity between every two songs
+// listened to by the same users: the number of users who listened to both,
+// divided by the geometric mean of the number who listened to each. listens
+// holds the songs of each user, most played first. Only the neighbours most
+// similar songs of each are kept, highest score first.
+func Similarities(listens map[int][]int, neighbours int) map[int][]models.SimilarSong {
+	listeners := map[int]int{}
+	together := map[[2]int]int{}
+	for _, songs := range listens {
+		songs = slices.Clone(songs[:min(len(songs), maxUserSongs)])
+		slices.Sort(songs)
+		songs = slices.Compact(songs)
+		for i, a := range songs {
+			listeners[a]++
+			for _, b := range songs[i+1:] {
+				together[[2]int{a, b}]++
+			}
+		}
+	}
+
+	similar := map[int][]models.SimilarSong{}
+	for pair, count := range together {
+		a, b := pair[0], pair[1]
+		score := math.Round(float64(count)/math.Sqrt(float64(listeners[a]*listeners[b]))*1e4) / 1e4
+		similar[a] = append(similar[a], models.SimilarSong{SongId: b, Score: score})
+		similar[b] = append(similar[b], models.SimilarSong{SongId: a, Score: score})
+	}
+	for songID, songs := range similar {
+		slices.SortFunc(songs, func(x, y models.SimilarSong) int {
+			return cmp.Or(cmp.Compare(y.Score, x.Score), cmp.Compare(x.SongId, y.SongId))
+		})
+		similar[songID] = songs[:min(len(songs), neighbours)]
+	}
+	return similar
+}
+
+// Rebuild recomputes the similarity matrix from every user's plays and
+// library and replaces the stored one.
+func Rebuild(ctx context.Context, store *repositories.Store) error {
+	listens, err := store.Recommendations.Listeners(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Recommendations.ReplaceSimilarities(ctx, Similarities(listens, Neighbours))
+}
+
+// Schedule rebuilds the similarity matrix straight away and then every
+// interval, until ctx is cancelled. Failures are passed to onError and the
+// next rebuild goes ahead as planned.
+func Schedule(ctx context.Context, store *repositories.Store, interval time.Duration, onError func(error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := Rebuild(ctx, store); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
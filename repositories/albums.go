@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+	"time"
+)
+
+type AlbumRepository interface {
+	GetAll(ctx context.Context) ([]models.Album, error)
+	GetByID(ctx context.Context, id int) (models.Album, error)
+	List(ctx context.Context, q query.Query) (query.Page[models.Album], error)
+	GetByIDs(ctx context.Context, ids []int) ([]models.Album, error)
+	// GetBySongIDs returns the albums of each song, keyed by song ID.
+	GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Album, error)
+	Create(ctx context.Context, album models.Album) (int, error)
+	Update(ctx context.Context, id int, album models.Album) error
+	Delete(ctx context.Context, id int) error
+	// SetSongs replaces the album's tracks.
+	SetSongs(ctx context.Context, albumID int, songIDs []int) error
+	// GetByLabelID returns the label's albums by release date, undated ones
+	// last.
+	GetByLabelID(ctx context.Context, labelID int) ([]models.Album, error)
+}
+
+const albumColumns = "id, title, price, label_id, release_date, catalog_number, barcode, format"
+
+// AlbumQuery lists the parameters accepted by GET /albums. artist_id and
+// band_id match albums the artist or band is credited on in any role.
+var AlbumQuery = query.Spec[models.Album]{Fields: map[string]query.Field[models.Album]{
+	"id":           {Column: "id", Kind: query.Int, Sortable: true, Value: func(a models.Album) any { return a.Id }},
+	"title":        {Column: "title", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(a models.Album) any { return a.Title }},
+	"price":        {Column: "price", Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(a models.Album) any { return int(a.Price) }},
+	"label_id":     {Column: "label_id", Kind: query.Int, Ops: []query.Op{query.Eq}, Value: func(a models.Album) any { return nullableInt(a.LabelId) }},
+	"release_date": {Column: "release_date", Kind: query.String, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(a models.Album) any { return nullableString(a.ReleaseDate) }},
+	"format":       {Column: "format", Kind: query.String, Ops: []query.Op{query.Eq}, Value: func(a models.Album) any { return nullableString(a.Format) }},
+	"artist_id":    {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM credits c WHERE c.album_id = albums.id AND c.artist_id = ?)"},
+	"band_id":      {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM credits c WHERE c.album_id = albums.id AND c.band_id = ?)"},
+}}
+
+type SQLiteAlbumRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAlbumRepository(db *sql.DB) *SQLiteAlbumRepository {
+	return &SQLiteAlbumRepository{db: db}
+}
+
+func (r *SQLiteAlbumRepository) GetAll(ctx context.Context) ([]models.Album, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+albumColumns+" FROM albums")
+	if err != nil {
+		return nil, err
+	}
+	return scanAlbums(rows)
+}
+
+func (r *SQLiteAlbumRepository) GetByID(ctx context.Context, id int) (models.Album, error) {
+	var album models.Album
+	err := r.db.QueryRowContext(ctx,
+		"SELECT "+albumColumns+" FROM albums WHERE id = ?", id,
+	).Scan(albumFields(&album)...)
+	if err != nil {
+		return models.Album{}, translateError(err)
+	}
+	return album, nil
+}
+
+func (r *SQLiteAlbumRepository) List(ctx context.Context, q query.Query) (query.Page[models.Album], error) {
+	return list(ctx, r.db, AlbumQuery, q, "albums", albumColumns, nil, scanAlbums)
+}
+
+func (r *SQLiteAlbumRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Album, error) {
+	var albums []models.Album
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT "+albumColumns+" FROM albums WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		batch, err := scanAlbums(rows)
+		albums = append(albums, batch...)
+		return err
+	})
+	return albums, err
+}
+
+func (r *SQLiteAlbumRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Album, error) {
+	result := map[int][]models.Album{}
+	err := inBatches(songIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT sa.song_id, a.id, a.title, a.price, a.label_id, a.release_date, a.catalog_number, a.barcode, a.format
+			FROM albums a
+			JOIN album_songs sa ON a.id = sa.album_id
+			WHERE sa.song_id IN (`+placeholders+`)
+			ORDER BY sa.song_id, a.id`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var songID int
+			var album models.Album
+			if err := rows.Scan(append([]any{&songID}, albumFields(&album)...)...); err != nil {
+				return err
+			}
+			result[songID] = append(result[songID], album)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+func (r *SQLiteAlbumRepository) Create(ctx context.Context, album models.Album) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO albums (title, price, label_id, release_date, catalog_number, barcode, format) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		album.Title, album.Price, album.LabelId, album.ReleaseDate, album.CatalogNumber, album.Barcode, album.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteAlbumRepository) Update(ctx context.Context, id int, album models.Album) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE albums SET title = ?, price = ?, label_id = ?, release_date = ?, catalog_number = ?, barcode = ?, format = ? WHERE id = ?",
+		album.Title, album.Price, album.LabelId, album.ReleaseDate, album.CatalogNumber, album.Barcode, album.Format, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteAlbumRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM albums WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteAlbumRepository) SetSongs(ctx context.Context, albumID int, songIDs []int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, "SELECT 1 FROM albums WHERE id = ?", albumID).Scan(&exists); err != nil {
+		return translateError(err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM album_songs WHERE album_id = ?", albumID); err != nil {
+		return err
+	}
+	for i, songID := range songIDs {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO album_songs (album_id, song_id, position) VALUES (?, ?, ?)", albumID, songID, i+1); err != nil {
+			return translateError(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteAlbumRepository) GetByLabelID(ctx context.Context, labelID int) ([]models.Album, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+albumColumns+" FROM albums WHERE label_id = ? ORDER BY release_date IS NULL, release_date, id", labelID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAlbums(rows)
+}
+
+// albumFields are the scan destinations for albumColumns.
+func albumFields(album *models.Album) []any {
+	return []any{&album.Id, &album.Title, &album.Price, &album.LabelId, &album.ReleaseDate, &album.CatalogNumber, &album.Barcode, &album.Format}
+}
+
+func scanAlbums(rows *sql.Rows) ([]models.Album, error) {
+	defer rows.Close()
+
+	var albums []models.Album
+	for rows.Next() {
+		var album models.Album
+		if err := rows.Scan(albumFields(&album)...); err != nil {
+			return nil, err
+		}
+		albums = append(albums, album)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
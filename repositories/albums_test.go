@@ -0,0 +1,266 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// albumColumns are the columns every album query selects.
+var albumColumns = []string{"id", "title", "price", "label_id", "release_date", "catalog_number", "barcode", "format"}
+
+const albumSelect = "SELECT id, title, price, label_id, release_date, catalog_number, barcode, format FROM albums"
+
+func TestSQLiteAlbumRepositoryGetAll(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	rows := sqlmock.NewRows(albumColumns).
+		AddRow(1, "Parachutes", 999, nil, nil, nil, nil, nil)
+	mock.ExpectQuery(albumSelect).
+		WillReturnRows(rows)
+
+	albums, err := repositories.NewSQLiteAlbumRepository(mockDB).GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+
+	if len(albums) != 1 || albums[0].Title != "Parachutes" || albums[0].Price != 999 {
+		t.Errorf("Wrong album data: got %+v", albums)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSQLiteAlbumRepositoryList(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	q, err := repositories.AlbumQuery.Parse(url.Values{"price_gte": {"500"}, "sort": {"-price"}, "limit": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM albums WHERE price >= \?`).
+		WithArgs(500).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(albumSelect+` WHERE price >= \? ORDER BY price DESC, id LIMIT \?`).
+		WithArgs(500, 2).
+		WillReturnRows(sqlmock.NewRows(albumColumns).
+			AddRow(2, "OK Computer", 1299, 1, "1997-05-21", "NODATA 02", "724385522925", "CD").
+			AddRow(1, "Parachutes", 999, nil, nil, nil, nil, nil))
+	mock.ExpectCommit()
+
+	page, err := repositories.NewSQLiteAlbumRepository(mockDB).List(context.Background(), q)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Title != "OK Computer" || page.NextCursor == "" {
+		t.Errorf("Wrong page: got %+v", page)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSQLiteAlbumRepositoryGetByID(t *testing.T) {
+	t.Run("Album found", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock DB: %v", err)
+		}
+		defer mockDB.Close()
+
+		rows := sqlmock.NewRows(albumColumns).
+			AddRow(2, "Kind of Blue", 1299, 3, "1959-08-17", "CL 1355", nil, "LP")
+		mock.ExpectQuery(albumSelect + " WHERE id = ?").
+			WithArgs(2).
+			WillReturnRows(rows)
+
+		album, err := repositories.NewSQLiteAlbumRepository(mockDB).GetByID(context.Background(), 2)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+
+		if album.Id != 2 || album.Title != "Kind of Blue" || *album.LabelId != 3 || *album.ReleaseDate != "1959-08-17" ||
+			*album.CatalogNumber != "CL 1355" || album.Barcode != nil || *album.Format != "LP" {
+			t.Errorf("Wrong album data: got %+v", album)
+		}
+	})
+
+	t.Run("Album not found", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock DB: %v", err)
+		}
+		defer mockDB.Close()
+
+		mock.ExpectQuery(albumSelect + " WHERE id = ?").
+			WithArgs(999).
+			WillReturnRows(sqlmock.NewRows(albumColumns))
+
+		_, err = repositories.NewSQLiteAlbumRepository(mockDB).GetByID(context.Background(), 999)
+		if !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("Wrong error: got %v want %v", err, repositories.ErrNotFound)
+		}
+	})
+}
+
+func TestSQLiteAlbumRepositoryCreate(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	labelID, format := 4, "CD"
+	album := models.Album{Title: "Parachutes", Price: 999, LabelId: &labelID, Format: &format}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO albums").
+		WithArgs(album.Title, album.Price, album.LabelId, nil, nil, nil, album.Format).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+
+	id, err := repositories.NewSQLiteAlbumRepository(mockDB).Create(context.Background(), album)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if id != 7 {
+		t.Errorf("Wrong id: got %d want 7", id)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSQLiteAlbumRepositoryUpdate(t *testing.T) {
+	t.Run("Successful update", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock DB: %v", err)
+		}
+		defer mockDB.Close()
+
+		album := models.Album{Title: "Parachutes", Price: 999}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE albums SET").
+			WithArgs(album.Title, album.Price, nil, nil, nil, nil, nil, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repositories.NewSQLiteAlbumRepository(mockDB).Update(context.Background(), 1, album); err != nil {
+			t.Errorf("Update returned error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("No rows affected", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock DB: %v", err)
+		}
+		defer mockDB.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE albums SET").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err = repositories.NewSQLiteAlbumRepository(mockDB).Update(context.Background(), 999, models.Album{Title: "Missing", Price: 100})
+		if !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("Wrong error: got %v want %v", err, repositories.ErrNotFound)
+		}
+	})
+
+	t.Run("Database error rolls back", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock DB: %v", err)
+		}
+		defer mockDB.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE albums SET").
+			WillReturnError(errors.New("database connection lost"))
+		mock.ExpectRollback()
+
+		err = repositories.NewSQLiteAlbumRepository(mockDB).Update(context.Background(), 1, models.Album{Title: "Parachutes", Price: 100})
+		if err == nil {
+			t.Errorf("Update returned nil error")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestSQLiteAlbumRepositoryDelete(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM albums WHERE id = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repositories.NewSQLiteAlbumRepository(mockDB).Delete(context.Background(), 1); err != nil {
+		t.Errorf("Delete returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSQLiteAlbumRepositoryConstraintErrors(t *testing.T) {
+	sqlDB := openMigratedDB(t)
+	albums := repositories.NewSQLiteAlbumRepository(sqlDB)
+	ctx := context.Background()
+
+	albumID, err := albums.Create(ctx, models.Album{Title: "Parachutes", Price: 999})
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingBand := 42
+	_, err = repositories.NewSQLiteCreditRepository(sqlDB).Create(ctx,
+		models.Credit{AlbumId: &albumID, BandId: &missingBand, Role: models.RolePerformer})
+	if !errors.Is(err, repositories.ErrInvalidReference) {
+		t.Errorf("Wrong error for a missing band: got %v want %v", err, repositories.ErrInvalidReference)
+	}
+
+	users := repositories.NewSQLiteUserRepository(sqlDB)
+	user := models.User{Username: "chris", Password: "hash", Email: "chris@example.com", Role: "viewer"}
+	if _, err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Create(ctx, user); !errors.Is(err, repositories.ErrConflict) {
+		t.Errorf("Wrong error for a duplicate user: got %v want %v", err, repositories.ErrConflict)
+	}
+}
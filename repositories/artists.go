@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+)
+
+type ArtistRepository interface {
+	GetAll(ctx context.Context) ([]models.Artist, error)
+	GetByID(ctx context.Context, id int) (models.Artist, error)
+	// List filters and sorts on age and alive as of the YYYY-MM-DD date asOf,
+	// or today when it is empty.
+	List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Artist], error)
+	GetByIDs(ctx context.Context, ids []int) ([]models.Artist, error)
+	// GetByName returns the artists whose first and last name, joined by a
+	// space, are name, ignoring case.
+	GetByName(ctx context.Context, name string) ([]models.Artist, error)
+	Create(ctx context.Context, artist models.Artist) (int, error)
+	Update(ctx context.Context, id int, artist models.Artist) error
+	Delete(ctx context.Context, id int) error
+	// GetSexNames and GetTitleNames resolve lookup IDs to display names.
+	GetSexNames(ctx context.Context, ids []int) (map[int]string, error)
+	GetTitleNames(ctx context.Context, ids []int) (map[int]string, error)
+}
+
+// birth_date is declared DATE, which the driver would otherwise hand back as
+// a timestamp rather than the YYYY-MM-DD text that was stored.
+const artistColumns = "id, first_name, last_name, nationality, CAST(birth_date AS TEXT) AS birth_date, death_date, sex_id, title_id"
+
+// ArtistQuery lists the parameters accepted by GET /artists. band_id matches
+// everyone who has ever been a member of the band. Lists compute age and
+// alive on their own date, with artistQueryOn.
+var ArtistQuery = artistQueryOn(models.Today())
+
+func artistQueryOn(date string) query.Spec[models.Artist] {
+	age, ageArgs := ageSQL("birth_date", "death_date", date)
+	alive, aliveArgs := aliveSQL("birth_date", "death_date", date)
+	return query.Spec[models.Artist]{Fields: map[string]query.Field[models.Artist]{
+		"id":          {Column: "id", Kind: query.Int, Sortable: true, Value: func(a models.Artist) any { return a.Id }},
+		"first_name":  {Column: "first_name", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(a models.Artist) any { return a.FirstName }},
+		"last_name":   {Column: "last_name", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(a models.Artist) any { return a.LastName }},
+		"nationality": {Column: "nationality", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(a models.Artist) any { return a.Nationality }},
+		"age":         {Column: age, Args: ageArgs, Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(a models.Artist) any { return a.AgeOn(date) }},
+		"alive":       {Column: alive, Args: aliveArgs, Kind: query.Bool, Ops: []query.Op{query.Eq}, Value: func(a models.Artist) any { return a.AliveOn(date) }},
+		"sex_id":      {Column: "sex_id", Kind: query.Int, Ops: []query.Op{query.Eq}, Value: func(a models.Artist) any { return nullableInt(a.SexId) }},
+		"band_id":     {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM band_memberships m WHERE m.artist_id = artists.id AND m.band_id = ?)"},
+	}}
+}
+
+type SQLiteArtistRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteArtistRepository(db *sql.DB) *SQLiteArtistRepository {
+	return &SQLiteArtistRepository{db: db}
+}
+
+func (r *SQLiteArtistRepository) GetAll(ctx context.Context) ([]models.Artist, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+artistColumns+" FROM artists")
+	if err != nil {
+		return nil, err
+	}
+	return scanArtists(rows)
+}
+
+func (r *SQLiteArtistRepository) GetByID(ctx context.Context, id int) (models.Artist, error) {
+	var artist models.Artist
+	err := r.db.QueryRowContext(ctx, "SELECT "+artistColumns+" FROM artists WHERE id = ?", id).
+		Scan(&artist.Id, &artist.FirstName, &artist.LastName, &artist.Nationality, &artist.BirthDate,
+			&artist.DeathDate, &artist.SexId, &artist.TitleId)
+	if err != nil {
+		return models.Artist{}, translateError(err)
+	}
+	return artist, nil
+}
+
+func (r *SQLiteArtistRepository) List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Artist], error) {
+	return list(ctx, r.db, artistQueryOn(dateOrToday(asOf)), q, "artists", artistColumns, nil, scanArtists)
+}
+
+func (r *SQLiteArtistRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Artist, error) {
+	var artists []models.Artist
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, "SELECT "+artistColumns+" FROM artists WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		batch, err := scanArtists(rows)
+		artists = append(artists, batch...)
+		return err
+	})
+	return artists, err
+}
+
+func (r *SQLiteArtistRepository) GetByName(ctx context.Context, name string) ([]models.Artist, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+artistColumns+" FROM artists WHERE first_name || ' ' || last_name = ? COLLATE NOCASE ORDER BY id", name)
+	if err != nil {
+		return nil, err
+	}
+	return scanArtists(rows)
+}
+
+func (r *SQLiteArtistRepository) Create(ctx context.Context, artist models.Artist) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO artists (first_name, last_name, nationality, birth_date, death_date, sex_id, title_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		artist.FirstName, artist.LastName, artist.Nationality, artist.BirthDate, artist.DeathDate, artist.SexId, artist.TitleId)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteArtistRepository) Update(ctx context.Context, id int, artist models.Artist) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE artists SET first_name = ?, last_name = ?, nationality = ?, birth_date = ?, death_date = ?, sex_id = ?, title_id = ? WHERE id = ?",
+		artist.FirstName, artist.LastName, artist.Nationality, artist.BirthDate, artist.DeathDate, artist.SexId, artist.TitleId, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteArtistRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM artists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteArtistRepository) GetSexNames(ctx context.Context, ids []int) (map[int]string, error) {
+	return r.lookupNames(ctx, "sexes", ids)
+}
+
+func (r *SQLiteArtistRepository) GetTitleNames(ctx context.Context, ids []int) (map[int]string, error) {
+	return r.lookupNames(ctx, "titles", ids)
+}
+
+func (r *SQLiteArtistRepository) lookupNames(ctx context.Context, table string, ids []int) (map[int]string, error) {
+	names := map[int]string{}
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, "SELECT id, name FROM "+table+" WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				return err
+			}
+			names[id] = name
+		}
+		return rows.Err()
+	})
+	return names, err
+}
+
+func scanArtists(rows *sql.Rows) ([]models.Artist, error) {
+	defer rows.Close()
+
+	var artists []models.Artist
+	for rows.Next() {
+		var artist models.Artist
+		if err := rows.Scan(&artist.Id, &artist.FirstName, &artist.LastName, &artist.Nationality, &artist.BirthDate,
+			&artist.DeathDate, &artist.SexId, &artist.TitleId); err != nil {
+			return nil, err
+		}
+		artists = append(artists, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
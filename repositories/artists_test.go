@@ -0,0 +1,91 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestArtistAgeAndAliveAreComputed(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	// Ages are relative to today, so the birthdays straddle it.
+	today := time.Now().UTC()
+	thirtyToday := today.AddDate(-30, 0, 0).Format(time.DateOnly)
+	thirtyTomorrow := today.AddDate(-30, 0, 1).Format(time.DateOnly)
+	died := "1967-07-17"
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			one := 1
+			ids := map[string]int{}
+			for _, artist := range []models.Artist{
+				{FirstName: "Birthday", BirthDate: thirtyToday},
+				{FirstName: "Tomorrow", BirthDate: thirtyTomorrow},
+				{FirstName: "John", BirthDate: "1926-09-23", DeathDate: &died},
+			} {
+				artist.LastName, artist.Nationality, artist.SexId, artist.TitleId = "Artist", "British", &one, &one
+				id, err := store.Artists.Create(ctx, artist)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids[artist.FirstName] = id
+			}
+
+			stored, err := store.Artists.GetByID(ctx, ids["John"])
+			if err != nil || stored.BirthDate != "1926-09-23" || stored.DeathDate == nil || *stored.DeathDate != died {
+				t.Errorf("dates should read back as stored: %+v, %v", stored, err)
+			}
+
+			if byName, err := store.Artists.GetByName(ctx, "john ARTIST"); err != nil || len(byName) != 1 || byName[0].Id != ids["John"] {
+				t.Errorf("GetByName = %+v, %v", byName, err)
+			}
+
+			listOn := func(asOf string, values url.Values) []int {
+				t.Helper()
+				q, err := repositories.ArtistQuery.Parse(values)
+				if err != nil {
+					t.Fatal(err)
+				}
+				page, err := store.Artists.List(ctx, q, asOf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []int
+				for _, artist := range page.Items {
+					got = append(got, artist.Id)
+				}
+				return got
+			}
+			list := func(values url.Values) []int { return listOn("", values) }
+
+			if got := list(url.Values{"sort": {"age"}}); len(got) != 3 || got[0] != ids["Tomorrow"] || got[1] != ids["Birthday"] || got[2] != ids["John"] {
+				t.Errorf("sort=age = %v, want ages 29, 30 then 40", got)
+			}
+			if got := list(url.Values{"age_gte": {"30"}, "age_lte": {"30"}}); len(got) != 1 || got[0] != ids["Birthday"] {
+				t.Errorf("age 30 = %v, want only the artist whose birthday is today", got)
+			}
+			if got := list(url.Values{"alive": {"false"}}); len(got) != 1 || got[0] != ids["John"] {
+				t.Errorf("alive=false = %v, want only the artist with a death date", got)
+			}
+
+			// In 1960 only John was born, and he was alive and 33.
+			if got := listOn("1960-01-01", url.Values{"alive": {"true"}}); len(got) != 1 || got[0] != ids["John"] {
+				t.Errorf("alive=true in 1960 = %v, want only John", got)
+			}
+			if got := listOn("1960-01-01", url.Values{"age_gte": {"33"}, "age_lte": {"33"}}); len(got) != 1 || got[0] != ids["John"] {
+				t.Errorf("age 33 in 1960 = %v, want only John", got)
+			}
+			if got := listOn("1960-01-01", url.Values{"sort": {"-age"}, "limit": {"1"}}); len(got) != 1 || got[0] != ids["John"] {
+				t.Errorf("oldest in 1960 = %v, want John", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type AudioRepository interface {
+	GetBySongID(ctx context.Context, songID int) (models.SongAudio, error)
+	// Save records the song's audio, replacing any earlier upload, and in
+	// the same transaction updates the song when the upload has one and adds
+	// its credits. Credits the song already has are skipped.
+	Save(ctx context.Context, upload models.AudioUpload) error
+}
+
+type SQLiteAudioRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAudioRepository(db *sql.DB) *SQLiteAudioRepository {
+	return &SQLiteAudioRepository{db: db}
+}
+
+func (r *SQLiteAudioRepository) GetBySongID(ctx context.Context, songID int) (models.SongAudio, error) {
+	var audio models.SongAudio
+	var uploadedAt int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT song_id, digest, format, size, length, title, artist, track_number, uploaded_at
+		FROM song_audio WHERE song_id = ?`, songID,
+	).Scan(&audio.SongId, &audio.Digest, &audio.Format, &audio.Size, &audio.Length,
+		&audio.Title, &audio.Artist, &audio.TrackNumber, &uploadedAt)
+	if err != nil {
+		return models.SongAudio{}, translateError(err)
+	}
+
+	audio.UploadedAt = time.Unix(uploadedAt, 0)
+	return audio, nil
+}
+
+func (r *SQLiteAudioRepository) Save(ctx context.Context, upload models.AudioUpload) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	audio := upload.Audio
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO song_audio (song_id, digest, format, size, length, title, artist, track_number, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (song_id) DO UPDATE SET
+			digest = excluded.digest, format = excluded.format, size = excluded.size,
+			length = excluded.length, title = excluded.title, artist = excluded.artist,
+			track_number = excluded.track_number, uploaded_at = excluded.uploaded_at`,
+		audio.SongId, audio.Digest, audio.Format, audio.Size, audio.Length,
+		audio.Title, audio.Artist, audio.TrackNumber, audio.UploadedAt.Unix())
+	if err != nil {
+		return translateError(err)
+	}
+
+	if song := upload.Song; song != nil {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE songs SET title = ?, length = ?, price = ? WHERE id = ?",
+			song.Title, song.Length, song.Price, audio.SongId)
+		if err != nil {
+			return translateError(err)
+		}
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+	}
+
+	for _, credit := range upload.Credits {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO credits (album_id, song_id, artist_id, band_id, role) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING",
+			credit.AlbumId, credit.SongId, credit.ArtistId, credit.BandId, credit.Role)
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return tx.Commit()
+}
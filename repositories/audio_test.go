@@ -0,0 +1,86 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"testing"
+	"time"
+)
+
+func TestSongAudio(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			songID, err := store.Songs.Create(ctx, models.Song{Title: "Yellow", Length: 266, Price: 129})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Audio.GetBySongID(ctx, songID); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("no upload yet: expected ErrNotFound, got %v", err)
+			}
+
+			first := models.SongAudio{SongId: songID, Digest: "aa", Format: "mp3", Size: 10, UploadedAt: time.Unix(1700000000, 0)}
+			second := models.SongAudio{SongId: songID, Digest: "bb", Format: "flac", Size: 20, Length: 266,
+				Title: "Yellow", Artist: "Coldplay", TrackNumber: 5, UploadedAt: time.Unix(1700000100, 0)}
+			for _, audio := range []models.SongAudio{first, second} {
+				if err := store.Audio.Save(ctx, models.AudioUpload{Audio: audio}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := store.Audio.GetBySongID(ctx, songID); err != nil || got != second {
+				t.Errorf("replaced upload: got %+v, %v", got, err)
+			}
+
+			bandID, err := store.Bands.Create(ctx, models.Band{Name: "Coldplay", DateFormed: "1996-01-01"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			third := second
+			third.Digest = "cc"
+			retitled := models.Song{Title: "Yellow (Live)", Length: 270, Price: 129}
+			performer := models.Credit{SongId: &songID, BandId: &bandID, Role: models.RolePerformer}
+			missing := 99
+			failed := models.AudioUpload{Audio: third, Song: &retitled,
+				Credits: []models.Credit{performer, {SongId: &songID, ArtistId: &missing, Role: models.RolePerformer}}}
+			if err := store.Audio.Save(ctx, failed); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing artist: expected ErrInvalidReference, got %v", err)
+			}
+			if got, _ := store.Audio.GetBySongID(ctx, songID); got.Digest != "bb" {
+				t.Errorf("a failed upload replaced the audio: %+v", got)
+			}
+			if song, _ := store.Songs.GetByID(ctx, songID); song.Title != "Yellow" {
+				t.Errorf("a failed upload changed the song: %+v", song)
+			}
+			if credits, _ := store.Credits.GetBySongIDs(ctx, []int{songID}); len(credits[songID]) != 0 {
+				t.Errorf("a failed upload added credits: %+v", credits)
+			}
+
+			for range 2 {
+				upload := models.AudioUpload{Audio: third, Song: &retitled, Credits: []models.Credit{performer}}
+				if err := store.Audio.Save(ctx, upload); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if song, _ := store.Songs.GetByID(ctx, songID); song.Title != "Yellow (Live)" || song.Length != 270 {
+				t.Errorf("applied tags: got %+v", song)
+			}
+			if credits, _ := store.Credits.GetBySongIDs(ctx, []int{songID}); len(credits[songID]) != 1 {
+				t.Errorf("the performer should be credited once: %+v", credits)
+			}
+
+			if err := store.Songs.Delete(ctx, songID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Audio.GetBySongID(ctx, songID); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleted song: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
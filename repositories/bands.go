@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+	"slices"
+)
+
+type BandRepository interface {
+	GetAll(ctx context.Context) ([]models.Band, error)
+	GetByID(ctx context.Context, id int) (models.Band, error)
+	// List filters and sorts on age and active as of the YYYY-MM-DD date
+	// asOf, or today when it is empty.
+	List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Band], error)
+	GetByIDs(ctx context.Context, ids []int) ([]models.Band, error)
+	// GetByName returns the bands called name, ignoring case.
+	GetByName(ctx context.Context, name string) ([]models.Band, error)
+	Create(ctx context.Context, band models.Band) (int, error)
+	Update(ctx context.Context, id int, band models.Band) error
+	Delete(ctx context.Context, id int) error
+}
+
+// memberCountSQL counts a band's members on the date on, the artists with a
+// membership covering it. The date is bound to the returned placeholders.
+func memberCountSQL(on string) (string, []any) {
+	return `(SELECT COUNT(DISTINCT m.artist_id) FROM band_memberships m WHERE m.band_id = bands.id
+	AND (m.date_joined IS NULL OR m.date_joined <= ?) AND (m.date_left IS NULL OR m.date_left > ?))`, []any{on, on}
+}
+
+// bandColumns selects a band with its members on the date on. date_formed is
+// cast for the same reason as artists.birth_date.
+func bandColumns(on string) (string, []any) {
+	count, args := memberCountSQL(on)
+	return "id, name, nationality, " + count + " AS number_of_members, CAST(date_formed AS TEXT) AS date_formed, disbanded_date", args
+}
+
+// BandQuery lists the parameters accepted by GET /bands. Lists compute age
+// and active on their own date, with bandQueryOn.
+var BandQuery = bandQueryOn(models.Today())
+
+func bandQueryOn(date string) query.Spec[models.Band] {
+	members, membersArgs := memberCountSQL(date)
+	age, ageArgs := ageSQL("date_formed", "disbanded_date", date)
+	active, activeArgs := aliveSQL("date_formed", "disbanded_date", date)
+	return query.Spec[models.Band]{Fields: map[string]query.Field[models.Band]{
+		"id":                {Column: "id", Kind: query.Int, Sortable: true, Value: func(b models.Band) any { return b.Id }},
+		"name":              {Column: "name", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(b models.Band) any { return b.Name }},
+		"nationality":       {Column: "nationality", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(b models.Band) any { return b.Nationality }},
+		"number_of_members": {Column: members, Args: membersArgs, Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(b models.Band) any { return b.NumberOfMembers }},
+		"age":               {Column: age, Args: ageArgs, Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(b models.Band) any { return b.AgeOn(date) }},
+		"active":            {Column: active, Args: activeArgs, Kind: query.Bool, Ops: []query.Op{query.Eq}, Value: func(b models.Band) any { return b.ActiveOn(date) }},
+	}}
+}
+
+type SQLiteBandRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteBandRepository(db *sql.DB) *SQLiteBandRepository {
+	return &SQLiteBandRepository{db: db}
+}
+
+func (r *SQLiteBandRepository) GetAll(ctx context.Context) ([]models.Band, error) {
+	columns, args := bandColumns(models.Today())
+	rows, err := r.db.QueryContext(ctx, "SELECT "+columns+" FROM bands", args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBands(rows)
+}
+
+func (r *SQLiteBandRepository) GetByID(ctx context.Context, id int) (models.Band, error) {
+	var band models.Band
+	columns, args := bandColumns(models.Today())
+	err := r.db.QueryRowContext(ctx,
+		"SELECT "+columns+" FROM bands WHERE id = ?", append(args, id)...,
+	).Scan(&band.Id, &band.Name, &band.Nationality, &band.NumberOfMembers, &band.DateFormed, &band.DisbandedDate)
+	if err != nil {
+		return models.Band{}, translateError(err)
+	}
+	return band, nil
+}
+
+func (r *SQLiteBandRepository) List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Band], error) {
+	date := dateOrToday(asOf)
+	columns, args := bandColumns(date)
+	return list(ctx, r.db, bandQueryOn(date), q, "bands", columns, args, scanBands)
+}
+
+func (r *SQLiteBandRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Band, error) {
+	var bands []models.Band
+	columns, columnArgs := bandColumns(models.Today())
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT "+columns+" FROM bands WHERE id IN ("+placeholders+")", append(slices.Clone(columnArgs), args...)...)
+		if err != nil {
+			return err
+		}
+		batch, err := scanBands(rows)
+		bands = append(bands, batch...)
+		return err
+	})
+	return bands, err
+}
+
+func (r *SQLiteBandRepository) GetByName(ctx context.Context, name string) ([]models.Band, error) {
+	columns, args := bandColumns(models.Today())
+	rows, err := r.db.QueryContext(ctx, "SELECT "+columns+" FROM bands WHERE name = ? COLLATE NOCASE ORDER BY id", append(args, name)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBands(rows)
+}
+
+func (r *SQLiteBandRepository) Create(ctx context.Context, band models.Band) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO bands (name, nationality, date_formed, disbanded_date) VALUES (?, ?, ?, ?)",
+		band.Name, band.Nationality, band.DateFormed, band.DisbandedDate)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteBandRepository) Update(ctx context.Context, id int, band models.Band) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE bands SET name = ?, nationality = ?, date_formed = ?, disbanded_date = ? WHERE id = ?",
+		band.Name, band.Nationality, band.DateFormed, band.DisbandedDate, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteBandRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM bands WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func scanBands(rows *sql.Rows) ([]models.Band, error) {
+	defer rows.Close()
+
+	var bands []models.Band
+	for rows.Next() {
+		var band models.Band
+		if err := rows.Scan(&band.Id, &band.Name, &band.Nationality, &band.NumberOfMembers, &band.DateFormed, &band.DisbandedDate); err != nil {
+			return nil, err
+		}
+		bands = append(bands, band)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return bands, nil
+}
@@ -0,0 +1,58 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBandAgeAndActiveAreComputed(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	// A band disbanding today is no longer active.
+	today := time.Now().UTC().Format(time.DateOnly)
+	split := "1970-04-10"
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			beatles, err := store.Bands.Create(ctx, models.Band{Name: "The Beatles", DateFormed: "1960-08-01", DisbandedDate: &split})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Bands.Create(ctx, models.Band{Name: "Radiohead", DateFormed: "1985-09-01"}); err != nil {
+				t.Fatal(err)
+			}
+			farewell, err := store.Bands.Create(ctx, models.Band{Name: "Farewell", DateFormed: "2000-01-01", DisbandedDate: &today})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q, err := repositories.BandQuery.Parse(url.Values{"active": {"false"}, "sort": {"age"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := store.Bands.List(ctx, q, "")
+			if err != nil || len(page.Items) != 2 || page.Items[0].Id != beatles || page.Items[1].Id != farewell {
+				t.Fatalf("active=false sorted by age = %+v, %v", page.Items, err)
+			}
+			if age := page.Items[0].AgeOn(today); age != 9 {
+				t.Errorf("a disbanded band stops ageing: got %d, want 9", age)
+			}
+
+			q, err = repositories.BandQuery.Parse(url.Values{"age_lte": {"9"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page, err := store.Bands.List(ctx, q, ""); err != nil || page.Total != 1 {
+				t.Errorf("age_lte=9 = %+v, %v; want only The Beatles", page.Items, err)
+			}
+		})
+	}
+}
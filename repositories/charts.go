@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type ChartRepository interface {
+	// Events returns the plays, purchases and ratings of songs or albums, as
+	// kind says, from from up to but not including to, oldest first. A play
+	// of a song counts for every album it is on.
+	Events(ctx context.Context, kind string, from, to time.Time) ([]models.ChartEvent, error)
+	// GetChart returns a stored chart, or ErrNotFound.
+	GetChart(ctx context.Context, kind, period, date string) (models.Chart, error)
+	// SaveChart stores a chart. It returns ErrConflict when that chart has
+	// already been stored.
+	SaveChart(ctx context.Context, chart models.Chart) error
+}
+
+type SQLiteChartRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteChartRepository(db *sql.DB) *SQLiteChartRepository {
+	return &SQLiteChartRepository{db: db}
+}
+
+// chartEventsSQL selects the events of each kind of chart, taking the range
+// as the first two, middle two and last two arguments.
+var chartEventsSQL = map[string]string{
+	models.ChartSongs: `
+		SELECT song_id, 'play', played_at, 0 FROM plays
+		WHERE song_id IS NOT NULL AND played_at >= ? AND played_at < ?
+		UNION ALL
+		SELECT order_items.song_id, 'purchase', orders.created_at, 0
+		FROM order_items JOIN orders ON orders.id = order_items.order_id
+		WHERE order_items.song_id IS NOT NULL AND orders.created_at >= ? AND orders.created_at < ?
+		UNION ALL
+		SELECT song_id, 'rating', updated_at, rating FROM reviews
+		WHERE song_id IS NOT NULL AND updated_at >= ? AND updated_at < ?
+		ORDER BY 3, 1, 2`,
+	models.ChartAlbums: `
+		SELECT album_songs.album_id, 'play', plays.played_at, 0
+		FROM plays JOIN album_songs ON album_songs.song_id = plays.song_id
+		WHERE plays.played_at >= ? AND plays.played_at < ?
+		UNION ALL
+		SELECT order_items.album_id, 'purchase', orders.created_at, 0
+		FROM order_items JOIN orders ON orders.id = order_items.order_id
+		WHERE order_items.album_id IS NOT NULL AND orders.created_at >= ? AND orders.created_at < ?
+		UNION ALL
+		SELECT album_id, 'rating', updated_at, rating FROM reviews
+		WHERE album_id IS NOT NULL AND updated_at >= ? AND updated_at < ?
+		ORDER BY 3, 1, 2`,
+}
+
+func (r *SQLiteChartRepository) Events(ctx context.Context, kind string, from, to time.Time) ([]models.ChartEvent, error) {
+	start, end := from.Unix(), to.Unix()
+	rows, err := r.db.QueryContext(ctx, chartEventsSQL[kind], start, end, start, end, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.ChartEvent
+	for rows.Next() {
+		var event models.ChartEvent
+		var at int64
+		if err := rows.Scan(&event.ItemId, &event.Type, &at, &event.Rating); err != nil {
+			return nil, err
+		}
+		event.At = time.Unix(at, 0).UTC()
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLiteChartRepository) GetChart(ctx context.Context, kind, period, date string) (models.Chart, error) {
+	chart := models.Chart{Kind: kind, Period: period, Date: date, Entries: []models.ChartEntry{}}
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM charts WHERE kind = ? AND period = ? AND chart_date = ?", kind, period, date).Scan(&id)
+	if err != nil {
+		return models.Chart{}, translateError(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT position, item_id, score, previous_position FROM chart_entries WHERE chart_id = ? ORDER BY position", id)
+	if err != nil {
+		return models.Chart{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.ChartEntry
+		if err := rows.Scan(&entry.Position, &entry.ItemId, &entry.Score, &entry.PreviousPosition); err != nil {
+			return models.Chart{}, err
+		}
+		chart.Entries = append(chart.Entries, entry)
+	}
+	return chart, rows.Err()
+}
+
+func (r *SQLiteChartRepository) SaveChart(ctx context.Context, chart models.Chart) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO charts (kind, period, chart_date) VALUES (?, ?, ?)", chart.Kind, chart.Period, chart.Date)
+	if err != nil {
+		return translateError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for _, entry := range chart.Entries {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO chart_entries (chart_id, position, item_id, score, previous_position) VALUES (?, ?, ?, ?, ?)",
+			id, entry.Position, entry.ItemId, entry.Score, entry.PreviousPosition,
+		); err != nil {
+			return translateError(err)
+		}
+	}
+	return tx.Commit()
+}
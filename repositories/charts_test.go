@@ -0,0 +1,133 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChartEvents(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			airbag, _ := store.Songs.Create(ctx, models.Song{Title: "Airbag", Length: 284, Price: 99})
+			lucky, _ := store.Songs.Create(ctx, models.Song{Title: "Lucky", Length: 259, Price: 99})
+			okComputer, _ := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			if err := store.Albums.SetSongs(ctx, okComputer, []int{airbag, lucky}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := store.Plays.Record(ctx, []models.Play{
+				{UserId: alice, SongId: &airbag, PlayedAt: day.Add(2 * time.Hour), Duration: 284},
+				{UserId: alice, SongId: &lucky, PlayedAt: day.Add(time.Hour), Duration: 259},
+				{UserId: alice, SongId: &lucky, PlayedAt: day.Add(-time.Hour), Duration: 259},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			order := models.Order{UserId: alice, Total: 999, CreatedAt: day.Add(3 * time.Hour), Items: []models.OrderItem{
+				{Type: models.ItemAlbum, AlbumId: &okComputer, Title: "OK Computer", Price: 999},
+			}}
+			if _, err := store.Orders.CreateOrder(ctx, order, []int{airbag, lucky}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := store.Charts.Events(ctx, models.ChartSongs, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []models.ChartEvent{
+				{ItemId: lucky, Type: models.EventPlay, At: day.Add(time.Hour)},
+				{ItemId: airbag, Type: models.EventPlay, At: day.Add(2 * time.Hour)},
+			}
+			if !reflect.DeepEqual(events, want) {
+				t.Errorf("song events = %+v, want %+v", events, want)
+			}
+
+			events, err = store.Charts.Events(ctx, models.ChartAlbums, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = []models.ChartEvent{
+				{ItemId: okComputer, Type: models.EventPlay, At: day.Add(time.Hour)},
+				{ItemId: okComputer, Type: models.EventPlay, At: day.Add(2 * time.Hour)},
+				{ItemId: okComputer, Type: models.EventPurchase, At: day.Add(3 * time.Hour)},
+			}
+			if !reflect.DeepEqual(events, want) {
+				t.Errorf("album events = %+v, want %+v", events, want)
+			}
+
+			// Reviews are stamped with the current time.
+			if _, err := store.Reviews.Create(ctx, models.Review{UserId: alice, SongId: &airbag, Rating: 4}); err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			events, err = store.Charts.Events(ctx, models.ChartSongs, now.Add(-time.Minute), now.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 || events[0].ItemId != airbag || events[0].Type != models.EventRating || events[0].Rating != 4 {
+				t.Errorf("rating events = %+v", events)
+			}
+		})
+	}
+}
+
+func TestChartSnapshots(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	two := 2
+	chart := models.Chart{Kind: models.ChartSongs, Period: models.ChartDaily, Date: "2026-10-01", Entries: []models.ChartEntry{
+		{Position: 1, ItemId: 7, Score: 3.5, PreviousPosition: &two},
+		{Position: 2, ItemId: 3, Score: 1.25},
+	}}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.Charts.GetChart(ctx, chart.Kind, chart.Period, chart.Date); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("before saving: got %v, want ErrNotFound", err)
+			}
+			if err := store.Charts.SaveChart(ctx, chart); err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.Charts.GetChart(ctx, chart.Kind, chart.Period, chart.Date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, chart) {
+				t.Errorf("got %+v, want %+v", got, chart)
+			}
+
+			if err := store.Charts.SaveChart(ctx, chart); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("saving again: got %v, want ErrConflict", err)
+			}
+			if _, err := store.Charts.GetChart(ctx, chart.Kind, models.ChartWeekly, chart.Date); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("other period: got %v, want ErrNotFound", err)
+			}
+
+			empty := models.Chart{Kind: models.ChartAlbums, Period: models.ChartWeekly, Date: "2026-10-01"}
+			if err := store.Charts.SaveChart(ctx, empty); err != nil {
+				t.Fatal(err)
+			}
+			got, err = store.Charts.GetChart(ctx, empty.Kind, empty.Period, empty.Date)
+			if err != nil || got.Entries == nil || len(got.Entries) != 0 {
+				t.Errorf("empty chart = %+v, %v", got, err)
+			}
+		})
+	}
+}
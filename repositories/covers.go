@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type CoverRepository interface {
+	// GetByAlbumIDs returns the cover variants of each album that has one.
+	GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.CoverImage, error)
+	Get(ctx context.Context, albumID int, variant string) (models.CoverImage, error)
+	// Replace swaps every variant of the album's cover for images at once.
+	Replace(ctx context.Context, albumID int, images []models.CoverImage) error
+}
+
+type SQLiteCoverRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteCoverRepository(db *sql.DB) *SQLiteCoverRepository {
+	return &SQLiteCoverRepository{db: db}
+}
+
+const coverColumns = "album_id, variant, digest, mime_type, width, height, uploaded_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCover(row rowScanner) (models.CoverImage, error) {
+	var cover models.CoverImage
+	var uploadedAt int64
+	if err := row.Scan(&cover.AlbumId, &cover.Variant, &cover.Digest, &cover.MIMEType,
+		&cover.Width, &cover.Height, &uploadedAt); err != nil {
+		return models.CoverImage{}, err
+	}
+	cover.UploadedAt = time.Unix(uploadedAt, 0)
+	return cover, nil
+}
+
+func (r *SQLiteCoverRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.CoverImage, error) {
+	covers := map[int][]models.CoverImage{}
+	err := inBatches(albumIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT "+coverColumns+" FROM album_covers WHERE album_id IN ("+placeholders+") ORDER BY album_id, width",
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			cover, err := scanCover(rows)
+			if err != nil {
+				return err
+			}
+			covers[cover.AlbumId] = append(covers[cover.AlbumId], cover)
+		}
+		return rows.Err()
+	})
+	return covers, err
+}
+
+func (r *SQLiteCoverRepository) Get(ctx context.Context, albumID int, variant string) (models.CoverImage, error) {
+	cover, err := scanCover(r.db.QueryRowContext(ctx,
+		"SELECT "+coverColumns+" FROM album_covers WHERE album_id = ? AND variant = ?", albumID, variant))
+	if err != nil {
+		return models.CoverImage{}, translateError(err)
+	}
+	return cover, nil
+}
+
+func (r *SQLiteCoverRepository) Replace(ctx context.Context, albumID int, images []models.CoverImage) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM album_covers WHERE album_id = ?", albumID); err != nil {
+		return translateError(err)
+	}
+	for _, image := range images {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO album_covers (`+coverColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			albumID, image.Variant, image.Digest, image.MIMEType, image.Width, image.Height, image.UploadedAt.Unix())
+		if err != nil {
+			return translateError(err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAlbumCovers(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			albumID, err := store.Albums.Create(ctx, models.Album{Title: "Parachutes", Price: 999})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Covers.Get(ctx, albumID, "original"); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("no cover yet: expected ErrNotFound, got %v", err)
+			}
+
+			uploadedAt := time.Unix(1700000000, 0)
+			first := []models.CoverImage{
+				{AlbumId: albumID, Variant: "original", Digest: "aa", MIMEType: "image/png", Width: 800, Height: 800, UploadedAt: uploadedAt},
+			}
+			second := []models.CoverImage{
+				{AlbumId: albumID, Variant: "original", Digest: "bb", MIMEType: "image/jpeg", Width: 1000, Height: 500, UploadedAt: uploadedAt},
+				{AlbumId: albumID, Variant: "small", Digest: "cc", MIMEType: "image/jpeg", Width: 100, Height: 50, UploadedAt: uploadedAt},
+			}
+			for _, images := range [][]models.CoverImage{first, second} {
+				if err := store.Covers.Replace(ctx, albumID, images); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			covers, err := store.Covers.GetByAlbumIDs(ctx, []int{albumID, albumID + 1})
+			want := map[int][]models.CoverImage{albumID: {second[1], second[0]}}
+			if err != nil || !reflect.DeepEqual(covers, want) {
+				t.Errorf("replaced cover: got %+v, %v", covers, err)
+			}
+			if got, err := store.Covers.Get(ctx, albumID, "small"); err != nil || got != second[1] {
+				t.Errorf("Get(small): got %+v, %v", got, err)
+			}
+			if err := store.Covers.Replace(ctx, albumID+1, first); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing album: expected ErrInvalidReference, got %v", err)
+			}
+
+			if err := store.Albums.Delete(ctx, albumID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Covers.Get(ctx, albumID, "original"); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleted album: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+)
+
+type CreditRepository interface {
+	GetByID(ctx context.Context, id int) (models.Credit, error)
+	// GetByAlbumIDs and GetBySongIDs return the credits of each album or
+	// song, keyed by its ID.
+	GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Credit, error)
+	GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Credit, error)
+	// GetByArtistID and GetByBandID list everything an artist or band is
+	// credited on.
+	GetByArtistID(ctx context.Context, artistID int) ([]models.Credit, error)
+	GetByBandID(ctx context.Context, bandID int) ([]models.Credit, error)
+	// Create returns ErrConflict when the same credit already exists.
+	Create(ctx context.Context, credit models.Credit) (int, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type SQLiteCreditRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteCreditRepository(db *sql.DB) *SQLiteCreditRepository {
+	return &SQLiteCreditRepository{db: db}
+}
+
+const creditColumns = "id, album_id, song_id, artist_id, band_id, role"
+
+func scanCredit(row rowScanner) (models.Credit, error) {
+	var credit models.Credit
+	err := row.Scan(&credit.Id, &credit.AlbumId, &credit.SongId, &credit.ArtistId, &credit.BandId, &credit.Role)
+	return credit, err
+}
+
+func (r *SQLiteCreditRepository) GetByID(ctx context.Context, id int) (models.Credit, error) {
+	credit, err := scanCredit(r.db.QueryRowContext(ctx, "SELECT "+creditColumns+" FROM credits WHERE id = ?", id))
+	if err != nil {
+		return models.Credit{}, translateError(err)
+	}
+	return credit, nil
+}
+
+func (r *SQLiteCreditRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Credit, error) {
+	return r.getByOwnerIDs(ctx, "album_id", albumIDs, func(c models.Credit) int { return *c.AlbumId })
+}
+
+func (r *SQLiteCreditRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Credit, error) {
+	return r.getByOwnerIDs(ctx, "song_id", songIDs, func(c models.Credit) int { return *c.SongId })
+}
+
+func (r *SQLiteCreditRepository) getByOwnerIDs(ctx context.Context, column string, ids []int, owner func(models.Credit) int) (map[int][]models.Credit, error) {
+	result := map[int][]models.Credit{}
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT "+creditColumns+" FROM credits WHERE "+column+" IN ("+placeholders+") ORDER BY "+column+", id", args...)
+		if err != nil {
+			return err
+		}
+		credits, err := scanCredits(rows)
+		for _, credit := range credits {
+			result[owner(credit)] = append(result[owner(credit)], credit)
+		}
+		return err
+	})
+	return result, err
+}
+
+func (r *SQLiteCreditRepository) GetByArtistID(ctx context.Context, artistID int) ([]models.Credit, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+creditColumns+" FROM credits WHERE artist_id = ? ORDER BY id", artistID)
+	if err != nil {
+		return nil, err
+	}
+	return scanCredits(rows)
+}
+
+func (r *SQLiteCreditRepository) GetByBandID(ctx context.Context, bandID int) ([]models.Credit, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+creditColumns+" FROM credits WHERE band_id = ? ORDER BY id", bandID)
+	if err != nil {
+		return nil, err
+	}
+	return scanCredits(rows)
+}
+
+func (r *SQLiteCreditRepository) Create(ctx context.Context, credit models.Credit) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO credits (album_id, song_id, artist_id, band_id, role) VALUES (?, ?, ?, ?, ?)",
+		credit.AlbumId, credit.SongId, credit.ArtistId, credit.BandId, credit.Role)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteCreditRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM credits WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func scanCredits(rows *sql.Rows) ([]models.Credit, error) {
+	defer rows.Close()
+
+	var credits []models.Credit
+	for rows.Next() {
+		credit, err := scanCredit(rows)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
@@ -0,0 +1,186 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestCredits(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			one := 1
+			artistID, err := store.Artists.Create(ctx, models.Artist{
+				FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07",
+				SexId: &one, TitleId: &one,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bandID, err := store.Bands.Create(ctx, models.Band{Name: "Radiohead", DateFormed: "1985-01-01"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			albumID, _ := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			songID, _ := store.Songs.Create(ctx, models.Song{Title: "Karma Police", Length: 264, Price: 129})
+
+			create := func(credit models.Credit) int {
+				t.Helper()
+				id, err := store.Credits.Create(ctx, credit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			albumCredit := create(models.Credit{AlbumId: &albumID, BandId: &bandID, Role: models.RolePerformer})
+			songPerformer := create(models.Credit{SongId: &songID, BandId: &bandID, Role: models.RolePerformer})
+			songLyricist := create(models.Credit{SongId: &songID, ArtistId: &artistID, Role: models.RoleLyricist})
+
+			duplicate := models.Credit{SongId: &songID, ArtistId: &artistID, Role: models.RoleLyricist}
+			if _, err := store.Credits.Create(ctx, duplicate); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("duplicate credit: expected ErrConflict, got %v", err)
+			}
+			missing := 99
+			if _, err := store.Credits.Create(ctx, models.Credit{SongId: &missing, ArtistId: &artistID, Role: models.RolePerformer}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing song: expected ErrInvalidReference, got %v", err)
+			}
+
+			byAlbum, err := store.Credits.GetByAlbumIDs(ctx, []int{albumID})
+			if err != nil || len(byAlbum[albumID]) != 1 || byAlbum[albumID][0].Id != albumCredit {
+				t.Errorf("GetByAlbumIDs = %+v, %v", byAlbum, err)
+			}
+			bySong, err := store.Credits.GetBySongIDs(ctx, []int{songID})
+			if err != nil || len(bySong[songID]) != 2 || bySong[songID][0].Id != songPerformer || bySong[songID][1].Role != models.RoleLyricist {
+				t.Errorf("GetBySongIDs = %+v, %v", bySong, err)
+			}
+			if credits, err := store.Credits.GetByBandID(ctx, bandID); err != nil || len(credits) != 2 {
+				t.Errorf("GetByBandID = %+v, %v", credits, err)
+			}
+
+			if err := store.Albums.SetSongs(ctx, albumID, []int{songID}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Albums.SetSongs(ctx, albumID, []int{missing}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing track: expected ErrInvalidReference, got %v", err)
+			}
+			if songs, err := store.Songs.GetByAlbumID(ctx, albumID); err != nil || len(songs) != 1 || songs[0].Id != songID {
+				t.Errorf("GetByAlbumID = %+v, %v", songs, err)
+			}
+			if err := store.Albums.SetSongs(ctx, missing, nil); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("missing album: expected ErrNotFound, got %v", err)
+			}
+
+			if err := store.Credits.Delete(ctx, songLyricist); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Credits.Delete(ctx, songLyricist); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleting twice: expected ErrNotFound, got %v", err)
+			}
+
+			if err := store.Bands.Delete(ctx, bandID); err != nil {
+				t.Fatal(err)
+			}
+			if credits, err := store.Credits.GetByBandID(ctx, bandID); err != nil || len(credits) != 0 {
+				t.Errorf("credits outlived their band: %+v, %v", credits, err)
+			}
+		})
+	}
+}
+
+func TestListFiltersByCredit(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			one := 1
+			thom, _ := store.Artists.Create(ctx, models.Artist{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07", SexId: &one, TitleId: &one})
+			radiohead, _ := store.Bands.Create(ctx, models.Band{Name: "Radiohead", DateFormed: "1985-01-01"})
+			okComputer, _ := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			eraser, _ := store.Albums.Create(ctx, models.Album{Title: "The Eraser", Price: 999})
+			store.Albums.Create(ctx, models.Album{Title: "Parachutes", Price: 999})
+			airbag, _ := store.Songs.Create(ctx, models.Song{Title: "Airbag", Length: 284, Price: 99})
+			analyse, _ := store.Songs.Create(ctx, models.Song{Title: "Analyse", Length: 242, Price: 99})
+			if err := store.Albums.SetSongs(ctx, okComputer, []int{airbag}); err != nil {
+				t.Fatal(err)
+			}
+			for _, credit := range []models.Credit{
+				{AlbumId: &okComputer, BandId: &radiohead, Role: models.RolePerformer},
+				{AlbumId: &okComputer, ArtistId: &thom, Role: models.RoleLyricist},
+				{AlbumId: &eraser, ArtistId: &thom, Role: models.RolePerformer},
+				{SongId: &analyse, ArtistId: &thom, Role: models.RolePerformer},
+			} {
+				if _, err := store.Credits.Create(ctx, credit); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			albums := func(raw string) []int {
+				t.Helper()
+				values, _ := url.ParseQuery(raw)
+				q, err := repositories.AlbumQuery.Parse(values)
+				if err != nil {
+					t.Fatal(err)
+				}
+				page, err := store.Albums.List(ctx, q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := []int{}
+				for _, album := range page.Items {
+					ids = append(ids, album.Id)
+				}
+				return ids
+			}
+			songs := func(raw string) []int {
+				t.Helper()
+				values, _ := url.ParseQuery(raw)
+				q, err := repositories.SongQuery.Parse(values)
+				if err != nil {
+					t.Fatal(err)
+				}
+				page, err := store.Songs.List(ctx, q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := []int{}
+				for _, song := range page.Items {
+					ids = append(ids, song.Id)
+				}
+				return ids
+			}
+
+			tests := []struct {
+				got, want []int
+				name      string
+			}{
+				{albums("artist_id=" + strconv.Itoa(thom)), []int{okComputer, eraser}, "albums by artist"},
+				{albums("band_id=" + strconv.Itoa(radiohead)), []int{okComputer}, "albums by band"},
+				{albums("artist_id=" + strconv.Itoa(thom) + "&title=The Eraser"), []int{eraser}, "artist and title"},
+				{albums("artist_id=99"), []int{}, "unknown artist"},
+				{songs("album_id=" + strconv.Itoa(okComputer)), []int{airbag}, "album tracks"},
+				{songs("artist_id=" + strconv.Itoa(thom)), []int{analyse}, "songs by artist"},
+				{songs("band_id=" + strconv.Itoa(radiohead)), []int{}, "songs by band"},
+			}
+			for _, tt := range tests {
+				if !reflect.DeepEqual(tt.got, tt.want) {
+					t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+				}
+			}
+		})
+	}
+}
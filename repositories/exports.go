@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+)
+
+// exportPageSize is how many rows an export reads at a time. The pool has a
+// single connection, so each page is read and released before its records
+// are written out, letting other requests run while a slow client reads.
+const exportPageSize = 500
+
+type ExportRepository interface {
+	// Export calls fn with every record of the catalog, kind by kind in the
+	// order of models.ExportTypes and oldest first within a kind, stopping at
+	// the first error fn returns. Records are read a page at a time, so the
+	// export of a catalog that changes meanwhile may not be consistent.
+	Export(ctx context.Context, fn func(models.ExportRecord) error) error
+}
+
+type SQLiteExportRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteExportRepository(db *sql.DB) *SQLiteExportRepository {
+	return &SQLiteExportRepository{db: db}
+}
+
+// exportSource selects the values of one kind of record from a table,
+// keyed by rowid.
+type exportSource struct {
+	kind    string
+	table   string
+	columns string
+	joins   string
+}
+
+// exportSources selects models.ExportColumns from each table. Dates are
+// cast to text because the driver parses DATE columns into timestamps.
+var exportSources = []exportSource{
+	{"artist", "artists", "id, first_name, last_name, nationality, CAST(birth_date AS TEXT), death_date, sex_id, title_id", ""},
+	{"band", "bands", "id, name, nationality, CAST(date_formed AS TEXT), disbanded_date", ""},
+	{"membership", "band_memberships", "id, artist_id, band_id, role, date_joined, date_left", ""},
+	{"genre", "genres", "id, name, parent_id", ""},
+	{"label", "labels", "id, name, country", ""},
+	{"album", "albums", "id, title, price, label_id, release_date, catalog_number, barcode, format", ""},
+	{"song", "songs", "id, title, length, price", ""},
+	{"track", "album_songs", "album_id, song_id, position", ""},
+	{"credit", "credits", "id, album_id, song_id, artist_id, band_id, role", ""},
+	{"album_genre", "album_genres", "album_id, genre_id", ""},
+	{"song_genre", "song_genres", "song_id, genre_id", ""},
+	{"tag", "album_tags", "'album', album_id, tags.name", " JOIN tags ON tags.id = album_tags.tag_id"},
+	{"tag", "song_tags", "'song', song_id, tags.name", " JOIN tags ON tags.id = song_tags.tag_id"},
+	{"tag", "artist_tags", "'artist', artist_id, tags.name", " JOIN tags ON tags.id = artist_tags.tag_id"},
+	{"tag", "band_tags", "'band', band_id, tags.name", " JOIN tags ON tags.id = band_tags.tag_id"},
+}
+
+func (r *SQLiteExportRepository) Export(ctx context.Context, fn func(models.ExportRecord) error) error {
+	for _, source := range exportSources {
+		query := "SELECT " + source.table + ".rowid, " + source.columns + " FROM " + source.table + source.joins +
+			" WHERE " + source.table + ".rowid > ? ORDER BY " + source.table + ".rowid LIMIT ?"
+		width := len(models.ExportColumns[source.kind])
+
+		for after := int64(0); ; {
+			records, last, err := r.exportPage(ctx, query, source.kind, width, after)
+			if err != nil {
+				return err
+			}
+			for _, record := range records {
+				if err := fn(record); err != nil {
+					return err
+				}
+			}
+			if len(records) < exportPageSize {
+				break
+			}
+			after = last
+		}
+	}
+	return nil
+}
+
+// exportPage reads the records after the given rowid, returning the rowid
+// of the last one.
+func (r *SQLiteExportRepository) exportPage(ctx context.Context, query, kind string, width int, after int64) ([]models.ExportRecord, int64, error) {
+	rows, err := r.db.QueryContext(ctx, query, after, exportPageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var records []models.ExportRecord
+	var rowid int64
+	for rows.Next() {
+		values := make([]any, width)
+		dest := []any{&rowid}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, err
+		}
+		for i, value := range values {
+			if text, ok := value.([]byte); ok {
+				values[i] = string(text)
+			}
+		}
+		records = append(records, models.ExportRecord{Type: kind, Values: values})
+	}
+	return records, rowid, rows.Err()
+}
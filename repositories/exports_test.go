@@ -0,0 +1,119 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	one, first := 1, 0
+	released, format := "1997-05-21", "CD"
+	batch := models.CatalogImport{
+		Artists: []models.Artist{{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07", SexId: &one, TitleId: &one}},
+		Bands:   []models.Band{{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"}},
+		Albums:  []models.ImportedAlbum{{Album: models.Album{Title: "OK Computer", Price: 999, ReleaseDate: &released, Format: &format}, Band: &first}},
+		Songs:   []models.ImportedSong{{Song: models.Song{Title: "Airbag", Length: 284, Price: 99}, Album: &first, Artist: &first}},
+	}
+	want := []models.ExportRecord{
+		{Type: "artist", Values: []any{int64(1), "Thom", "Yorke", "British", "1968-10-07", nil, int64(1), int64(1)}},
+		{Type: "band", Values: []any{int64(1), "Radiohead", "British", "1985-09-01", nil}},
+		{Type: "membership", Values: []any{int64(1), int64(1), int64(1), "vocals", nil, nil}},
+		{Type: "genre", Values: []any{int64(1), "Rock", nil}},
+		{Type: "label", Values: []any{int64(1), "Parlophone", "United Kingdom"}},
+		{Type: "album", Values: []any{int64(1), "OK Computer", int64(999), int64(1), "1997-05-21", nil, nil, "CD"}},
+		{Type: "song", Values: []any{int64(1), "Airbag", int64(284), int64(99)}},
+		{Type: "track", Values: []any{int64(1), int64(1), int64(1)}},
+		{Type: "credit", Values: []any{int64(1), int64(1), nil, nil, int64(1), "performer"}},
+		{Type: "credit", Values: []any{int64(2), nil, int64(1), int64(1), nil, "performer"}},
+		{Type: "album_genre", Values: []any{int64(1), int64(1)}},
+		{Type: "tag", Values: []any{"album", int64(1), "90s"}},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.Imports.Import(ctx, batch); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Memberships.Create(ctx, models.BandMembership{ArtistId: 1, BandId: 1, Role: "vocals"}); err != nil {
+				t.Fatal(err)
+			}
+			genreID, err := store.Genres.Create(ctx, models.Genre{Name: "Rock"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Genres.SetAlbumGenres(ctx, 1, []int{genreID}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Tags.Add(ctx, models.TagAlbum, 1, "90s"); err != nil {
+				t.Fatal(err)
+			}
+			country := "United Kingdom"
+			labelID, err := store.Labels.Create(ctx, models.Label{Name: "Parlophone", Country: &country})
+			if err != nil {
+				t.Fatal(err)
+			}
+			album, err := store.Albums.GetByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			album.LabelId = &labelID
+			if err := store.Albums.Update(ctx, 1, album); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []models.ExportRecord
+			if err := store.Exports.Export(ctx, func(record models.ExportRecord) error {
+				got = append(got, normalize(record))
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Export =\n%v\nwant\n%v", got, want)
+			}
+		})
+	}
+}
+
+// normalize widens the memory store's ints to the int64s SQLite returns.
+func normalize(record models.ExportRecord) models.ExportRecord {
+	for i, value := range record.Values {
+		if n, ok := value.(int); ok {
+			record.Values[i] = int64(n)
+		}
+	}
+	return record
+}
+
+func TestExportReadsEveryPage(t *testing.T) {
+	store := repositories.NewSQLiteStore(openMigratedDB(t))
+	ctx := context.Background()
+
+	songs := make([]models.ImportedSong, 1203)
+	for i := range songs {
+		songs[i] = models.ImportedSong{Song: models.Song{Title: "Track", Length: 60, Price: 99}}
+	}
+	if err := store.Imports.Import(ctx, models.CatalogImport{Songs: songs}); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int64
+	if err := store.Exports.Export(ctx, func(record models.ExportRecord) error {
+		ids = append(ids, record.Values[0].(int64))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(songs) || ids[0] != 1 || ids[len(ids)-1] != int64(len(songs)) {
+		t.Errorf("exported %d songs, from %v to %v", len(ids), ids[0], ids[len(ids)-1])
+	}
+}
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"maps"
+	"slices"
+	"time"
+)
+
+type GenreRepository interface {
+	// GetAll returns every genre ordered by name.
+	GetAll(ctx context.Context) ([]models.Genre, error)
+	GetByID(ctx context.Context, id int) (models.Genre, error)
+	Create(ctx context.Context, genre models.Genre) (int, error)
+	Update(ctx context.Context, id int, genre models.Genre) error
+	// Delete fails with ErrConflict while the genre has subgenres.
+	Delete(ctx context.Context, id int) error
+	// Subtree returns the IDs of the genre and of every genre below it.
+	Subtree(ctx context.Context, id int) ([]int, error)
+	// GetByAlbumIDs returns the genres of each album, keyed by album ID.
+	GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Genre, error)
+	// GetBySongIDs returns the genres of each song, keyed by song ID.
+	GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Genre, error)
+	// SetAlbumGenres replaces the album's genres.
+	SetAlbumGenres(ctx context.Context, albumID int, genreIDs []int) error
+	// SetSongGenres replaces the song's genres.
+	SetSongGenres(ctx context.Context, songID int, genreIDs []int) error
+	// GetAlbumIDs returns the albums in any of the genres, in ID order.
+	GetAlbumIDs(ctx context.Context, genreIDs []int) ([]int, error)
+	// GetSongIDs returns the songs in any of the genres, in ID order.
+	GetSongIDs(ctx context.Context, genreIDs []int) ([]int, error)
+}
+
+type SQLiteGenreRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteGenreRepository(db *sql.DB) *SQLiteGenreRepository {
+	return &SQLiteGenreRepository{db: db}
+}
+
+func (r *SQLiteGenreRepository) GetAll(ctx context.Context) ([]models.Genre, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, parent_id FROM genres ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var genres []models.Genre
+	for rows.Next() {
+		var genre models.Genre
+		if err := rows.Scan(&genre.Id, &genre.Name, &genre.ParentId); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	return genres, rows.Err()
+}
+
+func (r *SQLiteGenreRepository) GetByID(ctx context.Context, id int) (models.Genre, error) {
+	var genre models.Genre
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, parent_id FROM genres WHERE id = ?", id).
+		Scan(&genre.Id, &genre.Name, &genre.ParentId)
+	if err != nil {
+		return models.Genre{}, translateError(err)
+	}
+	return genre, nil
+}
+
+func (r *SQLiteGenreRepository) Create(ctx context.Context, genre models.Genre) (int, error) {
+	result, err := execInTx(ctx, r.db, "INSERT INTO genres (name, parent_id) VALUES (?, ?)", genre.Name, genre.ParentId)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteGenreRepository) Update(ctx context.Context, id int, genre models.Genre) error {
+	result, err := execInTx(ctx, r.db, "UPDATE genres SET name = ?, parent_id = ? WHERE id = ?", genre.Name, genre.ParentId, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteGenreRepository) Delete(ctx context.Context, id int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var subgenres int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM genres WHERE parent_id = ?", id).Scan(&subgenres); err != nil {
+			return err
+		}
+		if subgenres > 0 {
+			return ErrConflict
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM genres WHERE id = ?", id)
+		if err != nil {
+			return translateError(err)
+		}
+		return requireAffected(result)
+	})
+}
+
+func (r *SQLiteGenreRepository) Subtree(ctx context.Context, id int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH RECURSIVE subtree(id) AS (
+			SELECT id FROM genres WHERE id = ?
+			UNION
+			SELECT g.id FROM genres g JOIN subtree s ON g.parent_id = s.id
+		)
+		SELECT id FROM subtree ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := scanIDs(rows)
+	if err == nil && len(ids) == 0 {
+		return nil, ErrNotFound
+	}
+	return ids, err
+}
+
+func (r *SQLiteGenreRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Genre, error) {
+	return r.getByOwnerIDs(ctx, "album_genres", "album_id", albumIDs)
+}
+
+func (r *SQLiteGenreRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Genre, error) {
+	return r.getByOwnerIDs(ctx, "song_genres", "song_id", songIDs)
+}
+
+func (r *SQLiteGenreRepository) getByOwnerIDs(ctx context.Context, table, column string, ownerIDs []int) (map[int][]models.Genre, error) {
+	result := map[int][]models.Genre{}
+	err := inBatches(ownerIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT l.`+column+`, g.id, g.name, g.parent_id
+			FROM genres g JOIN `+table+` l ON g.id = l.genre_id
+			WHERE l.`+column+` IN (`+placeholders+`) ORDER BY g.name, g.id`,
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var ownerID int
+			var genre models.Genre
+			if err := rows.Scan(&ownerID, &genre.Id, &genre.Name, &genre.ParentId); err != nil {
+				return err
+			}
+			result[ownerID] = append(result[ownerID], genre)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+func (r *SQLiteGenreRepository) SetAlbumGenres(ctx context.Context, albumID int, genreIDs []int) error {
+	return r.setGenres(ctx, "album_genres", "album_id", albumID, genreIDs)
+}
+
+func (r *SQLiteGenreRepository) SetSongGenres(ctx context.Context, songID int, genreIDs []int) error {
+	return r.setGenres(ctx, "song_genres", "song_id", songID, genreIDs)
+}
+
+func (r *SQLiteGenreRepository) setGenres(ctx context.Context, table, column string, ownerID int, genreIDs []int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+column+" = ?", ownerID); err != nil {
+			return err
+		}
+		for _, genreID := range genreIDs {
+			if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO "+table+" ("+column+", genre_id) VALUES (?, ?)", ownerID, genreID); err != nil {
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteGenreRepository) GetAlbumIDs(ctx context.Context, genreIDs []int) ([]int, error) {
+	return r.getOwnerIDs(ctx, "album_genres", "album_id", genreIDs)
+}
+
+func (r *SQLiteGenreRepository) GetSongIDs(ctx context.Context, genreIDs []int) ([]int, error) {
+	return r.getOwnerIDs(ctx, "song_genres", "song_id", genreIDs)
+}
+
+func (r *SQLiteGenreRepository) getOwnerIDs(ctx context.Context, table, column string, genreIDs []int) ([]int, error) {
+	seen := map[int]bool{}
+	err := inBatches(genreIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, "SELECT "+column+" FROM "+table+" WHERE genre_id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		ids, err := scanIDs(rows)
+		for _, id := range ids {
+			seen[id] = true
+		}
+		return err
+	})
+	return slices.Sorted(maps.Keys(seen)), err
+}
+
+func (r *SQLiteGenreRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanIDs(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
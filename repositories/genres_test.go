@@ -0,0 +1,153 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+)
+
+func TestGenres(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			create := func(name string, parentID *int) int {
+				t.Helper()
+				id, err := store.Genres.Create(ctx, models.Genre{Name: name, ParentId: parentID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			jazz := create("Jazz", nil)
+			cool := create("Cool Jazz", &jazz)
+			westCoast := create("West Coast Jazz", &cool)
+			rock := create("Rock", nil)
+
+			if _, err := store.Genres.Create(ctx, models.Genre{Name: "jazz"}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("duplicate name: expected ErrConflict, got %v", err)
+			}
+			missing := 99
+			if _, err := store.Genres.Create(ctx, models.Genre{Name: "Bebop", ParentId: &missing}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing parent: expected ErrInvalidReference, got %v", err)
+			}
+
+			if ids, err := store.Genres.Subtree(ctx, jazz); err != nil || !reflect.DeepEqual(ids, []int{jazz, cool, westCoast}) {
+				t.Errorf("Subtree(jazz) = %v, %v", ids, err)
+			}
+			if _, err := store.Genres.Subtree(ctx, missing); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("Subtree(missing): expected ErrNotFound, got %v", err)
+			}
+
+			albumID, _ := store.Albums.Create(ctx, models.Album{Title: "Kind of Blue", Price: 999})
+			otherID, _ := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			if err := store.Genres.SetAlbumGenres(ctx, albumID, []int{rock, westCoast}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Genres.SetAlbumGenres(ctx, albumID, []int{westCoast}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Genres.SetAlbumGenres(ctx, otherID, []int{rock}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Genres.SetAlbumGenres(ctx, otherID, []int{missing}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing genre: expected ErrInvalidReference, got %v", err)
+			}
+
+			genres, err := store.Genres.GetByAlbumIDs(ctx, []int{albumID, otherID})
+			if err != nil || len(genres[albumID]) != 1 || genres[albumID][0].Name != "West Coast Jazz" || genres[otherID][0].Id != rock {
+				t.Errorf("GetByAlbumIDs = %+v, %v", genres, err)
+			}
+			if ids, err := store.Genres.GetAlbumIDs(ctx, []int{jazz, cool, westCoast}); err != nil || !reflect.DeepEqual(ids, []int{albumID}) {
+				t.Errorf("GetAlbumIDs(jazz subtree) = %v, %v", ids, err)
+			}
+
+			if err := store.Genres.Delete(ctx, cool); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("delete with subgenres: expected ErrConflict, got %v", err)
+			}
+			if err := store.Genres.Delete(ctx, westCoast); err != nil {
+				t.Fatal(err)
+			}
+			if genres, _ := store.Genres.GetByAlbumIDs(ctx, []int{albumID}); len(genres[albumID]) != 0 {
+				t.Errorf("deleted genre is still linked: %+v", genres)
+			}
+
+			if err := store.Albums.Delete(ctx, otherID); err != nil {
+				t.Fatal(err)
+			}
+			if ids, _ := store.Genres.GetAlbumIDs(ctx, []int{rock}); len(ids) != 0 {
+				t.Errorf("deleted album is still linked: %v", ids)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			albumID, _ := store.Albums.Create(ctx, models.Album{Title: "Parachutes", Price: 999})
+			songID, _ := store.Songs.Create(ctx, models.Song{Title: "Yellow", Length: 266, Price: 129})
+			bandID, _ := store.Bands.Create(ctx, models.Band{Name: "Coldplay", Nationality: "British", DateFormed: "1996-01-01"})
+
+			for _, tag := range []struct {
+				entityType string
+				id         int
+				name       string
+			}{
+				{models.TagAlbum, albumID, "britpop"},
+				{models.TagAlbum, albumID, "debut"},
+				{models.TagAlbum, albumID, "britpop"},
+				{models.TagSong, songID, "britpop"},
+				{models.TagBand, bandID, "britpop"},
+			} {
+				if err := store.Tags.Add(ctx, tag.entityType, tag.id, tag.name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.Tags.Add(ctx, models.TagArtist, 99, "ghost"); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing artist: expected ErrInvalidReference, got %v", err)
+			}
+
+			all, err := store.Tags.List(ctx, "")
+			want := []models.TagCount{{Name: "britpop", Count: 3}, {Name: "debut", Count: 1}}
+			if err != nil || !reflect.DeepEqual(all, want) {
+				t.Errorf("List() = %+v, %v", all, err)
+			}
+			if songs, _ := store.Tags.List(ctx, models.TagSong); !reflect.DeepEqual(songs, []models.TagCount{{Name: "britpop", Count: 1}}) {
+				t.Errorf("List(song) = %+v", songs)
+			}
+
+			tags, err := store.Tags.GetByEntityIDs(ctx, models.TagAlbum, []int{albumID})
+			if err != nil || !reflect.DeepEqual(tags[albumID], []string{"britpop", "debut"}) {
+				t.Errorf("GetByEntityIDs = %v, %v", tags, err)
+			}
+
+			if err := store.Tags.Remove(ctx, models.TagAlbum, albumID, "debut"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Tags.Remove(ctx, models.TagAlbum, albumID, "debut"); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("removing twice: expected ErrNotFound, got %v", err)
+			}
+
+			if err := store.Bands.Delete(ctx, bandID); err != nil {
+				t.Fatal(err)
+			}
+			if all, _ := store.Tags.List(ctx, ""); !reflect.DeepEqual(all, []models.TagCount{{Name: "britpop", Count: 2}}) {
+				t.Errorf("after deletes List() = %+v", all)
+			}
+		})
+	}
+}
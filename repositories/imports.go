@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+// importTimeout bounds a whole import, which may be thousands of rows.
+const importTimeout = time.Minute
+
+type ImportRepository interface {
+	// Import creates every record of the batch, crediting albums and songs
+	// to their performers and adding songs to their albums, or creates
+	// nothing if any of it fails.
+	Import(ctx context.Context, batch models.CatalogImport) error
+}
+
+type SQLiteImportRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteImportRepository(db *sql.DB) *SQLiteImportRepository {
+	return &SQLiteImportRepository{db: db}
+}
+
+func (r *SQLiteImportRepository) Import(ctx context.Context, batch models.CatalogImport) error {
+	ctx, cancel := context.WithTimeout(ctx, importTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert := func(query string, args ...any) (int, error) {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, translateError(err)
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	}
+	artistIDs := make([]int, len(batch.Artists))
+	bandIDs := make([]int, len(batch.Bands))
+	// credit credits an album or song to its performers, given their
+	// indexes in the batch.
+	credit := func(albumID, songID any, artist, band *int) error {
+		if artist != nil {
+			if _, err := insert("INSERT INTO credits (album_id, song_id, artist_id, role) VALUES (?, ?, ?, ?)",
+				albumID, songID, artistIDs[*artist], models.RolePerformer); err != nil {
+				return err
+			}
+		}
+		if band != nil {
+			if _, err := insert("INSERT INTO credits (album_id, song_id, band_id, role) VALUES (?, ?, ?, ?)",
+				albumID, songID, bandIDs[*band], models.RolePerformer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i, artist := range batch.Artists {
+		if artistIDs[i], err = insert(
+			"INSERT INTO artists (first_name, last_name, nationality, birth_date, death_date, sex_id, title_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			artist.FirstName, artist.LastName, artist.Nationality, artist.BirthDate, artist.DeathDate, artist.SexId, artist.TitleId,
+		); err != nil {
+			return err
+		}
+	}
+	for i, band := range batch.Bands {
+		if bandIDs[i], err = insert("INSERT INTO bands (name, nationality, date_formed, disbanded_date) VALUES (?, ?, ?, ?)",
+			band.Name, band.Nationality, band.DateFormed, band.DisbandedDate); err != nil {
+			return err
+		}
+	}
+
+	albumIDs := make([]int, len(batch.Albums))
+	for i, album := range batch.Albums {
+		if albumIDs[i], err = insert("INSERT INTO albums (title, price, release_date, catalog_number, barcode, format) VALUES (?, ?, ?, ?, ?, ?)",
+			album.Album.Title, album.Album.Price, album.Album.ReleaseDate, album.Album.CatalogNumber, album.Album.Barcode, album.Album.Format); err != nil {
+			return err
+		}
+		if err := credit(albumIDs[i], nil, album.Artist, album.Band); err != nil {
+			return err
+		}
+	}
+	tracks := map[int]int{}
+	for _, song := range batch.Songs {
+		songID, err := insert("INSERT INTO songs (title, length, price) VALUES (?, ?, ?)", song.Song.Title, song.Song.Length, song.Song.Price)
+		if err != nil {
+			return err
+		}
+		if song.Album != nil {
+			tracks[*song.Album]++
+			if _, err := insert("INSERT INTO album_songs (album_id, song_id, position) VALUES (?, ?, ?)", albumIDs[*song.Album], songID, tracks[*song.Album]); err != nil {
+				return err
+			}
+		}
+		if err := credit(nil, songID, song.Artist, song.Band); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
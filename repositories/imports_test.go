@@ -0,0 +1,71 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	one, first := 1, 0
+	batch := models.CatalogImport{
+		Artists: []models.Artist{{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07", SexId: &one, TitleId: &one}},
+		Bands:   []models.Band{{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"}},
+		Albums:  []models.ImportedAlbum{{Album: models.Album{Title: "OK Computer", Price: 999}, Band: &first}},
+		Songs: []models.ImportedSong{
+			{Song: models.Song{Title: "Airbag", Length: 284, Price: 99}, Album: &first, Band: &first},
+			{Song: models.Song{Title: "Hearing Damage", Length: 260, Price: 99}, Artist: &first},
+		},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.Imports.Import(ctx, batch); err != nil {
+				t.Fatal(err)
+			}
+
+			songs, err := store.Songs.GetByAlbumID(ctx, 1)
+			if err != nil || len(songs) != 1 || songs[0].Title != "Airbag" {
+				t.Errorf("album songs = %+v, %v", songs, err)
+			}
+			credits, err := store.Credits.GetByBandID(ctx, 1)
+			if err != nil || len(credits) != 2 || credits[0].AlbumId == nil || credits[1].SongId == nil || credits[1].Role != models.RolePerformer {
+				t.Errorf("band credits = %+v, %v", credits, err)
+			}
+			credits, err = store.Credits.GetByArtistID(ctx, 1)
+			if err != nil || len(credits) != 1 || credits[0].SongId == nil || *credits[0].SongId != 2 {
+				t.Errorf("artist credits = %+v, %v", credits, err)
+			}
+		})
+	}
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	store := repositories.NewSQLiteStore(openMigratedDB(t))
+	ctx := context.Background()
+
+	one, unknown := 1, 99
+	err := store.Imports.Import(ctx, models.CatalogImport{
+		Bands: []models.Band{{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"}},
+		Artists: []models.Artist{
+			{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07", SexId: &one, TitleId: &one},
+			{FirstName: "Nobody", LastName: "Known", Nationality: "British", BirthDate: "1970-01-01", SexId: &unknown, TitleId: &one},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected the unknown sex to fail the import")
+	}
+
+	artists, _ := store.Artists.GetAll(ctx)
+	bands, _ := store.Bands.GetAll(ctx)
+	if len(artists) != 0 || len(bands) != 0 {
+		t.Errorf("nothing should be created: %+v %+v", artists, bands)
+	}
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+)
+
+type LabelRepository interface {
+	GetByID(ctx context.Context, id int) (models.Label, error)
+	List(ctx context.Context, q query.Query) (query.Page[models.Label], error)
+	// GetByIDs returns the labels with the given IDs, skipping missing ones.
+	GetByIDs(ctx context.Context, ids []int) ([]models.Label, error)
+	Create(ctx context.Context, label models.Label) (int, error)
+	Update(ctx context.Context, id int, label models.Label) error
+	// Delete removes the label; its albums are left without one.
+	Delete(ctx context.Context, id int) error
+}
+
+const labelColumns = "id, name, country"
+
+// LabelQuery lists the parameters accepted by GET /labels.
+var LabelQuery = query.Spec[models.Label]{Fields: map[string]query.Field[models.Label]{
+	"id":      {Column: "id", Kind: query.Int, Sortable: true, Value: func(l models.Label) any { return l.Id }},
+	"name":    {Column: "name", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(l models.Label) any { return l.Name }},
+	"country": {Column: "country", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(l models.Label) any { return nullableString(l.Country) }},
+}}
+
+type SQLiteLabelRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteLabelRepository(db *sql.DB) *SQLiteLabelRepository {
+	return &SQLiteLabelRepository{db: db}
+}
+
+func (r *SQLiteLabelRepository) GetByID(ctx context.Context, id int) (models.Label, error) {
+	var label models.Label
+	err := r.db.QueryRowContext(ctx, "SELECT "+labelColumns+" FROM labels WHERE id = ?", id).
+		Scan(&label.Id, &label.Name, &label.Country)
+	if err != nil {
+		return models.Label{}, translateError(err)
+	}
+	return label, nil
+}
+
+func (r *SQLiteLabelRepository) List(ctx context.Context, q query.Query) (query.Page[models.Label], error) {
+	return list(ctx, r.db, LabelQuery, q, "labels", labelColumns, nil, scanLabels)
+}
+
+func (r *SQLiteLabelRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Label, error) {
+	var labels []models.Label
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT "+labelColumns+" FROM labels WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		batch, err := scanLabels(rows)
+		labels = append(labels, batch...)
+		return err
+	})
+	return labels, err
+}
+
+func (r *SQLiteLabelRepository) Create(ctx context.Context, label models.Label) (int, error) {
+	result, err := execInTx(ctx, r.db, "INSERT INTO labels (name, country) VALUES (?, ?)", label.Name, label.Country)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteLabelRepository) Update(ctx context.Context, id int, label models.Label) error {
+	result, err := execInTx(ctx, r.db, "UPDATE labels SET name = ?, country = ? WHERE id = ?", label.Name, label.Country, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteLabelRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM labels WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func scanLabels(rows *sql.Rows) ([]models.Label, error) {
+	defer rows.Close()
+
+	var labels []models.Label
+	for rows.Next() {
+		var label models.Label
+		if err := rows.Scan(&label.Id, &label.Name, &label.Country); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
@@ -0,0 +1,179 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			uk := "United Kingdom"
+			parlophone, err := store.Labels.Create(ctx, models.Label{Name: "Parlophone", Country: &uk})
+			if err != nil {
+				t.Fatal(err)
+			}
+			xl, _ := store.Labels.Create(ctx, models.Label{Name: "XL Recordings"})
+
+			if label, err := store.Labels.GetByID(ctx, parlophone); err != nil || label.Name != "Parlophone" || *label.Country != uk {
+				t.Errorf("GetByID = %+v, %v", label, err)
+			}
+			if err := store.Labels.Update(ctx, xl, models.Label{Name: "XL Recordings", Country: &uk}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Labels.Update(ctx, 99, models.Label{Name: "Missing"}); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("update missing: expected ErrNotFound, got %v", err)
+			}
+			q, _ := repositories.LabelQuery.Parse(url.Values{"country": {uk}, "sort": {"-name"}})
+			if page, err := store.Labels.List(ctx, q); err != nil || page.Total != 2 || page.Items[0].Id != xl {
+				t.Errorf("List = %+v, %v", page, err)
+			}
+
+			album := func(title string, date *string, barcode *string) int {
+				t.Helper()
+				id, err := store.Albums.Create(ctx, models.Album{Title: title, Price: 999, LabelId: &parlophone, ReleaseDate: date, Barcode: barcode})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			kidA, okComputer := "2000-10-02", "1997-05-21"
+			barcode := "724385522925"
+			first := album("Kid A", &kidA, nil)
+			second := album("OK Computer", &okComputer, &barcode)
+			third := album("Demo", nil, nil)
+
+			albums, err := store.Albums.GetByLabelID(ctx, parlophone)
+			if err != nil || len(albums) != 3 || albums[0].Id != second || albums[1].Id != first || albums[2].Id != third {
+				t.Errorf("GetByLabelID = %+v, %v", albums, err)
+			}
+
+			missing := 99
+			if _, err := store.Albums.Create(ctx, models.Album{Title: "Nowhere", Price: 100, LabelId: &missing}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing label: expected ErrInvalidReference, got %v", err)
+			}
+			if _, err := store.Albums.Create(ctx, models.Album{Title: "Copy", Price: 100, Barcode: &barcode}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("duplicate barcode: expected ErrConflict, got %v", err)
+			}
+			if err := store.Albums.Update(ctx, second, albums[0]); err != nil {
+				t.Errorf("keeping its own barcode: %v", err)
+			}
+
+			if err := store.Labels.Delete(ctx, parlophone); err != nil {
+				t.Fatal(err)
+			}
+			if a, err := store.Albums.GetByID(ctx, first); err != nil || a.LabelId != nil {
+				t.Errorf("album of a deleted label = %+v, %v", a, err)
+			}
+			if _, err := store.Labels.GetByID(ctx, parlophone); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleted label: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPagingOverNullValues(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dated := "1997-05-21"
+			for i, date := range []*string{nil, nil, &dated, nil, nil} {
+				if _, err := store.Albums.Create(ctx, models.Album{Title: "Album " + strconv.Itoa(i+1), Price: 999, ReleaseDate: date}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			uk := "United Kingdom"
+			for _, country := range []*string{nil, &uk, nil} {
+				if _, err := store.Labels.Create(ctx, models.Label{Name: "Label", Country: country}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tests := []struct {
+				sort string
+				want []int
+				ids  func(url.Values) ([]int, string)
+			}{
+				{"release_date", []int{1, 2, 4, 5, 3}, albumPage(t, store)},
+				{"-release_date", []int{3, 1, 2, 4, 5}, albumPage(t, store)},
+				{"country", []int{1, 3, 2}, labelPage(t, store)},
+				{"-country", []int{2, 1, 3}, labelPage(t, store)},
+			}
+			for _, tt := range tests {
+				var got []int
+				values := url.Values{"sort": {tt.sort}, "limit": {"2"}}
+				for pages := 0; ; pages++ {
+					if pages > 5 {
+						t.Fatalf("sort=%s did not terminate", tt.sort)
+					}
+					ids, next := tt.ids(values)
+					got = append(got, ids...)
+					if next == "" {
+						break
+					}
+					values.Set("cursor", next)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("sort=%s: got %v, want %v", tt.sort, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// albumPage lists one page of albums, returning their IDs and the next
+// cursor.
+func albumPage(t *testing.T, store *repositories.Store) func(url.Values) ([]int, string) {
+	return func(values url.Values) ([]int, string) {
+		t.Helper()
+		q, err := repositories.AlbumQuery.Parse(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := store.Albums.List(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, album := range page.Items {
+			ids = append(ids, album.Id)
+		}
+		return ids, page.NextCursor
+	}
+}
+
+func labelPage(t *testing.T, store *repositories.Store) func(url.Values) ([]int, string) {
+	return func(values url.Values) ([]int, string) {
+		t.Helper()
+		q, err := repositories.LabelQuery.Parse(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := store.Labels.List(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, label := range page.Items {
+			ids = append(ids, label.Id)
+		}
+		return ids, page.NextCursor
+	}
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+)
+
+type MembershipRepository interface {
+	GetByID(ctx context.Context, id int) (models.BandMembership, error)
+	// GetByBandID lists a band's memberships, only those active on the
+	// given YYYY-MM-DD date unless it is empty.
+	GetByBandID(ctx context.Context, bandID int, at string) ([]models.BandMembership, error)
+	GetByArtistID(ctx context.Context, artistID int) ([]models.BandMembership, error)
+	Create(ctx context.Context, membership models.BandMembership) (int, error)
+	// Update changes everything but the band.
+	Update(ctx context.Context, id int, membership models.BandMembership) error
+	Delete(ctx context.Context, id int) error
+}
+
+type SQLiteMembershipRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteMembershipRepository(db *sql.DB) *SQLiteMembershipRepository {
+	return &SQLiteMembershipRepository{db: db}
+}
+
+const membershipColumns = "id, artist_id, band_id, role, date_joined, date_left"
+
+// membershipOrder lists the founding members first and then everyone else in
+// the order they joined.
+const membershipOrder = " ORDER BY date_joined IS NOT NULL, date_joined, id"
+
+func scanMembership(row rowScanner) (models.BandMembership, error) {
+	var membership models.BandMembership
+	err := row.Scan(&membership.Id, &membership.ArtistId, &membership.BandId, &membership.Role,
+		&membership.DateJoined, &membership.DateLeft)
+	return membership, err
+}
+
+func (r *SQLiteMembershipRepository) GetByID(ctx context.Context, id int) (models.BandMembership, error) {
+	membership, err := scanMembership(r.db.QueryRowContext(ctx,
+		"SELECT "+membershipColumns+" FROM band_memberships WHERE id = ?", id))
+	if err != nil {
+		return models.BandMembership{}, translateError(err)
+	}
+	return membership, nil
+}
+
+func (r *SQLiteMembershipRepository) GetByBandID(ctx context.Context, bandID int, at string) ([]models.BandMembership, error) {
+	where, args := " WHERE band_id = ?", []interface{}{bandID}
+	if at != "" {
+		where += " AND (date_joined IS NULL OR date_joined <= ?) AND (date_left IS NULL OR date_left > ?)"
+		args = append(args, at, at)
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT "+membershipColumns+" FROM band_memberships"+where+membershipOrder, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanMemberships(rows)
+}
+
+func (r *SQLiteMembershipRepository) GetByArtistID(ctx context.Context, artistID int) ([]models.BandMembership, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+membershipColumns+" FROM band_memberships WHERE artist_id = ?"+membershipOrder, artistID)
+	if err != nil {
+		return nil, err
+	}
+	return scanMemberships(rows)
+}
+
+func (r *SQLiteMembershipRepository) Create(ctx context.Context, membership models.BandMembership) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO band_memberships (artist_id, band_id, role, date_joined, date_left) VALUES (?, ?, ?, ?, ?)",
+		membership.ArtistId, membership.BandId, membership.Role, membership.DateJoined, membership.DateLeft)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteMembershipRepository) Update(ctx context.Context, id int, membership models.BandMembership) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE band_memberships SET artist_id = ?, role = ?, date_joined = ?, date_left = ? WHERE id = ?",
+		membership.ArtistId, membership.Role, membership.DateJoined, membership.DateLeft, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteMembershipRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM band_memberships WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func scanMemberships(rows *sql.Rows) ([]models.BandMembership, error) {
+	defer rows.Close()
+
+	var memberships []models.BandMembership
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
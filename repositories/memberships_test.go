@@ -0,0 +1,127 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestMemberships(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			one := 1
+			newArtist := func(first, last string) int {
+				t.Helper()
+				id, err := store.Artists.Create(ctx, models.Artist{
+					FirstName: first, LastName: last, Nationality: "British", BirthDate: "1968-10-07",
+					SexId: &one, TitleId: &one,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			thom, jonny, nigel := newArtist("Thom", "Yorke"), newArtist("Jonny", "Greenwood"), newArtist("Nigel", "Godrich")
+			bandID, err := store.Bands.Create(ctx, models.Band{Name: "Radiohead", DateFormed: "1985-01-01"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			date := func(s string) *string { return &s }
+			create := func(membership models.BandMembership) int {
+				t.Helper()
+				membership.BandId = bandID
+				id, err := store.Memberships.Create(ctx, membership)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			later := create(models.BandMembership{ArtistId: jonny, Role: "guitar", DateJoined: date("1986-01-01")})
+			founder := create(models.BandMembership{ArtistId: thom, Role: "vocals"})
+			past := create(models.BandMembership{ArtistId: nigel, Role: "keyboards", DateJoined: date("1990-01-01"), DateLeft: date("1995-06-01")})
+
+			if _, err := store.Memberships.Create(ctx, models.BandMembership{ArtistId: 99, BandId: bandID}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("missing artist: expected ErrInvalidReference, got %v", err)
+			}
+
+			all, err := store.Memberships.GetByBandID(ctx, bandID, "")
+			if err != nil || len(all) != 3 || all[0].Id != founder || all[1].Id != later || all[2].Id != past {
+				t.Errorf("GetByBandID = %+v, %v", all, err)
+			}
+			early, err := store.Memberships.GetByBandID(ctx, bandID, "1985-06-01")
+			if err != nil || len(early) != 1 || early[0].Id != founder {
+				t.Errorf("GetByBandID at 1985-06-01 = %+v, %v", early, err)
+			}
+			if left, _ := store.Memberships.GetByBandID(ctx, bandID, "1995-06-01"); len(left) != 2 {
+				t.Errorf("members leave on date_left, got %+v", left)
+			}
+
+			band, err := store.Bands.GetByID(ctx, bandID)
+			if err != nil || band.NumberOfMembers != 2 {
+				t.Errorf("NumberOfMembers = %d, %v; want the 2 current members", band.NumberOfMembers, err)
+			}
+			q, err := repositories.BandQuery.Parse(url.Values{"number_of_members_gte": {"2"}, "sort": {"-number_of_members"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := store.Bands.List(ctx, q, "")
+			if err != nil || page.Total != 1 {
+				t.Errorf("filtering on number_of_members: %+v, %v", page, err)
+			}
+			q, _ = repositories.BandQuery.Parse(url.Values{"number_of_members_gte": {"3"}})
+			if page, err := store.Bands.List(ctx, q, "1992-01-01"); err != nil || page.Total != 1 || page.Items[0].NumberOfMembers != 3 {
+				t.Errorf("members as of 1992: %+v, %v", page, err)
+			}
+			if page, err := store.Bands.List(ctx, q, ""); err != nil || page.Total != 0 {
+				t.Errorf("members today: %+v, %v", page, err)
+			}
+
+			update := models.BandMembership{ArtistId: nigel, Role: "keyboards", DateJoined: date("1990-01-01")}
+			if err := store.Memberships.Update(ctx, past, update); err != nil {
+				t.Fatal(err)
+			}
+			if band, _ := store.Bands.GetByID(ctx, bandID); band.NumberOfMembers != 3 {
+				t.Errorf("NumberOfMembers after rejoining = %d, want 3", band.NumberOfMembers)
+			}
+			if byArtist, err := store.Memberships.GetByArtistID(ctx, nigel); err != nil || len(byArtist) != 1 || byArtist[0].BandId != bandID {
+				t.Errorf("GetByArtistID = %+v, %v", byArtist, err)
+			}
+
+			otherBand, _ := store.Bands.Create(ctx, models.Band{Name: "Atoms for Peace", DateFormed: "2009-01-01"})
+			store.Memberships.Create(ctx, models.BandMembership{ArtistId: thom, BandId: otherBand})
+			q, _ = repositories.ArtistQuery.Parse(url.Values{"band_id": {strconv.Itoa(bandID)}})
+			if artists, err := store.Artists.List(ctx, q, ""); err != nil || artists.Total != 3 {
+				t.Errorf("artists in the band = %+v, %v", artists, err)
+			}
+			q, _ = repositories.ArtistQuery.Parse(url.Values{"band_id": {strconv.Itoa(otherBand)}})
+			if artists, err := store.Artists.List(ctx, q, ""); err != nil || artists.Total != 1 || artists.Items[0].Id != thom {
+				t.Errorf("artists in the other band = %+v, %v", artists, err)
+			}
+
+			if err := store.Memberships.Delete(ctx, later); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Memberships.GetByID(ctx, later); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleted membership: expected ErrNotFound, got %v", err)
+			}
+
+			if err := store.Artists.Delete(ctx, thom); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Memberships.GetByID(ctx, founder); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleting the artist should delete their memberships, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,2418 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"goMusic/constants"
+	"goMusic/models"
+	"goMusic/query"
+	"html"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Memory is an in-memory implementation of every repository, intended for
+// tests. It mirrors the SQLite tables, including the song join tables, but
+// does not enforce foreign keys.
+type Memory struct {
+	mu sync.RWMutex
+
+	albums  map[int]models.Album
+	labels  map[int]models.Label
+	artists map[int]models.Artist
+	bands   map[int]models.Band
+	songs   map[int]models.Song
+	users   map[int]models.User
+	sexes   map[int]string
+	titles  map[int]string
+
+	refreshTokens   map[int]models.RefreshToken
+	revokedTokens   map[string]time.Time
+	tokensRevokedAt map[int]time.Time
+
+	playlists map[int]models.Playlist
+	// playlistSongs holds the song IDs of each playlist in position order.
+	playlistSongs         map[int][]int
+	playlistCollaborators map[int][]models.PlaylistCollaborator
+
+	cartItems   map[int]models.CartItem
+	orders      map[int]models.Order
+	ownedAlbums map[ownership]bool
+	ownedSongs  map[ownership]bool
+
+	songAudio map[int]models.SongAudio
+	// albumCovers holds each album's cover variants, smallest first.
+	albumCovers map[int][]models.CoverImage
+
+	albumSongs  map[songLink]int // track position
+	credits     map[int]models.Credit
+	memberships map[int]models.BandMembership
+
+	genres      map[int]models.Genre
+	albumGenres map[genreLink]bool
+	songGenres  map[genreLink]bool
+	tagLinks    map[tagLink]bool
+
+	reviews map[int]models.Review
+	// albumRatings and songRatings are updated with every review change,
+	// like the rating columns the SQLite triggers maintain.
+	albumRatings map[int]models.Rating
+	songRatings  map[int]models.Rating
+
+	plays map[int]models.Play
+	// similarSongs is the similarity matrix, each song's neighbours highest
+	// score first.
+	similarSongs map[int][]models.SimilarSong
+	charts       map[chartKey]models.Chart
+
+	sequences map[string]int
+}
+
+type songLink struct {
+	OwnerID int
+	SongID  int
+}
+
+type genreLink struct {
+	OwnerID int
+	GenreID int
+}
+
+// tagLink attaches the named tag to the entry of type EntityType with ID.
+type tagLink struct {
+	EntityType string
+	ID         int
+	Name       string
+}
+
+type chartKey struct {
+	Kind   string
+	Period string
+	Date   string
+}
+
+type ownership struct {
+	UserID int
+	ItemID int
+}
+
+// NewMemory returns an empty in-memory database with the sex and title
+// lookup tables seeded the same way as db.SeedDB.
+func NewMemory() *Memory {
+	return &Memory{
+		albums:          map[int]models.Album{},
+		labels:          map[int]models.Label{},
+		artists:         map[int]models.Artist{},
+		bands:           map[int]models.Band{},
+		songs:           map[int]models.Song{},
+		users:           map[int]models.User{},
+		sexes:           map[int]string{1: "Male", 2: "Female", 3: "Non-binary"},
+		titles:          map[int]string{1: "Mr.", 2: "Mrs.", 3: "Ms.", 4: "Dr.", 5: "Prof."},
+		refreshTokens:   map[int]models.RefreshToken{},
+		revokedTokens:   map[string]time.Time{},
+		tokensRevokedAt: map[int]time.Time{},
+
+		playlists:             map[int]models.Playlist{},
+		playlistSongs:         map[int][]int{},
+		playlistCollaborators: map[int][]models.PlaylistCollaborator{},
+
+		cartItems:   map[int]models.CartItem{},
+		orders:      map[int]models.Order{},
+		ownedAlbums: map[ownership]bool{},
+		ownedSongs:  map[ownership]bool{},
+
+		songAudio:   map[int]models.SongAudio{},
+		albumCovers: map[int][]models.CoverImage{},
+
+		albumSongs:  map[songLink]int{},
+		credits:     map[int]models.Credit{},
+		memberships: map[int]models.BandMembership{},
+
+		genres:      map[int]models.Genre{},
+		albumGenres: map[genreLink]bool{},
+		songGenres:  map[genreLink]bool{},
+		tagLinks:    map[tagLink]bool{},
+
+		reviews:      map[int]models.Review{},
+		albumRatings: map[int]models.Rating{},
+		songRatings:  map[int]models.Rating{},
+
+		plays:        map[int]models.Play{},
+		similarSongs: map[int][]models.SimilarSong{},
+		charts:       map[chartKey]models.Chart{},
+
+		sequences: map[string]int{},
+	}
+}
+
+// Store returns a Store whose repositories all share this in-memory database.
+func (m *Memory) Store() *Store {
+	return &Store{
+		Albums:          &memoryAlbumRepository{m},
+		Artists:         &memoryArtistRepository{m},
+		Bands:           &memoryBandRepository{m},
+		Songs:           &memorySongRepository{m},
+		Users:           &memoryUserRepository{m},
+		Tokens:          &memoryTokenRepository{m},
+		Search:          &memorySearchRepository{m},
+		Playlists:       &memoryPlaylistRepository{m},
+		Orders:          &memoryOrderRepository{m},
+		Audio:           &memoryAudioRepository{m},
+		Covers:          &memoryCoverRepository{m},
+		Genres:          &memoryGenreRepository{m},
+		Tags:            &memoryTagRepository{m},
+		Credits:         &memoryCreditRepository{m},
+		Memberships:     &memoryMembershipRepository{m},
+		Imports:         &memoryImportRepository{m},
+		Exports:         &memoryExportRepository{m},
+		Reviews:         &memoryReviewRepository{m},
+		Plays:           &memoryPlayRepository{m},
+		Recommendations: &memoryRecommendationRepository{m},
+		Charts:          &memoryChartRepository{m},
+		Labels:          &memoryLabelRepository{m},
+	}
+}
+
+// LinkAlbumSong adds a song to the end of an album's tracks.
+func (m *Memory) LinkAlbumSong(albumID, songID int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.appendTrack(albumID, songID)
+}
+
+// appendTrack links the song to the album after its last track, unless it is
+// already on it
+func (m *Memory) appendTrack(albumID, songID int) {
+	link := songLink{albumID, songID}
+	if _, ok := m.albumSongs[link]; ok {
+		return
+	}
+	last := 0
+	for l, position := range m.albumSongs {
+		if l.OwnerID == albumID {
+			last = max(last, position)
+		}
+	}
+	m.albumSongs[link] = last + 1
+}
+
+func (m *Memory) nextID(table string) int {
+	m.sequences[table]++
+	return m.sequences[table]
+}
+
+// byIDs returns the rows with the given IDs in ID order, skipping missing ones
+func byIDs[T any](rows map[int]T, ids []int) []T {
+	unique := slices.Clone(ids)
+	slices.Sort(unique)
+	var values []T
+	for _, id := range slices.Compact(unique) {
+		if row, ok := rows[id]; ok {
+			values = append(values, row)
+		}
+	}
+	return values
+}
+
+// bySongIDs groups the rows linked to each song through a join table
+func bySongIDs[T, V any](rows map[int]T, links map[songLink]V, songIDs []int) map[int][]T {
+	result := map[int][]T{}
+	for _, songID := range songIDs {
+		if _, done := result[songID]; done {
+			continue
+		}
+		var owners []int
+		for link := range links {
+			if link.SongID == songID {
+				owners = append(owners, link.OwnerID)
+			}
+		}
+		if related := byIDs(rows, owners); len(related) > 0 {
+			result[songID] = related
+		}
+	}
+	return result
+}
+
+// lookupNames resolves IDs against a lookup table, skipping unknown ones
+func lookupNames(table map[int]string, ids []int) map[int]string {
+	names := map[int]string{}
+	for _, id := range ids {
+		if name, ok := table[id]; ok {
+			names[id] = name
+		}
+	}
+	return names
+}
+
+// unlink removes every join row matching the predicate
+func unlink[V any](links map[songLink]V, match func(songLink) bool) {
+	for link := range links {
+		if match(link) {
+			delete(links, link)
+		}
+	}
+}
+
+func sortedValues[T any](rows map[int]T) []T {
+	var values []T
+	for _, id := range slices.Sorted(maps.Keys(rows)) {
+		values = append(values, rows[id])
+	}
+	return values
+}
+
+type memoryAlbumRepository struct{ m *Memory }
+
+func (r *memoryAlbumRepository) GetAll(ctx context.Context) ([]models.Album, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return sortedValues(r.m.albums), nil
+}
+
+func (r *memoryAlbumRepository) List(ctx context.Context, q query.Query) (query.Page[models.Album], error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	spec := AlbumQuery.
+		WithMatches("artist_id", creditMatch(r.m, func(a models.Album) int { return a.Id }, albumCredit, artistCredit)).
+		WithMatches("band_id", creditMatch(r.m, func(a models.Album) int { return a.Id }, albumCredit, bandCredit))
+	return spec.Apply(sortedValues(r.m.albums), q), nil
+}
+
+func (r *memoryAlbumRepository) GetByID(ctx context.Context, id int) (models.Album, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	album, ok := r.m.albums[id]
+	if !ok {
+		return models.Album{}, ErrNotFound
+	}
+	return album, nil
+}
+
+func (r *memoryAlbumRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Album, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return byIDs(r.m.albums, ids), nil
+}
+
+func (r *memoryAlbumRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Album, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return bySongIDs(r.m.albums, r.m.albumSongs, songIDs), nil
+}
+
+func (r *memoryAlbumRepository) Create(ctx context.Context, album models.Album) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if err := r.m.checkAlbum(0, album); err != nil {
+		return 0, err
+	}
+	album.Id = r.m.nextID("albums")
+	r.m.albums[album.Id] = album
+	return album.Id, nil
+}
+
+func (r *memoryAlbumRepository) Update(ctx context.Context, id int, album models.Album) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.albums[id]; !ok {
+		return ErrNotFound
+	}
+	if err := r.m.checkAlbum(id, album); err != nil {
+		return err
+	}
+	album.Id = id
+	r.m.albums[id] = album
+	return nil
+}
+
+func (r *memoryAlbumRepository) GetByLabelID(ctx context.Context, labelID int) ([]models.Album, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var albums []models.Album
+	for _, album := range sortedValues(r.m.albums) {
+		if album.LabelId != nil && *album.LabelId == labelID {
+			albums = append(albums, album)
+		}
+	}
+	// Undated albums last, like ORDER BY release_date IS NULL.
+	slices.SortStableFunc(albums, func(a, b models.Album) int {
+		switch {
+		case a.ReleaseDate == nil && b.ReleaseDate == nil:
+			return 0
+		case a.ReleaseDate == nil:
+			return 1
+		case b.ReleaseDate == nil:
+			return -1
+		}
+		return cmp.Compare(*a.ReleaseDate, *b.ReleaseDate)
+	})
+	return albums, nil
+}
+
+// checkAlbum enforces the label foreign key and the unique barcode of the
+// album with the given ID, 0 for a new one.
+func (m *Memory) checkAlbum(id int, album models.Album) error {
+	if !exists(m.labels, album.LabelId) {
+		return ErrInvalidReference
+	}
+	if album.Barcode != nil {
+		for _, other := range m.albums {
+			if other.Id != id && other.Barcode != nil && *other.Barcode == *album.Barcode {
+				return ErrConflict
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryAlbumRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.albums[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.albums, id)
+	unlink(r.m.albumSongs, func(l songLink) bool { return l.OwnerID == id })
+	r.m.uncredit(func(c models.Credit) bool { return c.AlbumId != nil && *c.AlbumId == id })
+	maps.DeleteFunc(r.m.cartItems, func(_ int, item models.CartItem) bool { return item.AlbumId != nil && *item.AlbumId == id })
+	maps.DeleteFunc(r.m.ownedAlbums, func(o ownership, _ bool) bool { return o.ItemID == id })
+	delete(r.m.albumCovers, id)
+	maps.DeleteFunc(r.m.albumGenres, func(l genreLink, _ bool) bool { return l.OwnerID == id })
+	r.m.untag(models.TagAlbum, id)
+	maps.DeleteFunc(r.m.reviews, func(_ int, review models.Review) bool { return review.AlbumId != nil && *review.AlbumId == id })
+	delete(r.m.albumRatings, id)
+	return nil
+}
+
+func (r *memoryAlbumRepository) SetSongs(ctx context.Context, albumID int, songIDs []int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.albums[albumID]; !ok {
+		return ErrNotFound
+	}
+	for _, songID := range songIDs {
+		if _, ok := r.m.songs[songID]; !ok {
+			return ErrInvalidReference
+		}
+	}
+	unlink(r.m.albumSongs, func(l songLink) bool { return l.OwnerID == albumID })
+	for _, songID := range songIDs {
+		r.m.appendTrack(albumID, songID)
+	}
+	return nil
+}
+
+type memoryArtistRepository struct{ m *Memory }
+
+func (r *memoryArtistRepository) GetAll(ctx context.Context) ([]models.Artist, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return sortedValues(r.m.artists), nil
+}
+
+func (r *memoryArtistRepository) List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Artist], error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	spec := artistQueryOn(dateOrToday(asOf)).WithMatches("band_id", func(artist models.Artist, bandID any) bool {
+		for _, membership := range r.m.memberships {
+			if membership.ArtistId == artist.Id && membership.BandId == bandID.(int) {
+				return true
+			}
+		}
+		return false
+	})
+	return spec.Apply(sortedValues(r.m.artists), q), nil
+}
+
+func (r *memoryArtistRepository) GetByID(ctx context.Context, id int) (models.Artist, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	artist, ok := r.m.artists[id]
+	if !ok {
+		return models.Artist{}, ErrNotFound
+	}
+	return artist, nil
+}
+
+func (r *memoryArtistRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Artist, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return byIDs(r.m.artists, ids), nil
+}
+
+func (r *memoryArtistRepository) GetByName(ctx context.Context, name string) ([]models.Artist, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var artists []models.Artist
+	for _, artist := range sortedValues(r.m.artists) {
+		if strings.EqualFold(artist.FirstName+" "+artist.LastName, name) {
+			artists = append(artists, artist)
+		}
+	}
+	return artists, nil
+}
+
+func (r *memoryArtistRepository) Create(ctx context.Context, artist models.Artist) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	artist.Id = r.m.nextID("artists")
+	r.m.artists[artist.Id] = artist
+	return artist.Id, nil
+}
+
+func (r *memoryArtistRepository) Update(ctx context.Context, id int, artist models.Artist) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.artists[id]; !ok {
+		return ErrNotFound
+	}
+	artist.Id = id
+	r.m.artists[id] = artist
+	return nil
+}
+
+func (r *memoryArtistRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.artists[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.artists, id)
+	r.m.uncredit(func(c models.Credit) bool { return c.ArtistId != nil && *c.ArtistId == id })
+	maps.DeleteFunc(r.m.memberships, func(_ int, m models.BandMembership) bool { return m.ArtistId == id })
+	r.m.untag(models.TagArtist, id)
+	return nil
+}
+
+func (r *memoryArtistRepository) GetSexNames(ctx context.Context, ids []int) (map[int]string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return lookupNames(r.m.sexes, ids), nil
+}
+
+func (r *memoryArtistRepository) GetTitleNames(ctx context.Context, ids []int) (map[int]string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return lookupNames(r.m.titles, ids), nil
+}
+
+type memoryBandRepository struct{ m *Memory }
+
+func (r *memoryBandRepository) GetAll(ctx context.Context) ([]models.Band, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return r.m.countMembers(sortedValues(r.m.bands), models.Today()), nil
+}
+
+func (r *memoryBandRepository) List(ctx context.Context, q query.Query, asOf string) (query.Page[models.Band], error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	date := dateOrToday(asOf)
+	return bandQueryOn(date).Apply(r.m.countMembers(sortedValues(r.m.bands), date), q), nil
+}
+
+func (r *memoryBandRepository) GetByID(ctx context.Context, id int) (models.Band, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	band, ok := r.m.bands[id]
+	if !ok {
+		return models.Band{}, ErrNotFound
+	}
+	return r.m.countMembers([]models.Band{band}, models.Today())[0], nil
+}
+
+func (r *memoryBandRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Band, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return r.m.countMembers(byIDs(r.m.bands, ids), models.Today()), nil
+}
+
+func (r *memoryBandRepository) GetByName(ctx context.Context, name string) ([]models.Band, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var bands []models.Band
+	for _, band := range sortedValues(r.m.bands) {
+		if strings.EqualFold(band.Name, name) {
+			bands = append(bands, band)
+		}
+	}
+	return r.m.countMembers(bands, models.Today()), nil
+}
+
+// countMembers fills in NumberOfMembers on the date the way memberCountSQL
+// computes it. The caller holds the lock.
+func (m *Memory) countMembers(bands []models.Band, date string) []models.Band {
+	for i := range bands {
+		members := map[int]bool{}
+		for _, membership := range m.memberships {
+			if membership.BandId == bands[i].Id && membership.ActiveOn(date) {
+				members[membership.ArtistId] = true
+			}
+		}
+		bands[i].NumberOfMembers = len(members)
+	}
+	return bands
+}
+
+func (r *memoryBandRepository) Create(ctx context.Context, band models.Band) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	band.Id = r.m.nextID("bands")
+	r.m.bands[band.Id] = band
+	return band.Id, nil
+}
+
+func (r *memoryBandRepository) Update(ctx context.Context, id int, band models.Band) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.bands[id]; !ok {
+		return ErrNotFound
+	}
+	band.Id = id
+	r.m.bands[id] = band
+	return nil
+}
+
+func (r *memoryBandRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.bands[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.bands, id)
+	r.m.uncredit(func(c models.Credit) bool { return c.BandId != nil && *c.BandId == id })
+	maps.DeleteFunc(r.m.memberships, func(_ int, m models.BandMembership) bool { return m.BandId == id })
+	r.m.untag(models.TagBand, id)
+	return nil
+}
+
+type memorySongRepository struct{ m *Memory }
+
+func (r *memorySongRepository) GetAll(ctx context.Context) ([]models.Song, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return sortedValues(r.m.songs), nil
+}
+
+func (r *memorySongRepository) List(ctx context.Context, q query.Query) (query.Page[models.Song], error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	songID := func(s models.Song) int { return s.Id }
+	spec := SongQuery.
+		WithMatches("album_id", func(song models.Song, albumID any) bool {
+			_, ok := r.m.albumSongs[songLink{OwnerID: albumID.(int), SongID: song.Id}]
+			return ok
+		}).
+		WithMatches("artist_id", creditMatch(r.m, songID, songCredit, artistCredit)).
+		WithMatches("band_id", creditMatch(r.m, songID, songCredit, bandCredit))
+	return spec.Apply(sortedValues(r.m.songs), q), nil
+}
+
+// Accessors for the sides of a credit, for creditMatch.
+func albumCredit(c models.Credit) *int  { return c.AlbumId }
+func songCredit(c models.Credit) *int   { return c.SongId }
+func artistCredit(c models.Credit) *int { return c.ArtistId }
+func bandCredit(c models.Credit) *int   { return c.BandId }
+
+// creditMatch matches the albums or songs credited to the artist or band a
+// filter names. The caller holds the lock.
+func creditMatch[T any](m *Memory, id func(T) int, item, creditee func(models.Credit) *int) func(T, any) bool {
+	return func(entry T, value any) bool {
+		for _, credit := range m.credits {
+			if on, by := item(credit), creditee(credit); on != nil && by != nil && *on == id(entry) && *by == value.(int) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (r *memorySongRepository) GetByID(ctx context.Context, id int) (models.Song, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	song, ok := r.m.songs[id]
+	if !ok {
+		return models.Song{}, ErrNotFound
+	}
+	return song, nil
+}
+
+func (r *memorySongRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return byIDs(r.m.songs, ids), nil
+}
+
+func (r *memorySongRepository) GetByAlbumID(ctx context.Context, albumID int) ([]models.Song, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var tracks []songLink
+	for link := range r.m.albumSongs {
+		if link.OwnerID == albumID {
+			tracks = append(tracks, link)
+		}
+	}
+	slices.SortFunc(tracks, func(a, b songLink) int { return r.m.albumSongs[a] - r.m.albumSongs[b] })
+	var songs []models.Song
+	for _, link := range tracks {
+		if song, ok := r.m.songs[link.SongID]; ok {
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+func (r *memorySongRepository) Create(ctx context.Context, song models.Song) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	song.Id = r.m.nextID("songs")
+	r.m.songs[song.Id] = song
+	return song.Id, nil
+}
+
+func (r *memorySongRepository) Update(ctx context.Context, id int, song models.Song) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.songs[id]; !ok {
+		return ErrNotFound
+	}
+	song.Id = id
+	r.m.songs[id] = song
+	return nil
+}
+
+func (r *memorySongRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.songs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.songs, id)
+	unlink(r.m.albumSongs, func(l songLink) bool { return l.SongID == id })
+	r.m.uncredit(func(c models.Credit) bool { return c.SongId != nil && *c.SongId == id })
+	for playlistID, songs := range r.m.playlistSongs {
+		r.m.playlistSongs[playlistID] = slices.DeleteFunc(songs, func(songID int) bool { return songID == id })
+	}
+	maps.DeleteFunc(r.m.cartItems, func(_ int, item models.CartItem) bool { return item.SongId != nil && *item.SongId == id })
+	maps.DeleteFunc(r.m.ownedSongs, func(o ownership, _ bool) bool { return o.ItemID == id })
+	delete(r.m.songAudio, id)
+	maps.DeleteFunc(r.m.songGenres, func(l genreLink, _ bool) bool { return l.OwnerID == id })
+	r.m.untag(models.TagSong, id)
+	maps.DeleteFunc(r.m.reviews, func(_ int, review models.Review) bool { return review.SongId != nil && *review.SongId == id })
+	delete(r.m.songRatings, id)
+	for playID, play := range r.m.plays {
+		if play.SongId != nil && *play.SongId == id {
+			play.SongId = nil
+			r.m.plays[playID] = play
+		}
+	}
+	delete(r.m.similarSongs, id)
+	for songID, similar := range r.m.similarSongs {
+		r.m.similarSongs[songID] = slices.DeleteFunc(similar, func(s models.SimilarSong) bool { return s.SongId == id })
+	}
+	return nil
+}
+
+type memoryUserRepository struct{ m *Memory }
+
+func (r *memoryUserRepository) Create(ctx context.Context, user models.User) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, existing := range r.m.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return 0, ErrConflict
+		}
+	}
+	if user.Role == "" {
+		user.Role = constants.Viewer.String()
+	}
+	user.Id = r.m.nextID("users")
+	r.m.users[user.Id] = user
+	return user.Id, nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id int) (models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	user, ok := r.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, user := range r.m.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	users := sortedValues(r.m.users)
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, id int, role constants.Role) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	user, ok := r.m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role.String()
+	r.m.users[id] = user
+	return nil
+}
+
+type memoryTokenRepository struct{ m *Memory }
+
+func (r *memoryTokenRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.insertRefreshToken(token)
+}
+
+func (r *memoryTokenRepository) insertRefreshToken(token models.RefreshToken) (int, error) {
+	for _, existing := range r.m.refreshTokens {
+		if existing.TokenHash == token.TokenHash {
+			return 0, ErrConflict
+		}
+	}
+	token.Id = r.m.nextID("refresh_tokens")
+	r.m.refreshTokens[token.Id] = token
+	return token.Id, nil
+}
+
+func (r *memoryTokenRepository) GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, token := range r.m.refreshTokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (r *memoryTokenRepository) RotateRefreshToken(ctx context.Context, id int, next models.RefreshToken) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	token, ok := r.m.refreshTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return 0, ErrConflict
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	r.m.refreshTokens[id] = token
+	return r.insertRefreshToken(next)
+}
+
+func (r *memoryTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	r.revokeRefreshTokens(func(token models.RefreshToken) bool { return token.FamilyId == familyID })
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeRefreshTokens(ctx context.Context, userID int) error {
+	r.revokeRefreshTokens(func(token models.RefreshToken) bool { return token.UserId == userID })
+	return nil
+}
+
+func (r *memoryTokenRepository) revokeRefreshTokens(match func(models.RefreshToken) bool) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	for id, token := range r.m.refreshTokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			r.m.refreshTokens[id] = token
+		}
+	}
+}
+
+func (r *memoryTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeAccessTokens(ctx context.Context, userID int, before time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.users[userID]; !ok {
+		return ErrNotFound
+	}
+	r.m.tokensRevokedAt[userID] = before
+	return nil
+}
+
+// IsRevoked compares times in whole seconds, like the unix timestamps stored
+// by SQLite and the numeric dates in a token.
+func (r *memoryTokenRepository) IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	if _, ok := r.m.revokedTokens[tokenID]; ok {
+		return true, nil
+	}
+	before, ok := r.m.tokensRevokedAt[userID]
+	return ok && before.Unix() > issuedAt.Unix(), nil
+}
+
+type memoryPlaylistRepository struct{ m *Memory }
+
+func (r *memoryPlaylistRepository) GetByID(ctx context.Context, id int) (models.Playlist, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	playlist, ok := r.m.playlists[id]
+	if !ok {
+		return models.Playlist{}, ErrNotFound
+	}
+	return playlist, nil
+}
+
+func (r *memoryPlaylistRepository) GetByUserID(ctx context.Context, userID int) ([]models.Playlist, error) {
+	return r.filter(func(playlist models.Playlist) bool {
+		if playlist.UserId == userID {
+			return true
+		}
+		c, ok := r.collaborator(playlist.Id, userID)
+		return ok && c.Accepted
+	}), nil
+}
+
+func (r *memoryPlaylistRepository) GetInvitations(ctx context.Context, userID int) ([]models.Playlist, error) {
+	return r.filter(func(playlist models.Playlist) bool {
+		c, ok := r.collaborator(playlist.Id, userID)
+		return ok && !c.Accepted
+	}), nil
+}
+
+func (r *memoryPlaylistRepository) filter(match func(models.Playlist) bool) []models.Playlist {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var playlists []models.Playlist
+	for _, playlist := range sortedValues(r.m.playlists) {
+		if match(playlist) {
+			playlists = append(playlists, playlist)
+		}
+	}
+	return playlists
+}
+
+// collaborator must be called with the lock held.
+func (r *memoryPlaylistRepository) collaborator(playlistID, userID int) (models.PlaylistCollaborator, bool) {
+	for _, c := range r.m.playlistCollaborators[playlistID] {
+		if c.UserId == userID {
+			return c, true
+		}
+	}
+	return models.PlaylistCollaborator{}, false
+}
+
+func (r *memoryPlaylistRepository) Create(ctx context.Context, playlist models.Playlist) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if playlist.Visibility == "" {
+		playlist.Visibility = constants.Private.String()
+	}
+	playlist.Id = r.m.nextID("playlists")
+	r.m.playlists[playlist.Id] = playlist
+	return playlist.Id, nil
+}
+
+func (r *memoryPlaylistRepository) Update(ctx context.Context, id int, playlist models.Playlist) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	existing, ok := r.m.playlists[id]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name, existing.Description, existing.Visibility = playlist.Name, playlist.Description, playlist.Visibility
+	r.m.playlists[id] = existing
+	return nil
+}
+
+func (r *memoryPlaylistRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.playlists[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.playlists, id)
+	delete(r.m.playlistSongs, id)
+	delete(r.m.playlistCollaborators, id)
+	return nil
+}
+
+func (r *memoryPlaylistRepository) GetTracks(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistTrack, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	tracks := map[int][]models.PlaylistTrack{}
+	for _, playlistID := range playlistIDs {
+		for i, songID := range r.m.playlistSongs[playlistID] {
+			tracks[playlistID] = append(tracks[playlistID], models.PlaylistTrack{PlaylistId: playlistID, SongId: songID, Position: i + 1})
+		}
+	}
+	return tracks, nil
+}
+
+func (r *memoryPlaylistRepository) AddTrack(ctx context.Context, playlistID, songID, position int) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	songs := r.m.playlistSongs[playlistID]
+	if position < 1 || position > len(songs) {
+		position = len(songs) + 1
+	}
+	r.m.playlistSongs[playlistID] = slices.Insert(songs, position-1, songID)
+	return position, nil
+}
+
+func (r *memoryPlaylistRepository) RemoveTrack(ctx context.Context, playlistID, position int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	songs := r.m.playlistSongs[playlistID]
+	if position < 1 || position > len(songs) {
+		return ErrNotFound
+	}
+	r.m.playlistSongs[playlistID] = slices.Delete(songs, position-1, position)
+	return nil
+}
+
+func (r *memoryPlaylistRepository) MoveTrack(ctx context.Context, playlistID, from, to int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	songs := r.m.playlistSongs[playlistID]
+	if from < 1 || from > len(songs) || to < 1 || to > len(songs) {
+		return ErrNotFound
+	}
+	songID := songs[from-1]
+	songs = slices.Delete(songs, from-1, from)
+	r.m.playlistSongs[playlistID] = slices.Insert(songs, to-1, songID)
+	return nil
+}
+
+func (r *memoryPlaylistRepository) ReorderTracks(ctx context.Context, playlistID int, order []int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	songs := r.m.playlistSongs[playlistID]
+	if !isPermutation(order, len(songs)) {
+		return ErrConflict
+	}
+	reordered := make([]int, len(order))
+	for i, from := range order {
+		reordered[i] = songs[from-1]
+	}
+	r.m.playlistSongs[playlistID] = reordered
+	return nil
+}
+
+func (r *memoryPlaylistRepository) GetCollaborators(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistCollaborator, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	collaborators := map[int][]models.PlaylistCollaborator{}
+	for _, playlistID := range playlistIDs {
+		for _, c := range r.m.playlistCollaborators[playlistID] {
+			c.Username = r.m.users[c.UserId].Username
+			collaborators[playlistID] = append(collaborators[playlistID], c)
+		}
+	}
+	return collaborators, nil
+}
+
+func (r *memoryPlaylistRepository) InviteCollaborator(ctx context.Context, playlistID, userID int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.collaborator(playlistID, userID); ok {
+		return ErrConflict
+	}
+	r.m.playlistCollaborators[playlistID] = append(r.m.playlistCollaborators[playlistID],
+		models.PlaylistCollaborator{PlaylistId: playlistID, UserId: userID})
+	return nil
+}
+
+func (r *memoryPlaylistRepository) AcceptInvitation(ctx context.Context, playlistID, userID int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, c := range r.m.playlistCollaborators[playlistID] {
+		if c.UserId == userID {
+			r.m.playlistCollaborators[playlistID][i].Accepted = true
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryPlaylistRepository) RemoveCollaborator(ctx context.Context, playlistID, userID int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	collaborators := r.m.playlistCollaborators[playlistID]
+	i := slices.IndexFunc(collaborators, func(c models.PlaylistCollaborator) bool { return c.UserId == userID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.m.playlistCollaborators[playlistID] = slices.Delete(collaborators, i, i+1)
+	return nil
+}
+
+type memoryOrderRepository struct{ m *Memory }
+
+func (r *memoryOrderRepository) GetCart(ctx context.Context, userID int) ([]models.CartItem, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var items []models.CartItem
+	for _, item := range sortedValues(r.m.cartItems) {
+		if item.UserId == userID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *memoryOrderRepository) AddToCart(ctx context.Context, item models.CartItem) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	same := func(a, b *int) bool { return a != nil && b != nil && *a == *b }
+	for _, existing := range r.m.cartItems {
+		if existing.UserId == item.UserId && (same(existing.AlbumId, item.AlbumId) || same(existing.SongId, item.SongId)) {
+			return 0, ErrConflict
+		}
+	}
+	item.Id = r.m.nextID("cart_items")
+	r.m.cartItems[item.Id] = item
+	return item.Id, nil
+}
+
+func (r *memoryOrderRepository) RemoveFromCart(ctx context.Context, userID, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if item, ok := r.m.cartItems[id]; !ok || item.UserId != userID {
+		return ErrNotFound
+	}
+	delete(r.m.cartItems, id)
+	return nil
+}
+
+func (r *memoryOrderRepository) CreateOrder(ctx context.Context, order models.Order, songIDs, cartItemIDs []int) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, id := range cartItemIDs {
+		if item, ok := r.m.cartItems[id]; !ok || item.UserId != order.UserId {
+			return 0, ErrConflict
+		}
+	}
+	for _, id := range cartItemIDs {
+		delete(r.m.cartItems, id)
+	}
+
+	order.Id = r.m.nextID("orders")
+	order.Items = slices.Clone(order.Items)
+	r.m.orders[order.Id] = order
+	for _, item := range order.Items {
+		if item.AlbumId != nil {
+			r.m.ownedAlbums[ownership{order.UserId, *item.AlbumId}] = true
+		}
+	}
+	for _, songID := range ownedSongs(order, songIDs) {
+		r.m.ownedSongs[ownership{order.UserId, songID}] = true
+	}
+	return order.Id, nil
+}
+
+func (r *memoryOrderRepository) GetOrders(ctx context.Context, userID int) ([]models.Order, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var orders []models.Order
+	for _, order := range sortedValues(r.m.orders) {
+		if order.UserId == userID {
+			orders = append(orders, order)
+		}
+	}
+	slices.Reverse(orders)
+	return orders, nil
+}
+
+func (r *memoryOrderRepository) GetOrderByID(ctx context.Context, id int) (models.Order, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	order, ok := r.m.orders[id]
+	if !ok {
+		return models.Order{}, ErrNotFound
+	}
+	return order, nil
+}
+
+func (r *memoryOrderRepository) GetLibrary(ctx context.Context, userID int) (models.Library, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	owned := func(items map[ownership]bool) []int {
+		var ids []int
+		for o := range items {
+			if o.UserID == userID {
+				ids = append(ids, o.ItemID)
+			}
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	return models.Library{AlbumIds: owned(r.m.ownedAlbums), SongIds: owned(r.m.ownedSongs)}, nil
+}
+
+type memoryAudioRepository struct{ m *Memory }
+
+func (r *memoryAudioRepository) GetBySongID(ctx context.Context, songID int) (models.SongAudio, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	audio, ok := r.m.songAudio[songID]
+	if !ok {
+		return models.SongAudio{}, ErrNotFound
+	}
+	return audio, nil
+}
+
+// Save checks the whole upload before writing any of it, like the
+// transaction in SQLite.
+func (r *memoryAudioRepository) Save(ctx context.Context, upload models.AudioUpload) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	songID := upload.Audio.SongId
+	if _, ok := r.m.songs[songID]; !ok {
+		if upload.Song != nil {
+			return ErrNotFound
+		}
+		return ErrInvalidReference
+	}
+	for _, credit := range upload.Credits {
+		if !exists(r.m.albums, credit.AlbumId) || !exists(r.m.songs, credit.SongId) ||
+			!exists(r.m.artists, credit.ArtistId) || !exists(r.m.bands, credit.BandId) {
+			return ErrInvalidReference
+		}
+	}
+
+	r.m.songAudio[songID] = upload.Audio
+	if upload.Song != nil {
+		song := *upload.Song
+		song.Id = songID
+		r.m.songs[songID] = song
+	}
+	for _, credit := range upload.Credits {
+		if !r.m.credited(credit) {
+			credit.Id = r.m.nextID("credits")
+			r.m.credits[credit.Id] = credit
+		}
+	}
+	return nil
+}
+
+type memoryCoverRepository struct{ m *Memory }
+
+func (r *memoryCoverRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.CoverImage, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	covers := map[int][]models.CoverImage{}
+	for _, id := range albumIDs {
+		if images, ok := r.m.albumCovers[id]; ok {
+			covers[id] = slices.Clone(images)
+		}
+	}
+	return covers, nil
+}
+
+func (r *memoryCoverRepository) Get(ctx context.Context, albumID int, variant string) (models.CoverImage, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, image := range r.m.albumCovers[albumID] {
+		if image.Variant == variant {
+			return image, nil
+		}
+	}
+	return models.CoverImage{}, ErrNotFound
+}
+
+func (r *memoryCoverRepository) Replace(ctx context.Context, albumID int, images []models.CoverImage) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.albums[albumID]; !ok {
+		return ErrInvalidReference
+	}
+	images = slices.Clone(images)
+	for i := range images {
+		images[i].AlbumId = albumID
+	}
+	slices.SortStableFunc(images, func(a, b models.CoverImage) int { return a.Width - b.Width })
+	r.m.albumCovers[albumID] = images
+	return nil
+}
+
+type memoryGenreRepository struct{ m *Memory }
+
+func (r *memoryGenreRepository) GetAll(ctx context.Context) ([]models.Genre, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	genres := sortedValues(r.m.genres)
+	slices.SortStableFunc(genres, byGenreName)
+	return genres, nil
+}
+
+func (r *memoryGenreRepository) GetByID(ctx context.Context, id int) (models.Genre, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	genre, ok := r.m.genres[id]
+	if !ok {
+		return models.Genre{}, ErrNotFound
+	}
+	return genre, nil
+}
+
+func (r *memoryGenreRepository) Create(ctx context.Context, genre models.Genre) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if err := r.check(0, genre); err != nil {
+		return 0, err
+	}
+	genre.Id = r.m.nextID("genres")
+	r.m.genres[genre.Id] = genre
+	return genre.Id, nil
+}
+
+func (r *memoryGenreRepository) Update(ctx context.Context, id int, genre models.Genre) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.genres[id]; !ok {
+		return ErrNotFound
+	}
+	if err := r.check(id, genre); err != nil {
+		return err
+	}
+	genre.Id = id
+	r.m.genres[id] = genre
+	return nil
+}
+
+// byGenreName orders genres the way the NOCASE name column does.
+func byGenreName(a, b models.Genre) int {
+	return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+}
+
+// check enforces the unique name and the parent foreign key.
+func (r *memoryGenreRepository) check(id int, genre models.Genre) error {
+	for _, existing := range r.m.genres {
+		if existing.Id != id && strings.EqualFold(existing.Name, genre.Name) {
+			return ErrConflict
+		}
+	}
+	if genre.ParentId != nil {
+		if _, ok := r.m.genres[*genre.ParentId]; !ok {
+			return ErrInvalidReference
+		}
+	}
+	return nil
+}
+
+func (r *memoryGenreRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.genres[id]; !ok {
+		return ErrNotFound
+	}
+	for _, genre := range r.m.genres {
+		if genre.ParentId != nil && *genre.ParentId == id {
+			return ErrConflict
+		}
+	}
+	delete(r.m.genres, id)
+	byGenre := func(l genreLink, _ bool) bool { return l.GenreID == id }
+	maps.DeleteFunc(r.m.albumGenres, byGenre)
+	maps.DeleteFunc(r.m.songGenres, byGenre)
+	return nil
+}
+
+func (r *memoryGenreRepository) Subtree(ctx context.Context, id int) ([]int, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	if _, ok := r.m.genres[id]; !ok {
+		return nil, ErrNotFound
+	}
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, genre := range r.m.genres {
+			if genre.ParentId != nil && *genre.ParentId == ids[i] && !slices.Contains(ids, genre.Id) {
+				ids = append(ids, genre.Id)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (r *memoryGenreRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Genre, error) {
+	return r.byOwners(r.m.albumGenres, albumIDs), nil
+}
+
+func (r *memoryGenreRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Genre, error) {
+	return r.byOwners(r.m.songGenres, songIDs), nil
+}
+
+func (r *memoryGenreRepository) byOwners(links map[genreLink]bool, ownerIDs []int) map[int][]models.Genre {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	result := map[int][]models.Genre{}
+	for _, ownerID := range ownerIDs {
+		if _, done := result[ownerID]; done {
+			continue
+		}
+		var genres []models.Genre
+		for _, genre := range sortedValues(r.m.genres) {
+			if links[genreLink{ownerID, genre.Id}] {
+				genres = append(genres, genre)
+			}
+		}
+		if len(genres) > 0 {
+			slices.SortStableFunc(genres, byGenreName)
+			result[ownerID] = genres
+		}
+	}
+	return result
+}
+
+func (r *memoryGenreRepository) SetAlbumGenres(ctx context.Context, albumID int, genreIDs []int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.albums[albumID]; !ok {
+		return ErrInvalidReference
+	}
+	return r.setGenres(r.m.albumGenres, albumID, genreIDs)
+}
+
+func (r *memoryGenreRepository) SetSongGenres(ctx context.Context, songID int, genreIDs []int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.songs[songID]; !ok {
+		return ErrInvalidReference
+	}
+	return r.setGenres(r.m.songGenres, songID, genreIDs)
+}
+
+func (r *memoryGenreRepository) setGenres(links map[genreLink]bool, ownerID int, genreIDs []int) error {
+	for _, genreID := range genreIDs {
+		if _, ok := r.m.genres[genreID]; !ok {
+			return ErrInvalidReference
+		}
+	}
+	maps.DeleteFunc(links, func(l genreLink, _ bool) bool { return l.OwnerID == ownerID })
+	for _, genreID := range genreIDs {
+		links[genreLink{ownerID, genreID}] = true
+	}
+	return nil
+}
+
+func (r *memoryGenreRepository) GetAlbumIDs(ctx context.Context, genreIDs []int) ([]int, error) {
+	return r.owners(r.m.albumGenres, genreIDs), nil
+}
+
+func (r *memoryGenreRepository) GetSongIDs(ctx context.Context, genreIDs []int) ([]int, error) {
+	return r.owners(r.m.songGenres, genreIDs), nil
+}
+
+func (r *memoryGenreRepository) owners(links map[genreLink]bool, genreIDs []int) []int {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var ids []int
+	for link := range links {
+		if slices.Contains(genreIDs, link.GenreID) {
+			ids = append(ids, link.OwnerID)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+type memoryTagRepository struct{ m *Memory }
+
+// untag removes every tag from a deleted entry. The caller holds the lock.
+func (m *Memory) untag(entityType string, id int) {
+	maps.DeleteFunc(m.tagLinks, func(l tagLink, _ bool) bool { return l.EntityType == entityType && l.ID == id })
+}
+
+func (r *memoryTagRepository) List(ctx context.Context, entityType string) ([]models.TagCount, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	counts := map[string]int{}
+	for link := range r.m.tagLinks {
+		if entityType == "" || link.EntityType == entityType {
+			counts[link.Name]++
+		}
+	}
+
+	var tags []models.TagCount
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		tags = append(tags, models.TagCount{Name: name, Count: counts[name]})
+	}
+	slices.SortStableFunc(tags, func(a, b models.TagCount) int { return b.Count - a.Count })
+	return tags, nil
+}
+
+func (r *memoryTagRepository) GetByEntityIDs(ctx context.Context, entityType string, ids []int) (map[int][]string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	result := map[int][]string{}
+	for link := range r.m.tagLinks {
+		if link.EntityType == entityType && slices.Contains(ids, link.ID) {
+			result[link.ID] = append(result[link.ID], link.Name)
+		}
+	}
+	for _, names := range result {
+		slices.Sort(names)
+	}
+	return result, nil
+}
+
+func (r *memoryTagRepository) Add(ctx context.Context, entityType string, id int, name string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	exists := false
+	switch entityType {
+	case models.TagAlbum:
+		_, exists = r.m.albums[id]
+	case models.TagSong:
+		_, exists = r.m.songs[id]
+	case models.TagArtist:
+		_, exists = r.m.artists[id]
+	case models.TagBand:
+		_, exists = r.m.bands[id]
+	}
+	if !exists {
+		return ErrInvalidReference
+	}
+	r.m.tagLinks[tagLink{entityType, id, name}] = true
+	return nil
+}
+
+func (r *memoryTagRepository) Remove(ctx context.Context, entityType string, id int, name string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	link := tagLink{entityType, id, name}
+	if !r.m.tagLinks[link] {
+		return ErrNotFound
+	}
+	delete(r.m.tagLinks, link)
+	return nil
+}
+
+type memorySearchRepository struct{ m *Memory }
+
+// Search approximates the FTS index: a row matches when every term is a
+// prefix of one of its folded words, and scores higher the larger the share
+// of its words that match.
+func (r *memorySearchRepository) Search(ctx context.Context, text string, limit int) ([]SearchHit, error) {
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	var hits []SearchHit
+	add := func(kind string, id int, name string) {
+		if snippet, score, ok := matchName(name, terms); ok {
+			hits = append(hits, SearchHit{Type: kind, ID: id, Snippet: snippet, Score: score})
+		}
+	}
+	for id, album := range r.m.albums {
+		add(SearchAlbum, id, album.Title)
+	}
+	for id, artist := range r.m.artists {
+		add(SearchArtist, id, artist.FirstName+" "+artist.LastName)
+	}
+	for id, band := range r.m.bands {
+		add(SearchBand, id, band.Name)
+	}
+	for id, song := range r.m.songs {
+		add(SearchSong, id, song.Title)
+	}
+
+	return rankHits(hits, limit), nil
+}
+
+func matchName(name string, terms []string) (string, float64, bool) {
+	isSeparator := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }
+
+	// Split the name into alternating word and separator runs so matched
+	// words can be marked without touching the rest of the text.
+	var parts []string
+	var words int
+	for rest := name; rest != ""; {
+		end := strings.IndexFunc(rest, isSeparator)
+		if end == 0 {
+			end = strings.IndexFunc(rest, func(r rune) bool { return !isSeparator(r) })
+		} else {
+			words++
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+		parts = append(parts, rest[:end])
+		rest = rest[end:]
+	}
+
+	marked := make([]bool, len(parts))
+	matches := 0
+	for _, term := range terms {
+		found := false
+		for i, part := range parts {
+			if !isSeparator([]rune(part)[0]) && strings.HasPrefix(foldText(part), term) {
+				found = true
+				if !marked[i] {
+					marked[i] = true
+					matches++
+				}
+			}
+		}
+		if !found {
+			return "", 0, false
+		}
+	}
+
+	var snippet strings.Builder
+	for i, part := range parts {
+		part = html.EscapeString(part)
+		if marked[i] {
+			part = "<mark>" + part + "</mark>"
+		}
+		snippet.WriteString(part)
+	}
+	return snippet.String(), float64(matches) / float64(words), true
+}
+
+type memoryCreditRepository struct{ m *Memory }
+
+func (r *memoryCreditRepository) GetByID(ctx context.Context, id int) (models.Credit, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	credit, ok := r.m.credits[id]
+	if !ok {
+		return models.Credit{}, ErrNotFound
+	}
+	return credit, nil
+}
+
+func (r *memoryCreditRepository) GetByAlbumIDs(ctx context.Context, albumIDs []int) (map[int][]models.Credit, error) {
+	return r.getByOwnerIDs(albumIDs, func(c models.Credit) *int { return c.AlbumId }), nil
+}
+
+func (r *memoryCreditRepository) GetBySongIDs(ctx context.Context, songIDs []int) (map[int][]models.Credit, error) {
+	return r.getByOwnerIDs(songIDs, func(c models.Credit) *int { return c.SongId }), nil
+}
+
+func (r *memoryCreditRepository) getByOwnerIDs(ids []int, owner func(models.Credit) *int) map[int][]models.Credit {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	result := map[int][]models.Credit{}
+	for _, credit := range sortedValues(r.m.credits) {
+		if id := owner(credit); id != nil && slices.Contains(ids, *id) {
+			result[*id] = append(result[*id], credit)
+		}
+	}
+	return result
+}
+
+func (r *memoryCreditRepository) GetByArtistID(ctx context.Context, artistID int) ([]models.Credit, error) {
+	return r.filter(func(c models.Credit) bool { return c.ArtistId != nil && *c.ArtistId == artistID }), nil
+}
+
+func (r *memoryCreditRepository) GetByBandID(ctx context.Context, bandID int) ([]models.Credit, error) {
+	return r.filter(func(c models.Credit) bool { return c.BandId != nil && *c.BandId == bandID }), nil
+}
+
+func (r *memoryCreditRepository) filter(match func(models.Credit) bool) []models.Credit {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var credits []models.Credit
+	for _, credit := range sortedValues(r.m.credits) {
+		if match(credit) {
+			credits = append(credits, credit)
+		}
+	}
+	return credits
+}
+
+func (r *memoryCreditRepository) Create(ctx context.Context, credit models.Credit) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if !exists(r.m.albums, credit.AlbumId) || !exists(r.m.songs, credit.SongId) ||
+		!exists(r.m.artists, credit.ArtistId) || !exists(r.m.bands, credit.BandId) {
+		return 0, ErrInvalidReference
+	}
+	if r.m.credited(credit) {
+		return 0, ErrConflict
+	}
+	credit.Id = r.m.nextID("credits")
+	r.m.credits[credit.Id] = credit
+	return credit.Id, nil
+}
+
+// credited reports whether the same credit, role included, already exists.
+// The caller holds the lock.
+func (m *Memory) credited(credit models.Credit) bool {
+	for _, existing := range m.credits {
+		if existing.Role == credit.Role && sameID(existing.AlbumId, credit.AlbumId) && sameID(existing.SongId, credit.SongId) &&
+			sameID(existing.ArtistId, credit.ArtistId) && sameID(existing.BandId, credit.BandId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryCreditRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.credits[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.credits, id)
+	return nil
+}
+
+// uncredit deletes the credits that match, as the foreign key cascades do.
+func (m *Memory) uncredit(match func(models.Credit) bool) {
+	maps.DeleteFunc(m.credits, func(_ int, c models.Credit) bool { return match(c) })
+}
+
+// exists reports whether an optional reference is unset or names a row.
+func exists[T any](rows map[int]T, id *int) bool {
+	if id == nil {
+		return true
+	}
+	_, ok := rows[*id]
+	return ok
+}
+
+func sameID(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+type memoryMembershipRepository struct{ m *Memory }
+
+func (r *memoryMembershipRepository) GetByID(ctx context.Context, id int) (models.BandMembership, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	membership, ok := r.m.memberships[id]
+	if !ok {
+		return models.BandMembership{}, ErrNotFound
+	}
+	return membership, nil
+}
+
+func (r *memoryMembershipRepository) GetByBandID(ctx context.Context, bandID int, at string) ([]models.BandMembership, error) {
+	return r.filter(func(m models.BandMembership) bool {
+		return m.BandId == bandID && (at == "" || m.ActiveOn(at))
+	}), nil
+}
+
+func (r *memoryMembershipRepository) GetByArtistID(ctx context.Context, artistID int) ([]models.BandMembership, error) {
+	return r.filter(func(m models.BandMembership) bool { return m.ArtistId == artistID }), nil
+}
+
+// filter returns the matching memberships in membershipOrder.
+func (r *memoryMembershipRepository) filter(match func(models.BandMembership) bool) []models.BandMembership {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var memberships []models.BandMembership
+	for _, membership := range sortedValues(r.m.memberships) {
+		if match(membership) {
+			memberships = append(memberships, membership)
+		}
+	}
+	// A missing date sorts as "", before every date.
+	joined := func(m models.BandMembership) string {
+		if m.DateJoined == nil {
+			return ""
+		}
+		return *m.DateJoined
+	}
+	slices.SortStableFunc(memberships, func(a, b models.BandMembership) int {
+		return strings.Compare(joined(a), joined(b))
+	})
+	return memberships
+}
+
+func (r *memoryMembershipRepository) Create(ctx context.Context, membership models.BandMembership) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if !exists(r.m.artists, &membership.ArtistId) || !exists(r.m.bands, &membership.BandId) {
+		return 0, ErrInvalidReference
+	}
+	membership.Id = r.m.nextID("band_memberships")
+	r.m.memberships[membership.Id] = membership
+	return membership.Id, nil
+}
+
+func (r *memoryMembershipRepository) Update(ctx context.Context, id int, membership models.BandMembership) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	existing, ok := r.m.memberships[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if !exists(r.m.artists, &membership.ArtistId) {
+		return ErrInvalidReference
+	}
+	membership.Id, membership.BandId = id, existing.BandId
+	r.m.memberships[id] = membership
+	return nil
+}
+
+func (r *memoryMembershipRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.memberships[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.memberships, id)
+	return nil
+}
+
+type memoryImportRepository struct{ m *Memory }
+
+func (r *memoryImportRepository) Import(ctx context.Context, batch models.CatalogImport) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	artistIDs := make([]int, len(batch.Artists))
+	for i, artist := range batch.Artists {
+		artist.Id = r.m.nextID("artists")
+		r.m.artists[artist.Id] = artist
+		artistIDs[i] = artist.Id
+	}
+	bandIDs := make([]int, len(batch.Bands))
+	for i, band := range batch.Bands {
+		band.Id = r.m.nextID("bands")
+		r.m.bands[band.Id] = band
+		bandIDs[i] = band.Id
+	}
+	credit := func(albumID, songID *int, artist, band *int) {
+		if artist != nil {
+			id, artistID := r.m.nextID("credits"), artistIDs[*artist]
+			r.m.credits[id] = models.Credit{Id: id, AlbumId: albumID, SongId: songID, ArtistId: &artistID, Role: models.RolePerformer}
+		}
+		if band != nil {
+			id, bandID := r.m.nextID("credits"), bandIDs[*band]
+			r.m.credits[id] = models.Credit{Id: id, AlbumId: albumID, SongId: songID, BandId: &bandID, Role: models.RolePerformer}
+		}
+	}
+
+	albumIDs := make([]int, len(batch.Albums))
+	for i, imported := range batch.Albums {
+		album := imported.Album
+		album.Id = r.m.nextID("albums")
+		r.m.albums[album.Id] = album
+		albumIDs[i] = album.Id
+		credit(&album.Id, nil, imported.Artist, imported.Band)
+	}
+	for _, imported := range batch.Songs {
+		song := imported.Song
+		song.Id = r.m.nextID("songs")
+		r.m.songs[song.Id] = song
+		if imported.Album != nil {
+			r.m.appendTrack(albumIDs[*imported.Album], song.Id)
+		}
+		credit(nil, &song.Id, imported.Artist, imported.Band)
+	}
+	return nil
+}
+
+type memoryExportRepository struct{ m *Memory }
+
+func (r *memoryExportRepository) Export(ctx context.Context, fn func(models.ExportRecord) error) error {
+	for _, record := range r.snapshot() {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot copies every record under the lock, so fn may use the store.
+func (r *memoryExportRepository) snapshot() []models.ExportRecord {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	var records []models.ExportRecord
+	add := func(kind string, values ...any) {
+		for i, value := range values {
+			switch v := value.(type) {
+			case *int:
+				values[i] = nullableInt(v)
+			case *string:
+				if v == nil {
+					values[i] = nil
+				} else {
+					values[i] = *v
+				}
+			}
+		}
+		records = append(records, models.ExportRecord{Type: kind, Values: values})
+	}
+	// links sorts join table rows, which have no ID, by their keys.
+	links := func(set map[genreLink]bool) []genreLink {
+		sorted := make([]genreLink, 0, len(set))
+		for link := range set {
+			sorted = append(sorted, link)
+		}
+		slices.SortFunc(sorted, func(a, b genreLink) int {
+			if a.OwnerID != b.OwnerID {
+				return a.OwnerID - b.OwnerID
+			}
+			return a.GenreID - b.GenreID
+		})
+		return sorted
+	}
+
+	for _, a := range sortedValues(r.m.artists) {
+		add("artist", a.Id, a.FirstName, a.LastName, a.Nationality, a.BirthDate, a.DeathDate, a.SexId, a.TitleId)
+	}
+	for _, b := range sortedValues(r.m.bands) {
+		add("band", b.Id, b.Name, b.Nationality, b.DateFormed, b.DisbandedDate)
+	}
+	for _, m := range sortedValues(r.m.memberships) {
+		add("membership", m.Id, m.ArtistId, m.BandId, m.Role, m.DateJoined, m.DateLeft)
+	}
+	for _, g := range sortedValues(r.m.genres) {
+		add("genre", g.Id, g.Name, g.ParentId)
+	}
+	for _, l := range sortedValues(r.m.labels) {
+		add("label", l.Id, l.Name, l.Country)
+	}
+	for _, a := range sortedValues(r.m.albums) {
+		add("album", a.Id, a.Title, a.Price, a.LabelId, a.ReleaseDate, a.CatalogNumber, a.Barcode, a.Format)
+	}
+	for _, s := range sortedValues(r.m.songs) {
+		add("song", s.Id, s.Title, s.Length, s.Price)
+	}
+
+	tracks := make([]songLink, 0, len(r.m.albumSongs))
+	for link := range r.m.albumSongs {
+		tracks = append(tracks, link)
+	}
+	slices.SortFunc(tracks, func(a, b songLink) int {
+		if a.OwnerID != b.OwnerID {
+			return a.OwnerID - b.OwnerID
+		}
+		return r.m.albumSongs[a] - r.m.albumSongs[b]
+	})
+	for _, link := range tracks {
+		add("track", link.OwnerID, link.SongID, r.m.albumSongs[link])
+	}
+
+	for _, c := range sortedValues(r.m.credits) {
+		add("credit", c.Id, c.AlbumId, c.SongId, c.ArtistId, c.BandId, c.Role)
+	}
+	for _, link := range links(r.m.albumGenres) {
+		add("album_genre", link.OwnerID, link.GenreID)
+	}
+	for _, link := range links(r.m.songGenres) {
+		add("song_genre", link.OwnerID, link.GenreID)
+	}
+
+	tags := make([]tagLink, 0, len(r.m.tagLinks))
+	for link := range r.m.tagLinks {
+		tags = append(tags, link)
+	}
+	order := []string{models.TagAlbum, models.TagSong, models.TagArtist, models.TagBand}
+	slices.SortFunc(tags, func(a, b tagLink) int {
+		if a.EntityType != b.EntityType {
+			return slices.Index(order, a.EntityType) - slices.Index(order, b.EntityType)
+		}
+		if a.ID != b.ID {
+			return a.ID - b.ID
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, link := range tags {
+		add("tag", link.EntityType, link.ID, link.Name)
+	}
+
+	return records
+}
+
+type memoryReviewRepository struct{ m *Memory }
+
+// withUsername fills in the author's name, as the SQLite join does. The
+// caller must hold the lock.
+func (r *memoryReviewRepository) withUsername(review models.Review) models.Review {
+	review.Username = r.m.users[review.UserId].Username
+	return review
+}
+
+func (r *memoryReviewRepository) GetByID(ctx context.Context, id int) (models.Review, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	review, ok := r.m.reviews[id]
+	if !ok {
+		return models.Review{}, ErrNotFound
+	}
+	return r.withUsername(review), nil
+}
+
+func (r *memoryReviewRepository) ListByAlbumID(ctx context.Context, albumID int, q query.Query) (query.Page[models.Review], error) {
+	q.Filters = append(q.Filters, query.Filter{Field: "album_id", Op: query.Eq, Value: albumID})
+	return r.list(q), nil
+}
+
+func (r *memoryReviewRepository) ListBySongID(ctx context.Context, songID int, q query.Query) (query.Page[models.Review], error) {
+	q.Filters = append(q.Filters, query.Filter{Field: "song_id", Op: query.Eq, Value: songID})
+	return r.list(q), nil
+}
+
+func (r *memoryReviewRepository) list(q query.Query) query.Page[models.Review] {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var reviews []models.Review
+	for _, review := range sortedValues(r.m.reviews) {
+		reviews = append(reviews, r.withUsername(review))
+	}
+	return ReviewQuery.Apply(reviews, q)
+}
+
+func (r *memoryReviewRepository) Create(ctx context.Context, review models.Review) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.users[review.UserId]; !ok || !exists(r.m.albums, review.AlbumId) || !exists(r.m.songs, review.SongId) {
+		return 0, ErrInvalidReference
+	}
+	for _, other := range r.m.reviews {
+		if other.UserId == review.UserId && nullableInt(other.AlbumId) == nullableInt(review.AlbumId) &&
+			nullableInt(other.SongId) == nullableInt(review.SongId) {
+			return 0, ErrConflict
+		}
+	}
+
+	review.Id = r.m.nextID("reviews")
+	review.CreatedAt = time.Unix(time.Now().Unix(), 0)
+	review.UpdatedAt = review.CreatedAt
+	r.m.reviews[review.Id] = review
+	r.rate(review, 1)
+	return review.Id, nil
+}
+
+func (r *memoryReviewRepository) Update(ctx context.Context, id int, review models.Review) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	existing, ok := r.m.reviews[id]
+	if !ok {
+		return ErrNotFound
+	}
+	r.rate(existing, -1)
+	existing.Rating, existing.Body = review.Rating, review.Body
+	existing.UpdatedAt = time.Unix(time.Now().Unix(), 0)
+	r.m.reviews[id] = existing
+	r.rate(existing, 1)
+	return nil
+}
+
+func (r *memoryReviewRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	review, ok := r.m.reviews[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.m.reviews, id)
+	r.rate(review, -1)
+	return nil
+}
+
+// rate adds a review to, or with sign -1 removes it from, its album's or
+// song's rating totals.
+func (r *memoryReviewRepository) rate(review models.Review, sign int) {
+	totals, id := r.m.songRatings, review.SongId
+	if review.AlbumId != nil {
+		totals, id = r.m.albumRatings, review.AlbumId
+	}
+	rating := totals[*id]
+	rating.Count += sign
+	rating.Sum += sign * review.Rating
+	totals[*id] = rating
+}
+
+func (r *memoryReviewRepository) GetAlbumRatings(ctx context.Context, albumIDs []int) (map[int]models.Rating, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return ratingsOf(r.m.albums, r.m.albumRatings, albumIDs), nil
+}
+
+func (r *memoryReviewRepository) GetSongRatings(ctx context.Context, songIDs []int) (map[int]models.Rating, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return ratingsOf(r.m.songs, r.m.songRatings, songIDs), nil
+}
+
+// ratingsOf returns the totals of the given rows that exist, zero for those
+// never rated.
+func ratingsOf[T any](rows map[int]T, totals map[int]models.Rating, ids []int) map[int]models.Rating {
+	result := map[int]models.Rating{}
+	for _, id := range ids {
+		if _, ok := rows[id]; ok {
+			result[id] = totals[id]
+		}
+	}
+	return result
+}
+
+type memoryPlayRepository struct{ m *Memory }
+
+func (r *memoryPlayRepository) Record(ctx context.Context, plays []models.Play) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, play := range plays {
+		if !exists(r.m.songs, play.SongId) {
+			return 0, ErrInvalidReference
+		}
+	}
+
+	recorded := 0
+	for _, play := range plays {
+		if play.ClientId != nil && r.hasClientID(play.UserId, *play.ClientId) {
+			continue
+		}
+		play.Id = r.m.nextID("plays")
+		play.PlayedAt = time.Unix(play.PlayedAt.Unix(), 0)
+		r.m.plays[play.Id] = play
+		recorded++
+	}
+	return recorded, nil
+}
+
+// hasClientID reports whether the user already submitted a play with the
+// client ID. The caller must hold the lock.
+func (r *memoryPlayRepository) hasClientID(userID int, clientID string) bool {
+	for _, play := range r.m.plays {
+		if play.UserId == userID && play.ClientId != nil && *play.ClientId == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// since returns the user's plays at or after since, in ID order. The caller
+// must hold the lock.
+func (r *memoryPlayRepository) since(userID int, since time.Time) []models.Play {
+	var plays []models.Play
+	for _, play := range sortedValues(r.m.plays) {
+		if play.UserId == userID && play.PlayedAt.Unix() >= since.Unix() {
+			plays = append(plays, play)
+		}
+	}
+	return plays
+}
+
+func (r *memoryPlayRepository) TopSongs(ctx context.Context, userID int, since time.Time, limit int) ([]models.SongPlays, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	counts := map[int]*models.SongPlays{}
+	for _, play := range r.since(userID, since) {
+		if play.SongId == nil {
+			continue
+		}
+		if counts[*play.SongId] == nil {
+			counts[*play.SongId] = &models.SongPlays{SongId: *play.SongId}
+		}
+		counts[*play.SongId].Plays++
+		counts[*play.SongId].Seconds += play.Duration
+	}
+
+	var songs []models.SongPlays
+	for _, song := range counts {
+		songs = append(songs, *song)
+	}
+	slices.SortFunc(songs, func(a, b models.SongPlays) int {
+		return cmp.Or(cmp.Compare(b.Plays, a.Plays), cmp.Compare(b.Seconds, a.Seconds), cmp.Compare(a.SongId, b.SongId))
+	})
+	return songs[:min(limit, len(songs))], nil
+}
+
+func (r *memoryPlayRepository) TopPerformers(ctx context.Context, userID int, since time.Time, limit int) ([]models.PerformerPlays, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	type performer struct{ artistID, bandID int }
+	counts := map[performer]*models.PerformerPlays{}
+	for _, play := range r.since(userID, since) {
+		for _, credit := range r.m.credits {
+			if play.SongId == nil || credit.SongId == nil || *credit.SongId != *play.SongId || credit.Role != models.RolePerformer {
+				continue
+			}
+			key := performer{artistID: orZero(credit.ArtistId), bandID: orZero(credit.BandId)}
+			if counts[key] == nil {
+				counts[key] = &models.PerformerPlays{ArtistId: credit.ArtistId, BandId: credit.BandId}
+			}
+			counts[key].Plays++
+			counts[key].Seconds += play.Duration
+		}
+	}
+
+	var performers []models.PerformerPlays
+	for _, counted := range counts {
+		performers = append(performers, *counted)
+	}
+	// Artists sort before bands on a tie, as in SQLite.
+	slices.SortFunc(performers, func(a, b models.PerformerPlays) int {
+		return cmp.Or(cmp.Compare(b.Plays, a.Plays), cmp.Compare(b.Seconds, a.Seconds),
+			cmp.Compare(orZero(a.BandId), orZero(b.BandId)), cmp.Compare(orZero(a.ArtistId), orZero(b.ArtistId)))
+	})
+	return performers[:min(limit, len(performers))], nil
+}
+
+// orZero dereferences an optional ID, treating nil as 0.
+func orZero(id *int) int {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+func (r *memoryPlayRepository) ListeningTime(ctx context.Context, userID int, since time.Time) ([]models.ListeningDay, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	totals := map[string]*models.ListeningDay{}
+	for _, play := range r.since(userID, since) {
+		date := play.PlayedAt.UTC().Format(time.DateOnly)
+		if totals[date] == nil {
+			totals[date] = &models.ListeningDay{Date: date}
+		}
+		totals[date].Plays++
+		totals[date].Seconds += play.Duration
+	}
+
+	var days []models.ListeningDay
+	for _, day := range totals {
+		days = append(days, *day)
+	}
+	slices.SortFunc(days, func(a, b models.ListeningDay) int { return strings.Compare(a.Date, b.Date) })
+	return days, nil
+}
+
+type memoryRecommendationRepository struct{ m *Memory }
+
+// listens counts each user's plays of every song they played or own, keyed
+// by user and then song ID. The caller must hold the lock.
+func (r *memoryRecommendationRepository) listens() map[int]map[int]int {
+	listens := map[int]map[int]int{}
+	add := func(userID, songID, plays int) {
+		if listens[userID] == nil {
+			listens[userID] = map[int]int{}
+		}
+		listens[userID][songID] += plays
+	}
+	for _, play := range r.m.plays {
+		if play.SongId != nil {
+			add(play.UserId, *play.SongId, 1)
+		}
+	}
+	for owned := range r.m.ownedSongs {
+		add(owned.UserID, owned.ItemID, 0)
+	}
+	return listens
+}
+
+func (r *memoryRecommendationRepository) Listeners(ctx context.Context) (map[int][]int, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	listeners := map[int][]int{}
+	for userID, songs := range r.listens() {
+		ids := slices.Collect(maps.Keys(songs))
+		slices.SortFunc(ids, func(a, b int) int { return cmp.Or(cmp.Compare(songs[b], songs[a]), cmp.Compare(a, b)) })
+		listeners[userID] = ids
+	}
+	return listeners, nil
+}
+
+func (r *memoryRecommendationRepository) Listened(ctx context.Context, userID int) (map[int]int, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	listened := r.listens()[userID]
+	if listened == nil {
+		listened = map[int]int{}
+	}
+	return listened, nil
+}
+
+func (r *memoryRecommendationRepository) ReplaceSimilarities(ctx context.Context, similar map[int][]models.SimilarSong) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.similarSongs = map[int][]models.SimilarSong{}
+	for songID, neighbours := range similar {
+		if _, ok := r.m.songs[songID]; !ok {
+			continue
+		}
+		kept := slices.DeleteFunc(slices.Clone(neighbours), func(s models.SimilarSong) bool {
+			_, ok := r.m.songs[s.SongId]
+			return !ok
+		})
+		slices.SortFunc(kept, func(a, b models.SimilarSong) int {
+			return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.SongId, b.SongId))
+		})
+		if len(kept) > 0 {
+			r.m.similarSongs[songID] = kept
+		}
+	}
+	return nil
+}
+
+func (r *memoryRecommendationRepository) GetSimilar(ctx context.Context, songIDs []int) (map[int][]models.SimilarSong, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	result := map[int][]models.SimilarSong{}
+	for _, songID := range songIDs {
+		if similar, ok := r.m.similarSongs[songID]; ok {
+			result[songID] = slices.Clone(similar)
+		}
+	}
+	return result, nil
+}
+
+type memoryChartRepository struct{ m *Memory }
+
+func (r *memoryChartRepository) Events(ctx context.Context, kind string, from, to time.Time) ([]models.ChartEvent, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var events []models.ChartEvent
+	add := func(itemID *int, eventType string, at time.Time, rating int) {
+		if itemID != nil && !at.Before(from) && at.Before(to) {
+			events = append(events, models.ChartEvent{ItemId: *itemID, Type: eventType, At: at.UTC(), Rating: rating})
+		}
+	}
+
+	for _, play := range r.m.plays {
+		if kind == models.ChartSongs {
+			add(play.SongId, models.EventPlay, play.PlayedAt, 0)
+			continue
+		}
+		for link := range r.m.albumSongs {
+			if play.SongId != nil && link.SongID == *play.SongId {
+				add(&link.OwnerID, models.EventPlay, play.PlayedAt, 0)
+			}
+		}
+	}
+	for _, order := range r.m.orders {
+		for _, item := range order.Items {
+			if kind == models.ChartSongs {
+				add(item.SongId, models.EventPurchase, order.CreatedAt, 0)
+			} else {
+				add(item.AlbumId, models.EventPurchase, order.CreatedAt, 0)
+			}
+		}
+	}
+	for _, review := range r.m.reviews {
+		if kind == models.ChartSongs {
+			add(review.SongId, models.EventRating, review.UpdatedAt, review.Rating)
+		} else {
+			add(review.AlbumId, models.EventRating, review.UpdatedAt, review.Rating)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b models.ChartEvent) int {
+		return cmp.Or(a.At.Compare(b.At), cmp.Compare(a.ItemId, b.ItemId), strings.Compare(a.Type, b.Type))
+	})
+	return events, nil
+}
+
+func (r *memoryChartRepository) GetChart(ctx context.Context, kind, period, date string) (models.Chart, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	chart, ok := r.m.charts[chartKey{kind, period, date}]
+	if !ok {
+		return models.Chart{}, ErrNotFound
+	}
+	chart.Entries = slices.Clone(chart.Entries)
+	return chart, nil
+}
+
+func (r *memoryChartRepository) SaveChart(ctx context.Context, chart models.Chart) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := chartKey{chart.Kind, chart.Period, chart.Date}
+	if _, ok := r.m.charts[key]; ok {
+		return ErrConflict
+	}
+	if chart.Entries == nil {
+		chart.Entries = []models.ChartEntry{}
+	}
+	chart.Entries = slices.Clone(chart.Entries)
+	r.m.charts[key] = chart
+	return nil
+}
+
+type memoryLabelRepository struct{ m *Memory }
+
+func (r *memoryLabelRepository) GetByID(ctx context.Context, id int) (models.Label, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	label, ok := r.m.labels[id]
+	if !ok {
+		return models.Label{}, ErrNotFound
+	}
+	return label, nil
+}
+
+func (r *memoryLabelRepository) List(ctx context.Context, q query.Query) (query.Page[models.Label], error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return LabelQuery.Apply(sortedValues(r.m.labels), q), nil
+}
+
+func (r *memoryLabelRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Label, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return byIDs(r.m.labels, ids), nil
+}
+
+func (r *memoryLabelRepository) Create(ctx context.Context, label models.Label) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	label.Id = r.m.nextID("labels")
+	r.m.labels[label.Id] = label
+	return label.Id, nil
+}
+
+func (r *memoryLabelRepository) Update(ctx context.Context, id int, label models.Label) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.labels[id]; !ok {
+		return ErrNotFound
+	}
+	label.Id = id
+	r.m.labels[id] = label
+	return nil
+}
+
+func (r *memoryLabelRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.labels[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.m.labels, id)
+	for albumID, album := range r.m.albums {
+		if album.LabelId != nil && *album.LabelId == id {
+			album.LabelId = nil
+			r.m.albums[albumID] = album
+		}
+	}
+	return nil
+}
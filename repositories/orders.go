@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"slices"
+	"time"
+)
+
+type OrderRepository interface {
+	GetCart(ctx context.Context, userID int) ([]models.CartItem, error)
+	// AddToCart returns ErrConflict when the item is already in the cart.
+	AddToCart(ctx context.Context, item models.CartItem) (int, error)
+	RemoveFromCart(ctx context.Context, userID, id int) error
+	// CreateOrder stores the order, gives the user its albums and songs plus
+	// the extra songIDs, and removes cartItemIDs from the cart, all in one
+	// transaction. It returns ErrConflict if any of the cart items are gone,
+	// which means the cart was checked out concurrently.
+	CreateOrder(ctx context.Context, order models.Order, songIDs, cartItemIDs []int) (int, error)
+	// GetOrders returns the user's orders with their items, newest first.
+	GetOrders(ctx context.Context, userID int) ([]models.Order, error)
+	GetOrderByID(ctx context.Context, id int) (models.Order, error)
+	GetLibrary(ctx context.Context, userID int) (models.Library, error)
+}
+
+type SQLiteOrderRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteOrderRepository(db *sql.DB) *SQLiteOrderRepository {
+	return &SQLiteOrderRepository{db: db}
+}
+
+func (r *SQLiteOrderRepository) GetCart(ctx context.Context, userID int) ([]models.CartItem, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, album_id, song_id FROM cart_items WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.CartItem
+	for rows.Next() {
+		var item models.CartItem
+		if err := rows.Scan(&item.Id, &item.UserId, &item.AlbumId, &item.SongId); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *SQLiteOrderRepository) AddToCart(ctx context.Context, item models.CartItem) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO cart_items (user_id, album_id, song_id) VALUES (?, ?, ?)", item.UserId, item.AlbumId, item.SongId)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteOrderRepository) RemoveFromCart(ctx context.Context, userID, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM cart_items WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteOrderRepository) CreateOrder(ctx context.Context, order models.Order, songIDs, cartItemIDs []int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, id := range cartItemIDs {
+		result, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE id = ? AND user_id = ?", id, order.UserId)
+		if err != nil {
+			return 0, err
+		}
+		if err := requireAffected(result); err != nil {
+			return 0, ErrConflict
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO orders (user_id, total, created_at) VALUES (?, ?, ?)",
+		order.UserId, order.Total, order.CreatedAt.Unix())
+	if err != nil {
+		return 0, translateError(err)
+	}
+	orderID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range order.Items {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO order_items (order_id, item_type, album_id, song_id, title, price) VALUES (?, ?, ?, ?, ?, ?)",
+			orderID, item.Type, item.AlbumId, item.SongId, item.Title, item.Price,
+		); err != nil {
+			return 0, translateError(err)
+		}
+
+		if item.AlbumId != nil {
+			if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO owned_albums (user_id, album_id, order_id) VALUES (?, ?, ?)",
+				order.UserId, *item.AlbumId, orderID,
+			); err != nil {
+				return 0, translateError(err)
+			}
+		}
+	}
+
+	for _, songID := range ownedSongs(order, songIDs) {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO owned_songs (user_id, song_id, order_id) VALUES (?, ?, ?)",
+			order.UserId, songID, orderID,
+		); err != nil {
+			return 0, translateError(err)
+		}
+	}
+
+	return int(orderID), tx.Commit()
+}
+
+func (r *SQLiteOrderRepository) GetOrders(ctx context.Context, userID int) ([]models.Order, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, user_id, total, created_at FROM orders WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	return orders, r.loadItems(ctx, orders)
+}
+
+func (r *SQLiteOrderRepository) GetOrderByID(ctx context.Context, id int) (models.Order, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, total, created_at FROM orders WHERE id = ?", id)
+	if err != nil {
+		return models.Order{}, err
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(orders) == 0 {
+		return models.Order{}, ErrNotFound
+	}
+	if err := r.loadItems(ctx, orders); err != nil {
+		return models.Order{}, err
+	}
+	return orders[0], nil
+}
+
+// loadItems fills in the items of a batch of orders.
+func (r *SQLiteOrderRepository) loadItems(ctx context.Context, orders []models.Order) error {
+	index := map[int]int{}
+	ids := make([]int, 0, len(orders))
+	for i, order := range orders {
+		index[order.Id] = i
+		ids = append(ids, order.Id)
+	}
+
+	return inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT order_id, item_type, album_id, song_id, title, price FROM order_items WHERE order_id IN ("+placeholders+") ORDER BY id",
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var orderID int
+			var item models.OrderItem
+			if err := rows.Scan(&orderID, &item.Type, &item.AlbumId, &item.SongId, &item.Title, &item.Price); err != nil {
+				return err
+			}
+			order := &orders[index[orderID]]
+			order.Items = append(order.Items, item)
+		}
+		return rows.Err()
+	})
+}
+
+func (r *SQLiteOrderRepository) GetLibrary(ctx context.Context, userID int) (models.Library, error) {
+	var library models.Library
+	var err error
+	if library.AlbumIds, err = r.ownedIDs(ctx, "SELECT album_id FROM owned_albums WHERE user_id = ? ORDER BY album_id", userID); err != nil {
+		return models.Library{}, err
+	}
+	if library.SongIds, err = r.ownedIDs(ctx, "SELECT song_id FROM owned_songs WHERE user_id = ? ORDER BY song_id", userID); err != nil {
+		return models.Library{}, err
+	}
+	return library, nil
+}
+
+func (r *SQLiteOrderRepository) ownedIDs(ctx context.Context, query string, userID int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ownedSongs lists the songs an order grants: the songs bought on their own
+// and the extra ones from its albums.
+func ownedSongs(order models.Order, songIDs []int) []int {
+	owned := slices.Clone(songIDs)
+	for _, item := range order.Items {
+		if item.SongId != nil {
+			owned = append(owned, *item.SongId)
+		}
+	}
+	return owned
+}
+
+func scanOrders(rows *sql.Rows) ([]models.Order, error) {
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		var createdAt int64
+		if err := rows.Scan(&order.Id, &order.UserId, &order.Total, &createdAt); err != nil {
+			return nil, err
+		}
+		order.CreatedAt = time.Unix(createdAt, 0)
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,89 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrders(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			userID, err := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			albumID, err := store.Albums.Create(ctx, models.Album{Title: "Parachutes", Price: 999})
+			if err != nil {
+				t.Fatal(err)
+			}
+			yellow, _ := store.Songs.Create(ctx, models.Song{Title: "Yellow", Length: 266, Price: 129})
+			single, _ := store.Songs.Create(ctx, models.Song{Title: "Single", Length: 200, Price: 99})
+
+			albumItem, err := store.Orders.AddToCart(ctx, models.CartItem{UserId: userID, AlbumId: &albumID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			songItem, err := store.Orders.AddToCart(ctx, models.CartItem{UserId: userID, SongId: &single})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Orders.AddToCart(ctx, models.CartItem{UserId: userID, SongId: &single}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("adding twice: expected ErrConflict, got %v", err)
+			}
+
+			order := models.Order{UserId: userID, Total: 1098, CreatedAt: time.Unix(1700000000, 0), Items: []models.OrderItem{
+				{Type: models.ItemAlbum, AlbumId: &albumID, Title: "Parachutes", Price: 999},
+				{Type: models.ItemSong, SongId: &single, Title: "Single", Price: 99},
+			}}
+			orderID, err := store.Orders.CreateOrder(ctx, order, []int{yellow}, []int{albumItem, songItem})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cart, _ := store.Orders.GetCart(ctx, userID); len(cart) != 0 {
+				t.Errorf("cart was not emptied: %+v", cart)
+			}
+			if _, err := store.Orders.CreateOrder(ctx, order, nil, []int{albumItem}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("checking out a stale cart: expected ErrConflict, got %v", err)
+			}
+
+			library, err := store.Orders.GetLibrary(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(library.AlbumIds, []int{albumID}) || !slices.Equal(library.SongIds, []int{yellow, single}) {
+				t.Errorf("wrong library: %+v", library)
+			}
+
+			if err := store.Albums.Delete(ctx, albumID); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := store.Orders.GetOrderByID(ctx, orderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Total != 1098 || !saved.CreatedAt.Equal(order.CreatedAt) || len(saved.Items) != 2 {
+				t.Fatalf("wrong order: %+v", saved)
+			}
+			if item := saved.Items[0]; item.Type != models.ItemAlbum || item.Title != "Parachutes" || item.Price != 999 {
+				t.Errorf("wrong album line: %+v", item)
+			}
+			if orders, _ := store.Orders.GetOrders(ctx, userID); len(orders) != 1 || orders[0].Id != orderID {
+				t.Errorf("wrong order history: %+v", orders)
+			}
+			if library, _ := store.Orders.GetLibrary(ctx, userID); len(library.AlbumIds) != 0 {
+				t.Errorf("deleted album is still owned: %+v", library)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type PlaylistRepository interface {
+	GetByID(ctx context.Context, id int) (models.Playlist, error)
+	// GetByUserID lists the playlists the user owns or has accepted an
+	// invitation to.
+	GetByUserID(ctx context.Context, userID int) ([]models.Playlist, error)
+	// GetInvitations lists the playlists the user has been invited to but
+	// has not accepted yet.
+	GetInvitations(ctx context.Context, userID int) ([]models.Playlist, error)
+	Create(ctx context.Context, playlist models.Playlist) (int, error)
+	Update(ctx context.Context, id int, playlist models.Playlist) error
+	Delete(ctx context.Context, id int) error
+
+	// GetTracks returns the tracks of each playlist in position order.
+	GetTracks(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistTrack, error)
+	// AddTrack inserts the song at position, moving later tracks down, and
+	// returns the position used. Positions below 1 or past the end append.
+	AddTrack(ctx context.Context, playlistID, songID, position int) (int, error)
+	// RemoveTrack removes the track at position and closes the gap.
+	RemoveTrack(ctx context.Context, playlistID, position int) error
+	// MoveTrack moves the track at from to position to, shifting the tracks
+	// in between. Both must be existing positions.
+	MoveTrack(ctx context.Context, playlistID, from, to int) error
+	// ReorderTracks rearranges every track: order lists the current
+	// positions in their new order. It returns ErrConflict when order does
+	// not cover the playlist's tracks exactly.
+	ReorderTracks(ctx context.Context, playlistID int, order []int) error
+
+	// GetCollaborators returns the invited users of each playlist, accepted
+	// or not, in the order they were invited.
+	GetCollaborators(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistCollaborator, error)
+	InviteCollaborator(ctx context.Context, playlistID, userID int) error
+	// AcceptInvitation returns ErrNotFound when the user was not invited.
+	AcceptInvitation(ctx context.Context, playlistID, userID int) error
+	RemoveCollaborator(ctx context.Context, playlistID, userID int) error
+}
+
+const playlistColumns = "id, user_id, name, description, visibility"
+
+type SQLitePlaylistRepository struct {
+	db *sql.DB
+}
+
+func NewSQLitePlaylistRepository(db *sql.DB) *SQLitePlaylistRepository {
+	return &SQLitePlaylistRepository{db: db}
+}
+
+func (r *SQLitePlaylistRepository) GetByID(ctx context.Context, id int) (models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.QueryRowContext(ctx, "SELECT "+playlistColumns+" FROM playlists WHERE id = ?", id).
+		Scan(&playlist.Id, &playlist.UserId, &playlist.Name, &playlist.Description, &playlist.Visibility)
+	if err != nil {
+		return models.Playlist{}, translateError(err)
+	}
+	return playlist, nil
+}
+
+func (r *SQLitePlaylistRepository) GetByUserID(ctx context.Context, userID int) ([]models.Playlist, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+playlistColumns+` FROM playlists
+		WHERE user_id = ? OR id IN (SELECT playlist_id FROM playlist_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL)
+		ORDER BY id`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPlaylists(rows)
+}
+
+func (r *SQLitePlaylistRepository) GetInvitations(ctx context.Context, userID int) ([]models.Playlist, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+playlistColumns+` FROM playlists
+		WHERE id IN (SELECT playlist_id FROM playlist_collaborators WHERE user_id = ? AND accepted_at IS NULL)
+		ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPlaylists(rows)
+}
+
+func (r *SQLitePlaylistRepository) Create(ctx context.Context, playlist models.Playlist) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO playlists (user_id, name, description, visibility) VALUES (?, ?, ?, ?)",
+		playlist.UserId, playlist.Name, playlist.Description, playlist.Visibility)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// Update changes the name, description and visibility. The owner cannot be
+// changed.
+func (r *SQLitePlaylistRepository) Update(ctx context.Context, id int, playlist models.Playlist) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE playlists SET name = ?, description = ?, visibility = ? WHERE id = ?",
+		playlist.Name, playlist.Description, playlist.Visibility, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLitePlaylistRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM playlists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLitePlaylistRepository) GetTracks(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistTrack, error) {
+	tracks := map[int][]models.PlaylistTrack{}
+	err := inBatches(playlistIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT playlist_id, song_id, position FROM playlist_songs WHERE playlist_id IN ("+placeholders+") ORDER BY playlist_id, position",
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track models.PlaylistTrack
+			if err := rows.Scan(&track.PlaylistId, &track.SongId, &track.Position); err != nil {
+				return err
+			}
+			tracks[track.PlaylistId] = append(tracks[track.PlaylistId], track)
+		}
+		return rows.Err()
+	})
+	return tracks, err
+}
+
+func (r *SQLitePlaylistRepository) AddTrack(ctx context.Context, playlistID, songID, position int) (int, error) {
+	var added int
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		count, err := countTracks(ctx, tx, playlistID)
+		if err != nil {
+			return err
+		}
+		if position < 1 || position > count {
+			position = count + 1
+		} else if _, err := tx.ExecContext(ctx,
+			"UPDATE playlist_songs SET position = position + 1 WHERE playlist_id = ? AND position >= ?", playlistID, position,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO playlist_songs (playlist_id, song_id, position) VALUES (?, ?, ?)", playlistID, songID, position,
+		); err != nil {
+			return translateError(err)
+		}
+		added = position
+		return nil
+	})
+	return added, err
+}
+
+func (r *SQLitePlaylistRepository) RemoveTrack(ctx context.Context, playlistID, position int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM playlist_songs WHERE playlist_id = ? AND position = ?", playlistID, position)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLitePlaylistRepository) MoveTrack(ctx context.Context, playlistID, from, to int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		count, err := countTracks(ctx, tx, playlistID)
+		if err != nil {
+			return err
+		}
+		if from < 1 || from > count || to < 1 || to > count {
+			return ErrNotFound
+		}
+		if from == to {
+			return nil
+		}
+
+		var id int
+		if err := tx.QueryRowContext(ctx,
+			"SELECT id FROM playlist_songs WHERE playlist_id = ? AND position = ?", playlistID, from,
+		).Scan(&id); err != nil {
+			return translateError(err)
+		}
+
+		shift := "UPDATE playlist_songs SET position = position - 1 WHERE playlist_id = ? AND position > ? AND position <= ?"
+		low, high := from, to
+		if to < from {
+			shift = "UPDATE playlist_songs SET position = position + 1 WHERE playlist_id = ? AND position >= ? AND position < ?"
+			low, high = to, from
+		}
+		if _, err := tx.ExecContext(ctx, shift, playlistID, low, high); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE playlist_songs SET position = ? WHERE id = ?", to, id)
+		return err
+	})
+}
+
+func (r *SQLitePlaylistRepository) ReorderTracks(ctx context.Context, playlistID int, order []int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM playlist_songs WHERE playlist_id = ? ORDER BY position", playlistID)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if !isPermutation(order, len(ids)) {
+			return ErrConflict
+		}
+		for i, from := range order {
+			if _, err := tx.ExecContext(ctx, "UPDATE playlist_songs SET position = ? WHERE id = ?", i+1, ids[from-1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLitePlaylistRepository) GetCollaborators(ctx context.Context, playlistIDs []int) (map[int][]models.PlaylistCollaborator, error) {
+	collaborators := map[int][]models.PlaylistCollaborator{}
+	err := inBatches(playlistIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT c.playlist_id, c.user_id, u.username, c.accepted_at IS NOT NULL
+			FROM playlist_collaborators c JOIN users u ON u.id = c.user_id
+			WHERE c.playlist_id IN (`+placeholders+`) ORDER BY c.playlist_id, c.rowid`,
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c models.PlaylistCollaborator
+			if err := rows.Scan(&c.PlaylistId, &c.UserId, &c.Username, &c.Accepted); err != nil {
+				return err
+			}
+			collaborators[c.PlaylistId] = append(collaborators[c.PlaylistId], c)
+		}
+		return rows.Err()
+	})
+	return collaborators, err
+}
+
+func (r *SQLitePlaylistRepository) InviteCollaborator(ctx context.Context, playlistID, userID int) error {
+	_, err := execInTx(ctx, r.db, "INSERT INTO playlist_collaborators (playlist_id, user_id) VALUES (?, ?)", playlistID, userID)
+	return err
+}
+
+func (r *SQLitePlaylistRepository) AcceptInvitation(ctx context.Context, playlistID, userID int) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE playlist_collaborators SET accepted_at = COALESCE(accepted_at, ?) WHERE playlist_id = ? AND user_id = ?",
+		time.Now().Unix(), playlistID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLitePlaylistRepository) RemoveCollaborator(ctx context.Context, playlistID, userID int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM playlist_collaborators WHERE playlist_id = ? AND user_id = ?", playlistID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// inTx runs fn in a transaction with the same timeout as execInTx, committing
+// only when fn succeeds.
+func (r *SQLitePlaylistRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func countTracks(ctx context.Context, tx *sql.Tx, playlistID int) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM playlist_songs WHERE playlist_id = ?", playlistID).Scan(&count)
+	return count, err
+}
+
+// isPermutation reports whether order holds each position from 1 to n once.
+func isPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+	seen := make([]bool, n+1)
+	for _, position := range order {
+		if position < 1 || position > n || seen[position] {
+			return false
+		}
+		seen[position] = true
+	}
+	return true
+}
+
+func scanPlaylists(rows *sql.Rows) ([]models.Playlist, error) {
+	defer rows.Close()
+
+	var playlists []models.Playlist
+	for rows.Next() {
+		var playlist models.Playlist
+		if err := rows.Scan(&playlist.Id, &playlist.UserId, &playlist.Name, &playlist.Description, &playlist.Visibility); err != nil {
+			return nil, err
+		}
+		playlists = append(playlists, playlist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
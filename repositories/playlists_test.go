@@ -0,0 +1,160 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"slices"
+	"testing"
+)
+
+// trackSongs returns the song IDs of a playlist in position order, failing
+// the test when the positions are not numbered 1, 2, 3...
+func trackSongs(t *testing.T, store *repositories.Store, playlistID int) []int {
+	t.Helper()
+	tracks, err := store.Playlists.GetTracks(context.Background(), []int{playlistID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var songs []int
+	for i, track := range tracks[playlistID] {
+		if track.Position != i+1 {
+			t.Fatalf("positions are not dense: %+v", tracks[playlistID])
+		}
+		songs = append(songs, track.SongId)
+	}
+	return songs
+}
+
+func TestPlaylistTracks(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			userID, err := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var songs []int
+			for _, title := range []string{"Yellow", "Trouble", "Shiver", "Sparks"} {
+				id, err := store.Songs.Create(ctx, models.Song{Title: title, Length: 200, Price: 1})
+				if err != nil {
+					t.Fatal(err)
+				}
+				songs = append(songs, id)
+			}
+			playlistID, err := store.Playlists.Create(ctx, models.Playlist{UserId: userID, Name: "Mix", Visibility: "private"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, song := range songs[:3] {
+				if _, err := store.Playlists.AddTrack(ctx, playlistID, song, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			position, err := store.Playlists.AddTrack(ctx, playlistID, songs[3], 2)
+			if err != nil || position != 2 {
+				t.Fatalf("AddTrack at 2 returned %d, %v", position, err)
+			}
+			if got, want := trackSongs(t, store, playlistID), []int{songs[0], songs[3], songs[1], songs[2]}; !slices.Equal(got, want) {
+				t.Errorf("after insert: got %v want %v", got, want)
+			}
+
+			if err := store.Playlists.MoveTrack(ctx, playlistID, 1, 4); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := trackSongs(t, store, playlistID), []int{songs[3], songs[1], songs[2], songs[0]}; !slices.Equal(got, want) {
+				t.Errorf("after moving down: got %v want %v", got, want)
+			}
+			if err := store.Playlists.MoveTrack(ctx, playlistID, 3, 1); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := trackSongs(t, store, playlistID), []int{songs[2], songs[3], songs[1], songs[0]}; !slices.Equal(got, want) {
+				t.Errorf("after moving up: got %v want %v", got, want)
+			}
+			if err := store.Playlists.MoveTrack(ctx, playlistID, 1, 5); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("moving past the end: expected ErrNotFound, got %v", err)
+			}
+
+			if err := store.Playlists.ReorderTracks(ctx, playlistID, []int{4, 3, 2, 1}); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := trackSongs(t, store, playlistID), []int{songs[0], songs[1], songs[3], songs[2]}; !slices.Equal(got, want) {
+				t.Errorf("after reorder: got %v want %v", got, want)
+			}
+			if err := store.Playlists.ReorderTracks(ctx, playlistID, []int{1, 2, 3}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("incomplete order: expected ErrConflict, got %v", err)
+			}
+
+			if err := store.Playlists.RemoveTrack(ctx, playlistID, 2); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Songs.Delete(ctx, songs[3]); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := trackSongs(t, store, playlistID), []int{songs[0], songs[2]}; !slices.Equal(got, want) {
+				t.Errorf("after removing tracks: got %v want %v", got, want)
+			}
+			if err := store.Playlists.RemoveTrack(ctx, playlistID, 3); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("removing a missing track: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPlaylistCollaborators(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			ownerID, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			guestID, _ := store.Users.Create(ctx, models.User{Username: "bob", Password: "hash", Email: "bob@example.com"})
+			playlistID, err := store.Playlists.Create(ctx, models.Playlist{UserId: ownerID, Name: "Mix", Visibility: "private"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Playlists.InviteCollaborator(ctx, playlistID, guestID); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Playlists.InviteCollaborator(ctx, playlistID, guestID); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("inviting twice: expected ErrConflict, got %v", err)
+			}
+
+			invitations, _ := store.Playlists.GetInvitations(ctx, guestID)
+			playlists, _ := store.Playlists.GetByUserID(ctx, guestID)
+			if len(invitations) != 1 || len(playlists) != 0 {
+				t.Errorf("pending invitation: got invitations %v and playlists %v", invitations, playlists)
+			}
+
+			if err := store.Playlists.AcceptInvitation(ctx, playlistID, guestID); err != nil {
+				t.Fatal(err)
+			}
+			collaborators, _ := store.Playlists.GetCollaborators(ctx, []int{playlistID})
+			want := models.PlaylistCollaborator{PlaylistId: playlistID, UserId: guestID, Username: "bob", Accepted: true}
+			if len(collaborators[playlistID]) != 1 || collaborators[playlistID][0] != want {
+				t.Errorf("wrong collaborators: %+v", collaborators)
+			}
+			if playlists, _ := store.Playlists.GetByUserID(ctx, guestID); len(playlists) != 1 || playlists[0].Id != playlistID {
+				t.Errorf("accepted playlist is not listed: %v", playlists)
+			}
+
+			if err := store.Playlists.RemoveCollaborator(ctx, playlistID, guestID); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Playlists.AcceptInvitation(ctx, playlistID, guestID); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("accepting a withdrawn invitation: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
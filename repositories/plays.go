@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type PlayRepository interface {
+	// Record stores a batch of plays in one transaction and returns how many
+	// were new. Plays whose ClientId the user has already submitted are
+	// skipped. An unknown song fails the whole batch with
+	// ErrInvalidReference.
+	Record(ctx context.Context, plays []models.Play) (int, error)
+
+	// TopSongs, TopPerformers and ListeningTime summarise the user's plays
+	// at or after since; the zero time covers them all. The top lists are
+	// ordered by plays, then seconds listened.
+	TopSongs(ctx context.Context, userID int, since time.Time, limit int) ([]models.SongPlays, error)
+	TopPerformers(ctx context.Context, userID int, since time.Time, limit int) ([]models.PerformerPlays, error)
+	// ListeningTime totals each day with plays, oldest first.
+	ListeningTime(ctx context.Context, userID int, since time.Time) ([]models.ListeningDay, error)
+}
+
+type SQLitePlayRepository struct {
+	db *sql.DB
+}
+
+func NewSQLitePlayRepository(db *sql.DB) *SQLitePlayRepository {
+	return &SQLitePlayRepository{db: db}
+}
+
+func (r *SQLitePlayRepository) Record(ctx context.Context, plays []models.Play) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	recorded := 0
+	for _, play := range plays {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO plays (user_id, song_id, played_at, duration, client_id) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING",
+			play.UserId, play.SongId, play.PlayedAt.Unix(), play.Duration, play.ClientId)
+		if err != nil {
+			return 0, translateError(err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		recorded += int(affected)
+	}
+	return recorded, tx.Commit()
+}
+
+func (r *SQLitePlayRepository) TopSongs(ctx context.Context, userID int, since time.Time, limit int) ([]models.SongPlays, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT song_id, COUNT(*) AS plays, SUM(duration) AS seconds
+		FROM plays
+		WHERE user_id = ? AND played_at >= ? AND song_id IS NOT NULL
+		GROUP BY song_id
+		ORDER BY plays DESC, seconds DESC, song_id
+		LIMIT ?`, userID, since.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []models.SongPlays
+	for rows.Next() {
+		var song models.SongPlays
+		if err := rows.Scan(&song.SongId, &song.Plays, &song.Seconds); err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, rows.Err()
+}
+
+func (r *SQLitePlayRepository) TopPerformers(ctx context.Context, userID int, since time.Time, limit int) ([]models.PerformerPlays, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT credits.artist_id, credits.band_id, COUNT(*) AS plays, SUM(plays.duration) AS seconds
+		FROM plays
+		JOIN credits ON credits.song_id = plays.song_id AND credits.role = ?
+		WHERE plays.user_id = ? AND plays.played_at >= ?
+		GROUP BY credits.artist_id, credits.band_id
+		ORDER BY plays DESC, seconds DESC, credits.artist_id IS NULL, credits.artist_id, credits.band_id
+		LIMIT ?`, models.RolePerformer, userID, since.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var performers []models.PerformerPlays
+	for rows.Next() {
+		var performer models.PerformerPlays
+		if err := rows.Scan(&performer.ArtistId, &performer.BandId, &performer.Plays, &performer.Seconds); err != nil {
+			return nil, err
+		}
+		performers = append(performers, performer)
+	}
+	return performers, rows.Err()
+}
+
+func (r *SQLitePlayRepository) ListeningTime(ctx context.Context, userID int, since time.Time) ([]models.ListeningDay, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT date(played_at, 'unixepoch') AS day, COUNT(*), SUM(duration)
+		FROM plays
+		WHERE user_id = ? AND played_at >= ?
+		GROUP BY day
+		ORDER BY day`, userID, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []models.ListeningDay
+	for rows.Next() {
+		var day models.ListeningDay
+		if err := rows.Scan(&day.Date, &day.Plays, &day.Seconds); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
@@ -0,0 +1,102 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlays(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	day := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+	clientID := func(id string) *string { return &id }
+	one := 1
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			bob, _ := store.Users.Create(ctx, models.User{Username: "bob", Password: "hash", Email: "bob@example.com"})
+			artistID, _ := store.Artists.Create(ctx, models.Artist{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07", SexId: &one, TitleId: &one})
+			bandID, _ := store.Bands.Create(ctx, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"})
+			airbag, _ := store.Songs.Create(ctx, models.Song{Title: "Airbag", Length: 284, Price: 99})
+			eraser, _ := store.Songs.Create(ctx, models.Song{Title: "The Eraser", Length: 295, Price: 99})
+			for _, credit := range []models.Credit{
+				{SongId: &airbag, BandId: &bandID, Role: models.RolePerformer},
+				{SongId: &eraser, ArtistId: &artistID, Role: models.RolePerformer},
+				{SongId: &airbag, ArtistId: &artistID, Role: models.RoleComposer},
+			} {
+				if _, err := store.Credits.Create(ctx, credit); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			batch := []models.Play{
+				{UserId: alice, SongId: &airbag, PlayedAt: day, Duration: 284, ClientId: clientID("a")},
+				{UserId: alice, SongId: &airbag, PlayedAt: day.Add(time.Hour), Duration: 100, ClientId: clientID("b")},
+				{UserId: alice, SongId: &eraser, PlayedAt: day.Add(-48 * time.Hour), Duration: 295},
+				{UserId: bob, SongId: &eraser, PlayedAt: day, Duration: 295, ClientId: clientID("a")},
+			}
+			recorded, err := store.Plays.Record(ctx, batch)
+			if err != nil || recorded != 4 {
+				t.Fatalf("Record = %d, %v", recorded, err)
+			}
+			recorded, err = store.Plays.Record(ctx, batch[:2])
+			if err != nil || recorded != 0 {
+				t.Errorf("resubmitting: Record = %d, %v, want 0 recorded", recorded, err)
+			}
+			missing := 99
+			if _, err := store.Plays.Record(ctx, []models.Play{{UserId: alice, SongId: &missing, PlayedAt: day}}); !errors.Is(err, repositories.ErrInvalidReference) {
+				t.Errorf("unknown song: got %v, want ErrInvalidReference", err)
+			}
+
+			songs, err := store.Plays.TopSongs(ctx, alice, time.Time{}, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []models.SongPlays{{SongId: airbag, Plays: 2, Seconds: 384}, {SongId: eraser, Plays: 1, Seconds: 295}}; !reflect.DeepEqual(songs, want) {
+				t.Errorf("top songs = %+v, want %+v", songs, want)
+			}
+			songs, _ = store.Plays.TopSongs(ctx, alice, day.Add(-24*time.Hour), 10)
+			if len(songs) != 1 || songs[0].SongId != airbag {
+				t.Errorf("top songs since yesterday = %+v", songs)
+			}
+
+			performers, err := store.Plays.TopPerformers(ctx, alice, time.Time{}, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []models.PerformerPlays{{BandId: &bandID, Plays: 2, Seconds: 384}, {ArtistId: &artistID, Plays: 1, Seconds: 295}}; !reflect.DeepEqual(performers, want) {
+				t.Errorf("top performers = %+v, want %+v", performers, want)
+			}
+
+			days, err := store.Plays.ListeningTime(ctx, alice, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []models.ListeningDay{{Date: "2024-02-28", Plays: 1, Seconds: 295}, {Date: "2024-03-01", Plays: 1, Seconds: 284}, {Date: "2024-03-02", Plays: 1, Seconds: 100}}
+			if !reflect.DeepEqual(days, want) {
+				t.Errorf("listening time = %+v, want %+v", days, want)
+			}
+
+			if err := store.Songs.Delete(ctx, airbag); err != nil {
+				t.Fatal(err)
+			}
+			songs, _ = store.Plays.TopSongs(ctx, alice, time.Time{}, 10)
+			if len(songs) != 1 || songs[0].SongId != eraser {
+				t.Errorf("top songs after deleting one = %+v", songs)
+			}
+			if days, _ := store.Plays.ListeningTime(ctx, alice, time.Time{}); !reflect.DeepEqual(days, want) {
+				t.Errorf("listening time changed after deleting a song: %+v", days)
+			}
+		})
+	}
+}
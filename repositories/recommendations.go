@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type RecommendationRepository interface {
+	// Listeners returns the songs each user has played or owns, keyed by
+	// user ID, most played first.
+	Listeners(ctx context.Context) (map[int][]int, error)
+	// Listened returns how many times the user played each song they have
+	// played or own, keyed by song ID. Songs they only own count 0.
+	Listened(ctx context.Context, userID int) (map[int]int, error)
+
+	// ReplaceSimilarities swaps the whole similarity matrix for a new one in
+	// one transaction. Songs deleted since it was computed are skipped.
+	ReplaceSimilarities(ctx context.Context, similar map[int][]models.SimilarSong) error
+	// GetSimilar returns the songs most similar to each of the given ones,
+	// keyed by song ID, highest score first.
+	GetSimilar(ctx context.Context, songIDs []int) (map[int][]models.SimilarSong, error)
+}
+
+type SQLiteRecommendationRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRecommendationRepository(db *sql.DB) *SQLiteRecommendationRepository {
+	return &SQLiteRecommendationRepository{db: db}
+}
+
+// listensSQL counts each user's plays of every song they played or own.
+const listensSQL = `
+	SELECT user_id, song_id, SUM(played) AS plays FROM (
+		SELECT user_id, song_id, 1 AS played FROM plays WHERE song_id IS NOT NULL
+		UNION ALL
+		SELECT user_id, song_id, 0 FROM owned_songs
+	)`
+
+func (r *SQLiteRecommendationRepository) Listeners(ctx context.Context) (map[int][]int, error) {
+	rows, err := r.db.QueryContext(ctx, listensSQL+" GROUP BY user_id, song_id ORDER BY user_id, plays DESC, song_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listeners := map[int][]int{}
+	for rows.Next() {
+		var userID, songID, plays int
+		if err := rows.Scan(&userID, &songID, &plays); err != nil {
+			return nil, err
+		}
+		listeners[userID] = append(listeners[userID], songID)
+	}
+	return listeners, rows.Err()
+}
+
+func (r *SQLiteRecommendationRepository) Listened(ctx context.Context, userID int) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, listensSQL+" WHERE user_id = ? GROUP BY user_id, song_id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listened := map[int]int{}
+	for rows.Next() {
+		var user, songID, plays int
+		if err := rows.Scan(&user, &songID, &plays); err != nil {
+			return nil, err
+		}
+		listened[songID] = plays
+	}
+	return listened, rows.Err()
+}
+
+func (r *SQLiteRecommendationRepository) ReplaceSimilarities(ctx context.Context, similar map[int][]models.SimilarSong) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM song_similarity"); err != nil {
+		return err
+	}
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO song_similarity (song_id, similar_id, score)
+		SELECT ?, ?, ? WHERE EXISTS (SELECT 1 FROM songs WHERE id = ?) AND EXISTS (SELECT 1 FROM songs WHERE id = ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for songID, neighbours := range similar {
+		for _, neighbour := range neighbours {
+			if _, err := insert.ExecContext(ctx, songID, neighbour.SongId, neighbour.Score, songID, neighbour.SongId); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRecommendationRepository) GetSimilar(ctx context.Context, songIDs []int) (map[int][]models.SimilarSong, error) {
+	result := map[int][]models.SimilarSong{}
+	err := inBatches(songIDs, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT song_id, similar_id, score FROM song_similarity WHERE song_id IN ("+placeholders+") ORDER BY song_id, score DESC, similar_id",
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var songID int
+			var similar models.SimilarSong
+			if err := rows.Scan(&songID, &similar.SongId, &similar.Score); err != nil {
+				return err
+			}
+			result[songID] = append(result[songID], similar)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
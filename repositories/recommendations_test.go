@@ -0,0 +1,92 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecommendationData(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			var songs []int
+			for _, title := range []string{"Yellow", "Shiver", "Sparks"} {
+				id, _ := store.Songs.Create(ctx, models.Song{Title: title, Length: 200, Price: 99})
+				songs = append(songs, id)
+			}
+
+			order := models.Order{UserId: alice, Total: 99, CreatedAt: time.Unix(1700000000, 0), Items: []models.OrderItem{
+				{Type: models.ItemSong, SongId: &songs[2], Title: "Sparks", Price: 99},
+			}}
+			if _, err := store.Orders.CreateOrder(ctx, order, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Plays.Record(ctx, []models.Play{
+				{UserId: alice, SongId: &songs[0], PlayedAt: time.Unix(1700000000, 0), Duration: 200},
+				{UserId: alice, SongId: &songs[1], PlayedAt: time.Unix(1700000100, 0), Duration: 200},
+				{UserId: alice, SongId: &songs[1], PlayedAt: time.Unix(1700000200, 0), Duration: 200},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			listeners, err := store.Recommendations.Listeners(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[int][]int{alice: {songs[1], songs[0], songs[2]}}; !reflect.DeepEqual(listeners, want) {
+				t.Errorf("listeners = %v, want %v", listeners, want)
+			}
+			listened, err := store.Recommendations.Listened(ctx, alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[int]int{songs[0]: 1, songs[1]: 2, songs[2]: 0}; !reflect.DeepEqual(listened, want) {
+				t.Errorf("listened = %v, want %v", listened, want)
+			}
+
+			err = store.Recommendations.ReplaceSimilarities(ctx, map[int][]models.SimilarSong{
+				songs[0]: {{SongId: songs[2], Score: 0.5}, {SongId: songs[1], Score: 0.9}},
+				songs[1]: {{SongId: songs[0], Score: 0.9}, {SongId: 99, Score: 1}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			similar, err := store.Recommendations.GetSimilar(ctx, []int{songs[0], songs[1], songs[2]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[int][]models.SimilarSong{
+				songs[0]: {{SongId: songs[1], Score: 0.9}, {SongId: songs[2], Score: 0.5}},
+				songs[1]: {{SongId: songs[0], Score: 0.9}},
+			}
+			if !reflect.DeepEqual(similar, want) {
+				t.Errorf("similar = %v, want %v", similar, want)
+			}
+
+			if err := store.Songs.Delete(ctx, songs[1]); err != nil {
+				t.Fatal(err)
+			}
+			similar, _ = store.Recommendations.GetSimilar(ctx, []int{songs[0], songs[1]})
+			if want := map[int][]models.SimilarSong{songs[0]: {{SongId: songs[2], Score: 0.5}}}; !reflect.DeepEqual(similar, want) {
+				t.Errorf("after deleting a song: similar = %v, want %v", similar, want)
+			}
+
+			if err := store.Recommendations.ReplaceSimilarities(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			if similar, _ := store.Recommendations.GetSimilar(ctx, []int{songs[0]}); len(similar) != 0 {
+				t.Errorf("after replacing with nothing: similar = %v", similar)
+			}
+		})
+	}
+}
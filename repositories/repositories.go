@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"goMusic/models"
+	"goMusic/query"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrConflict = errors.New("record already exists")
+	// ErrInvalidReference is returned when a write would leave a foreign key
+	// pointing at a record that does not exist.
+	ErrInvalidReference = errors.New("referenced record does not exist")
+)
+
+// Store bundles the repositories a service may depend on.
+type Store struct {
+	Albums          AlbumRepository
+	Artists         ArtistRepository
+	Bands           BandRepository
+	Songs           SongRepository
+	Users           UserRepository
+	Tokens          TokenRepository
+	Search          SearchRepository
+	Playlists       PlaylistRepository
+	Orders          OrderRepository
+	Audio           AudioRepository
+	Covers          CoverRepository
+	Genres          GenreRepository
+	Tags            TagRepository
+	Credits         CreditRepository
+	Memberships     MembershipRepository
+	Imports         ImportRepository
+	Exports         ExportRepository
+	Reviews         ReviewRepository
+	Plays           PlayRepository
+	Recommendations RecommendationRepository
+	Charts          ChartRepository
+	Labels          LabelRepository
+}
+
+// NewSQLiteStore returns a Store backed by the given SQLite database.
+func NewSQLiteStore(db *sql.DB) *Store {
+	return &Store{
+		Albums:          NewSQLiteAlbumRepository(db),
+		Artists:         NewSQLiteArtistRepository(db),
+		Bands:           NewSQLiteBandRepository(db),
+		Songs:           NewSQLiteSongRepository(db),
+		Users:           NewSQLiteUserRepository(db),
+		Tokens:          NewSQLiteTokenRepository(db),
+		Search:          NewSQLiteSearchRepository(db),
+		Playlists:       NewSQLitePlaylistRepository(db),
+		Orders:          NewSQLiteOrderRepository(db),
+		Audio:           NewSQLiteAudioRepository(db),
+		Covers:          NewSQLiteCoverRepository(db),
+		Genres:          NewSQLiteGenreRepository(db),
+		Tags:            NewSQLiteTagRepository(db),
+		Credits:         NewSQLiteCreditRepository(db),
+		Memberships:     NewSQLiteMembershipRepository(db),
+		Imports:         NewSQLiteImportRepository(db),
+		Exports:         NewSQLiteExportRepository(db),
+		Reviews:         NewSQLiteReviewRepository(db),
+		Plays:           NewSQLitePlayRepository(db),
+		Recommendations: NewSQLiteRecommendationRepository(db),
+		Charts:          NewSQLiteChartRepository(db),
+		Labels:          NewSQLiteLabelRepository(db),
+	}
+}
+
+// NewMemoryStore returns a Store backed by a fresh in-memory database.
+func NewMemoryStore() *Store {
+	return NewMemory().Store()
+}
+
+// execInTx executes a single statement inside a transaction with timeout
+func execInTx(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, translateError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// requireAffected returns ErrNotFound when a statement touched no rows
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return ErrConflict
+		case sqlite3.ErrConstraintForeignKey:
+			return ErrInvalidReference
+		}
+	}
+
+	return err
+}
+
+// maxBatchSize keeps IN (...) lists well below SQLite's bound parameter limit
+const maxBatchSize = 500
+
+// inBatches calls fn with the distinct IDs split into chunks of at most
+// maxBatchSize, passing the placeholder list and arguments for an IN clause.
+func inBatches(ids []int, fn func(placeholders string, args []interface{}) error) error {
+	unique := slices.Clone(ids)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+
+	for start := 0; start < len(unique); start += maxBatchSize {
+		chunk := unique[start:min(start+maxBatchSize, len(unique))]
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		if err := fn(strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", "), args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list counts the rows matching the filters and fetches one page of them,
+// ordered and limited by the shared query builder. Both queries run in one
+// read transaction so the total describes the same rows as the page. The
+// columns may have placeholders of their own, bound to columnArgs.
+func list[T any](ctx context.Context, db *sql.DB, spec query.Spec[T], q query.Query, table, columns string, columnArgs []any, scan func(*sql.Rows) ([]T, error)) (query.Page[T], error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return query.Page[T]{}, err
+	}
+	defer tx.Rollback()
+
+	where, args := spec.Where(q)
+	var total int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+where, args...).Scan(&total); err != nil {
+		return query.Page[T]{}, err
+	}
+
+	clauses, args := spec.Select(q)
+	rows, err := tx.QueryContext(ctx, "SELECT "+columns+" FROM "+table+clauses, append(slices.Clone(columnArgs), args...)...)
+	if err != nil {
+		return query.Page[T]{}, err
+	}
+	items, err := scan(rows)
+	if err != nil {
+		return query.Page[T]{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return query.Page[T]{}, err
+	}
+
+	return spec.Paginate(items, q, total), nil
+}
+
+// nullableInt unwraps an optional foreign key for filtering and cursors
+func nullableInt(id *int) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// nullableString unwraps an optional text column for filtering and cursors.
+func nullableString(text *string) any {
+	if text == nil {
+		return nil
+	}
+	return *text
+}
+
+// ageSQL counts whole years from the start date column to the date on, or
+// to the end date column when that is earlier, matching models.Artist.AgeOn.
+// The date is bound to the returned placeholders.
+func ageSQL(start, end, on string) (string, []any) {
+	to := "IFNULL(MIN(" + end + ", ?), ?)"
+	return "MAX(0, CAST(strftime('%Y', " + to + ") AS INTEGER) - CAST(strftime('%Y', " + start + ") AS INTEGER)" +
+		" - (strftime('%m-%d', " + to + ") < strftime('%m-%d', " + start + ")))", []any{on, on, on, on}
+}
+
+// aliveSQL is true on the date on from the start date column up to the day
+// before the end date column, which is open when NULL, matching
+// models.Artist.AliveOn.
+func aliveSQL(start, end, on string) (string, []any) {
+	return "(" + start + " <= ? AND (" + end + " IS NULL OR " + end + " > ?))", []any{on, on}
+}
+
+// dateOrToday is the date lists compute ages on: the one asked for, or today.
+func dateOrToday(date string) string {
+	if date == "" {
+		return models.Today()
+	}
+	return date
+}
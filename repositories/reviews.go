@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+	"time"
+)
+
+type ReviewRepository interface {
+	GetByID(ctx context.Context, id int) (models.Review, error)
+	// ListByAlbumID and ListBySongID page through the reviews of one album
+	// or song.
+	ListByAlbumID(ctx context.Context, albumID int, q query.Query) (query.Page[models.Review], error)
+	ListBySongID(ctx context.Context, songID int, q query.Query) (query.Page[models.Review], error)
+	// Create returns ErrConflict when the user has already reviewed the
+	// album or song.
+	Create(ctx context.Context, review models.Review) (int, error)
+	// Update changes the rating and text.
+	Update(ctx context.Context, id int, review models.Review) error
+	Delete(ctx context.Context, id int) error
+
+	// GetAlbumRatings and GetSongRatings return the rating totals of each
+	// album or song, keyed by ID. They are kept up to date as reviews
+	// change, so reading them costs one lookup per batch.
+	GetAlbumRatings(ctx context.Context, albumIDs []int) (map[int]models.Rating, error)
+	GetSongRatings(ctx context.Context, songIDs []int) (map[int]models.Rating, error)
+}
+
+// ReviewQuery lists the parameters accepted by GET /albums/{id}/reviews and
+// GET /songs/{id}/reviews. album_id and song_id have no operators, so they
+// can't be given as parameters; the repository filters on them.
+var ReviewQuery = query.Spec[models.Review]{Fields: map[string]query.Field[models.Review]{
+	"id":         {Column: "reviews.id", Kind: query.Int, Sortable: true, Value: func(r models.Review) any { return r.Id }},
+	"rating":     {Column: "reviews.rating", Kind: query.Int, Sortable: true, Ops: []query.Op{query.Eq, query.Gte, query.Lte}, Value: func(r models.Review) any { return r.Rating }},
+	"created_at": {Column: "reviews.created_at", Kind: query.Int, Sortable: true, Value: func(r models.Review) any { return r.CreatedAt.Unix() }},
+	"updated_at": {Column: "reviews.updated_at", Kind: query.Int, Sortable: true, Value: func(r models.Review) any { return r.UpdatedAt.Unix() }},
+	"album_id":   {Column: "reviews.album_id", Kind: query.Int, Value: func(r models.Review) any { return nullableInt(r.AlbumId) }},
+	"song_id":    {Column: "reviews.song_id", Kind: query.Int, Value: func(r models.Review) any { return nullableInt(r.SongId) }},
+}}
+
+type SQLiteReviewRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteReviewRepository(db *sql.DB) *SQLiteReviewRepository {
+	return &SQLiteReviewRepository{db: db}
+}
+
+const (
+	reviewColumns = "reviews.id, reviews.user_id, users.username, reviews.album_id, reviews.song_id, reviews.rating, reviews.body, reviews.created_at, reviews.updated_at"
+	reviewTables  = "reviews JOIN users ON users.id = reviews.user_id"
+)
+
+func scanReview(row rowScanner) (models.Review, error) {
+	var review models.Review
+	var createdAt, updatedAt int64
+	err := row.Scan(&review.Id, &review.UserId, &review.Username, &review.AlbumId, &review.SongId,
+		&review.Rating, &review.Body, &createdAt, &updatedAt)
+	review.CreatedAt, review.UpdatedAt = time.Unix(createdAt, 0), time.Unix(updatedAt, 0)
+	return review, err
+}
+
+func scanReviews(rows *sql.Rows) ([]models.Review, error) {
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *SQLiteReviewRepository) GetByID(ctx context.Context, id int) (models.Review, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, "SELECT "+reviewColumns+" FROM "+reviewTables+" WHERE reviews.id = ?", id))
+	if err != nil {
+		return models.Review{}, translateError(err)
+	}
+	return review, nil
+}
+
+func (r *SQLiteReviewRepository) ListByAlbumID(ctx context.Context, albumID int, q query.Query) (query.Page[models.Review], error) {
+	q.Filters = append(q.Filters, query.Filter{Field: "album_id", Op: query.Eq, Value: albumID})
+	return list(ctx, r.db, ReviewQuery, q, reviewTables, reviewColumns, nil, scanReviews)
+}
+
+func (r *SQLiteReviewRepository) ListBySongID(ctx context.Context, songID int, q query.Query) (query.Page[models.Review], error) {
+	q.Filters = append(q.Filters, query.Filter{Field: "song_id", Op: query.Eq, Value: songID})
+	return list(ctx, r.db, ReviewQuery, q, reviewTables, reviewColumns, nil, scanReviews)
+}
+
+func (r *SQLiteReviewRepository) Create(ctx context.Context, review models.Review) (int, error) {
+	now := time.Now().Unix()
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO reviews (user_id, album_id, song_id, rating, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		review.UserId, review.AlbumId, review.SongId, review.Rating, review.Body, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteReviewRepository) Update(ctx context.Context, id int, review models.Review) error {
+	result, err := execInTx(ctx, r.db, "UPDATE reviews SET rating = ?, body = ?, updated_at = ? WHERE id = ?",
+		review.Rating, review.Body, time.Now().Unix(), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteReviewRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM reviews WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteReviewRepository) GetAlbumRatings(ctx context.Context, albumIDs []int) (map[int]models.Rating, error) {
+	return r.ratings(ctx, "albums", albumIDs)
+}
+
+func (r *SQLiteReviewRepository) GetSongRatings(ctx context.Context, songIDs []int) (map[int]models.Rating, error) {
+	return r.ratings(ctx, "songs", songIDs)
+}
+
+func (r *SQLiteReviewRepository) ratings(ctx context.Context, table string, ids []int) (map[int]models.Rating, error) {
+	result := map[int]models.Rating{}
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx,
+			"SELECT id, rating_count, rating_sum FROM "+table+" WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			var rating models.Rating
+			if err := rows.Scan(&id, &rating.Count, &rating.Sum); err != nil {
+				return err
+			}
+			result[id] = rating
+		}
+		return rows.Err()
+	})
+	return result, err
+}
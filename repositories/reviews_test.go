@@ -0,0 +1,98 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/query"
+	"goMusic/repositories"
+	"testing"
+)
+
+func TestReviewRatings(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice, _ := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			bob, _ := store.Users.Create(ctx, models.User{Username: "bob", Password: "hash", Email: "bob@example.com"})
+			albumID, err := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			if err != nil {
+				t.Fatal(err)
+			}
+			songID, err := store.Songs.Create(ctx, models.Song{Title: "Airbag", Length: 284, Price: 99})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			first, err := store.Reviews.Create(ctx, models.Review{UserId: alice, AlbumId: &albumID, Rating: 5, Body: "A classic."})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Reviews.Create(ctx, models.Review{UserId: bob, AlbumId: &albumID, Rating: 2}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Reviews.Create(ctx, models.Review{UserId: alice, SongId: &songID, Rating: 4}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Reviews.Create(ctx, models.Review{UserId: alice, AlbumId: &albumID, Rating: 1}); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("second review of the album: got %v, want ErrConflict", err)
+			}
+
+			ratings, err := store.Reviews.GetAlbumRatings(ctx, []int{albumID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := ratings[albumID], (models.Rating{Count: 2, Sum: 7}); got != want {
+				t.Errorf("album rating = %+v, want %+v", got, want)
+			}
+
+			if err := store.Reviews.Update(ctx, first, models.Review{Rating: 3, Body: "Grew tired of it."}); err != nil {
+				t.Fatal(err)
+			}
+			ratings, _ = store.Reviews.GetAlbumRatings(ctx, []int{albumID})
+			if got, want := ratings[albumID], (models.Rating{Count: 2, Sum: 5}); got != want {
+				t.Errorf("after update: album rating = %+v, want %+v", got, want)
+			}
+			review, err := store.Reviews.GetByID(ctx, first)
+			if err != nil || review.Username != "alice" || review.Rating != 3 || review.Body != "Grew tired of it." {
+				t.Errorf("GetByID = %+v, %v", review, err)
+			}
+
+			page, err := store.Reviews.ListByAlbumID(ctx, albumID, query.Query{Sort: []query.Sort{{Field: "rating"}}, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Items) != 2 || page.Items[0].Username != "bob" || page.Items[1].Id != first {
+				t.Errorf("album reviews = %+v", page.Items)
+			}
+
+			if err := store.Reviews.Delete(ctx, first); err != nil {
+				t.Fatal(err)
+			}
+			ratings, _ = store.Reviews.GetAlbumRatings(ctx, []int{albumID})
+			if got, want := ratings[albumID], (models.Rating{Count: 1, Sum: 2}); got != want {
+				t.Errorf("after delete: album rating = %+v, want %+v", got, want)
+			}
+			if err := store.Reviews.Delete(ctx, first); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("deleting twice: got %v, want ErrNotFound", err)
+			}
+
+			ratings, _ = store.Reviews.GetSongRatings(ctx, []int{songID})
+			if got, want := ratings[songID], (models.Rating{Count: 1, Sum: 4}); got != want {
+				t.Errorf("song rating = %+v, want %+v", got, want)
+			}
+			if err := store.Songs.Delete(ctx, songID); err != nil {
+				t.Fatal(err)
+			}
+			page, _ = store.Reviews.ListBySongID(ctx, songID, query.Query{Limit: 10})
+			if len(page.Items) != 0 {
+				t.Errorf("reviews outlived their song: %+v", page.Items)
+			}
+		})
+	}
+}
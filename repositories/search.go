@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Search result types, in the order they are encoded in search_index rowids.
+const (
+	SearchAlbum  = "album"
+	SearchArtist = "artist"
+	SearchBand   = "band"
+	SearchSong   = "song"
+)
+
+// SearchHit is one ranked match from the catalog search index. Snippet is the
+// matched name as HTML, escaped, with the matching words wrapped in <mark>
+// tags.
+type SearchHit struct {
+	Type    string
+	ID      int
+	Snippet string
+	Score   float64
+}
+
+type SearchRepository interface {
+	// Search returns the best matches for every word of text, each treated
+	// as a prefix, ordered by descending score.
+	Search(ctx context.Context, text string, limit int) ([]SearchHit, error)
+}
+
+type SQLiteSearchRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSearchRepository(db *sql.DB) *SQLiteSearchRepository {
+	return &SQLiteSearchRepository{db: db}
+}
+
+func (r *SQLiteSearchRepository) Search(ctx context.Context, text string, limit int) ([]SearchHit, error) {
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var match []string
+	for _, term := range terms {
+		match = append(match, `"`+term+`"*`)
+	}
+
+	// bm25() ranks the best matches most negative. Ties are broken by type
+	// and ID, like rankHits.
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rowid, kind, highlight(search_index, 1, ?, ?), -rank
+		FROM search_index
+		WHERE search_index MATCH ?
+		ORDER BY rank, rowid % 4, rowid
+		LIMIT ?`, markStart, markEnd, strings.Join(match, " "), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var rowID int
+		var hit SearchHit
+		if err := rows.Scan(&rowID, &hit.Type, &hit.Snippet, &hit.Score); err != nil {
+			return nil, err
+		}
+		hit.ID = rowID / 4
+		hit.Snippet = markSnippet(hit.Snippet)
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// The highlight delimits matches with control characters, which aren't
+// expected in names, so they survive escaping and are then replaced by <mark>
+// tags.
+const markStart, markEnd = "\x02", "\x03"
+
+var snippetMarks = strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>")
+
+// markSnippet escapes the names in a snippet for HTML and marks its matches.
+func markSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+// rankHits orders hits by descending score, breaking ties by type and ID so
+// results are stable, and keeps the first limit of them.
+func rankHits(hits []SearchHit, limit int) []SearchHit {
+	order := map[string]int{SearchAlbum: 0, SearchArtist: 1, SearchBand: 2, SearchSong: 3}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return order[hits[i].Type] < order[hits[j].Type]
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+var foldDiacritics = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// foldText lower-cases text and strips diacritics, matching the unicode61
+// tokenizer with remove_diacritics=1.
+func foldText(text string) string {
+	folded, _, err := transform.String(foldDiacritics, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.ToLower(folded)
+}
+
+// searchTerms splits text into folded words, dropping the punctuation that
+// FTS would otherwise read as query syntax.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(foldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,151 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/db"
+	"goMusic/models"
+	"goMusic/repositories"
+	"path/filepath"
+	"testing"
+)
+
+// openMigratedDB returns a real SQLite database with every migration
+// applied, for queries sqlmock cannot meaningfully stand in for.
+func openMigratedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrations, err := db.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.NewMigrator(sqlDB, migrations).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlDB.Exec("INSERT INTO sexes (id, name) VALUES (1, 'Female'); INSERT INTO titles (id, name) VALUES (1, 'Ms.')"); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+func seedSearchCatalog(t *testing.T, store *repositories.Store) {
+	t.Helper()
+	ctx := context.Background()
+	one := 1
+
+	for _, band := range []string{"Sigur Rós", "Love"} {
+		if _, err := store.Bands.Create(ctx, models.Band{Name: band, Nationality: "Icelandic", DateFormed: "1994-01-01"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Artists.Create(ctx, models.Artist{FirstName: "Björk", LastName: "Guðmundsdóttir", Nationality: "Icelandic", BirthDate: "1965-11-21", SexId: &one, TitleId: &one}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Albums.Create(ctx, models.Album{Title: "Ágætis byrjun", Price: 999}); err != nil {
+		t.Fatal(err)
+	}
+	for _, song := range []string{"Love Will Tear Us Apart", "Lovely Day", "Hoppípolla"} {
+		if _, err := store.Songs.Create(ctx, models.Song{Title: song, Length: 200, Price: 99}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func searchTypes(hits []repositories.SearchHit) []string {
+	var result []string
+	for _, hit := range hits {
+		result = append(result, hit.Type+":"+hit.Snippet)
+	}
+	return result
+}
+
+func TestSearch(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			seedSearchCatalog(t, store)
+			ctx := context.Background()
+
+			hits, err := store.Search.Search(ctx, "bjork", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].Type != repositories.SearchArtist || hits[0].ID != 1 || hits[0].Snippet != "<mark>Björk</mark> Guðmundsdóttir" {
+				t.Errorf("diacritic-insensitive match failed: %v", searchTypes(hits))
+			}
+
+			hits, err = store.Search.Search(ctx, "agæt", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].Type != repositories.SearchAlbum {
+				t.Errorf("prefix match failed: %v", searchTypes(hits))
+			}
+
+			hits, err = store.Search.Search(ctx, "love", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 3 || hits[0].Type != repositories.SearchBand || hits[0].Snippet != "<mark>Love</mark>" {
+				t.Errorf("expected the band named Love to rank first: %v", searchTypes(hits))
+			}
+
+			hits, err = store.Search.Search(ctx, "love tear", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].Type != repositories.SearchSong {
+				t.Errorf("every term should have to match: %v", searchTypes(hits))
+			}
+
+			if hits, _ := store.Search.Search(ctx, `"* OR (`, 10); len(hits) != 0 {
+				t.Errorf("query syntax should be ignored: %v", searchTypes(hits))
+			}
+
+			if hits, _ := store.Search.Search(ctx, "love", 1); len(hits) != 1 {
+				t.Errorf("limit was not applied: %v", searchTypes(hits))
+			}
+
+			if _, err := store.Bands.Create(ctx, models.Band{Name: "<script>alert(1)</script> & Co", Nationality: "British", DateFormed: "2000-01-01"}); err != nil {
+				t.Fatal(err)
+			}
+			hits, err = store.Search.Search(ctx, "script", 10)
+			if want := "&lt;<mark>script</mark>&gt;alert(1)&lt;/<mark>script</mark>&gt; &amp; Co"; err != nil || len(hits) != 1 || hits[0].Snippet != want {
+				t.Errorf("snippet = %v, %v; want the name escaped as %s", searchTypes(hits), err, want)
+			}
+		})
+	}
+}
+
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	store := repositories.NewSQLiteStore(openMigratedDB(t))
+	seedSearchCatalog(t, store)
+	ctx := context.Background()
+
+	if err := store.Songs.Update(ctx, 3, models.Song{Title: "Svefn-g-englar", Length: 600, Price: 99}); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := store.Search.Search(ctx, "hoppipolla", 10); len(hits) != 0 {
+		t.Errorf("old title is still indexed: %v", searchTypes(hits))
+	}
+	if hits, _ := store.Search.Search(ctx, "svefn", 10); len(hits) != 1 || hits[0].ID != 3 {
+		t.Errorf("new title is not indexed: %v", searchTypes(hits))
+	}
+
+	if err := store.Bands.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := store.Search.Search(ctx, "sigur", 10); len(hits) != 0 {
+		t.Errorf("deleted band is still indexed: %v", searchTypes(hits))
+	}
+}
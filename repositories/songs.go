@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"goMusic/query"
+)
+
+type SongRepository interface {
+	GetAll(ctx context.Context) ([]models.Song, error)
+	GetByID(ctx context.Context, id int) (models.Song, error)
+	GetByIDs(ctx context.Context, ids []int) ([]models.Song, error)
+	List(ctx context.Context, q query.Query) (query.Page[models.Song], error)
+	GetByAlbumID(ctx context.Context, albumID int) ([]models.Song, error)
+	Create(ctx context.Context, song models.Song) (int, error)
+	Update(ctx context.Context, id int, song models.Song) error
+	Delete(ctx context.Context, id int) error
+}
+
+const songColumns = "id, title, length, price"
+
+// SongQuery lists the parameters accepted by GET /songs. album_id matches
+// the album's tracks, and artist_id and band_id songs the artist or band is
+// credited on in any role.
+var SongQuery = query.Spec[models.Song]{Fields: map[string]query.Field[models.Song]{
+	"id":        {Column: "id", Kind: query.Int, Sortable: true, Value: func(s models.Song) any { return s.Id }},
+	"title":     {Column: "title", Kind: query.String, Sortable: true, Ops: []query.Op{query.Eq}, Value: func(s models.Song) any { return s.Title }},
+	"length":    {Column: "length", Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(s models.Song) any { return s.Length }},
+	"price":     {Column: "price", Kind: query.Int, Sortable: true, Ops: []query.Op{query.Gte, query.Lte}, Value: func(s models.Song) any { return int(s.Price) }},
+	"album_id":  {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM album_songs t WHERE t.song_id = songs.id AND t.album_id = ?)"},
+	"artist_id": {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM credits c WHERE c.song_id = songs.id AND c.artist_id = ?)"},
+	"band_id":   {Kind: query.Int, Ops: []query.Op{query.Eq}, Condition: "EXISTS (SELECT 1 FROM credits c WHERE c.song_id = songs.id AND c.band_id = ?)"},
+}}
+
+type SQLiteSongRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSongRepository(db *sql.DB) *SQLiteSongRepository {
+	return &SQLiteSongRepository{db: db}
+}
+
+func (r *SQLiteSongRepository) GetAll(ctx context.Context) ([]models.Song, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+songColumns+" FROM songs")
+	if err != nil {
+		return nil, err
+	}
+	return scanSongs(rows)
+}
+
+func (r *SQLiteSongRepository) GetByID(ctx context.Context, id int) (models.Song, error) {
+	var song models.Song
+	err := r.db.QueryRowContext(ctx, "SELECT "+songColumns+" FROM songs WHERE id = ?", id).
+		Scan(&song.Id, &song.Title, &song.Length, &song.Price)
+	if err != nil {
+		return models.Song{}, translateError(err)
+	}
+	return song, nil
+}
+
+func (r *SQLiteSongRepository) List(ctx context.Context, q query.Query) (query.Page[models.Song], error) {
+	return list(ctx, r.db, SongQuery, q, "songs", songColumns, nil, scanSongs)
+}
+
+func (r *SQLiteSongRepository) GetByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	var songs []models.Song
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, "SELECT "+songColumns+" FROM songs WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return err
+		}
+		batch, err := scanSongs(rows)
+		songs = append(songs, batch...)
+		return err
+	})
+	return songs, err
+}
+
+func (r *SQLiteSongRepository) GetByAlbumID(ctx context.Context, albumID int) ([]models.Song, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, s.title, s.length, s.price
+		FROM songs s
+		JOIN album_songs sa ON s.id = sa.song_id
+		WHERE sa.album_id = ?
+		ORDER BY sa.position, s.id`, albumID)
+	if err != nil {
+		return nil, err
+	}
+	return scanSongs(rows)
+}
+
+func (r *SQLiteSongRepository) Create(ctx context.Context, song models.Song) (int, error) {
+	result, err := execInTx(ctx, r.db,
+		"INSERT INTO songs (title, length, price) VALUES (?, ?, ?)",
+		song.Title, song.Length, song.Price)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteSongRepository) Update(ctx context.Context, id int, song models.Song) error {
+	result, err := execInTx(ctx, r.db,
+		"UPDATE songs SET title = ?, length = ?, price = ? WHERE id = ?",
+		song.Title, song.Length, song.Price, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteSongRepository) Delete(ctx context.Context, id int) error {
+	result, err := execInTx(ctx, r.db, "DELETE FROM songs WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func scanSongs(rows *sql.Rows) ([]models.Song, error) {
+	defer rows.Close()
+
+	var songs []models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(&song.Id, &song.Title, &song.Length, &song.Price); err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return songs, nil
+}
@@ -0,0 +1,128 @@
+package repositories_test
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSQLiteSongRepositoryGetByAlbumID(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "length", "price"}).
+		AddRow(1, "Yellow", 269, 129).
+		AddRow(2, "Trouble", 273, 129)
+	mock.ExpectQuery(`SELECT (.+) FROM songs s JOIN album_songs sa ON s.id = sa.song_id WHERE sa.album_id = \?`).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	songs, err := repositories.NewSQLiteSongRepository(mockDB).GetByAlbumID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetByAlbumID returned error: %v", err)
+	}
+
+	if len(songs) != 2 || songs[1].Title != "Trouble" || songs[1].Length != 273 {
+		t.Errorf("Wrong song data: got %+v", songs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAlbumTrackOrder(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			albumID, err := store.Albums.Create(ctx, models.Album{Title: "OK Computer", Price: 999})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var songIDs []int
+			for _, title := range []string{"Lucky", "Airbag", "Let Down"} {
+				id, err := store.Songs.Create(ctx, models.Song{Title: title, Length: 260, Price: 99})
+				if err != nil {
+					t.Fatal(err)
+				}
+				songIDs = append(songIDs, id)
+			}
+
+			titles := func() []string {
+				t.Helper()
+				songs, err := store.Songs.GetByAlbumID(ctx, albumID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var titles []string
+				for _, song := range songs {
+					titles = append(titles, song.Title)
+				}
+				return titles
+			}
+
+			if err := store.Albums.SetSongs(ctx, albumID, []int{songIDs[1], songIDs[2], songIDs[0]}); err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(); !reflect.DeepEqual(got, []string{"Airbag", "Let Down", "Lucky"}) {
+				t.Errorf("tracks = %v, want them in the order set", got)
+			}
+			if err := store.Albums.SetSongs(ctx, albumID, []int{songIDs[2], songIDs[1]}); err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(); !reflect.DeepEqual(got, []string{"Let Down", "Airbag"}) {
+				t.Errorf("tracks after reordering = %v", got)
+			}
+		})
+	}
+}
+
+func TestSQLiteRelationshipQueries(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM albums a JOIN album_songs sa ON a.id = sa.album_id WHERE sa.song_id IN \(\?, \?\)`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows(append([]string{"song_id"}, albumColumns...)).
+			AddRow(1, 1, "Parachutes", 2999, nil, nil, nil, nil, nil).
+			AddRow(2, 1, "Parachutes", 2999, nil, nil, nil, nil, nil))
+
+	mock.ExpectQuery(`SELECT (.+) FROM credits WHERE song_id IN \(\?, \?\) ORDER BY song_id, id`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "album_id", "song_id", "artist_id", "band_id", "role"}).
+			AddRow(1, nil, 1, 1, nil, "performer").
+			AddRow(2, nil, 1, 1, nil, "composer").
+			AddRow(3, nil, 2, nil, 1, "performer"))
+
+	store := repositories.NewSQLiteStore(mockDB)
+	ctx := context.Background()
+
+	albums, err := store.Albums.GetBySongIDs(ctx, []int{2, 1, 2})
+	if err != nil || len(albums[1]) != 1 || len(albums[2]) != 1 || albums[1][0].Title != "Parachutes" {
+		t.Errorf("Wrong albums: got %+v, %v", albums, err)
+	}
+
+	credits, err := store.Credits.GetBySongIDs(ctx, []int{1, 2})
+	if err != nil || len(credits[1]) != 2 || credits[1][1].Role != "composer" || len(credits[2]) != 1 || *credits[2][0].BandId != 1 {
+		t.Errorf("Wrong credits: got %+v, %v", credits, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
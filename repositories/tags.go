@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type TagRepository interface {
+	// List returns every tag in use with the number of entries carrying it,
+	// most used first. entityType limits the count to one kind of entry;
+	// empty counts them all.
+	List(ctx context.Context, entityType string) ([]models.TagCount, error)
+	// GetByEntityIDs returns the tag names of each entry, keyed by its ID.
+	GetByEntityIDs(ctx context.Context, entityType string, ids []int) (map[int][]string, error)
+	// Add attaches a tag to an entry, creating the tag if it is new. Adding
+	// a tag the entry already has does nothing.
+	Add(ctx context.Context, entityType string, id int, name string) error
+	Remove(ctx context.Context, entityType string, id int, name string) error
+}
+
+// tagLinks names the join table and column linking each kind of entry to
+// its tags.
+var tagLinks = map[string]struct{ table, column string }{
+	models.TagAlbum:  {"album_tags", "album_id"},
+	models.TagSong:   {"song_tags", "song_id"},
+	models.TagArtist: {"artist_tags", "artist_id"},
+	models.TagBand:   {"band_tags", "band_id"},
+}
+
+type SQLiteTagRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteTagRepository(db *sql.DB) *SQLiteTagRepository {
+	return &SQLiteTagRepository{db: db}
+}
+
+func (r *SQLiteTagRepository) List(ctx context.Context, entityType string) ([]models.TagCount, error) {
+	links := "SELECT tag_id FROM album_tags UNION ALL SELECT tag_id FROM song_tags " +
+		"UNION ALL SELECT tag_id FROM artist_tags UNION ALL SELECT tag_id FROM band_tags"
+	if link, ok := tagLinks[entityType]; ok {
+		links = "SELECT tag_id FROM " + link.table
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.name, COUNT(*) FROM tags t JOIN (`+links+`) l ON l.tag_id = t.id
+		GROUP BY t.id ORDER BY COUNT(*) DESC, t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.TagCount
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r *SQLiteTagRepository) GetByEntityIDs(ctx context.Context, entityType string, ids []int) (map[int][]string, error) {
+	link := tagLinks[entityType]
+	result := map[int][]string{}
+	err := inBatches(ids, func(placeholders string, args []interface{}) error {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT l.`+link.column+`, t.name FROM tags t JOIN `+link.table+` l ON t.id = l.tag_id
+			WHERE l.`+link.column+` IN (`+placeholders+`) ORDER BY t.name`,
+			args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				return err
+			}
+			result[id] = append(result[id], name)
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+func (r *SQLiteTagRepository) Add(ctx context.Context, entityType string, id int, name string) error {
+	link := tagLinks[entityType]
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO `+link.table+` (`+link.column+`, tag_id)
+		SELECT ?, id FROM tags WHERE name = ?`, id, name)
+	if err != nil {
+		return translateError(err)
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteTagRepository) Remove(ctx context.Context, entityType string, id int, name string) error {
+	link := tagLinks[entityType]
+	result, err := execInTx(ctx, r.db, `
+		DELETE FROM `+link.table+`
+		WHERE `+link.column+` = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)`, id, name)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/models"
+	"time"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) (int, error)
+	// GetRefreshToken looks a refresh token up by its hash, including
+	// revoked and expired ones so reuse can be detected.
+	GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error)
+	// RotateRefreshToken revokes the token with the given ID and stores next
+	// in its place. It returns ErrConflict when the token was already
+	// revoked, so only one of two concurrent refreshes succeeds.
+	RotateRefreshToken(ctx context.Context, id int, next models.RefreshToken) (int, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeRefreshTokens(ctx context.Context, userID int) error
+	// RevokeAccessToken denies an access token by ID until it expires.
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// RevokeAccessTokens denies every access token issued to the user before
+	// the given time.
+	RevokeAccessTokens(ctx context.Context, userID int, before time.Time) error
+	IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error)
+}
+
+type SQLiteTokenRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteTokenRepository(db *sql.DB) *SQLiteTokenRepository {
+	return &SQLiteTokenRepository{db: db}
+}
+
+const insertRefreshToken = "INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at) VALUES (?, ?, ?, ?)"
+
+// CreateRefreshToken stores a token and drops the user's expired ones, which
+// can no longer be exchanged.
+func (r *SQLiteTokenRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (int, error) {
+	if _, err := r.db.ExecContext(ctx,
+		"DELETE FROM refresh_tokens WHERE user_id = ? AND expires_at < ?", token.UserId, time.Now().Unix(),
+	); err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, insertRefreshToken, token.UserId, token.TokenHash, token.FamilyId, token.ExpiresAt.Unix())
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteTokenRepository) GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var expiresAt int64
+	var revokedAt sql.NullInt64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user_id, token_hash, family_id, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = ?", hash,
+	).Scan(&token.Id, &token.UserId, &token.TokenHash, &token.FamilyId, &expiresAt, &revokedAt)
+	if err != nil {
+		return models.RefreshToken{}, translateError(err)
+	}
+
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+	if revokedAt.Valid {
+		revoked := time.Unix(revokedAt.Int64, 0)
+		token.RevokedAt = &revoked
+	}
+	return token, nil
+}
+
+func (r *SQLiteTokenRepository) RotateRefreshToken(ctx context.Context, id int, next models.RefreshToken) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now().Unix(), id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireAffected(result); err != nil {
+		return 0, ErrConflict
+	}
+
+	result, err = tx.ExecContext(ctx, insertRefreshToken, next.UserId, next.TokenHash, next.FamilyId, next.ExpiresAt.Unix())
+	if err != nil {
+		return 0, translateError(err)
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(newID), tx.Commit()
+}
+
+func (r *SQLiteTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := execInTx(ctx, r.db,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", time.Now().Unix(), familyID,
+	)
+	return err
+}
+
+func (r *SQLiteTokenRepository) RevokeRefreshTokens(ctx context.Context, userID int) error {
+	_, err := execInTx(ctx, r.db,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now().Unix(), userID,
+	)
+	return err
+}
+
+// RevokeAccessToken adds a token to the denylist, pruning entries for tokens
+// that have expired and would be rejected anyway.
+func (r *SQLiteTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now().Unix()); err != nil {
+		return err
+	}
+	_, err := execInTx(ctx, r.db,
+		"INSERT OR IGNORE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", tokenID, expiresAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteTokenRepository) RevokeAccessTokens(ctx context.Context, userID int, before time.Time) error {
+	result, err := execInTx(ctx, r.db, "UPDATE users SET tokens_revoked_at = ? WHERE id = ?", before.Unix(), userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *SQLiteTokenRepository) IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+			OR EXISTS (SELECT 1 FROM users WHERE id = ? AND tokens_revoked_at > ?)`,
+		tokenID, userID, issuedAt.Unix(),
+	).Scan(&revoked)
+	return revoked, err
+}
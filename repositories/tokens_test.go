@@ -0,0 +1,89 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/models"
+	"goMusic/repositories"
+	"testing"
+	"time"
+)
+
+func TestTokenRepository(t *testing.T) {
+	stores := map[string]*repositories.Store{
+		"sqlite": repositories.NewSQLiteStore(openMigratedDB(t)),
+		"memory": repositories.NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			userID, err := store.Users.Create(ctx, models.User{Username: "alice", Password: "hash", Email: "alice@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+			firstID, err := store.Tokens.CreateRefreshToken(ctx, models.RefreshToken{UserId: userID, TokenHash: "first", FamilyId: "family", ExpiresAt: expires})
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, err := store.Tokens.GetRefreshToken(ctx, "first")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.Id != firstID || first.UserId != userID || first.FamilyId != "family" || !first.ExpiresAt.Equal(expires) || first.RevokedAt != nil {
+				t.Errorf("wrong refresh token: %+v", first)
+			}
+			if _, err := store.Tokens.GetRefreshToken(ctx, "missing"); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+
+			second := models.RefreshToken{UserId: userID, TokenHash: "second", FamilyId: "family", ExpiresAt: expires}
+			if _, err := store.Tokens.RotateRefreshToken(ctx, firstID, second); err != nil {
+				t.Fatal(err)
+			}
+			if first, _ := store.Tokens.GetRefreshToken(ctx, "first"); first.RevokedAt == nil {
+				t.Error("rotated token was not revoked")
+			}
+			third := models.RefreshToken{UserId: userID, TokenHash: "third", FamilyId: "family", ExpiresAt: expires}
+			if _, err := store.Tokens.RotateRefreshToken(ctx, firstID, third); !errors.Is(err, repositories.ErrConflict) {
+				t.Errorf("rotating a revoked token should conflict, got %v", err)
+			}
+			if _, err := store.Tokens.GetRefreshToken(ctx, "third"); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("a conflicting rotation should not store its replacement: %v", err)
+			}
+
+			if err := store.Tokens.RevokeRefreshTokenFamily(ctx, "family"); err != nil {
+				t.Fatal(err)
+			}
+			if second, _ := store.Tokens.GetRefreshToken(ctx, "second"); second.RevokedAt == nil {
+				t.Error("family was not revoked")
+			}
+
+			issued := time.Now().Add(-time.Minute)
+			if revoked, err := store.Tokens.IsRevoked(ctx, "jti", userID, issued); err != nil || revoked {
+				t.Errorf("fresh token reported revoked: %v %v", revoked, err)
+			}
+			if err := store.Tokens.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if revoked, _ := store.Tokens.IsRevoked(ctx, "jti", userID, issued); !revoked {
+				t.Error("denied token was not reported revoked")
+			}
+
+			if err := store.Tokens.RevokeAccessTokens(ctx, userID, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			if revoked, _ := store.Tokens.IsRevoked(ctx, "other", userID, issued); !revoked {
+				t.Error("token issued before the cutoff was not reported revoked")
+			}
+			if revoked, _ := store.Tokens.IsRevoked(ctx, "later", userID, time.Now().Add(time.Second)); revoked {
+				t.Error("token issued after the cutoff was reported revoked")
+			}
+			if err := store.Tokens.RevokeAccessTokens(ctx, 999, time.Now()); !errors.Is(err, repositories.ErrNotFound) {
+				t.Errorf("expected ErrNotFound for a missing user, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"goMusic/constants"
+	"goMusic/models"
+)
+
+type UserRepository interface {
+	// Create stores a user whose Password field already holds the hash.
+	Create(ctx context.Context, user models.User) (int, error)
+	GetByID(ctx context.Context, id int) (models.User, error)
+	// GetByUsername returns the user including the stored password hash.
+	GetByUsername(ctx context.Context, username string) (models.User, error)
+	GetAll(ctx context.Context) ([]models.User, error)
+	UpdateRole(ctx context.Context, id int, role constants.Role) error
+}
+
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+// Create stores a user. Users without a role are registered as viewers.
+func (r *SQLiteUserRepository) Create(ctx context.Context, user models.User) (int, error) {
+	if user.Role == "" {
+		user.Role = constants.Viewer.String()
+	}
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (username, password, email, role) VALUES (?, ?, ?, ?)",
+		user.Username, user.Password, user.Email, user.Role,
+	)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, email, role FROM users WHERE id = ?", id,
+	).Scan(&user.Id, &user.Username, &user.Email, &user.Role)
+	if err != nil {
+		return models.User{}, translateError(err)
+	}
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, password, email, role FROM users WHERE username = ?", username,
+	).Scan(&user.Id, &user.Username, &user.Password, &user.Email, &user.Role)
+	if err != nil {
+		return models.User{}, translateError(err)
+	}
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, username, email, role FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.Id, &user.Username, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *SQLiteUserRepository) UpdateRole(ctx context.Context, id int, role constants.Role) error {
+	result, err := execInTx(ctx, r.db, "UPDATE users SET role = ? WHERE id = ?", role.String(), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
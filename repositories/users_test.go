@@ -0,0 +1,95 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/constants"
+	"goMusic/models"
+	"goMusic/repositories"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteUserRepositoryCreate(t *testing.T) {
+	t.Run("successful insert", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer mockDB.Close()
+
+		mock.ExpectExec("INSERT INTO users").
+			WithArgs("testuser", "hash", "test@example.com", "viewer").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		id, err := repositories.NewSQLiteUserRepository(mockDB).Create(context.Background(), models.User{
+			Username: "testuser", Password: "hash", Email: "test@example.com",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+	})
+
+	t.Run("unique constraint maps to conflict", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer mockDB.Close()
+
+		mock.ExpectExec("INSERT INTO users").
+			WillReturnError(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique})
+
+		_, err = repositories.NewSQLiteUserRepository(mockDB).Create(context.Background(), models.User{
+			Username: "existinguser", Password: "hash", Email: "test@example.com",
+		})
+		assert.True(t, errors.Is(err, repositories.ErrConflict))
+	})
+}
+
+func TestSQLiteUserRepositoryGetByUsername(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	mock.ExpectQuery("SELECT id, username, password, email, role FROM users WHERE username = ?").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "email", "role"}).
+			AddRow(1, "testuser", "hash", "test@example.com", "editor"))
+
+	mock.ExpectQuery("SELECT id, username, password, email, role FROM users WHERE username = ?").
+		WithArgs("nonexistentuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "email", "role"}))
+
+	repo := repositories.NewSQLiteUserRepository(mockDB)
+
+	user, err := repo.GetByUsername(context.Background(), "testuser")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", user.Password)
+	assert.Equal(t, "editor", user.Role)
+
+	_, err = repo.GetByUsername(context.Background(), "nonexistentuser")
+	assert.True(t, errors.Is(err, repositories.ErrNotFound))
+}
+
+func TestSQLiteUserRepositoryUpdateRole(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET role = \\? WHERE id = \\?").
+		WithArgs("admin", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET role = \\? WHERE id = \\?").
+		WithArgs("editor", 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repo := repositories.NewSQLiteUserRepository(mockDB)
+
+	assert.NoError(t, repo.UpdateRole(context.Background(), 1, constants.Admin))
+	assert.True(t, errors.Is(repo.UpdateRole(context.Background(), 99, constants.Editor), repositories.ErrNotFound))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,115 +1,94 @@
 package services
 
 import (
-	"database/sql"
 	"encoding/json"
-	"goMusic/db"
+	"errors"
+	"goMusic/exporter"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
 	"goMusic/utils"
 	viewModelAlbum "goMusic/viewModels"
 	"net/http"
 )
 
-func GetAlbums(w http.ResponseWriter, r *http.Request) {
+type AlbumService struct {
+	store *repositories.Store
+}
+
+func NewAlbumService(store *repositories.Store) *AlbumService {
+	return &AlbumService{store: store}
+}
+
+func (s *AlbumService) GetAlbums(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	rows, err := db.DB.Query("SELECT id, title, price, artist_id, band_id FROM albums")
-	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+	q, ok := parseQuery(w, r, repositories.AlbumQuery)
+	if !ok {
 		return
 	}
-	defer rows.Close()
-
-	var albums []models.Album
 
-	for rows.Next() {
-		var album models.Album
-		var artistID, bandID sql.NullInt64
-
-		err := rows.Scan(&album.Id, &album.Title, &album.Price, &artistID, &bandID)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if artistID.Valid {
-			id := int(artistID.Int64)
-			album.ArtistId = &id
-		}
-
-		if bandID.Valid {
-			id := int(bandID.Int64)
-			album.BandId = &id
-		}
+	page, err := s.store.Albums.List(r.Context(), q)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
 
-		albums = append(albums, album)
+	albumVMs, err := viewModelAlbum.GetAlbumViewModels(r.Context(), s.store, page.Items)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		http.Error(w, "Error iterating rows: "+err.Error(), http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(query.WithItems(page, albumVMs))
+}
+
+func (s *AlbumService) GetAlbumByID(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+	album, err := s.store.Albums.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "album")
 		return
 	}
 
-	albumVMs, err := viewModelAlbum.GetAlbumViewModels(albums)
+	albumVM, err := viewModelAlbum.GetAlbumViewModel(r.Context(), s.store, album)
 	if err != nil {
-		http.Error(w, "Error generating view models: "+err.Error(), http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(albumVMs)
+	json.NewEncoder(w).Encode(albumVM)
 }
 
-func GetAlbumByID(w http.ResponseWriter, r *http.Request, id int) {
-	w.Header().Set("Content-Type", "application/json")
-	row, err := db.DB.Query("SELECT id, title, price, artist_id, band_id FROM albums WHERE id = ?", id)
+// ExportAlbum sends the album's tracklist as an XSPF or M3U8 playlist.
+func (s *AlbumService) ExportAlbum(w http.ResponseWriter, r *http.Request, id int) {
+	format, ok := exportFormat(w, r, exporter.TracklistFormats)
+	if !ok {
+		return
+	}
+	album, err := s.store.Albums.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, r, err, "album")
 		return
 	}
-	if row.Next() {
-		var album models.Album
-		var artistID, bandID sql.NullInt64
-
-		err := row.Scan(&album.Id, &album.Title, &album.Price, &artistID, &bandID)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if artistID.Valid {
-			id := int(artistID.Int64)
-			album.ArtistId = &id
-		}
-
-		if bandID.Valid {
-			id := int(bandID.Int64)
-			album.BandId = &id
-		}
-
-		albumVM, err := viewModelAlbum.GetAlbumViewModel(album)
-		if err != nil {
-			http.Error(w, "Error generating view model: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		json.NewEncoder(w).Encode(albumVM)
+
+	list, err := viewModelAlbum.GetAlbumTracklist(r.Context(), s.store, album)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{"message": "album not found"})
+	writeTracklist(w, r, list, format, "album", id)
 }
 
-func PostAlbum(w http.ResponseWriter, r *http.Request) {
+func (s *AlbumService) PostAlbum(w http.ResponseWriter, r *http.Request) {
 	var newAlbum models.Album
 
 	if !utils.DecodeAndValidate(w, r, &newAlbum) {
 		return
 	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"INSERT INTO albums (title, price, artist_id, band_id) VALUES (?, ?, ?, ?)",
-		newAlbum.Title, newAlbum.Price, newAlbum.ArtistId, newAlbum.BandId)
-
-	if !success {
+	if _, err := s.store.Albums.Create(r.Context(), newAlbum); err != nil {
+		writeAlbumError(w, r, err)
 		return
 	}
 
@@ -117,18 +96,15 @@ func PostAlbum(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
-func UpdateAlbumByID(w http.ResponseWriter, r *http.Request, id int) bool {
+func (s *AlbumService) UpdateAlbumByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	var updatedAlbum models.Album
 
 	if !utils.DecodeAndValidate(w, r, &updatedAlbum) {
 		return false
 	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"UPDATE albums SET title = ?, price = ?, artist_id = ?, band_id = ? WHERE id = ?",
-		updatedAlbum.Title, updatedAlbum.Price, updatedAlbum.ArtistId, updatedAlbum.BandId, id)
-
-	if !success {
+	if err := s.store.Albums.Update(r.Context(), id, updatedAlbum); err != nil {
+		writeAlbumError(w, r, err)
 		return false
 	}
 
@@ -137,15 +113,48 @@ func UpdateAlbumByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	return true
 }
 
-func DeleteAlbumByID(w http.ResponseWriter, id int) bool {
-	success := utils.ExecuteWithTransaction(w,
-		"DELETE FROM albums WHERE id = ?",
-		id)
+// writeAlbumError responds to a failed album write. The barcode is the only
+// thing two albums cannot share.
+func writeAlbumError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "Another album already has this barcode.")
+		return
+	}
+	writeRepositoryError(w, r, err, "album")
+}
 
-	if !success {
+func (s *AlbumService) DeleteAlbumByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Albums.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "album")
 		return false
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 	return true
 }
+
+// SetAlbumSongs replaces an album's tracks and responds with the album.
+func (s *AlbumService) SetAlbumSongs(w http.ResponseWriter, r *http.Request, id int) bool {
+	album, err := s.store.Albums.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	var req viewModelAlbum.AlbumSongsRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+	if err := s.store.Albums.SetSongs(r.Context(), id, req.SongIds); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	albumVM, err := viewModelAlbum.GetAlbumViewModel(r.Context(), s.store, album)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusOK, albumVM)
+	return true
+}
@@ -2,11 +2,11 @@ package services_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
-	"github.com/DATA-DOG/go-sqlmock"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
 	"goMusic/services"
 	"goMusic/viewModels"
 	"net/http"
@@ -15,31 +15,10 @@ import (
 )
 
 func TestGetAlbums(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	rows := sqlmock.NewRows([]string{"id", "title", "price", "artist_id", "band_id"}).
-		AddRow(1, "Parachutes", 9.99, nil, 1)
-
-	mock.ExpectQuery("SELECT id, title, price, artist_id, band_id FROM albums").
-		WillReturnRows(rows)
-
-	bandRows := sqlmock.NewRows([]string{"name"}).
-		AddRow("Coldplay")
-
-	mock.ExpectQuery("SELECT name FROM bands WHERE id = ?").
-		WithArgs(1).
-		WillReturnRows(bandRows)
+	store, _ := newTestStore(t)
+	bandID := seedBand(t, store, models.Band{Name: "Coldplay", Nationality: "British", DateFormed: "1996-01-01"})
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	seedCredit(t, store, models.Credit{AlbumId: &albumID, BandId: &bandID, Role: models.RolePerformer})
 
 	req, err := http.NewRequest("GET", "/albums", nil)
 	if err != nil {
@@ -47,60 +26,103 @@ func TestGetAlbums(t *testing.T) {
 	}
 	rr := httptest.NewRecorder()
 
-	services.GetAlbums(rr, req)
+	services.NewAlbumService(store).GetAlbums(rr, req)
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	var albums []viewModels.AlbumViewModel
-	if err := json.Unmarshal(rr.Body.Bytes(), &albums); err != nil {
+	var page query.Page[viewModels.AlbumViewModel]
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
+	albums := page.Items
+
 	if len(albums) != 1 || albums[0].Title != "Parachutes" {
 		t.Errorf("Wrong album data: got %+v", albums)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	if credits := albums[0].Credits; len(credits) != 1 || credits[0].Band == nil || credits[0].Band.Name != "Coldplay" {
+		t.Errorf("Missing or incorrect band credit: %+v", credits)
 	}
 }
 
-func TestGetAlbumByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+func TestGetAlbumsPagination(t *testing.T) {
+	store, _ := newTestStore(t)
+	for _, album := range []models.Album{
+		{Title: "Parachutes", Price: 999},
+		{Title: "OK Computer", Price: 1299},
+		{Title: "Kid A", Price: 1299},
+		{Title: "Pablo Honey", Price: 499},
+	} {
+		seedAlbum(t, store, album)
+	}
 
-	t.Run("Album found with band", func(t *testing.T) {
-		mockDB, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("Failed to create mock DB: %v", err)
+	var titles []string
+	target := "/albums?sort=-price,title&price_gte=500&limit=2"
+	for target != "" {
+		req := httptest.NewRequest("GET", target, nil)
+		rr := httptest.NewRecorder()
+
+		services.NewAlbumService(store).GetAlbums(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var page query.Page[viewModels.AlbumViewModel]
+		if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if page.Total != 3 {
+			t.Errorf("Wrong total: got %d want 3", page.Total)
+		}
+
+		for _, album := range page.Items {
+			titles = append(titles, album.Title)
+		}
+
+		target = ""
+		if page.NextCursor != "" {
+			target = "/albums?sort=-price,title&price_gte=500&limit=2&cursor=" + page.NextCursor
 		}
-		defer mockDB.Close()
-		db.DB = mockDB
+	}
+
+	want := []string{"Kid A", "OK Computer", "Parachutes"}
+	if len(titles) != len(want) || titles[0] != want[0] || titles[1] != want[1] || titles[2] != want[2] {
+		t.Errorf("Wrong albums: got %v want %v", titles, want)
+	}
+}
+
+func TestGetAlbumsInvalidQuery(t *testing.T) {
+	store, _ := newTestStore(t)
 
-		albumRows := sqlmock.NewRows([]string{"id", "title", "price", "artist_id", "band_id"}).
-			AddRow(1, "Parachutes", 9.99, nil, 1)
-		mock.ExpectQuery("SELECT id, title, price, artist_id, band_id FROM albums WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(albumRows)
+	for _, target := range []string{"/albums?sort=password", "/albums?limit=0", "/albums?price_gte=free"} {
+		req := httptest.NewRequest("GET", target, nil)
+		rr := httptest.NewRecorder()
+
+		services.NewAlbumService(store).GetAlbums(rr, req)
 
-		bandRows := sqlmock.NewRows([]string{"name", "nationality", "number_of_members", "date_formed", "age", "active"}).
-			AddRow("Coldplay", "British", 4, "1996-01-01", 27, true)
-		mock.ExpectQuery("SELECT name, nationality, number_of_members, date_formed, age, active FROM bands WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(bandRows)
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: wrong status code: got %v want %v", target, status, http.StatusBadRequest)
+		}
+	}
+}
 
-		songRows := sqlmock.NewRows([]string{"id", "title", "length", "price"})
-		mock.ExpectQuery("SELECT id, title, length, price FROM songs").
-			WillReturnRows(songRows)
+func TestGetAlbumByID(t *testing.T) {
+	t.Run("Album found with band", func(t *testing.T) {
+		store, mem := newTestStore(t)
+		bandID := seedBand(t, store, models.Band{Name: "Coldplay", Nationality: "British", DateFormed: "1996-01-01"})
+		albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+		seedCredit(t, store, models.Credit{AlbumId: &albumID, BandId: &bandID, Role: models.RolePerformer})
+		songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 269, Price: 129})
+		mem.LinkAlbumSong(albumID, songID)
 
 		req := httptest.NewRequest("GET", "/albums/1", nil)
 		res := httptest.NewRecorder()
 
-		services.GetAlbumByID(res, req, 1)
+		services.NewAlbumService(store).GetAlbumByID(res, req, albumID)
 
 		if status := res.Code; status != http.StatusOK {
 			t.Errorf("Wrong status code: got %v want %v", status, http.StatusOK)
@@ -111,53 +133,33 @@ func TestGetAlbumByID(t *testing.T) {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
 
-		if album.Title != "Parachutes" || album.Price != 9.99 {
+		if album.Title != "Parachutes" || album.Price != 999 {
 			t.Errorf("Wrong album data: got %+v", album)
 		}
 
-		if album.Band == nil || album.Band.Name != "Coldplay" {
-			t.Errorf("Missing or incorrect band data: %+v", album.Band)
+		if len(album.Credits) != 1 || album.Credits[0].Band == nil || album.Credits[0].Band.Name != "Coldplay" {
+			t.Errorf("Missing or incorrect band credit: %+v", album.Credits)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		if len(album.Songs) != 1 || album.Songs[0].Title != "Yellow" {
+			t.Errorf("Wrong song data: got %+v", album.Songs)
 		}
 	})
 
 	t.Run("Album found with artist", func(t *testing.T) {
-		mockDB, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("Failed to create mock DB: %v", err)
-		}
-		defer mockDB.Close()
-		db.DB = mockDB
-
-		artistID := 2
-		albumRows := sqlmock.NewRows([]string{"id", "title", "price", "artist_id", "band_id"}).
-			AddRow(2, "Kind of Blue", 12.99, artistID, nil)
-		mock.ExpectQuery("SELECT id, title, price, artist_id, band_id FROM albums WHERE id = ?").
-			WithArgs(2).
-			WillReturnRows(albumRows)
-
-		artistRows := sqlmock.NewRows([]string{
-			"first_name", "last_name", "nationality", "birth_date",
-			"age", "alive", "sex", "title", "band_name",
-		}).AddRow(
-			"Miles", "Davis", "American", "1926-05-26",
-			65, false, "Male", "Mr.", nil,
-		)
-		mock.ExpectQuery("SELECT a.first_name, a.last_name, a.nationality").
-			WithArgs(artistID).
-			WillReturnRows(artistRows)
-
-		songRows := sqlmock.NewRows([]string{"id", "title", "length", "price"})
-		mock.ExpectQuery("SELECT id, title, length, price FROM songs").
-			WillReturnRows(songRows)
-
-		req := httptest.NewRequest("GET", "/albums/2", nil)
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, models.Artist{
+			FirstName: "Miles", LastName: "Davis", Nationality: "American", BirthDate: "1926-05-26",
+			SexId: intPtr(1), TitleId: intPtr(1),
+		})
+		albumID := seedAlbum(t, store, models.Album{Title: "Kind of Blue", Price: 1299})
+		seedCredit(t, store, models.Credit{AlbumId: &albumID, ArtistId: &artistID, Role: models.RolePerformer})
+		seedCredit(t, store, models.Credit{AlbumId: &albumID, ArtistId: &artistID, Role: models.RoleProducer})
+
+		req := httptest.NewRequest("GET", "/albums/1", nil)
 		res := httptest.NewRecorder()
 
-		services.GetAlbumByID(res, req, 2)
+		services.NewAlbumService(store).GetAlbumByID(res, req, albumID)
 
 		if status := res.Code; status != http.StatusOK {
 			t.Errorf("Wrong status code: got %v want %v", status, http.StatusOK)
@@ -168,99 +170,65 @@ func TestGetAlbumByID(t *testing.T) {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
 
-		if album.Title != "Kind of Blue" || album.Artist == nil {
-			t.Errorf("Wrong album data: got %+v", album)
+		if album.Title != "Kind of Blue" || len(album.Credits) != 2 {
+			t.Fatalf("Wrong album data: got %+v", album)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		for i, role := range []string{models.RolePerformer, models.RoleProducer} {
+			credit := album.Credits[i]
+			if credit.Role != role || credit.Artist == nil || credit.Artist.LastName != "Davis" || credit.Band != nil {
+				t.Errorf("Wrong credit %d: got %+v", i, credit)
+			}
 		}
 	})
 
 	t.Run("Album not found", func(t *testing.T) {
-		mockDB, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("Failed to create mock DB: %v", err)
-		}
-		defer mockDB.Close()
-		db.DB = mockDB
-
-		albumRows := sqlmock.NewRows([]string{"id", "title", "price", "artist_id", "band_id"})
-		mock.ExpectQuery("SELECT id, title, price, artist_id, band_id FROM albums WHERE id = ?").
-			WithArgs(999).
-			WillReturnRows(albumRows)
+		store, _ := newTestStore(t)
 
 		req := httptest.NewRequest("GET", "/albums/999", nil)
 		res := httptest.NewRecorder()
 
-		services.GetAlbumByID(res, req, 999)
+		services.NewAlbumService(store).GetAlbumByID(res, req, 999)
 
 		if status := res.Code; status != http.StatusNotFound {
 			t.Errorf("Wrong status code: got %v want %v", status, http.StatusNotFound)
 		}
 
-		var response map[string]string
-		if err := json.Unmarshal(res.Body.Bytes(), &response); err != nil {
-			t.Fatalf("Failed to unmarshal response: %v", err)
+		if contentType := res.Header().Get("Content-Type"); contentType != problem.ContentType {
+			t.Errorf("Wrong content type: got %q want %q", contentType, problem.ContentType)
 		}
 
-		if message, exists := response["message"]; !exists || message != "album not found" {
-			t.Errorf("Wrong error message: got %v", response)
+		var response problem.Problem
+		if err := json.Unmarshal(res.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		if response.Code != problem.NotFound || response.Detail != "album not found" || response.Instance != "/albums/999" {
+			t.Errorf("Wrong problem: got %+v", response)
 		}
 	})
 
 	t.Run("Database error", func(t *testing.T) {
-		mockDB, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("Failed to create mock DB: %v", err)
-		}
-		defer mockDB.Close()
-		db.DB = mockDB
-
-		// Return error on query
-		mock.ExpectQuery("SELECT id, title, price, artist_id, band_id FROM albums WHERE id = ?").
-			WithArgs(1).
-			WillReturnError(errors.New("database connection lost"))
+		store, _ := newTestStore(t)
+		store.Albums = failingAlbumRepository{}
 
 		req := httptest.NewRequest("GET", "/albums/1", nil)
 		res := httptest.NewRecorder()
 
-		services.GetAlbumByID(res, req, 1)
+		services.NewAlbumService(store).GetAlbumByID(res, req, 1)
 
 		if status := res.Code; status != http.StatusInternalServerError {
 			t.Errorf("Wrong status code: got %v want %v", status, http.StatusInternalServerError)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
 	})
 }
 
 func TestPostAlbum(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
+	store, _ := newTestStore(t)
 
-	bandID := 1
 	album := models.Album{
-		Title:    "Parachutes",
-		Price:    9.99,
-		BandId:   &bandID,
-		ArtistId: nil,
+		Title: "Parachutes",
+		Price: 999,
 	}
 
 	albumJSON, _ := json.Marshal(album)
@@ -271,101 +239,82 @@ func TestPostAlbum(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO albums").
-		WithArgs(album.Title, album.Price, album.ArtistId, album.BandId).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
-
-	services.PostAlbum(rr, req)
+	services.NewAlbumService(store).PostAlbum(rr, req)
 
 	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	stored, err := store.Albums.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Album was not stored: %v", err)
+	}
+	if stored.Title != album.Title || stored.Price != album.Price {
+		t.Errorf("Wrong album stored: got %+v", stored)
 	}
 }
 
 func TestUpdateAlbumByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
 	t.Run("Successful update", func(t *testing.T) {
-		band := models.Band{
-			Name:            "Coldplay",
-			Nationality:     "British",
-			NumberOfMembers: 4,
-			DateFormed:      "1996-01-01",
-			Age:             28,
-			Active:          true,
-		}
+		store, _ := newTestStore(t)
+		albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
 
-		bandJSON, _ := json.Marshal(band)
-		req, err := http.NewRequest("PUT", "/bands/1", bytes.NewBuffer(bandJSON))
+		album := models.Album{Title: "Parachutes (Deluxe)", Price: 1499}
+		albumJSON, _ := json.Marshal(album)
+		req, err := http.NewRequest("PUT", "/albums/1", bytes.NewBuffer(albumJSON))
 		if err != nil {
 			t.Fatal(err)
 		}
 		req.Header.Set("Content-Type", "application/json")
 		rr := httptest.NewRecorder()
 
-		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE bands SET").
-			WithArgs(band.Name, band.Nationality, band.NumberOfMembers, band.DateFormed, band.Age, band.Active, 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
-
-		result := services.UpdateBandByID(rr, req, 1)
+		result := services.NewAlbumService(store).UpdateAlbumByID(rr, req, albumID)
 
 		if !result {
-			t.Errorf("UpdateBandByID returned false, expected true")
+			t.Errorf("UpdateAlbumByID returned false, expected true")
 		}
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		stored, _ := store.Albums.GetByID(context.Background(), albumID)
+		if stored.Title != album.Title || stored.Price != album.Price {
+			t.Errorf("Album was not updated: got %+v", stored)
 		}
 	})
-}
 
-func TestDeleteAlbumByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+	t.Run("Album not found", func(t *testing.T) {
+		store, _ := newTestStore(t)
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
+		albumJSON, _ := json.Marshal(models.Album{Title: "Parachutes", Price: 999})
+		req, err := http.NewRequest("PUT", "/albums/999", bytes.NewBuffer(albumJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		result := services.NewAlbumService(store).UpdateAlbumByID(rr, req, 999)
+
+		if result {
+			t.Errorf("UpdateAlbumByID returned true, expected false")
+		}
 
-	db.DB = mockDB
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
 
+func TestDeleteAlbumByID(t *testing.T) {
 	t.Run("Successful delete", func(t *testing.T) {
-		rr := httptest.NewRecorder()
+		store, _ := newTestStore(t)
+		albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
 
-		mock.ExpectBegin()
-		mock.ExpectExec("DELETE FROM albums WHERE id = ?").
-			WithArgs(1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
+		req := httptest.NewRequest("DELETE", "/albums/1", nil)
+		rr := httptest.NewRecorder()
 
-		result := services.DeleteAlbumByID(rr, 1)
+		result := services.NewAlbumService(store).DeleteAlbumByID(rr, req, albumID)
 
 		if !result {
 			t.Errorf("DeleteAlbumByID returned false, expected true")
@@ -375,8 +324,25 @@ func TestDeleteAlbumByID(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		if _, err := store.Albums.GetByID(context.Background(), albumID); err == nil {
+			t.Errorf("Album was not deleted")
+		}
+	})
+
+	t.Run("Album not found", func(t *testing.T) {
+		store, _ := newTestStore(t)
+
+		req := httptest.NewRequest("DELETE", "/albums/999", nil)
+		rr := httptest.NewRecorder()
+
+		result := services.NewAlbumService(store).DeleteAlbumByID(rr, req, 999)
+
+		if result {
+			t.Errorf("DeleteAlbumByID returned true, expected false")
+		}
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 		}
 	})
 }
@@ -2,121 +2,112 @@ package services
 
 import (
 	"encoding/json"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
 	"goMusic/utils"
 	viewModelArtist "goMusic/viewModels"
 	"net/http"
 )
 
-func GetArtists(w http.ResponseWriter, r *http.Request) {
+type ArtistService struct {
+	store *repositories.Store
+}
+
+func NewArtistService(store *repositories.Store) *ArtistService {
+	return &ArtistService{store: store}
+}
+
+func (s *ArtistService) GetArtists(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	rows, err := db.DB.Query("SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id FROM artists")
-	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+	q, ok := parseQuery(w, r, repositories.ArtistQuery)
+	if !ok {
 		return
 	}
-	defer rows.Close()
-
-	var artists []models.Artist
-
-	for rows.Next() {
-		var artist models.Artist
-
-		err := rows.Scan(&artist.Id, &artist.FirstName, &artist.LastName, &artist.Nationality, &artist.BirthDate, &artist.Age, &artist.Alive, &artist.SexId, &artist.TitleId, &artist.BandId)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		artists = append(artists, artist)
+	asOf, ok := parseDateParam(w, r, "as_of")
+	if !ok {
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		http.Error(w, "Error iterating rows: "+err.Error(), http.StatusInternalServerError)
+	page, err := s.store.Artists.List(r.Context(), q, asOf)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	artistVMs, err := viewModelArtist.GetArtistViewModels(artists)
+	artistVMs, err := viewModelArtist.GetArtistViewModels(r.Context(), s.store, page.Items, asOf)
 	if err != nil {
-		http.Error(w, "Error generating view models: "+err.Error(), http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(artistVMs)
+	json.NewEncoder(w).Encode(query.WithItems(page, artistVMs))
 }
 
-func GetArtistByID(w http.ResponseWriter, r *http.Request, id int) {
+func (s *ArtistService) GetArtistByID(w http.ResponseWriter, r *http.Request, id int) {
 	w.Header().Set("Content-Type", "application/json")
-	row, err := db.DB.Query("SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id FROM artists WHERE id = ?", id)
+	asOf, ok := parseDateParam(w, r, "as_of")
+	if !ok {
+		return
+	}
+	artist, err := s.store.Artists.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, r, err, "artist")
 		return
 	}
-	if row.Next() {
-		var artist models.Artist
-
-		err := row.Scan(&artist.Id, &artist.FirstName, &artist.LastName, &artist.Nationality, &artist.BirthDate, &artist.Age, &artist.Alive, &artist.SexId, &artist.TitleId, &artist.BandId)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		artistVM, err := viewModelArtist.GetArtistViewModel(artist)
-		if err != nil {
-			http.Error(w, "Error generating view model: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		json.NewEncoder(w).Encode(artistVM)
+
+	artistVM, err := viewModelArtist.GetArtistViewModel(r.Context(), s.store, artist, asOf)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{"message": "band not found"})
+
+	json.NewEncoder(w).Encode(artistVM)
 }
 
-func PostArtist(w http.ResponseWriter, r *http.Request) {
+func (s *ArtistService) PostArtist(w http.ResponseWriter, r *http.Request) {
 	var newArtist models.Artist
 
 	if !utils.DecodeAndValidate(w, r, &newArtist) {
 		return
 	}
-	success := utils.ExecuteWithTransaction(w,
-		"INSERT INTO artists (first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		newArtist.FirstName, newArtist.LastName, newArtist.Nationality, newArtist.BirthDate, newArtist.Age, newArtist.Alive, newArtist.SexId, newArtist.TitleId, newArtist.BandId)
+	if !datesInOrder(w, r, "birth_date", &newArtist.BirthDate, "death_date", newArtist.DeathDate) {
+		return
+	}
 
-	if !success {
+	if _, err := s.store.Artists.Create(r.Context(), newArtist); err != nil {
+		writeRepositoryError(w, r, err, "artist")
 		return
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 }
 
-func UpdateArtistByID(w http.ResponseWriter, r *http.Request, id int) bool {
+func (s *ArtistService) UpdateArtistByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	var updatedArtist models.Artist
 
 	if !utils.DecodeAndValidate(w, r, &updatedArtist) {
 		return false
 	}
+	if !datesInOrder(w, r, "birth_date", &updatedArtist.BirthDate, "death_date", updatedArtist.DeathDate) {
+		return false
+	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"UPDATE artists SET first_name = ?, last_name = ?, nationality = ?, birth_date = ?, age = ?, alive = ?, sex_id = ?, title_id = ?, band_id = ? WHERE id = ?",
-		updatedArtist.FirstName, updatedArtist.LastName, updatedArtist.Nationality, updatedArtist.BirthDate, updatedArtist.Age, updatedArtist.Alive, updatedArtist.SexId, updatedArtist.TitleId, updatedArtist.BandId, id)
-
-	if !success {
+	if err := s.store.Artists.Update(r.Context(), id, updatedArtist); err != nil {
+		writeRepositoryError(w, r, err, "artist")
 		return false
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedArtist)
 	return true
 }
 
-func DeleteArtistByID(w http.ResponseWriter, id int) bool {
-	success := utils.ExecuteWithTransaction(w,
-		"DELETE FROM artists WHERE id = ?",
-		id)
-
-	if !success {
+func (s *ArtistService) DeleteArtistByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Artists.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "artist")
 		return false
 	}
 
@@ -2,35 +2,31 @@ package services_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"github.com/DATA-DOG/go-sqlmock"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/query"
 	"goMusic/services"
+	"goMusic/viewModels"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 )
 
-func TestGetArtists(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+func newTestArtist() models.Artist {
+	return models.Artist{
+		FirstName:   "Ed",
+		LastName:    "Sheeran",
+		Nationality: "British",
+		BirthDate:   "1991-02-17",
+		SexId:       intPtr(1),
+		TitleId:     intPtr(1),
 	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "age", "alive", "sex_id", "title_id", "band_id"}).
-		AddRow(1, "Ed", "Sheeran", "British", "1991-02-17", 32, true, nil, nil, nil)
+}
 
-	mock.ExpectQuery("SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id FROM artists").
-		WillReturnRows(rows)
+func TestGetArtists(t *testing.T) {
+	store, _ := newTestStore(t)
+	seedArtist(t, store, newTestArtist())
 
 	req, err := http.NewRequest("GET", "/artists", nil)
 	if err != nil {
@@ -38,38 +34,28 @@ func TestGetArtists(t *testing.T) {
 	}
 	rr := httptest.NewRecorder()
 
-	services.GetArtists(rr, req)
+	services.NewArtistService(store).GetArtists(rr, req)
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	var page query.Page[viewModels.ArtistViewModel]
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
-}
 
-func TestGetArtistByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+	artists := page.Items
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	if len(artists) != 1 || artists[0].FirstName != "Ed" || artists[0].Sex != "Male" {
+		t.Errorf("Wrong artist data: got %+v", artists)
 	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
+}
 
+func TestGetArtistByID(t *testing.T) {
 	t.Run("Artist found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "age", "alive", "sex_id", "title_id", "band_id"}).
-			AddRow(1, "Ed", "Sheeran", "British", "1991-02-17", 32, true, nil, nil, nil)
-
-		mock.ExpectQuery("SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id FROM artists WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, newTestArtist())
 
 		rr := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/artists/1", nil)
@@ -77,23 +63,65 @@ func TestGetArtistByID(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		services.GetArtistByID(rr, req, 1)
+		services.NewArtistService(store).GetArtistByID(rr, req, artistID)
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		var artist viewModels.ArtistViewModel
+		if err := json.Unmarshal(rr.Body.Bytes(), &artist); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if artist.LastName != "Sheeran" || artist.Title != "Mr." {
+			t.Errorf("Wrong artist data: got %+v", artist)
 		}
 	})
 
-	t.Run("Artist not found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "age", "alive", "sex_id", "title_id", "band_id"})
+	t.Run("As of a date", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		artist := newTestArtist()
+		died := "2030-01-01"
+		artist.DeathDate = &died
+		artistID := seedArtist(t, store, artist)
+
+		for asOf, want := range map[string]viewModels.ArtistViewModel{
+			"2001-02-16": {Age: 9, Alive: true},
+			"2001-02-17": {Age: 10, Alive: true},
+			"2040-06-01": {Age: 38, Alive: false},
+		} {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/artists/1?as_of="+asOf, nil)
+
+			services.NewArtistService(store).GetArtistByID(rr, req, artistID)
+
+			var got viewModels.ArtistViewModel
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if got.Age != want.Age || got.Alive != want.Alive || got.DeathDate == nil {
+				t.Errorf("as_of=%s: got age %d alive %v, want age %d alive %v", asOf, got.Age, got.Alive, want.Age, want.Alive)
+			}
+		}
+	})
 
-		mock.ExpectQuery("SELECT id, first_name, last_name, nationality, birth_date, age, alive, sex_id, title_id, band_id FROM artists WHERE id = ?").
-			WithArgs(999).
-			WillReturnRows(rows)
+	t.Run("Invalid as_of", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, newTestArtist())
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/artists/1?as_of=2001-02-30", nil)
+
+		services.NewArtistService(store).GetArtistByID(rr, req, artistID)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Artist not found", func(t *testing.T) {
+		store, _ := newTestStore(t)
 
 		rr := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/artists/999", nil)
@@ -101,45 +129,17 @@ func TestGetArtistByID(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		services.GetArtistByID(rr, req, 999)
+		services.NewArtistService(store).GetArtistByID(rr, req, 999)
 
 		if status := rr.Code; status != http.StatusNotFound {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
-		}
 	})
 }
 
 func TestPostArtist(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	sexId := 1
-	titleId := 1
-	artist := models.Artist{
-		FirstName:   "Ed",
-		LastName:    "Sheeran",
-		Nationality: "British",
-		BirthDate:   "1991-02-17",
-		Age:         32,
-		Alive:       true,
-		SexId:       &sexId,
-		TitleId:     &titleId,
-		BandId:      nil,
-	}
+	store, _ := newTestStore(t)
+	artist := newTestArtist()
 
 	artistJSON, _ := json.Marshal(artist)
 	req, err := http.NewRequest("POST", "/artists", bytes.NewBuffer(artistJSON))
@@ -149,51 +149,29 @@ func TestPostArtist(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO artists").
-		WithArgs(artist.FirstName, artist.LastName, artist.Nationality, artist.BirthDate, artist.Age, artist.Alive, artist.SexId, artist.TitleId, artist.BandId).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
-
-	services.PostArtist(rr, req)
+	services.NewArtistService(store).PostArtist(rr, req)
 
 	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	stored, err := store.Artists.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Artist was not stored: %v", err)
+	}
+	if stored.FirstName != artist.FirstName || stored.BirthDate != artist.BirthDate {
+		t.Errorf("Wrong artist stored: got %+v", stored)
 	}
 }
 
 func TestUpdateArtistByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-	sexId := 1
-	titleId := 1
 	t.Run("Successful update", func(t *testing.T) {
-		artist := models.Artist{
-			FirstName:   "Ed",
-			LastName:    "Sheeran",
-			Nationality: "British",
-			BirthDate:   "1991-02-17",
-			Age:         32,
-			Alive:       true,
-			SexId:       &sexId,
-			TitleId:     &titleId,
-			BandId:      nil,
-		}
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, newTestArtist())
 
+		artist := newTestArtist()
+		ended := "2020-01-01"
+		artist.DeathDate = &ended
 		artistJSON, _ := json.Marshal(artist)
 		req, err := http.NewRequest("PUT", "/artists/1", bytes.NewBuffer(artistJSON))
 		if err != nil {
@@ -202,13 +180,7 @@ func TestUpdateArtistByID(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rr := httptest.NewRecorder()
 
-		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE artists SET").
-			WithArgs(artist.FirstName, artist.LastName, artist.Nationality, artist.BirthDate, artist.Age, artist.Alive, artist.SexId, artist.TitleId, artist.BandId, 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
-
-		result := services.UpdateArtistByID(rr, req, 1)
+		result := services.NewArtistService(store).UpdateArtistByID(rr, req, artistID)
 
 		if !result {
 			t.Errorf("UpdateArtistByID returned false, expected true")
@@ -218,40 +190,72 @@ func TestUpdateArtistByID(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		stored, _ := store.Artists.GetByID(context.Background(), artistID)
+		if stored.DeathDate == nil || *stored.DeathDate != ended {
+			t.Errorf("Artist was not updated: got %+v", stored)
 		}
 	})
-}
-
-func TestDeleteArtistByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
 
-	db.DB = mockDB
+	t.Run("Invalid body", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, newTestArtist())
 
-	t.Run("Successful delete", func(t *testing.T) {
-		_, err := http.NewRequest("DELETE", "/artists/1", nil)
+		artist := newTestArtist()
+		artist.FirstName = ""
+		artistJSON, _ := json.Marshal(artist)
+		req, err := http.NewRequest("PUT", "/artists/1", bytes.NewBuffer(artistJSON))
 		if err != nil {
 			t.Fatal(err)
 		}
 		rr := httptest.NewRecorder()
 
-		mock.ExpectBegin()
-		mock.ExpectExec("DELETE FROM artists WHERE id = ?").
-			WithArgs(1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
+		result := services.NewArtistService(store).UpdateArtistByID(rr, req, artistID)
+
+		if result {
+			t.Errorf("UpdateArtistByID returned true, expected false")
+		}
 
-		result := services.DeleteArtistByID(rr, 1)
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestArtistDatesAreValidated(t *testing.T) {
+	store, _ := newTestStore(t)
+	artistID := seedArtist(t, store, newTestArtist())
+
+	for name, edit := range map[string]func(*models.Artist){
+		"Partial birth date": func(a *models.Artist) { a.BirthDate = "1991" },
+		"Impossible date":    func(a *models.Artist) { died := "2020-02-30"; a.DeathDate = &died },
+		"Death before birth": func(a *models.Artist) { died := "1990-01-01"; a.DeathDate = &died },
+	} {
+		t.Run(name, func(t *testing.T) {
+			artist := newTestArtist()
+			edit(&artist)
+			artistJSON, _ := json.Marshal(artist)
+			req := httptest.NewRequest("PUT", "/artists/1", bytes.NewBuffer(artistJSON))
+			rr := httptest.NewRecorder()
+
+			if services.NewArtistService(store).UpdateArtistByID(rr, req, artistID) {
+				t.Errorf("UpdateArtistByID returned true, expected false")
+			}
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestDeleteArtistByID(t *testing.T) {
+	t.Run("Successful delete", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		artistID := seedArtist(t, store, newTestArtist())
+
+		req := httptest.NewRequest("DELETE", "/artists/1", nil)
+		rr := httptest.NewRecorder()
+
+		result := services.NewArtistService(store).DeleteArtistByID(rr, req, artistID)
 
 		if !result {
 			t.Errorf("DeleteArtistByID returned false, expected true")
@@ -260,9 +264,5 @@ func TestDeleteArtistByID(t *testing.T) {
 		if status := rr.Code; status != http.StatusNoContent {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
-		}
 	})
 }
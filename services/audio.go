@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"goMusic/audio"
+	"goMusic/blobs"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/validation"
+	"goMusic/viewModels"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxAudioSize is the largest audio file that can be uploaded.
+const MaxAudioSize = 200 << 20
+
+type AudioService struct {
+	store *repositories.Store
+	blobs *blobs.Store
+}
+
+func NewAudioService(store *repositories.Store, blobs *blobs.Store) *AudioService {
+	return &AudioService{store: store, blobs: blobs}
+}
+
+// UploadAudio stores an MP3, FLAC or Ogg file for a song, sent as the "file"
+// field of a multipart form, replacing any earlier one. The song's length is
+// taken from the file. The title and artist tags are only suggested, unless
+// ?apply=true asks for them to be applied as well.
+func (s *AudioService) UploadAudio(w http.ResponseWriter, r *http.Request, id int) bool {
+	song, err := s.store.Songs.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return false
+	}
+
+	apply := false
+	if value := r.URL.Query().Get("apply"); value != "" {
+		if apply, err = strconv.ParseBool(value); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "apply must be true or false")
+			return false
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAudioSize)
+	part, err := uploadedFile(r)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "Send the audio as the file field of a multipart/form-data request.")
+		return false
+	}
+
+	record, meta, ok := s.receive(w, r, part)
+	if !ok {
+		return false
+	}
+	record.SongId = id
+
+	suggestions, err := s.suggest(r, meta)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+
+	var applied []string
+	if record.Length > 0 {
+		song.Length = record.Length
+		applied = append(applied, "length")
+	}
+	// A title tag the song would not validate with is only suggested.
+	retitled := song
+	retitled.Title = meta.Title
+	if apply && meta.Title != "" && validation.ValidateStruct(retitled) == nil {
+		song = retitled
+		applied = append(applied, "title")
+	}
+	var credits []models.Credit
+	if apply && suggestions.ArtistId != nil {
+		credits = append(credits, models.Credit{SongId: &id, ArtistId: suggestions.ArtistId, Role: models.RolePerformer})
+	}
+	if apply && suggestions.BandId != nil {
+		credits = append(credits, models.Credit{SongId: &id, BandId: suggestions.BandId, Role: models.RolePerformer})
+	}
+
+	upload := models.AudioUpload{Audio: record, Credits: credits}
+	if len(applied) > 0 {
+		upload.Song = &song
+	}
+	if err := s.store.Audio.Save(r.Context(), upload); err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return false
+	}
+	if len(credits) > 0 {
+		applied = append(applied, "credits")
+	}
+
+	writeJSON(w, http.StatusCreated, viewModels.AudioUploadViewModel{
+		Song:        song,
+		Audio:       viewModels.GetAudioViewModel(record),
+		Suggestions: suggestions,
+		Applied:     append([]string{}, applied...),
+	})
+	return true
+}
+
+// StreamAudio serves a song's audio file. Range requests are supported so
+// players can seek, and the content digest is used as the ETag.
+func (s *AudioService) StreamAudio(w http.ResponseWriter, r *http.Request, id int) {
+	record, err := s.store.Audio.GetBySongID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "audio")
+		return
+	}
+
+	file, err := s.blobs.Open(record.Digest)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", audio.Format(record.Format).MIMEType())
+	w.Header().Set("ETag", `"`+record.Digest+`"`)
+	http.ServeContent(w, r, "", record.UploadedAt, file)
+}
+
+// receive checks that an upload is a supported audio file, reads its tags
+// and commits it to the blob store. Files that are not accepted are never
+// committed.
+func (s *AudioService) receive(w http.ResponseWriter, r *http.Request, part io.Reader) (models.SongAudio, audio.Metadata, bool) {
+	content := bufio.NewReader(part)
+	header, _ := content.Peek(audio.HeaderSize)
+	if audio.Detect(header) == "" {
+		problem.Error(w, r, http.StatusUnsupportedMediaType, problem.UnsupportedMedia, "Upload an MP3, FLAC or Ogg file.")
+		return models.SongAudio{}, audio.Metadata{}, false
+	}
+
+	upload, err := s.blobs.Create()
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return models.SongAudio{}, audio.Metadata{}, false
+	}
+	if _, err := io.Copy(upload, content); err != nil {
+		upload.Abort()
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Error(w, r, http.StatusRequestEntityTooLarge, problem.PayloadTooLarge,
+				"Audio files can be at most "+strconv.Itoa(MaxAudioSize>>20)+" MB.")
+		} else {
+			problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "The upload could not be read.")
+		}
+		return models.SongAudio{}, audio.Metadata{}, false
+	}
+
+	meta, err := audio.Parse(upload, upload.Size())
+	if err != nil {
+		upload.Abort()
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidAudio, "The audio file could not be read.")
+		return models.SongAudio{}, audio.Metadata{}, false
+	}
+
+	blob, err := upload.Commit()
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return models.SongAudio{}, audio.Metadata{}, false
+	}
+
+	return models.SongAudio{
+		Digest:      blob.Digest,
+		Format:      string(meta.Format),
+		Size:        blob.Size,
+		Length:      int(meta.Length.Round(time.Second) / time.Second),
+		Title:       meta.Title,
+		Artist:      meta.Artist,
+		TrackNumber: meta.TrackNumber,
+		UploadedAt:  time.Now(),
+	}, meta, true
+}
+
+// suggest turns the tags into suggestions, looking the artist tag up among
+// the artists' full names and the band names.
+func (s *AudioService) suggest(r *http.Request, meta audio.Metadata) (viewModels.TagSuggestionsViewModel, error) {
+	vm := viewModels.TagSuggestionsViewModel{
+		Title:       meta.Title,
+		Artist:      meta.Artist,
+		TrackNumber: meta.TrackNumber,
+		Length:      int(meta.Length.Round(time.Second) / time.Second),
+	}
+	if meta.Artist == "" {
+		return vm, nil
+	}
+
+	artists, err := s.store.Artists.GetByName(r.Context(), meta.Artist)
+	if err != nil {
+		return vm, err
+	}
+	if len(artists) == 1 {
+		vm.ArtistId = &artists[0].Id
+	}
+
+	bands, err := s.store.Bands.GetByName(r.Context(), meta.Artist)
+	if err != nil {
+		return vm, err
+	}
+	if len(bands) == 1 {
+		vm.BandId = &bands[0].Id
+	}
+
+	return vm, nil
+}
+
+// uploadedFile finds the "file" part of a multipart request without
+// buffering the whole body.
+func uploadedFile(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"goMusic/blobs"
+	"goMusic/models"
+	"goMusic/repositories"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAudioService(t *testing.T, store *repositories.Store) *services.AudioService {
+	t.Helper()
+	files, err := blobs.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewAudioService(store, files)
+}
+
+// testMP3 is an MP3 file with an ID3v2.3 tag and ten seconds of 128 kbit/s
+// frames.
+func testMP3(title, artist, track string) []byte {
+	var tag bytes.Buffer
+	for _, frame := range [][2]string{{"TIT2", title}, {"TPE1", artist}, {"TRCK", track}} {
+		tag.WriteString(frame[0])
+		binary.Write(&tag, binary.BigEndian, uint32(len(frame[1])+1))
+		tag.Write([]byte{0, 0, 3})
+		tag.WriteString(frame[1])
+	}
+
+	// The tag size is syncsafe: seven bits in each byte.
+	size := tag.Len()
+	file := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	file = append(file, tag.Bytes()...)
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	// 128 kbit/s for ten seconds is 160000 bytes.
+	for range 160000 / 417 {
+		file = append(file, frame...)
+	}
+	return append(file, make([]byte, 160000%417)...)
+}
+
+func uploadRequest(t *testing.T, target string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "song.mp3")
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest("POST", target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadAudio(t *testing.T) {
+	store, _ := newTestStore(t)
+	bandID := seedBand(t, store, models.Band{Name: "Coldplay", Nationality: "British", DateFormed: "1996-01-01"})
+	songID := seedSong(t, store, models.Song{Title: "Untitled", Length: 1, Price: 129})
+	audio := newAudioService(t, store)
+
+	w := httptest.NewRecorder()
+	audio.UploadAudio(w, uploadRequest(t, "/songs/1/audio", testMP3("Yellow", "coldplay", "5/10")), songID)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response viewModels.AudioUploadViewModel
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []string{"length"}, response.Applied)
+	assert.Equal(t, 10, response.Song.Length)
+	assert.Equal(t, "Untitled", response.Song.Title)
+	assert.Equal(t, "mp3", response.Audio.Format)
+	assert.Equal(t, "Yellow", response.Suggestions.Title)
+	assert.Equal(t, 5, response.Suggestions.TrackNumber)
+	assert.Equal(t, &bandID, response.Suggestions.BandId)
+
+	w = httptest.NewRecorder()
+	audio.UploadAudio(w, uploadRequest(t, "/songs/1/audio?apply=true", testMP3("Yellow", "Coldplay", "5")), songID)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []string{"length", "title", "credits"}, response.Applied)
+	song, _ := store.Songs.GetByID(context.Background(), songID)
+	assert.Equal(t, "Yellow", song.Title)
+	assert.Equal(t, 10, song.Length)
+	credits, _ := store.Credits.GetBySongIDs(context.Background(), []int{songID})
+	if assert.Len(t, credits[songID], 1) {
+		assert.Equal(t, &bandID, credits[songID][0].BandId)
+		assert.Equal(t, models.RolePerformer, credits[songID][0].Role)
+	}
+
+	w = httptest.NewRecorder()
+	audio.UploadAudio(w, uploadRequest(t, "/songs/1/audio?apply=true", testMP3("Yellow", "Coldplay", "5")), songID)
+	assert.Equal(t, http.StatusCreated, w.Code, "crediting the same band again is not an error")
+
+	long := strings.Repeat("Yellow", 200)
+	w = httptest.NewRecorder()
+	audio.UploadAudio(w, uploadRequest(t, "/songs/1/audio?apply=true", testMP3(long, "Coldplay", "5")), songID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	response = viewModels.AudioUploadViewModel{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []string{"length", "credits"}, response.Applied, "a title too long for a song is only suggested")
+	assert.Equal(t, long, response.Suggestions.Title)
+	song, _ = store.Songs.GetByID(context.Background(), songID)
+	assert.Equal(t, "Yellow", song.Title)
+}
+
+func TestUploadAudioErrors(t *testing.T) {
+	store, _ := newTestStore(t)
+	songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	audio := newAudioService(t, store)
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		id     int
+		status int
+	}{
+		{"missing song", uploadRequest(t, "/songs/99/audio", testMP3("a", "b", "1")), 99, http.StatusNotFound},
+		{"not multipart", httptest.NewRequest("POST", "/songs/1/audio", bytes.NewBufferString("{}")), songID, http.StatusBadRequest},
+		{"not audio", uploadRequest(t, "/songs/1/audio", []byte("RIFF....WAVE")), songID, http.StatusUnsupportedMediaType},
+		{"truncated", uploadRequest(t, "/songs/1/audio", []byte("fLaC\x00\x00")), songID, http.StatusUnprocessableEntity},
+		{"bad apply", uploadRequest(t, "/songs/1/audio?apply=maybe", testMP3("a", "b", "1")), songID, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			audio.UploadAudio(w, tt.req, tt.id)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestStreamAudio(t *testing.T) {
+	store, _ := newTestStore(t)
+	songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	audio := newAudioService(t, store)
+	file := testMP3("Yellow", "Coldplay", "5")
+
+	w := httptest.NewRecorder()
+	audio.StreamAudio(w, httptest.NewRequest("GET", "/songs/1/audio", nil), songID)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	audio.UploadAudio(httptest.NewRecorder(), uploadRequest(t, "/songs/1/audio", file), songID)
+
+	w = httptest.NewRecorder()
+	audio.StreamAudio(w, httptest.NewRequest("GET", "/songs/1/audio", nil), songID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "audio/mpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, file, w.Body.Bytes())
+
+	req := httptest.NewRequest("GET", "/songs/1/audio", nil)
+	req.Header.Set("Range", "bytes=100-199")
+	w = httptest.NewRecorder()
+	audio.StreamAudio(w, req, songID)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, file[100:200], w.Body.Bytes())
+
+	req = httptest.NewRequest("GET", "/songs/1/audio", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	audio.StreamAudio(w, req, songID)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -2,108 +2,101 @@ package services
 
 import (
 	"encoding/json"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
 	"goMusic/utils"
 	viewModelBand "goMusic/viewModels"
 	"net/http"
 )
 
-func GetBands(w http.ResponseWriter, r *http.Request) {
+type BandService struct {
+	store *repositories.Store
+}
+
+func NewBandService(store *repositories.Store) *BandService {
+	return &BandService{store: store}
+}
+
+func (s *BandService) GetBands(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	rows, err := db.DB.Query("SELECT id, name, nationality, number_of_members, date_formed, age, active FROM bands")
-	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+	q, ok := parseQuery(w, r, repositories.BandQuery)
+	if !ok {
 		return
 	}
-	defer rows.Close()
-
-	var bands []models.Band
-
-	for rows.Next() {
-		var band models.Band
-
-		err := rows.Scan(&band.Id, &band.Name, &band.Nationality, &band.NumberOfMembers, &band.DateFormed, &band.Age, &band.Active)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		bands = append(bands, band)
+	asOf, ok := parseDateParam(w, r, "as_of")
+	if !ok {
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		http.Error(w, "Error iterating rows: "+err.Error(), http.StatusInternalServerError)
+	page, err := s.store.Bands.List(r.Context(), q, asOf)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	bandVMs, err := viewModelBand.GetBandViewModels(bands)
+	bandVMs, err := viewModelBand.GetBandViewModels(r.Context(), s.store, page.Items, asOf)
 	if err != nil {
-		http.Error(w, "Error generating view models: "+err.Error(), http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(bandVMs)
+	json.NewEncoder(w).Encode(query.WithItems(page, bandVMs))
 }
 
-func GetBandByID(w http.ResponseWriter, r *http.Request, id int) {
+func (s *BandService) GetBandByID(w http.ResponseWriter, r *http.Request, id int) {
 	w.Header().Set("Content-Type", "application/json")
-	row, err := db.DB.Query("SELECT id, name, nationality, number_of_members, date_formed, age, active FROM bands WHERE id = ?", id)
+	asOf, ok := parseDateParam(w, r, "as_of")
+	if !ok {
+		return
+	}
+	band, err := s.store.Bands.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, r, err, "band")
 		return
 	}
-	if row.Next() {
-		var band models.Band
-
-		err := row.Scan(&band.Id, &band.Name, &band.Nationality, &band.NumberOfMembers, &band.DateFormed, &band.Age, &band.Active)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		bandVM, err := viewModelBand.GetBandViewModel(band)
-		if err != nil {
-			http.Error(w, "Error generating view model: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		json.NewEncoder(w).Encode(bandVM)
+
+	bandVM, err := viewModelBand.GetBandViewModel(r.Context(), s.store, band, asOf)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{"message": "band not found"})
+
+	json.NewEncoder(w).Encode(bandVM)
 }
 
-func PostBand(w http.ResponseWriter, r *http.Request) {
+func (s *BandService) PostBand(w http.ResponseWriter, r *http.Request) {
 	var newBand models.Band
 
 	if !utils.DecodeAndValidate(w, r, &newBand) {
 		return
 	}
-	success := utils.ExecuteWithTransaction(w,
-		"INSERT INTO bands (name, nationality, number_of_members, date_formed, age, active) VALUES (?, ?, ?, ?, ?, ?)",
-		newBand.Name, newBand.Nationality, newBand.NumberOfMembers, newBand.DateFormed, newBand.Age, newBand.Active)
+	if !datesInOrder(w, r, "date_formed", &newBand.DateFormed, "disbanded_date", newBand.DisbandedDate) {
+		return
+	}
 
-	if !success {
+	if _, err := s.store.Bands.Create(r.Context(), newBand); err != nil {
+		writeRepositoryError(w, r, err, "band")
 		return
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 }
 
-func UpdateBandByID(w http.ResponseWriter, r *http.Request, id int) bool {
+func (s *BandService) UpdateBandByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	var updatedBand models.Band
 
 	if !utils.DecodeAndValidate(w, r, &updatedBand) {
 		return false
 	}
+	if !datesInOrder(w, r, "date_formed", &updatedBand.DateFormed, "disbanded_date", updatedBand.DisbandedDate) {
+		return false
+	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"UPDATE bands SET name = ?, nationality = ?, number_of_members = ?, date_formed = ?, age = ?, active = ? WHERE id = ?",
-		updatedBand.Name, updatedBand.Nationality, updatedBand.NumberOfMembers, updatedBand.DateFormed, updatedBand.Age, updatedBand.Active, id)
-
-	if !success {
+	if err := s.store.Bands.Update(r.Context(), id, updatedBand); err != nil {
+		writeRepositoryError(w, r, err, "band")
 		return false
 	}
 
@@ -112,12 +105,9 @@ func UpdateBandByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	return true
 }
 
-func DeleteBandByID(w http.ResponseWriter, id int) bool {
-	success := utils.ExecuteWithTransaction(w,
-		"DELETE FROM bands WHERE id = ?",
-		id)
-
-	if !success {
+func (s *BandService) DeleteBandByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Bands.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "band")
 		return false
 	}
 
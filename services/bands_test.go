@@ -2,34 +2,28 @@ package services_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"github.com/DATA-DOG/go-sqlmock"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/query"
 	"goMusic/services"
+	"goMusic/viewModels"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
 	"testing"
 )
 
-func TestGetBands(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+func newTestBand() models.Band {
+	return models.Band{
+		Name:        "Coldplay",
+		Nationality: "British",
+		DateFormed:  "1996-01-01",
 	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
+}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "age", "active"}).
-		AddRow(1, "Coldplay", "British", 4, "1996-01-01", 27, true)
-	mock.ExpectQuery("SELECT id, name, nationality, number_of_members, date_formed, age, active FROM bands").WillReturnRows(rows)
+func TestGetBands(t *testing.T) {
+	store, _ := newTestStore(t)
+	seedBand(t, store, newTestBand())
 
 	req, err := http.NewRequest("GET", "/bands", nil)
 	if err != nil {
@@ -37,113 +31,99 @@ func TestGetBands(t *testing.T) {
 	}
 	rr := httptest.NewRecorder()
 
-	services.GetBands(rr, req)
+	services.NewBandService(store).GetBands(rr, req)
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	expected := []models.Band{
-		{Id: 1, Name: "Coldplay", Nationality: "British", NumberOfMembers: 4, DateFormed: "1996-01-01", Age: 27, Active: true},
-	}
-	var actual []models.Band
-	if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+	var page query.Page[viewModels.BandViewModel]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
 		t.Fatal(err)
 	}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("handler returned unexpected body: got %v want %v", actual, expected)
+	actual := page.Items
+	if page.Total != 1 || len(actual) != 1 || actual[0].Name != "Coldplay" || *actual[0].Id != 1 || actual[0].NumberOfMembers != 0 {
+		t.Errorf("handler returned unexpected body: got %+v", actual)
 	}
 }
 
-func TestGetBandByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+func TestGetBandsAsOf(t *testing.T) {
+	store, _ := newTestStore(t)
+	band := newTestBand()
+	split := "2010-01-01"
+	band.DisbandedDate = &split
+	seedBand(t, store, band)
+
+	for asOf, want := range map[string]viewModels.BandViewModel{
+		"2005-12-31": {Age: 9, Active: true},
+		"2010-01-01": {Age: 14, Active: false},
+		"2020-01-01": {Age: 14, Active: false},
+	} {
+		rr := httptest.NewRecorder()
+		services.NewBandService(store).GetBands(rr, httptest.NewRequest("GET", "/bands?as_of="+asOf, nil))
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		var page query.Page[viewModels.BandViewModel]
+		if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(page.Items) != 1 || page.Items[0].Age != want.Age || page.Items[0].Active != want.Active {
+			t.Errorf("as_of=%s: got %+v, want age %d active %v", asOf, page.Items, want.Age, want.Active)
+		}
 	}
-	defer mockDB.Close()
 
-	db.DB = mockDB
+	rr := httptest.NewRecorder()
+	services.NewBandService(store).GetBands(rr, httptest.NewRequest("GET", "/bands?as_of=yesterday", nil))
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
 
+func TestGetBandByID(t *testing.T) {
 	t.Run("Successful band retrieval", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "age", "active"}).
-			AddRow(1, "Coldplay", "British", 4, "1996-01-01", 27, true)
-		mock.ExpectQuery("SELECT id, name, nationality, number_of_members, date_formed, age, active FROM bands WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
+		bandID := seedBand(t, store, newTestBand())
 
-		rr := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/bands/1", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
+		rr := httptest.NewRecorder()
 
-		services.GetBandByID(rr, req, 1)
+		services.NewBandService(store).GetBandByID(rr, req, bandID)
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		var band models.Band
+		var band viewModels.BandViewModel
 		if err := json.NewDecoder(rr.Body).Decode(&band); err != nil {
 			t.Fatal(err)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		if band.Name != "Coldplay" || band.DateFormed != "1996-01-01" {
+			t.Errorf("handler returned unexpected body: got %+v", band)
 		}
 	})
 
 	t.Run("Band not found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "age", "active"})
-		mock.ExpectQuery("SELECT id, name, nationality, number_of_members, date_formed, age, active FROM bands WHERE id = ?").
-			WithArgs(999).
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
 
-		rr := httptest.NewRecorder()
 		req, err := http.NewRequest("GET", "/bands/999", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
+		rr := httptest.NewRecorder()
 
-		services.GetBandByID(rr, req, 999)
+		services.NewBandService(store).GetBandByID(rr, req, 999)
 
 		if status := rr.Code; status != http.StatusNotFound {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
-		}
 	})
 }
 
 func TestPostBand(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	band := models.Band{
-		Name:            "Coldplay",
-		Nationality:     "British",
-		NumberOfMembers: 4,
-		DateFormed:      "1996-01-01",
-		Age:             27,
-		Active:          true,
-	}
+	store, _ := newTestStore(t)
+	band := newTestBand()
 
 	bandJSON, _ := json.Marshal(band)
 	req, err := http.NewRequest("POST", "/bands", bytes.NewBuffer(bandJSON))
@@ -153,47 +133,29 @@ func TestPostBand(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO bands").
-		WithArgs("Coldplay", "British", 4, "1996-01-01", 27, true).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
-
-	services.PostBand(rr, req)
+	services.NewBandService(store).PostBand(rr, req)
 
 	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	stored, err := store.Bands.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Band was not stored: %v", err)
+	}
+	if stored.Name != band.Name {
+		t.Errorf("Wrong band stored: got %+v", stored)
 	}
 }
 
 func TestUpdateBandByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
 	t.Run("Successful update", func(t *testing.T) {
-		band := models.Band{
-			Name:            "Coldplay",
-			Nationality:     "British",
-			NumberOfMembers: 4,
-			DateFormed:      "1996-01-01",
-			Age:             28,
-			Active:          true,
-		}
+		store, _ := newTestStore(t)
+		bandID := seedBand(t, store, newTestBand())
 
+		band := newTestBand()
+		ended := "2020-01-01"
+		band.DisbandedDate = &ended
 		bandJSON, _ := json.Marshal(band)
 		req, err := http.NewRequest("PUT", "/bands/1", bytes.NewBuffer(bandJSON))
 		if err != nil {
@@ -202,13 +164,7 @@ func TestUpdateBandByID(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rr := httptest.NewRecorder()
 
-		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE bands SET").
-			WithArgs(band.Name, band.Nationality, band.NumberOfMembers, band.DateFormed, band.Age, band.Active, 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
-
-		result := services.UpdateBandByID(rr, req, 1)
+		result := services.NewBandService(store).UpdateBandByID(rr, req, bandID)
 
 		if !result {
 			t.Errorf("UpdateBandByID returned false, expected true")
@@ -218,40 +174,41 @@ func TestUpdateBandByID(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		stored, _ := store.Bands.GetByID(context.Background(), bandID)
+		if stored.DisbandedDate == nil || *stored.DisbandedDate != ended {
+			t.Errorf("Band was not updated: got %+v", stored)
 		}
 	})
 }
 
-func TestDeleteBandByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+func TestPostBandDisbandedBeforeFormed(t *testing.T) {
+	store, _ := newTestStore(t)
+	band := newTestBand()
+	split := "1995-12-31"
+	band.DisbandedDate = &split
+	bandJSON, _ := json.Marshal(band)
+	req := httptest.NewRequest("POST", "/bands", bytes.NewBuffer(bandJSON))
+	rr := httptest.NewRecorder()
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
+	services.NewBandService(store).PostBand(rr, req)
 
-	db.DB = mockDB
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if !bytes.Contains(rr.Body.Bytes(), []byte(`"disbanded_date"`)) {
+		t.Errorf("the problem should name disbanded_date: %s", rr.Body.String())
+	}
+}
 
+func TestDeleteBandByID(t *testing.T) {
 	t.Run("Successful delete", func(t *testing.T) {
-		_, err := http.NewRequest("DELETE", "/bands/1", nil)
-		if err != nil {
-			t.Fatal(err)
-		}
-		rr := httptest.NewRecorder()
+		store, _ := newTestStore(t)
+		bandID := seedBand(t, store, newTestBand())
 
-		mock.ExpectBegin()
-		mock.ExpectExec("DELETE FROM bands WHERE id = ?").
-			WithArgs(1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
+		req := httptest.NewRequest("DELETE", "/bands/1", nil)
+		rr := httptest.NewRecorder()
 
-		result := services.DeleteBandByID(rr, 1)
+		result := services.NewBandService(store).DeleteBandByID(rr, req, bandID)
 
 		if !result {
 			t.Errorf("DeleteBandByID returned false, expected true")
@@ -260,9 +217,5 @@ func TestDeleteBandByID(t *testing.T) {
 		if status := rr.Code; status != http.StatusNoContent {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
-		}
 	})
 }
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"goMusic/charts"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+const (
+	defaultChartLimit = 20
+	maxChartLimit     = charts.Size
+)
+
+type ChartService struct {
+	store  *repositories.Store
+	config charts.Config
+}
+
+func NewChartService(store *repositories.Store, config charts.Config) *ChartService {
+	return &ChartService{store: store, config: config}
+}
+
+// GetSongChart returns the song chart for ?period= and ?date=, today's by
+// default.
+func (s *ChartService) GetSongChart(w http.ResponseWriter, r *http.Request) {
+	chart, provisional, limit, ok := s.loadChart(w, r, models.ChartSongs)
+	if !ok {
+		return
+	}
+	vm, err := viewModels.GetSongChartViewModel(r.Context(), s.store, chart, provisional, limit)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vm)
+}
+
+// GetAlbumChart returns the album chart for ?period= and ?date=, today's by
+// default.
+func (s *ChartService) GetAlbumChart(w http.ResponseWriter, r *http.Request) {
+	chart, provisional, limit, ok := s.loadChart(w, r, models.ChartAlbums)
+	if !ok {
+		return
+	}
+	vm, err := viewModels.GetAlbumChartViewModel(r.Context(), s.store, chart, provisional, limit)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vm)
+}
+
+// loadChart reads the chart query parameters and loads the chart they ask
+// for, responding with a problem when it can't.
+func (s *ChartService) loadChart(w http.ResponseWriter, r *http.Request, kind string) (models.Chart, bool, int, bool) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.ChartWeekly
+	}
+	if period != models.ChartDaily && period != models.ChartWeekly {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "period must be daily or weekly")
+		return models.Chart{}, false, 0, false
+	}
+	date, ok := parseDateParam(w, r, "date")
+	if !ok {
+		return models.Chart{}, false, 0, false
+	}
+	today := models.Today()
+	if date == "" {
+		date = today
+	}
+	if date > today {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "date must not be in the future")
+		return models.Chart{}, false, 0, false
+	}
+	limit, ok := parseLimit(w, r, defaultChartLimit, maxChartLimit)
+	if !ok {
+		return models.Chart{}, false, 0, false
+	}
+
+	chart, provisional, err := charts.Get(r.Context(), s.store, s.config, kind, period, date, today)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return models.Chart{}, false, 0, false
+	}
+	return chart, provisional, limit, true
+}
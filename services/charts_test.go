@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"goMusic/charts"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharts(t *testing.T) {
+	store, _ := newTestStore(t)
+	alice := seedUser(t, store, "alice", "password123")
+	airbag := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 99})
+	lucky := seedSong(t, store, models.Song{Title: "Lucky", Length: 259, Price: 99})
+	okComputer := seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	ctx := context.Background()
+	if err := store.Albums.SetSongs(ctx, okComputer, []int{airbag, lucky}); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := store.Plays.Record(ctx, []models.Play{
+		{UserId: alice, SongId: &airbag, PlayedAt: day.AddDate(0, 0, -1), Duration: 284},
+		{UserId: alice, SongId: &lucky, PlayedAt: day.AddDate(0, 0, -1), Duration: 259},
+		{UserId: alice, SongId: &lucky, PlayedAt: day.AddDate(0, 0, -1).Add(time.Hour), Duration: 259},
+		{UserId: alice, SongId: &airbag, PlayedAt: day, Duration: 284},
+		{UserId: alice, SongId: &airbag, PlayedAt: day.Add(time.Hour), Duration: 284},
+		{UserId: alice, SongId: &lucky, PlayedAt: time.Now(), Duration: 259},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	chartService := services.NewChartService(store, charts.DefaultConfig())
+
+	t.Run("historical daily songs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		chartService.GetSongChart(w, httptest.NewRequest("GET", "/charts/songs?period=daily&date=2024-03-01", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got viewModels.ChartViewModel[viewModels.BasicSongViewModel]
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "daily", got.Period)
+		assert.Equal(t, "2024-03-01", got.Date)
+		assert.False(t, got.Provisional)
+		if assert.Len(t, got.Entries, 1) {
+			entry := got.Entries[0]
+			assert.Equal(t, "Airbag", entry.Item.Title)
+			assert.Equal(t, 1, entry.Position)
+			assert.Equal(t, 2, *entry.PreviousPosition)
+			assert.Equal(t, "up", entry.Movement)
+			assert.Equal(t, 1, *entry.Change)
+		}
+		_, err := store.Charts.GetChart(ctx, models.ChartSongs, models.ChartDaily, "2024-03-01")
+		assert.NoError(t, err, "the chart should have been stored")
+	})
+
+	t.Run("weekly albums", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		chartService.GetAlbumChart(w, httptest.NewRequest("GET", "/charts/albums?date=2024-03-01", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got viewModels.ChartViewModel[viewModels.BasicAlbumViewModel]
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "weekly", got.Period)
+		if assert.Len(t, got.Entries, 1) {
+			assert.Equal(t, "OK Computer", got.Entries[0].Item.Title)
+			assert.Nil(t, got.Entries[0].PreviousPosition)
+			assert.Equal(t, "new", got.Entries[0].Movement)
+		}
+	})
+
+	t.Run("today is provisional", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		chartService.GetSongChart(w, httptest.NewRequest("GET", "/charts/songs?period=daily", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got viewModels.ChartViewModel[viewModels.BasicSongViewModel]
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.True(t, got.Provisional)
+		assert.Equal(t, models.Today(), got.Date)
+		if assert.Len(t, got.Entries, 1) {
+			assert.Equal(t, "Lucky", got.Entries[0].Item.Title)
+		}
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+		for _, target := range []string{
+			"/charts/songs?period=monthly",
+			"/charts/songs?date=yesterday",
+			"/charts/songs?date=" + tomorrow,
+			"/charts/songs?limit=101",
+		} {
+			w := httptest.NewRecorder()
+			chartService.GetSongChart(w, httptest.NewRequest("GET", target, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"goMusic/blobs"
+	"goMusic/images"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxCoverSize is the largest cover image that can be uploaded.
+	MaxCoverSize = 20 << 20
+	// MinCoverDimension and MaxCoverDimension bound the width and height of
+	// uploaded covers, in pixels.
+	MinCoverDimension = 200
+	MaxCoverDimension = 4096
+)
+
+// CoverThumbnails are the sizes each cover is shrunk to, by variant name.
+// Each thumbnail fits within a square of that many pixels.
+var CoverThumbnails = []struct {
+	Variant string
+	Size    int
+}{
+	{"small", 100},
+	{"medium", 300},
+	{"large", 600},
+}
+
+type CoverService struct {
+	store *repositories.Store
+	blobs *blobs.Store
+}
+
+func NewCoverService(store *repositories.Store, blobs *blobs.Store) *CoverService {
+	return &CoverService{store: store, blobs: blobs}
+}
+
+// PutCover stores a JPEG or PNG sent as the request body as the album's
+// cover, along with thumbnails in the same format, replacing any earlier
+// cover.
+func (s *CoverService) PutCover(w http.ResponseWriter, r *http.Request, id int) bool {
+	album, err := s.store.Albums.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxCoverSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Error(w, r, http.StatusRequestEntityTooLarge, problem.PayloadTooLarge,
+				"Cover images can be at most "+strconv.Itoa(MaxCoverSize>>20)+" MB.")
+		} else {
+			problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "The upload could not be read.")
+		}
+		return false
+	}
+
+	// The declared type, if any, has to agree with the content.
+	mimeType := http.DetectContentType(body)
+	declared, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if (mimeType != "image/jpeg" && mimeType != "image/png") || (declared != "" && declared != mimeType) {
+		problem.Error(w, r, http.StatusUnsupportedMediaType, problem.UnsupportedMedia, "Upload a JPEG or PNG image.")
+		return false
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidImage, "The image could not be read.")
+		return false
+	}
+	if !validCoverDimension(config.Width) || !validCoverDimension(config.Height) {
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidImage,
+			"Cover images must be between "+strconv.Itoa(MinCoverDimension)+" and "+strconv.Itoa(MaxCoverDimension)+" pixels wide and high.")
+		return false
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidImage, "The image could not be read.")
+		return false
+	}
+
+	covers, err := s.storeCover(body, mimeType, decoded)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	if err := s.store.Covers.Replace(r.Context(), id, covers); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	albumVM, err := viewModels.GetAlbumViewModel(r.Context(), s.store, album)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusOK, albumVM)
+	return true
+}
+
+// GetCover serves one variant of an album's cover. The content digest is
+// the ETag, and URLs that name the current version with ?v= may be cached
+// for good since a new upload changes the URL.
+func (s *CoverService) GetCover(w http.ResponseWriter, r *http.Request, id int, variant string) {
+	cover, err := s.store.Covers.Get(r.Context(), id, variant)
+	if err != nil {
+		writeRepositoryError(w, r, err, "cover")
+		return
+	}
+
+	file, err := s.blobs.Open(cover.Digest)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", cover.MIMEType)
+	w.Header().Set("ETag", `"`+cover.Digest+`"`)
+	if r.URL.Query().Get("v") == viewModels.CoverVersion(cover.Digest) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "public, no-cache")
+	}
+	http.ServeContent(w, r, "", cover.UploadedAt, file)
+}
+
+// storeCover writes the original and its thumbnails to the blob store.
+func (s *CoverService) storeCover(original []byte, mimeType string, decoded image.Image) ([]models.CoverImage, error) {
+	uploadedAt := time.Now()
+	bounds := decoded.Bounds()
+
+	blob, err := s.blobs.Put(bytes.NewReader(original))
+	if err != nil {
+		return nil, err
+	}
+	covers := []models.CoverImage{{
+		Variant: "original", Digest: blob.Digest, MIMEType: mimeType,
+		Width: bounds.Dx(), Height: bounds.Dy(), UploadedAt: uploadedAt,
+	}}
+
+	rgba := images.RGBA(decoded)
+	for _, thumbnail := range CoverThumbnails {
+		thumb := images.Thumbnail(rgba, thumbnail.Size)
+
+		var encoded bytes.Buffer
+		if mimeType == "image/png" {
+			err = png.Encode(&encoded, thumb)
+		} else {
+			err = jpeg.Encode(&encoded, thumb, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		blob, err := s.blobs.Put(&encoded)
+		if err != nil {
+			return nil, err
+		}
+		covers = append(covers, models.CoverImage{
+			Variant: thumbnail.Variant, Digest: blob.Digest, MIMEType: mimeType,
+			Width: thumb.Rect.Dx(), Height: thumb.Rect.Dy(), UploadedAt: uploadedAt,
+		})
+	}
+	return covers, nil
+}
+
+func validCoverDimension(pixels int) bool {
+	return pixels >= MinCoverDimension && pixels <= MaxCoverDimension
+}
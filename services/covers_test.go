@@ -0,0 +1,168 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"goMusic/blobs"
+	"goMusic/models"
+	"goMusic/repositories"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCoverService(t *testing.T, store *repositories.Store) *services.CoverService {
+	t.Helper()
+	files, err := blobs.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewCoverService(store, files)
+}
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func testPNG(width, height int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(width, height))
+	return buf.Bytes()
+}
+
+func testJPEG(width, height int) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(width, height), nil)
+	return buf.Bytes()
+}
+
+func coverRequest(target, contentType string, content []byte) *http.Request {
+	req := httptest.NewRequest("PUT", target, bytes.NewReader(content))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func TestPutCover(t *testing.T) {
+	store, _ := newTestStore(t)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	covers := newCoverService(t, store)
+
+	w := httptest.NewRecorder()
+	covers.PutCover(w, coverRequest("/albums/1/cover", "image/jpeg", testJPEG(1000, 800)), albumID)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var album viewModels.DetailedAlbumViewModel
+	json.Unmarshal(w.Body.Bytes(), &album)
+	assert.Len(t, album.CoverURLs, 4)
+	assert.True(t, strings.HasPrefix(album.CoverURLs["small"], "/albums/1/cover/small?v="), album.CoverURLs["small"])
+
+	for variant, size := range map[string]image.Point{"original": {1000, 800}, "large": {600, 480}, "medium": {300, 240}, "small": {100, 80}} {
+		w := httptest.NewRecorder()
+		covers.GetCover(w, httptest.NewRequest("GET", album.CoverURLs[variant], nil), albumID, variant)
+		assert.Equal(t, http.StatusOK, w.Code, variant)
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+
+		config, format, err := image.DecodeConfig(w.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, size, image.Point{config.Width, config.Height}, variant)
+	}
+
+	// A PNG replaces the JPEG and its thumbnails stay PNGs.
+	w = httptest.NewRecorder()
+	covers.PutCover(w, coverRequest("/albums/1/cover", "", testPNG(400, 400)), albumID)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	services.NewAlbumService(store).GetAlbums(w, httptest.NewRequest("GET", "/albums", nil))
+	var page struct{ Items []viewModels.AlbumViewModel }
+	json.Unmarshal(w.Body.Bytes(), &page)
+	medium := page.Items[0].CoverURLs["medium"]
+	assert.NotEqual(t, album.CoverURLs["medium"], medium)
+
+	w = httptest.NewRecorder()
+	covers.GetCover(w, httptest.NewRequest("GET", medium, nil), albumID, "medium")
+	config, format, _ := image.DecodeConfig(w.Body)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 300, config.Width)
+}
+
+func TestPutCoverErrors(t *testing.T) {
+	store, _ := newTestStore(t)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	covers := newCoverService(t, store)
+
+	truncated := testPNG(300, 300)[:100]
+	tests := []struct {
+		name   string
+		req    *http.Request
+		id     int
+		status int
+	}{
+		{"missing album", coverRequest("/albums/99/cover", "image/png", testPNG(300, 300)), 99, http.StatusNotFound},
+		{"not an image", coverRequest("/albums/1/cover", "", []byte("GIF89a......")), albumID, http.StatusUnsupportedMediaType},
+		{"wrong declared type", coverRequest("/albums/1/cover", "image/jpeg", testPNG(300, 300)), albumID, http.StatusUnsupportedMediaType},
+		{"too small", coverRequest("/albums/1/cover", "image/png", testPNG(150, 300)), albumID, http.StatusUnprocessableEntity},
+		{"too large", coverRequest("/albums/1/cover", "image/png", testPNG(300, 5000)), albumID, http.StatusUnprocessableEntity},
+		{"truncated", coverRequest("/albums/1/cover", "image/png", truncated), albumID, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			covers.PutCover(w, tt.req, tt.id)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+
+	w := httptest.NewRecorder()
+	covers.GetCover(w, httptest.NewRequest("GET", "/albums/1/cover/original", nil), albumID, "original")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetCoverCaching(t *testing.T) {
+	store, _ := newTestStore(t)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	covers := newCoverService(t, store)
+
+	w := httptest.NewRecorder()
+	covers.PutCover(w, coverRequest("/albums/1/cover", "image/png", testPNG(300, 300)), albumID)
+	var album viewModels.DetailedAlbumViewModel
+	json.Unmarshal(w.Body.Bytes(), &album)
+
+	w = httptest.NewRecorder()
+	covers.GetCover(w, httptest.NewRequest("GET", album.CoverURLs["small"], nil), albumID, "small")
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	etag := w.Header().Get("ETag")
+	assert.Len(t, etag, 66)
+
+	w = httptest.NewRecorder()
+	covers.GetCover(w, httptest.NewRequest("GET", "/albums/1/cover/small?v=stale", nil), albumID, "small")
+	assert.Equal(t, "public, no-cache", w.Header().Get("Cache-Control"))
+
+	req := httptest.NewRequest("GET", "/albums/1/cover/small", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	covers.GetCover(w, req, albumID, "small")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = httptest.NewRecorder()
+	covers.GetCover(w, httptest.NewRequest("GET", "/albums/1/cover/huge", nil), albumID, "huge")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
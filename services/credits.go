@@ -0,0 +1,115 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+type CreditService struct {
+	store *repositories.Store
+}
+
+func NewCreditService(store *repositories.Store) *CreditService {
+	return &CreditService{store: store}
+}
+
+// AddAlbumCredit credits an artist or band on an album and responds with
+// the album's credits.
+func (s *CreditService) AddAlbumCredit(w http.ResponseWriter, r *http.Request, id int) bool {
+	if _, err := s.store.Albums.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+	return s.addCredit(w, r, models.Credit{AlbumId: &id}, func() ([]models.Credit, error) {
+		credits, err := s.store.Credits.GetByAlbumIDs(r.Context(), []int{id})
+		return credits[id], err
+	})
+}
+
+// AddSongCredit credits an artist or band on a song and responds with the
+// song's credits.
+func (s *CreditService) AddSongCredit(w http.ResponseWriter, r *http.Request, id int) bool {
+	if _, err := s.store.Songs.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return false
+	}
+	return s.addCredit(w, r, models.Credit{SongId: &id}, func() ([]models.Credit, error) {
+		credits, err := s.store.Credits.GetBySongIDs(r.Context(), []int{id})
+		return credits[id], err
+	})
+}
+
+// addCredit completes credit, which names the album or song, from the
+// request and stores it. load fetches the credits to respond with.
+func (s *CreditService) addCredit(w http.ResponseWriter, r *http.Request, credit models.Credit, load func() ([]models.Credit, error)) bool {
+	var req viewModels.CreditRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+	credit.ArtistId, credit.BandId, credit.Role = req.ArtistId, req.BandId, req.Role
+
+	if _, err := s.store.Credits.Create(r.Context(), credit); err != nil {
+		writeRepositoryError(w, r, err, "credit")
+		return false
+	}
+
+	credits, err := load()
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	vms, err := viewModels.GetCreditViewModels(r.Context(), s.store, credits)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusCreated, vms)
+	return true
+}
+
+func (s *CreditService) DeleteCredit(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Credits.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "credit")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// GetArtistCredits lists the albums and songs an artist is credited on.
+func (s *CreditService) GetArtistCredits(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := s.store.Artists.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "artist")
+		return
+	}
+	credits, err := s.store.Credits.GetByArtistID(r.Context(), id)
+	s.writeCreditedWork(w, r, credits, err)
+}
+
+// GetBandCredits lists the albums and songs a band is credited on.
+func (s *CreditService) GetBandCredits(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := s.store.Bands.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "band")
+		return
+	}
+	credits, err := s.store.Credits.GetByBandID(r.Context(), id)
+	s.writeCreditedWork(w, r, credits, err)
+}
+
+func (s *CreditService) writeCreditedWork(w http.ResponseWriter, r *http.Request, credits []models.Credit, err error) {
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	vms, err := viewModels.GetCreditedWorkViewModels(r.Context(), s.store, credits)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vms)
+}
@@ -0,0 +1,111 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredits(t *testing.T) {
+	store, _ := newTestStore(t)
+	credits := services.NewCreditService(store)
+	albumID := seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	songID := seedSong(t, store, models.Song{Title: "Karma Police", Length: 264, Price: 129})
+	artistID := seedArtist(t, store, models.Artist{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07"})
+	bandID := seedBand(t, store, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-01-01"})
+
+	addToAlbum := func(id int, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		credits.AddAlbumCredit(w, httptest.NewRequest("POST", "/albums/1/credits", bytes.NewBufferString(body)), id)
+		return w
+	}
+	addToSong := func(id int, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		credits.AddSongCredit(w, httptest.NewRequest("POST", "/songs/1/credits", bytes.NewBufferString(body)), id)
+		return w
+	}
+
+	w := addToAlbum(albumID, fmt.Sprintf(`{"band_id": %d, "role": "performer"}`, bandID))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = addToAlbum(albumID, fmt.Sprintf(`{"artist_id": %d, "role": "producer"}`, artistID))
+	var albumCredits []viewModels.CreditViewModel
+	json.Unmarshal(w.Body.Bytes(), &albumCredits)
+	if assert.Len(t, albumCredits, 2) {
+		assert.Equal(t, "Radiohead", albumCredits[0].Band.Name)
+		assert.Equal(t, "Yorke", albumCredits[1].Artist.LastName)
+		assert.Equal(t, models.RoleProducer, albumCredits[1].Role)
+	}
+
+	assert.Equal(t, http.StatusConflict, addToAlbum(albumID, fmt.Sprintf(`{"band_id": %d, "role": "performer"}`, bandID)).Code)
+	assert.Equal(t, http.StatusNotFound, addToAlbum(99, fmt.Sprintf(`{"band_id": %d, "role": "performer"}`, bandID)).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, addToAlbum(albumID, `{"band_id": 99, "role": "performer"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, addToAlbum(albumID, `{"role": "performer"}`).Code, "an artist or band is required")
+	assert.Equal(t, http.StatusBadRequest, addToAlbum(albumID,
+		fmt.Sprintf(`{"artist_id": %d, "band_id": %d, "role": "performer"}`, artistID, bandID)).Code, "not both")
+	assert.Equal(t, http.StatusBadRequest, addToAlbum(albumID, fmt.Sprintf(`{"artist_id": %d, "role": "drummer"}`, artistID)).Code)
+
+	w = addToSong(songID, fmt.Sprintf(`{"artist_id": %d, "role": "lyricist"}`, artistID))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var songCredits []viewModels.CreditViewModel
+	json.Unmarshal(w.Body.Bytes(), &songCredits)
+	assert.Len(t, songCredits, 1)
+
+	w = httptest.NewRecorder()
+	credits.GetArtistCredits(w, httptest.NewRequest("GET", "/artists/1/credits", nil), artistID)
+	var work []viewModels.CreditedWorkViewModel
+	json.Unmarshal(w.Body.Bytes(), &work)
+	if assert.Len(t, work, 2) {
+		assert.Equal(t, "OK Computer", work[0].Album.Title)
+		assert.Nil(t, work[0].Song)
+		assert.Equal(t, "Karma Police", work[1].Song.Title)
+		assert.Equal(t, models.RoleLyricist, work[1].Role)
+	}
+
+	w = httptest.NewRecorder()
+	credits.GetBandCredits(w, httptest.NewRequest("GET", "/bands/99/credits", nil), 99)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	credits.DeleteCredit(w, httptest.NewRequest("DELETE", "/credits/1", nil), songCredits[0].Id)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = httptest.NewRecorder()
+	credits.DeleteCredit(w, httptest.NewRequest("DELETE", "/credits/1", nil), songCredits[0].Id)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSetAlbumSongs(t *testing.T) {
+	store, _ := newTestStore(t)
+	albums := services.NewAlbumService(store)
+	albumID := seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	airbag := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 129})
+	karma := seedSong(t, store, models.Song{Title: "Karma Police", Length: 264, Price: 129})
+
+	set := func(id int, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		albums.SetAlbumSongs(w, httptest.NewRequest("PUT", "/albums/1/songs", bytes.NewBufferString(body)), id)
+		return w
+	}
+
+	w := set(albumID, fmt.Sprintf(`{"song_ids": [%d, %d]}`, karma, airbag))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var album viewModels.DetailedAlbumViewModel
+	json.Unmarshal(w.Body.Bytes(), &album)
+	assert.Len(t, album.Songs, 2)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, set(albumID, `{"song_ids": [99]}`).Code)
+	assert.Equal(t, http.StatusNotFound, set(99, `{"song_ids": []}`).Code)
+
+	w = set(albumID, fmt.Sprintf(`{"song_ids": [%d]}`, karma))
+	json.Unmarshal(w.Body.Bytes(), &album)
+	if assert.Len(t, album.Songs, 1) {
+		assert.Equal(t, "Karma Police", album.Songs[0].Title)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"goMusic/exporter"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type ExportService struct {
+	store *repositories.Store
+}
+
+func NewExportService(store *repositories.Store) *ExportService {
+	return &ExportService{store: store}
+}
+
+// GetExport streams the whole catalog with its relationships in the
+// format named by ?format, JSON by default. Records are written as they are
+// read, so the response starts before the export is complete; a failure
+// part way through aborts the connection instead of sending a problem.
+func (s *ExportService) GetExport(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r, exporter.CatalogFormats)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="catalog.`+string(format)+`"`)
+	encoder, err := exporter.NewEncoder(w, format)
+	if err == nil {
+		if err = s.store.Exports.Export(r.Context(), encoder.Encode); err == nil {
+			err = encoder.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFormat reads ?format, which must be one of allowed and defaults to
+// the first.
+func exportFormat(w http.ResponseWriter, r *http.Request, allowed []exporter.Format) (exporter.Format, bool) {
+	format := exporter.Format(strings.ToLower(r.URL.Query().Get("format")))
+	if format == "" {
+		return allowed[0], true
+	}
+	if !slices.Contains(allowed, format) {
+		names := make([]string, len(allowed))
+		for i, f := range allowed {
+			names[i] = string(f)
+		}
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "format must be one of "+strings.Join(names, ", "))
+		return "", false
+	}
+	return format, true
+}
+
+// writeTracklist sends an album or playlist as a playlist file named after
+// the kind and ID, such as album-3.xspf. Track locations are relative to
+// the server root.
+func writeTracklist(w http.ResponseWriter, r *http.Request, list models.Tracklist, format exporter.Format, kind string, id int) {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.%s"`, kind, id, format))
+	if err := exporter.WriteTracklist(w, list, format, ""); err != nil {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
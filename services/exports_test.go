@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/repositories"
+	"goMusic/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seedExportCatalog seeds a band credited on an album of two songs and
+// returns the album's ID.
+func seedExportCatalog(t *testing.T) (*repositories.Store, int) {
+	t.Helper()
+	store, mem := newTestStore(t)
+	band := seedBand(t, store, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"})
+	album := seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	airbag := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 99})
+	paranoid := seedSong(t, store, models.Song{Title: "Paranoid Android", Length: 387, Price: 99})
+	mem.LinkAlbumSong(album, airbag)
+	mem.LinkAlbumSong(album, paranoid)
+	seedCredit(t, store, models.Credit{AlbumId: &album, BandId: &band, Role: models.RolePerformer})
+	seedCredit(t, store, models.Credit{SongId: &airbag, BandId: &band, Role: models.RolePerformer})
+	seedCredit(t, store, models.Credit{SongId: &paranoid, BandId: &band, Role: models.RolePerformer})
+	return store, album
+}
+
+func TestGetExport(t *testing.T) {
+	store, _ := seedExportCatalog(t)
+	exports := services.NewExportService(store)
+
+	t.Run("JSON by default", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		exports.GetExport(rr, httptest.NewRequest("GET", "/export", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), `filename="catalog.json"`)
+		var catalog map[string][]map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &catalog); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		assert.Len(t, catalog, len(models.ExportTypes))
+		assert.Len(t, catalog["songs"], 2)
+		assert.Len(t, catalog["tracks"], 2)
+		assert.Len(t, catalog["credits"], 3)
+		assert.Empty(t, catalog["artists"])
+		assert.Equal(t, "Radiohead", catalog["bands"][0]["name"])
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		exports.GetExport(rr, httptest.NewRequest("GET", "/export?format=ndjson", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		assert.Len(t, lines, 1+1+2+2+3)
+		assert.True(t, strings.HasPrefix(lines[0], `{"type":"band","id":1,"name":"Radiohead"`), lines[0])
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		exports.GetExport(rr, httptest.NewRequest("GET", "/export?format=CSV", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(rr.Body.String(), "type,id,"), rr.Body.String())
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		exports.GetExport(rr, httptest.NewRequest("GET", "/export?format=xspf", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestExportAlbum(t *testing.T) {
+	store, album := seedExportCatalog(t)
+	albums := services.NewAlbumService(store)
+
+	rr := httptest.NewRecorder()
+	albums.ExportAlbum(rr, httptest.NewRequest("GET", "/albums/1/export", nil), album)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/xspf+xml", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "<creator>Radiohead</creator>")
+	assert.Contains(t, rr.Body.String(), "<location>/songs/2/audio</location>")
+	assert.Contains(t, rr.Body.String(), "<duration>387000</duration>")
+
+	rr = httptest.NewRecorder()
+	albums.ExportAlbum(rr, httptest.NewRequest("GET", "/albums/1/export?format=m3u8", nil), album)
+	assert.Equal(t, "#EXTM3U\n#PLAYLIST:OK Computer\n#EXTINF:284,Radiohead - Airbag\n/songs/1/audio\n#EXTINF:387,Radiohead - Paranoid Android\n/songs/2/audio\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	albums.ExportAlbum(rr, httptest.NewRequest("GET", "/albums/99/export", nil), 99)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	albums.ExportAlbum(rr, httptest.NewRequest("GET", "/albums/1/export?format=json", nil), album)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestExportPlaylist(t *testing.T) {
+	store, _ := seedExportCatalog(t)
+	playlists := services.NewPlaylistService(store)
+	ownerID := seedUser(t, store, "alice", "password123")
+	otherID := seedUser(t, store, "bob", "password123")
+	private := seedPlaylist(t, store, ownerID, "private", 2, 1)
+
+	rr := httptest.NewRecorder()
+	playlists.ExportPlaylist(rr, asUser(ownerID, "GET", "/playlists/1/export?format=m3u8", ""), private)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "#EXTM3U\n#PLAYLIST:Road trip\n#EXTINF:387,Radiohead - Paranoid Android\n/songs/2/audio\n#EXTINF:284,Radiohead - Airbag\n/songs/1/audio\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	playlists.ExportPlaylist(rr, asUser(otherID, "GET", "/playlists/1/export", ""), private)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"cmp"
+	"errors"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+	"slices"
+)
+
+type GenreService struct {
+	store *repositories.Store
+}
+
+func NewGenreService(store *repositories.Store) *GenreService {
+	return &GenreService{store: store}
+}
+
+func (s *GenreService) GetGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := s.store.Genres.GetAll(r.Context())
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetGenreViewModels(genres))
+}
+
+func (s *GenreService) GetGenreByID(w http.ResponseWriter, r *http.Request, id int) {
+	genre, err := s.store.Genres.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return
+	}
+	s.writeGenre(w, r, genre, http.StatusOK)
+}
+
+func (s *GenreService) PostGenre(w http.ResponseWriter, r *http.Request) {
+	var genre models.Genre
+	if !utils.DecodeAndValidate(w, r, &genre) {
+		return
+	}
+
+	id, err := s.store.Genres.Create(r.Context(), genre)
+	if err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return
+	}
+
+	genre.Id = id
+	s.writeGenre(w, r, genre, http.StatusCreated)
+}
+
+// UpdateGenreByID renames a genre or moves it under another parent. A genre
+// cannot be moved under itself or one of its own subgenres.
+func (s *GenreService) UpdateGenreByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	var genre models.Genre
+	if !utils.DecodeAndValidate(w, r, &genre) {
+		return false
+	}
+
+	if genre.ParentId != nil {
+		subtree, err := s.store.Genres.Subtree(r.Context(), id)
+		if err != nil {
+			writeRepositoryError(w, r, err, "genre")
+			return false
+		}
+		if slices.Contains(subtree, *genre.ParentId) {
+			problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidReference, "A genre cannot be placed under itself or one of its subgenres.")
+			return false
+		}
+	}
+
+	if err := s.store.Genres.Update(r.Context(), id, genre); err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return false
+	}
+
+	genre.Id = id
+	s.writeGenre(w, r, genre, http.StatusOK)
+	return true
+}
+
+func (s *GenreService) DeleteGenreByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	err := s.store.Genres.Delete(r.Context(), id)
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "Move or delete the genre's subgenres first.")
+		return false
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// GetGenreAlbums lists the albums in a genre or any of its subgenres.
+func (s *GenreService) GetGenreAlbums(w http.ResponseWriter, r *http.Request, id int) {
+	subtree, err := s.store.Genres.Subtree(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return
+	}
+
+	albumIDs, err := s.store.Genres.GetAlbumIDs(r.Context(), subtree)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	albums, err := s.store.Albums.GetByIDs(r.Context(), albumIDs)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	slices.SortFunc(albums, func(a, b models.Album) int { return cmp.Compare(a.Id, b.Id) })
+
+	albumVMs, err := viewModels.GetAlbumViewModels(r.Context(), s.store, albums)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, albumVMs)
+}
+
+// GetGenreSongs lists the songs in a genre or any of its subgenres.
+func (s *GenreService) GetGenreSongs(w http.ResponseWriter, r *http.Request, id int) {
+	subtree, err := s.store.Genres.Subtree(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "genre")
+		return
+	}
+
+	songIDs, err := s.store.Genres.GetSongIDs(r.Context(), subtree)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	songs, err := s.store.Songs.GetByIDs(r.Context(), songIDs)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	slices.SortFunc(songs, func(a, b models.Song) int { return cmp.Compare(a.Id, b.Id) })
+
+	songVMs, err := viewModels.GetSongViewModels(r.Context(), s.store, songs)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, songVMs)
+}
+
+// SetAlbumGenres replaces an album's genres and responds with the album.
+func (s *GenreService) SetAlbumGenres(w http.ResponseWriter, r *http.Request, id int) bool {
+	album, err := s.store.Albums.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	var req viewModels.GenresRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+	if err := s.store.Genres.SetAlbumGenres(r.Context(), id, req.GenreIds); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return false
+	}
+
+	albumVM, err := viewModels.GetAlbumViewModel(r.Context(), s.store, album)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusOK, albumVM)
+	return true
+}
+
+// SetSongGenres replaces a song's genres and responds with the song.
+func (s *GenreService) SetSongGenres(w http.ResponseWriter, r *http.Request, id int) bool {
+	song, err := s.store.Songs.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return false
+	}
+
+	var req viewModels.GenresRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+	if err := s.store.Genres.SetSongGenres(r.Context(), id, req.GenreIds); err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return false
+	}
+
+	songVM, err := viewModels.GetSongViewModel(r.Context(), s.store, song)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusOK, songVM)
+	return true
+}
+
+func (s *GenreService) writeGenre(w http.ResponseWriter, r *http.Request, genre models.Genre, status int) {
+	all, err := s.store.Genres.GetAll(r.Context())
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, status, viewModels.GetGenreViewModel(genre, all))
+}
@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedGenre(t *testing.T, genres *services.GenreService, name string, parentID *int) int {
+	t.Helper()
+	body, _ := json.Marshal(models.Genre{Name: name, ParentId: parentID})
+	w := httptest.NewRecorder()
+	genres.PostGenre(w, httptest.NewRequest("POST", "/genres", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to seed genre: %d %s", w.Code, w.Body)
+	}
+	var genre viewModels.DetailedGenreViewModel
+	json.Unmarshal(w.Body.Bytes(), &genre)
+	return genre.Id
+}
+
+func TestGenreTree(t *testing.T) {
+	store, _ := newTestStore(t)
+	genres := services.NewGenreService(store)
+	jazz := seedGenre(t, genres, "Jazz", nil)
+	cool := seedGenre(t, genres, "Cool Jazz", &jazz)
+	bebop := seedGenre(t, genres, "Bebop", &jazz)
+
+	w := httptest.NewRecorder()
+	genres.GetGenreByID(w, httptest.NewRequest("GET", "/genres/1", nil), jazz)
+	var detail viewModels.DetailedGenreViewModel
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	assert.Nil(t, detail.Parent)
+	assert.Equal(t, []viewModels.BasicGenreViewModel{{Id: bebop, Name: "Bebop"}, {Id: cool, Name: "Cool Jazz"}}, detail.Subgenres)
+
+	tests := []struct {
+		name   string
+		id     int
+		body   string
+		status int
+	}{
+		{"under its own subgenre", jazz, `{"name": "Jazz", "parent_id": 2}`, http.StatusUnprocessableEntity},
+		{"under itself", cool, `{"name": "Cool Jazz", "parent_id": 2}`, http.StatusUnprocessableEntity},
+		{"missing parent", cool, `{"name": "Cool Jazz", "parent_id": 99}`, http.StatusUnprocessableEntity},
+		{"taken name", cool, `{"name": "bebop", "parent_id": 1}`, http.StatusConflict},
+		{"missing genre", 99, `{"name": "Swing"}`, http.StatusNotFound},
+		{"move", bebop, `{"name": "Bebop", "parent_id": 2}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			genres.UpdateGenreByID(w, httptest.NewRequest("PUT", "/genres", bytes.NewBufferString(tt.body)), tt.id)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+
+	w = httptest.NewRecorder()
+	genres.DeleteGenreByID(w, httptest.NewRequest("DELETE", "/genres/2", nil), cool)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	genres.DeleteGenreByID(w, httptest.NewRequest("DELETE", "/genres/3", nil), bebop)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestGenreListings(t *testing.T) {
+	store, _ := newTestStore(t)
+	genres := services.NewGenreService(store)
+	jazz := seedGenre(t, genres, "Jazz", nil)
+	cool := seedGenre(t, genres, "Cool Jazz", &jazz)
+	rock := seedGenre(t, genres, "Rock", nil)
+
+	kindOfBlue := seedAlbum(t, store, models.Album{Title: "Kind of Blue", Price: 999})
+	birth := seedAlbum(t, store, models.Album{Title: "Birth of the Cool", Price: 999})
+	seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	song := seedSong(t, store, models.Song{Title: "So What", Length: 562, Price: 129})
+
+	for _, tt := range []struct {
+		album  int
+		genres string
+	}{{kindOfBlue, `{"genre_ids": [1]}`}, {birth, `{"genre_ids": [2]}`}} {
+		w := httptest.NewRecorder()
+		genres.SetAlbumGenres(w, httptest.NewRequest("PUT", "/albums/1/genres", bytes.NewBufferString(tt.genres)), tt.album)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	genres.SetSongGenres(w, httptest.NewRequest("PUT", "/songs/1/genres", bytes.NewBufferString(`{"genre_ids": [2, 3]}`)), song)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var songVM viewModels.DetailedSongViewModel
+	json.Unmarshal(w.Body.Bytes(), &songVM)
+	assert.Equal(t, []viewModels.BasicGenreViewModel{{Id: cool, Name: "Cool Jazz"}, {Id: rock, Name: "Rock"}}, songVM.Genres)
+
+	w = httptest.NewRecorder()
+	genres.SetSongGenres(w, httptest.NewRequest("PUT", "/songs/1/genres", bytes.NewBufferString(`{"genre_ids": [99]}`)), song)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Jazz includes its subgenre Cool Jazz.
+	w = httptest.NewRecorder()
+	genres.GetGenreAlbums(w, httptest.NewRequest("GET", "/genres/1/albums", nil), jazz)
+	var albums []viewModels.AlbumViewModel
+	json.Unmarshal(w.Body.Bytes(), &albums)
+	if assert.Len(t, albums, 2) {
+		assert.Equal(t, "Kind of Blue", albums[0].Title)
+		assert.Equal(t, "Jazz", albums[0].Genres[0].Name)
+		assert.Equal(t, "Birth of the Cool", albums[1].Title)
+	}
+
+	w = httptest.NewRecorder()
+	genres.GetGenreAlbums(w, httptest.NewRequest("GET", "/genres/2/albums", nil), cool)
+	json.Unmarshal(w.Body.Bytes(), &albums)
+	assert.Len(t, albums, 1)
+
+	w = httptest.NewRecorder()
+	genres.GetGenreSongs(w, httptest.NewRequest("GET", "/genres/1/songs", nil), jazz)
+	var songs []viewModels.SongViewModel
+	json.Unmarshal(w.Body.Bytes(), &songs)
+	assert.Len(t, songs, 1)
+
+	w = httptest.NewRecorder()
+	genres.GetGenreSongs(w, httptest.NewRequest("GET", "/genres/99/songs", nil), 99)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The album listing shows genres, and untagged albums an empty list.
+	w = httptest.NewRecorder()
+	services.NewAlbumService(store).GetAlbumByID(w, httptest.NewRequest("GET", "/albums/3", nil), 3)
+	assert.Contains(t, w.Body.String(), `"genres":[],"tags":[]`)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"goMusic/importer"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// MaxImportSize is the largest import file that can be uploaded.
+const MaxImportSize = 32 << 20
+
+type ImportService struct {
+	store *repositories.Store
+}
+
+func NewImportService(store *repositories.Store) *ImportService {
+	return &ImportService{store: store}
+}
+
+// PostImport creates the artists, bands, albums and songs of a CSV or JSON
+// Lines body in one transaction, or none of them if any row has a problem.
+// With ?dry_run=true nothing is written and the response reports every
+// problem instead.
+func (s *ImportService) PostImport(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if text := r.URL.Query().Get("dry_run"); text != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(text); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "dry_run must be true or false")
+			return
+		}
+	}
+
+	declared, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := importer.FormatFor(declared)
+	if !ok {
+		problem.Error(w, r, http.StatusUnsupportedMediaType, problem.UnsupportedMedia,
+			"Upload a CSV (text/csv) or JSON Lines (application/jsonl) file.")
+		return
+	}
+
+	batch, problems, err := importer.Read(http.MaxBytesReader(w, r.Body, MaxImportSize), format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Error(w, r, http.StatusRequestEntityTooLarge, problem.PayloadTooLarge,
+				"Import files can be at most "+strconv.Itoa(MaxImportSize>>20)+" MB.")
+		} else {
+			problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "The file could not be read: "+err.Error())
+		}
+		return
+	}
+
+	report := viewModels.GetImportReport(batch, problems, dryRun)
+	if dryRun {
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+	if len(problems) > 0 {
+		p := problem.New(http.StatusBadRequest, problem.ValidationFailed,
+			"The file has "+strconv.Itoa(len(problems))+" problems, so nothing was imported.")
+		p.Errors = problems
+		problem.Write(w, r, p)
+		return
+	}
+
+	if err := s.store.Imports.Import(r.Context(), batch); err != nil {
+		writeRepositoryError(w, r, err, "import")
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"goMusic/problem"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const importCSV = `type,first_name,last_name,nationality,birth_date,sex_id,title_id,name,date_formed,title,price,length,album,band
+band,,,British,,,,Radiohead,1985-09-01,,,,,
+album,,,,,,,,,OK Computer,999,,,Radiohead
+song,,,,,,,,,Airbag,99,284,OK Computer,Radiohead
+artist,Thom,Yorke,British,1968-10-07,1,1,,,,,,,
+`
+
+func importRequest(target, contentType, body string) *http.Request {
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestPostImport(t *testing.T) {
+	t.Run("Dry run", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		rr := httptest.NewRecorder()
+
+		services.NewImportService(store).PostImport(rr, importRequest("/import?dry_run=true", "text/csv", importCSV))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var report viewModels.ImportReport
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if !report.DryRun || report.Artists != 1 || report.Bands != 1 || report.Albums != 1 || report.Songs != 1 || len(report.Errors) != 0 {
+			t.Errorf("Wrong report: %+v", report)
+		}
+		if bands, _ := store.Bands.GetAll(context.Background()); len(bands) != 0 {
+			t.Errorf("A dry run should not write anything, got %+v", bands)
+		}
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		rr := httptest.NewRecorder()
+
+		services.NewImportService(store).PostImport(rr, importRequest("/import", "text/csv; charset=utf-8", importCSV))
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusCreated, rr.Body)
+		}
+		songs, err := store.Songs.GetByAlbumID(context.Background(), 1)
+		if err != nil || len(songs) != 1 || songs[0].Title != "Airbag" {
+			t.Errorf("The album's songs were not imported: %+v, %v", songs, err)
+		}
+	})
+
+	t.Run("Problems", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		body := `{"type": "band", "name": "Radiohead", "nationality": "British", "date_formed": "1985-09-01"}
+{"type": "song", "title": "Airbag", "price": 99, "length": 284, "band": "Radiohed"}
+`
+		for target, want := range map[string]int{"/import?dry_run=true": http.StatusOK, "/import": http.StatusBadRequest} {
+			rr := httptest.NewRecorder()
+			services.NewImportService(store).PostImport(rr, importRequest(target, "application/x-ndjson", body))
+
+			if status := rr.Code; status != want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", target, status, want)
+			}
+			var report struct{ Errors []problem.FieldError }
+			if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(report.Errors) != 1 || report.Errors[0].Line != 2 || report.Errors[0].Field != "band" {
+				t.Errorf("%s: wrong errors %+v", target, report.Errors)
+			}
+		}
+		if bands, _ := store.Bands.GetAll(context.Background()); len(bands) != 0 {
+			t.Errorf("Nothing should be imported, got %+v", bands)
+		}
+	})
+
+	for name, tt := range map[string]struct {
+		req  *http.Request
+		want int
+	}{
+		"JSON body":   {importRequest("/import", "application/json", "[]"), http.StatusUnsupportedMediaType},
+		"Bad dry_run": {importRequest("/import?dry_run=maybe", "text/csv", importCSV), http.StatusBadRequest},
+		"Unreadable":  {importRequest("/import", "text/csv", "type,title\nsong,\"Airbag\n"), http.StatusBadRequest},
+		"Too large":   {importRequest("/import", "text/csv", "type,title\nsong,"+strings.Repeat("a", services.MaxImportSize)), http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store, _ := newTestStore(t)
+			rr := httptest.NewRecorder()
+
+			services.NewImportService(store).PostImport(rr, tt.req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+type LabelService struct {
+	store *repositories.Store
+}
+
+func NewLabelService(store *repositories.Store) *LabelService {
+	return &LabelService{store: store}
+}
+
+func (s *LabelService) GetLabels(w http.ResponseWriter, r *http.Request) {
+	q, ok := parseQuery(w, r, repositories.LabelQuery)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.Labels.List(r.Context(), q)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, query.WithItems(page, viewModels.GetLabelViewModels(page.Items)))
+}
+
+func (s *LabelService) GetLabelByID(w http.ResponseWriter, r *http.Request, id int) {
+	label, err := s.store.Labels.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "label")
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetLabelViewModel(label))
+}
+
+func (s *LabelService) PostLabel(w http.ResponseWriter, r *http.Request) {
+	var label models.Label
+	if !utils.DecodeAndValidate(w, r, &label) {
+		return
+	}
+
+	id, err := s.store.Labels.Create(r.Context(), label)
+	if err != nil {
+		writeRepositoryError(w, r, err, "label")
+		return
+	}
+
+	label.Id = id
+	writeJSON(w, http.StatusCreated, viewModels.GetLabelViewModel(label))
+}
+
+func (s *LabelService) UpdateLabelByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	var label models.Label
+	if !utils.DecodeAndValidate(w, r, &label) {
+		return false
+	}
+
+	if err := s.store.Labels.Update(r.Context(), id, label); err != nil {
+		writeRepositoryError(w, r, err, "label")
+		return false
+	}
+
+	label.Id = id
+	writeJSON(w, http.StatusOK, viewModels.GetLabelViewModel(label))
+	return true
+}
+
+// DeleteLabelByID deletes a label. Its albums are kept without a label.
+func (s *LabelService) DeleteLabelByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Labels.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "label")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// GetLabelAlbums lists a label's releases by release date, undated ones
+// last.
+func (s *LabelService) GetLabelAlbums(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := s.store.Labels.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "label")
+		return
+	}
+
+	albums, err := s.store.Albums.GetByLabelID(r.Context(), id)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	albumVMs, err := viewModels.GetAlbumViewModels(r.Context(), s.store, albums)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, albumVMs)
+}
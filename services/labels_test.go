@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels(t *testing.T) {
+	store, _ := newTestStore(t)
+	labels := services.NewLabelService(store)
+	albums := services.NewAlbumService(store)
+
+	w := httptest.NewRecorder()
+	labels.PostLabel(w, httptest.NewRequest("POST", "/labels", bytes.NewBufferString(`{"name": "Parlophone", "country": "United Kingdom"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var label viewModels.LabelViewModel
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &label))
+	assert.Equal(t, "Parlophone", label.Name)
+
+	postAlbum := func(body string) int {
+		w := httptest.NewRecorder()
+		albums.PostAlbum(w, httptest.NewRequest("POST", "/albums", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	t.Run("album releases", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{"UPC", `{"title": "OK Computer", "price": 999, "label_id": 1, "release_date": "1997-05-21", "catalog_number": "NODATA 02", "barcode": "036000291452", "format": "CD"}`, http.StatusCreated},
+			{"EAN", `{"title": "Kid A", "price": 999, "label_id": 1, "release_date": "2000-10-02", "barcode": "4006381333931", "format": "LP"}`, http.StatusCreated},
+			{"undated", `{"title": "Demo", "price": 100, "label_id": 1, "format": "digital"}`, http.StatusCreated},
+			{"wrong check digit", `{"title": "Amnesiac", "price": 999, "barcode": "036000291453"}`, http.StatusBadRequest},
+			{"wrong length", `{"title": "Amnesiac", "price": 999, "barcode": "12345"}`, http.StatusBadRequest},
+			{"unknown format", `{"title": "Amnesiac", "price": 999, "format": "cassette"}`, http.StatusBadRequest},
+			{"bad date", `{"title": "Amnesiac", "price": 999, "release_date": "2001"}`, http.StatusBadRequest},
+			{"missing label", `{"title": "Amnesiac", "price": 999, "label_id": 99}`, http.StatusUnprocessableEntity},
+			{"taken barcode", `{"title": "Amnesiac", "price": 999, "barcode": "4006381333931"}`, http.StatusConflict},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.status, postAlbum(tt.body))
+			})
+		}
+	})
+
+	w = httptest.NewRecorder()
+	labels.GetLabelAlbums(w, httptest.NewRequest("GET", "/labels/1/albums", nil), label.Id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var releases []viewModels.AlbumViewModel
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &releases))
+	if assert.Len(t, releases, 3) {
+		assert.Equal(t, []string{"OK Computer", "Kid A", "Demo"}, []string{releases[0].Title, releases[1].Title, releases[2].Title})
+		assert.Equal(t, &viewModels.BasicLabelViewModel{Id: label.Id, Name: "Parlophone"}, releases[0].Label)
+	}
+
+	w = httptest.NewRecorder()
+	albums.GetAlbumByID(w, httptest.NewRequest("GET", "/albums/1", nil), 1)
+	var detail viewModels.DetailedAlbumViewModel
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, "Parlophone", detail.Label.Name)
+	assert.Equal(t, "1997-05-21", *detail.ReleaseDate)
+	assert.Equal(t, "NODATA 02", *detail.CatalogNumber)
+	assert.Equal(t, "036000291452", *detail.Barcode)
+	assert.Equal(t, "CD", *detail.Format)
+
+	w = httptest.NewRecorder()
+	labels.GetLabelAlbums(w, httptest.NewRequest("GET", "/labels/99/albums", nil), 99)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	labels.DeleteLabelByID(w, httptest.NewRequest("DELETE", "/labels/1", nil), label.Id)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	albums.GetAlbumByID(w, httptest.NewRequest("GET", "/albums/1", nil), 1)
+	detail = viewModels.DetailedAlbumViewModel{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Nil(t, detail.Label, "deleting a label keeps its albums")
+	assert.Equal(t, "CD", *detail.Format)
+}
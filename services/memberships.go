@@ -0,0 +1,145 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+type MembershipService struct {
+	store *repositories.Store
+}
+
+func NewMembershipService(store *repositories.Store) *MembershipService {
+	return &MembershipService{store: store}
+}
+
+// GetBandMembers lists everyone who has played in a band, or with
+// ?at=YYYY-MM-DD only those who were members on that day.
+func (s *MembershipService) GetBandMembers(w http.ResponseWriter, r *http.Request, id int) {
+	at, ok := parseDateParam(w, r, "at")
+	if !ok {
+		return
+	}
+
+	if _, err := s.store.Bands.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "band")
+		return
+	}
+	memberships, err := s.store.Memberships.GetByBandID(r.Context(), id, at)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	s.writeMembers(w, r, memberships, http.StatusOK)
+}
+
+// GetArtistBands lists every band an artist has played in.
+func (s *MembershipService) GetArtistBands(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := s.store.Artists.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "artist")
+		return
+	}
+	memberships, err := s.store.Memberships.GetByArtistID(r.Context(), id)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	vms, err := viewModels.GetArtistBandViewModels(r.Context(), s.store, memberships)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vms)
+}
+
+// AddBandMember adds an artist to a band and responds with every member.
+func (s *MembershipService) AddBandMember(w http.ResponseWriter, r *http.Request, id int) bool {
+	if _, err := s.store.Bands.GetByID(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "band")
+		return false
+	}
+	membership, ok := decodeMembership(w, r)
+	if !ok {
+		return false
+	}
+
+	membership.BandId = id
+	if _, err := s.store.Memberships.Create(r.Context(), membership); err != nil {
+		writeRepositoryError(w, r, err, "membership")
+		return false
+	}
+	return s.writeBandMembers(w, r, id, http.StatusCreated)
+}
+
+// UpdateMembership changes who a membership is for, their role or the
+// dates, and responds with every member of the band.
+func (s *MembershipService) UpdateMembership(w http.ResponseWriter, r *http.Request, id int) bool {
+	existing, err := s.store.Memberships.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "membership")
+		return false
+	}
+	membership, ok := decodeMembership(w, r)
+	if !ok {
+		return false
+	}
+
+	if err := s.store.Memberships.Update(r.Context(), id, membership); err != nil {
+		writeRepositoryError(w, r, err, "membership")
+		return false
+	}
+	return s.writeBandMembers(w, r, existing.BandId, http.StatusOK)
+}
+
+func (s *MembershipService) DeleteMembership(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Memberships.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "membership")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// decodeMembership reads a MembershipRequest, responding with a 400 problem
+// when it is invalid or the artist left before they joined.
+func decodeMembership(w http.ResponseWriter, r *http.Request) (models.BandMembership, bool) {
+	var req viewModels.MembershipRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return models.BandMembership{}, false
+	}
+	if !datesInOrder(w, r, "date_joined", req.DateJoined, "date_left", req.DateLeft) {
+		return models.BandMembership{}, false
+	}
+
+	return models.BandMembership{
+		ArtistId:   req.ArtistId,
+		Role:       req.Role,
+		DateJoined: req.DateJoined,
+		DateLeft:   req.DateLeft,
+	}, true
+}
+
+func (s *MembershipService) writeBandMembers(w http.ResponseWriter, r *http.Request, bandID, status int) bool {
+	memberships, err := s.store.Memberships.GetByBandID(r.Context(), bandID, "")
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	return s.writeMembers(w, r, memberships, status)
+}
+
+func (s *MembershipService) writeMembers(w http.ResponseWriter, r *http.Request, memberships []models.BandMembership, status int) bool {
+	vms, err := viewModels.GetBandMemberViewModels(r.Context(), s.store, memberships)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, status, vms)
+	return true
+}
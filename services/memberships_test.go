@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandMemberships(t *testing.T) {
+	store, _ := newTestStore(t)
+	memberships := services.NewMembershipService(store)
+	bands := services.NewBandService(store)
+	thom := seedArtist(t, store, models.Artist{FirstName: "Thom", LastName: "Yorke", Nationality: "British", BirthDate: "1968-10-07"})
+	jonny := seedArtist(t, store, models.Artist{FirstName: "Jonny", LastName: "Greenwood", Nationality: "British", BirthDate: "1971-11-05"})
+	radiohead := seedBand(t, store, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-01-01"})
+	smile := seedBand(t, store, models.Band{Name: "The Smile", Nationality: "British", DateFormed: "2020-05-01"})
+
+	add := func(bandID int, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		memberships.AddBandMember(w, httptest.NewRequest("POST", "/bands/1/members", bytes.NewBufferString(body)), bandID)
+		return w
+	}
+
+	w := add(radiohead, fmt.Sprintf(`{"artist_id": %d, "role": "vocals"}`, thom))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = add(radiohead, fmt.Sprintf(`{"artist_id": %d, "role": "guitar", "date_joined": "1986-01-01"}`, jonny))
+	var members []viewModels.BandMemberViewModel
+	json.Unmarshal(w.Body.Bytes(), &members)
+	if !assert.Len(t, members, 2) {
+		return
+	}
+	assert.Equal(t, "Yorke", members[0].Artist.LastName)
+	assert.Equal(t, "1986-01-01", *members[1].DateJoined)
+	assert.Nil(t, members[1].DateLeft)
+	thomMembership, jonnyMembership := members[0].Id, members[1].Id
+	assert.Equal(t, http.StatusCreated, add(smile, fmt.Sprintf(`{"artist_id": %d, "role": "vocals"}`, thom)).Code)
+
+	assert.Equal(t, http.StatusNotFound, add(99, fmt.Sprintf(`{"artist_id": %d}`, thom)).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, add(radiohead, `{"artist_id": 99}`).Code)
+	assert.Equal(t, http.StatusBadRequest, add(radiohead, fmt.Sprintf(`{"artist_id": %d, "date_joined": "1986"}`, jonny)).Code)
+	w = add(radiohead, fmt.Sprintf(`{"artist_id": %d, "date_joined": "1990-01-01", "date_left": "1989-01-01"}`, jonny))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "date_left")
+
+	memberCount := func() int {
+		w := httptest.NewRecorder()
+		bands.GetBandByID(w, httptest.NewRequest("GET", "/bands/1", nil), radiohead)
+		var band viewModels.BandViewModel
+		json.Unmarshal(w.Body.Bytes(), &band)
+		return band.NumberOfMembers
+	}
+	assert.Equal(t, 2, memberCount(), "counted from the current memberships")
+
+	w = httptest.NewRecorder()
+	memberships.GetBandMembers(w, httptest.NewRequest("GET", "/bands/1/members?at=1985-06-01", nil), radiohead)
+	json.Unmarshal(w.Body.Bytes(), &members)
+	if assert.Len(t, members, 1) {
+		assert.Equal(t, thom, *members[0].Artist.Id)
+	}
+	w = httptest.NewRecorder()
+	memberships.GetBandMembers(w, httptest.NewRequest("GET", "/bands/1/members?at=yesterday", nil), radiohead)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	memberships.GetArtistBands(w, httptest.NewRequest("GET", "/artists/1/bands", nil), thom)
+	var artistBands []viewModels.ArtistBandViewModel
+	json.Unmarshal(w.Body.Bytes(), &artistBands)
+	if assert.Len(t, artistBands, 2) {
+		assert.ElementsMatch(t, []string{"Radiohead", "The Smile"}, []string{artistBands[0].Band.Name, artistBands[1].Band.Name})
+	}
+
+	// Leaving the band takes the artist out of the member count.
+	w = httptest.NewRecorder()
+	memberships.UpdateMembership(w, httptest.NewRequest("PUT", "/memberships/1",
+		bytes.NewBufferString(fmt.Sprintf(`{"artist_id": %d, "role": "guitar", "date_joined": "1986-01-01", "date_left": "2000-01-01"}`, jonny))),
+		jonnyMembership)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, memberCount())
+
+	w = httptest.NewRecorder()
+	memberships.DeleteMembership(w, httptest.NewRequest("DELETE", "/memberships/1", nil), thomMembership)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 0, memberCount())
+	w = httptest.NewRecorder()
+	memberships.DeleteMembership(w, httptest.NewRequest("DELETE", "/memberships/1", nil), thomMembership)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type OrderService struct {
+	store *repositories.Store
+}
+
+func NewOrderService(store *repositories.Store) *OrderService {
+	return &OrderService{store: store}
+}
+
+// cartLine is a cart item priced at the current catalog price.
+type cartLine struct {
+	id   int
+	item models.OrderItem
+	// covered lines are already owned, or are songs on an album in the same
+	// cart. They cost nothing and are dropped at checkout.
+	covered bool
+}
+
+func (s *OrderService) GetCart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	s.writeCart(w, r, userID, http.StatusOK)
+}
+
+// AddToCart puts an album or a song in the cart. Items the user already owns
+// are refused.
+func (s *OrderService) AddToCart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req viewModels.CartItemRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	library, err := s.store.Orders.GetLibrary(r.Context(), userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	item := models.CartItem{UserId: userID}
+	owned := library.SongIds
+	if req.Type == models.ItemAlbum {
+		item.AlbumId = &req.ItemId
+		owned = library.AlbumIds
+		_, err = s.store.Albums.GetByID(r.Context(), req.ItemId)
+	} else {
+		item.SongId = &req.ItemId
+		_, err = s.store.Songs.GetByID(r.Context(), req.ItemId)
+	}
+	if errors.Is(err, repositories.ErrNotFound) {
+		err = repositories.ErrInvalidReference
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "cart item")
+		return
+	}
+	for _, id := range owned {
+		if id == req.ItemId {
+			problem.Error(w, r, http.StatusConflict, problem.Conflict, "You already own this "+req.Type+".")
+			return
+		}
+	}
+
+	if _, err := s.store.Orders.AddToCart(r.Context(), item); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			problem.Error(w, r, http.StatusConflict, problem.Conflict, "This "+req.Type+" is already in your cart.")
+			return
+		}
+		writeRepositoryError(w, r, err, "cart item")
+		return
+	}
+
+	s.writeCart(w, r, userID, http.StatusCreated)
+}
+
+func (s *OrderService) RemoveFromCart(w http.ResponseWriter, r *http.Request, id int) bool {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return false
+	}
+
+	if err := s.store.Orders.RemoveFromCart(r.Context(), userID, id); err != nil {
+		writeRepositoryError(w, r, err, "cart item")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// Checkout turns the cart into an order, charging the catalog prices at this
+// moment, and adds the albums, their songs and the songs bought on their own
+// to the user's library.
+func (s *OrderService) Checkout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lines, err := s.priceCart(r, userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	order := models.Order{UserId: userID, CreatedAt: time.Now()}
+	var cartItemIDs, songIDs []int
+	for _, line := range lines {
+		cartItemIDs = append(cartItemIDs, line.id)
+		if line.covered {
+			continue
+		}
+		order.Items = append(order.Items, line.item)
+		order.Total += line.item.Price
+
+		if line.item.AlbumId != nil {
+			songs, err := s.store.Songs.GetByAlbumID(r.Context(), *line.item.AlbumId)
+			if err != nil {
+				problem.InternalError(w, r, err)
+				return
+			}
+			for _, song := range songs {
+				songIDs = append(songIDs, song.Id)
+			}
+		}
+	}
+	if len(order.Items) == 0 {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "There is nothing in your cart to buy.")
+		return
+	}
+
+	order.Id, err = s.store.Orders.CreateOrder(r.Context(), order, songIDs, cartItemIDs)
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "Your cart changed during checkout; review it and try again.")
+		return
+	}
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, viewModels.GetOrderViewModel(order))
+}
+
+// GetOrders lists the user's orders, newest first.
+func (s *OrderService) GetOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	orders, err := s.store.Orders.GetOrders(r.Context(), userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetOrderViewModels(orders))
+}
+
+// GetOrderByID returns one of the user's orders. Other users' orders are
+// reported as not found.
+func (s *OrderService) GetOrderByID(w http.ResponseWriter, r *http.Request, id int) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := s.store.Orders.GetOrderByID(r.Context(), id)
+	if err == nil && order.UserId != userID {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "order")
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetOrderViewModel(order))
+}
+
+// GetLibrary lists the albums and songs the user owns.
+func (s *OrderService) GetLibrary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	library, err := s.store.Orders.GetLibrary(r.Context(), userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	vm, err := viewModels.GetLibraryViewModel(r.Context(), s.store, library)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vm)
+}
+
+// priceCart prices every item in the user's cart, marking the ones that are
+// already owned or come with an album in the same cart as covered.
+func (s *OrderService) priceCart(r *http.Request, userID int) ([]cartLine, error) {
+	ctx := r.Context()
+	items, err := s.store.Orders.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	library, err := s.store.Orders.GetLibrary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var albumIDs, songIDs []int
+	for _, item := range items {
+		if item.AlbumId != nil {
+			albumIDs = append(albumIDs, *item.AlbumId)
+		} else {
+			songIDs = append(songIDs, *item.SongId)
+		}
+	}
+
+	albums, err := s.store.Albums.GetByIDs(ctx, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	songs, err := s.store.Songs.GetByIDs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+	songAlbums, err := s.store.Albums.GetBySongIDs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	albumsByID := map[int]models.Album{}
+	for _, album := range albums {
+		albumsByID[album.Id] = album
+	}
+	songsByID := map[int]models.Song{}
+	for _, song := range songs {
+		songsByID[song.Id] = song
+	}
+	ownedAlbums := idSet(library.AlbumIds)
+	ownedSongs := idSet(library.SongIds)
+	cartAlbums := idSet(albumIDs)
+
+	var lines []cartLine
+	for _, item := range items {
+		line := cartLine{id: item.Id}
+		if item.AlbumId != nil {
+			album, ok := albumsByID[*item.AlbumId]
+			if !ok {
+				continue
+			}
+			line.item = models.OrderItem{Type: models.ItemAlbum, AlbumId: item.AlbumId, Title: album.Title, Price: album.Price}
+			line.covered = ownedAlbums[album.Id]
+		} else {
+			song, ok := songsByID[*item.SongId]
+			if !ok {
+				continue
+			}
+			line.item = models.OrderItem{Type: models.ItemSong, SongId: item.SongId, Title: song.Title, Price: song.Price}
+			line.covered = ownedSongs[song.Id] || slices.ContainsFunc(songAlbums[song.Id], func(a models.Album) bool { return cartAlbums[a.Id] })
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (s *OrderService) writeCart(w http.ResponseWriter, r *http.Request, userID, status int) {
+	lines, err := s.priceCart(r, userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	vm := viewModels.CartViewModel{Items: make([]viewModels.CartItemViewModel, 0, len(lines))}
+	for _, line := range lines {
+		item := viewModels.GetCartItemViewModel(line.id, line.item, line.covered)
+		vm.Items = append(vm.Items, item)
+		vm.TotalCents += item.PriceCents
+	}
+	writeJSON(w, status, vm)
+}
+
+func idSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
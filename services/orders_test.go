@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeCart(t *testing.T, w *httptest.ResponseRecorder) viewModels.CartViewModel {
+	t.Helper()
+	var cart viewModels.CartViewModel
+	if err := json.Unmarshal(w.Body.Bytes(), &cart); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return cart
+}
+
+func TestAddToCart(t *testing.T) {
+	store, mem := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	yellow := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	mem.LinkAlbumSong(albumID, yellow)
+	orders := services.NewOrderService(store)
+
+	add := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		orders.AddToCart(w, asUser(userID, "POST", "/me/cart/items", body))
+		return w
+	}
+
+	w := add(fmt.Sprintf(`{"type": "song", "item_id": %d}`, yellow))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	cart := decodeCart(t, w)
+	assert.Equal(t, int64(129), cart.TotalCents)
+
+	w = add(fmt.Sprintf(`{"type": "album", "item_id": %d}`, albumID))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	cart = decodeCart(t, w)
+	assert.Len(t, cart.Items, 2)
+	assert.True(t, cart.Items[0].Covered, "a song on an album in the cart is covered")
+	assert.Equal(t, int64(0), cart.Items[0].PriceCents)
+	assert.Equal(t, int64(999), cart.TotalCents)
+
+	assert.Equal(t, http.StatusConflict, add(fmt.Sprintf(`{"type": "song", "item_id": %d}`, yellow)).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, add(`{"type": "album", "item_id": 999}`).Code)
+	assert.Equal(t, http.StatusBadRequest, add(`{"type": "band", "item_id": 1}`).Code)
+
+	w = httptest.NewRecorder()
+	orders.RemoveFromCart(w, asUser(userID, "DELETE", "/me/cart/items/1", ""), cart.Items[0].Id)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = httptest.NewRecorder()
+	orders.RemoveFromCart(w, asUser(userID+1, "DELETE", "/me/cart/items/2", ""), cart.Items[1].Id)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCheckout(t *testing.T) {
+	store, mem := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	otherID := seedUser(t, store, "bob", "password123")
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	yellow := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	mem.LinkAlbumSong(albumID, yellow)
+	single := seedSong(t, store, models.Song{Title: "Single", Length: 200, Price: 10})
+	orders := services.NewOrderService(store)
+
+	w := httptest.NewRecorder()
+	orders.Checkout(w, asUser(userID, "POST", "/checkout", ""))
+	assert.Equal(t, http.StatusConflict, w.Code, "an empty cart cannot be checked out")
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"type": "album", "item_id": %d}`, albumID),
+		fmt.Sprintf(`{"type": "song", "item_id": %d}`, yellow),
+		fmt.Sprintf(`{"type": "song", "item_id": %d}`, single),
+	} {
+		orders.AddToCart(httptest.NewRecorder(), asUser(userID, "POST", "/me/cart/items", body))
+	}
+
+	// The price at checkout is charged, not the price when it was added.
+	store.Songs.Update(context.Background(), single, models.Song{Title: "Single", Length: 200, Price: 20})
+
+	w = httptest.NewRecorder()
+	orders.Checkout(w, asUser(userID, "POST", "/checkout", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var order viewModels.OrderViewModel
+	json.Unmarshal(w.Body.Bytes(), &order)
+	assert.Equal(t, int64(1019), order.TotalCents)
+	assert.Len(t, order.Items, 2, "the album's own song is not charged for")
+
+	w = httptest.NewRecorder()
+	orders.GetCart(w, asUser(userID, "GET", "/me/cart", ""))
+	assert.Empty(t, decodeCart(t, w).Items)
+
+	w = httptest.NewRecorder()
+	orders.GetLibrary(w, asUser(userID, "GET", "/me/library", ""))
+	var library viewModels.LibraryViewModel
+	json.Unmarshal(w.Body.Bytes(), &library)
+	assert.Len(t, library.Albums, 1)
+	assert.Len(t, library.Songs, 2)
+
+	w = httptest.NewRecorder()
+	orders.AddToCart(w, asUser(userID, "POST", "/me/cart/items", fmt.Sprintf(`{"type": "song", "item_id": %d}`, yellow)))
+	assert.Equal(t, http.StatusConflict, w.Code, "owned songs cannot be added again")
+
+	w = httptest.NewRecorder()
+	orders.GetOrders(w, asUser(userID, "GET", "/me/orders", ""))
+	var history []viewModels.OrderViewModel
+	json.Unmarshal(w.Body.Bytes(), &history)
+	assert.Len(t, history, 1)
+
+	w = httptest.NewRecorder()
+	orders.GetOrderByID(w, asUser(userID, "GET", "/me/orders/1", ""), order.Id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	orders.GetOrderByID(w, asUser(otherID, "GET", "/me/orders/1", ""), order.Id)
+	assert.Equal(t, http.StatusNotFound, w.Code, "other users' orders are hidden")
+}
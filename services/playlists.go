@@ -0,0 +1,425 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"goMusic/constants"
+	"goMusic/exporter"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+type PlaylistService struct {
+	store *repositories.Store
+}
+
+func NewPlaylistService(store *repositories.Store) *PlaylistService {
+	return &PlaylistService{store: store}
+}
+
+// playlistAccess is what a user may do with a playlist. Each level includes
+// the ones before it.
+type playlistAccess int
+
+const (
+	noAccess playlistAccess = iota
+	// canView applies to public playlists.
+	canView
+	// canEdit lets accepted collaborators change the tracks.
+	canEdit
+	// isOwner also allows changing the details, deleting the playlist and
+	// managing collaborators.
+	isOwner
+)
+
+// GetPlaylists lists the playlists the user owns or collaborates on.
+func (s *PlaylistService) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	playlists, err := s.store.Playlists.GetByUserID(r.Context(), userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	s.writePlaylists(w, r, playlists)
+}
+
+// GetInvitations lists the playlists the user has been invited to but has
+// not accepted yet.
+func (s *PlaylistService) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	playlists, err := s.store.Playlists.GetInvitations(r.Context(), userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	s.writePlaylists(w, r, playlists)
+}
+
+func (s *PlaylistService) GetPlaylistByID(w http.ResponseWriter, r *http.Request, id int) {
+	playlist, ok := s.loadPlaylist(w, r, id, canView)
+	if !ok {
+		return
+	}
+	s.writePlaylist(w, r, playlist, http.StatusOK)
+}
+
+// ExportPlaylist sends the playlist's tracks as an XSPF or M3U8 playlist
+// to anyone who can view it.
+func (s *PlaylistService) ExportPlaylist(w http.ResponseWriter, r *http.Request, id int) {
+	format, ok := exportFormat(w, r, exporter.TracklistFormats)
+	if !ok {
+		return
+	}
+	playlist, ok := s.loadPlaylist(w, r, id, canView)
+	if !ok {
+		return
+	}
+
+	list, err := viewModels.GetPlaylistTracklist(r.Context(), s.store, playlist)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeTracklist(w, r, list, format, "playlist", id)
+}
+
+func (s *PlaylistService) PostPlaylist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req viewModels.PlaylistRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	playlist := playlistFromRequest(req)
+	playlist.UserId = userID
+	id, err := s.store.Playlists.Create(r.Context(), playlist)
+	if err != nil {
+		writeRepositoryError(w, r, err, "playlist")
+		return
+	}
+
+	playlist.Id = id
+	s.writePlaylist(w, r, playlist, http.StatusCreated)
+}
+
+// UpdatePlaylistByID changes the name, description and visibility. Only the
+// owner may do so.
+func (s *PlaylistService) UpdatePlaylistByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	existing, ok := s.loadPlaylist(w, r, id, isOwner)
+	if !ok {
+		return false
+	}
+
+	var req viewModels.PlaylistRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+
+	playlist := playlistFromRequest(req)
+	if err := s.store.Playlists.Update(r.Context(), id, playlist); err != nil {
+		writeRepositoryError(w, r, err, "playlist")
+		return false
+	}
+
+	playlist.Id, playlist.UserId = existing.Id, existing.UserId
+	s.writePlaylist(w, r, playlist, http.StatusOK)
+	return true
+}
+
+func (s *PlaylistService) DeletePlaylistByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if _, ok := s.loadPlaylist(w, r, id, isOwner); !ok {
+		return false
+	}
+
+	if err := s.store.Playlists.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "playlist")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// AddTrack inserts a song into the playlist, at the end unless a position is
+// given.
+func (s *PlaylistService) AddTrack(w http.ResponseWriter, r *http.Request, id int) bool {
+	playlist, ok := s.loadPlaylist(w, r, id, canEdit)
+	if !ok {
+		return false
+	}
+
+	var req viewModels.AddTrackRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+
+	if _, err := s.store.Songs.GetByID(r.Context(), req.SongId); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			err = repositories.ErrInvalidReference
+		}
+		writeRepositoryError(w, r, err, "track")
+		return false
+	}
+
+	if _, err := s.store.Playlists.AddTrack(r.Context(), id, req.SongId, req.Position); err != nil {
+		writeRepositoryError(w, r, err, "track")
+		return false
+	}
+
+	s.writePlaylist(w, r, playlist, http.StatusCreated)
+	return true
+}
+
+func (s *PlaylistService) RemoveTrack(w http.ResponseWriter, r *http.Request, id, position int) bool {
+	if _, ok := s.loadPlaylist(w, r, id, canEdit); !ok {
+		return false
+	}
+
+	if err := s.store.Playlists.RemoveTrack(r.Context(), id, position); err != nil {
+		writeRepositoryError(w, r, err, "track")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// MoveTrack moves one track to another position, shifting the tracks in
+// between.
+func (s *PlaylistService) MoveTrack(w http.ResponseWriter, r *http.Request, id int) bool {
+	playlist, ok := s.loadPlaylist(w, r, id, canEdit)
+	if !ok {
+		return false
+	}
+
+	var req viewModels.MoveTrackRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+
+	if err := s.store.Playlists.MoveTrack(r.Context(), id, req.From, req.To); err != nil {
+		writeRepositoryError(w, r, err, "track")
+		return false
+	}
+
+	s.writePlaylist(w, r, playlist, http.StatusOK)
+	return true
+}
+
+// ReorderTracks rearranges the whole playlist at once. The order must list
+// every current position, so a client working from a stale copy of the
+// playlist gets a 409 rather than losing tracks.
+func (s *PlaylistService) ReorderTracks(w http.ResponseWriter, r *http.Request, id int) bool {
+	playlist, ok := s.loadPlaylist(w, r, id, canEdit)
+	if !ok {
+		return false
+	}
+
+	var req viewModels.ReorderTracksRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+
+	err := s.store.Playlists.ReorderTracks(r.Context(), id, req.Order)
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "order must list every position in the playlist once")
+		return false
+	}
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+
+	s.writePlaylist(w, r, playlist, http.StatusOK)
+	return true
+}
+
+// InviteCollaborator invites a user, by username, to edit the playlist's
+// tracks. They become a collaborator once they accept.
+func (s *PlaylistService) InviteCollaborator(w http.ResponseWriter, r *http.Request, id int) bool {
+	playlist, ok := s.loadPlaylist(w, r, id, isOwner)
+	if !ok {
+		return false
+	}
+
+	var req viewModels.InviteRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+
+	user, err := s.store.Users.GetByUsername(r.Context(), req.Username)
+	if errors.Is(err, repositories.ErrNotFound) {
+		err = repositories.ErrInvalidReference
+	}
+	if err == nil && user.Id == playlist.UserId {
+		err = repositories.ErrConflict
+	}
+	if err == nil {
+		err = s.store.Playlists.InviteCollaborator(r.Context(), id, user.Id)
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "collaborator")
+		return false
+	}
+
+	s.writePlaylist(w, r, playlist, http.StatusCreated)
+	return true
+}
+
+// AcceptInvitation makes the user a collaborator on a playlist they were
+// invited to.
+func (s *PlaylistService) AcceptInvitation(w http.ResponseWriter, r *http.Request, id int) bool {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return false
+	}
+
+	playlist, err := s.store.Playlists.GetByID(r.Context(), id)
+	if err == nil {
+		err = s.store.Playlists.AcceptInvitation(r.Context(), id, userID)
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "invitation")
+		return false
+	}
+
+	s.writePlaylist(w, r, playlist, http.StatusOK)
+	return true
+}
+
+// RemoveCollaborator removes a collaborator or withdraws an invitation.
+// Owners can remove anyone; other users can only remove themselves, which
+// declines an invitation or leaves the playlist.
+func (s *PlaylistService) RemoveCollaborator(w http.ResponseWriter, r *http.Request, id, userID int) bool {
+	currentUserID, ok := requestUserID(w, r)
+	if !ok {
+		return false
+	}
+
+	if userID != currentUserID {
+		if _, ok := s.loadPlaylist(w, r, id, isOwner); !ok {
+			return false
+		}
+	}
+
+	if err := s.store.Playlists.RemoveCollaborator(r.Context(), id, userID); err != nil {
+		writeRepositoryError(w, r, err, "collaborator")
+		return false
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// loadPlaylist fetches a playlist and checks that the user has at least the
+// needed access to it. Playlists the user cannot see are reported as not
+// found, so private playlists do not reveal that they exist.
+func (s *PlaylistService) loadPlaylist(w http.ResponseWriter, r *http.Request, id int, needed playlistAccess) (models.Playlist, bool) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return models.Playlist{}, false
+	}
+
+	playlist, err := s.store.Playlists.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "playlist")
+		return models.Playlist{}, false
+	}
+
+	access, err := s.access(r, playlist, userID)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return models.Playlist{}, false
+	}
+	if access == noAccess {
+		writeRepositoryError(w, r, repositories.ErrNotFound, "playlist")
+		return models.Playlist{}, false
+	}
+	if access < needed {
+		detail := "Only the owner and collaborators can change this playlist's tracks."
+		if needed == isOwner {
+			detail = "Only the owner can do this."
+		}
+		problem.Error(w, r, http.StatusForbidden, problem.Forbidden, detail)
+		return models.Playlist{}, false
+	}
+
+	return playlist, true
+}
+
+func (s *PlaylistService) access(r *http.Request, playlist models.Playlist, userID int) (playlistAccess, error) {
+	if playlist.UserId == userID {
+		return isOwner, nil
+	}
+
+	collaborators, err := s.store.Playlists.GetCollaborators(r.Context(), []int{playlist.Id})
+	if err != nil {
+		return noAccess, err
+	}
+	for _, c := range collaborators[playlist.Id] {
+		if c.UserId == userID && c.Accepted {
+			return canEdit, nil
+		}
+	}
+
+	if playlist.Visibility == constants.Public.String() {
+		return canView, nil
+	}
+	return noAccess, nil
+}
+
+func (s *PlaylistService) writePlaylist(w http.ResponseWriter, r *http.Request, playlist models.Playlist, status int) {
+	vm, err := viewModels.GetPlaylistViewModel(r.Context(), s.store, playlist)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(vm)
+}
+
+func (s *PlaylistService) writePlaylists(w http.ResponseWriter, r *http.Request, playlists []models.Playlist) {
+	vms, err := viewModels.GetPlaylistViewModels(r.Context(), s.store, playlists)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vms)
+}
+
+func playlistFromRequest(req viewModels.PlaylistRequest) models.Playlist {
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = constants.Private.String()
+	}
+	return models.Playlist{Name: req.Name, Description: req.Description, Visibility: visibility}
+}
+
+// requestUserID reads the user ID AuthMiddleware puts in the context.
+func requestUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		problem.InternalError(w, r, errors.New("request has no user context"))
+	}
+	return userID, ok
+}
@@ -0,0 +1,243 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// asUser builds a request carrying the user ID AuthMiddleware would set.
+func asUser(userID int, method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	return req.WithContext(context.WithValue(req.Context(), "userID", userID))
+}
+
+func seedPlaylist(t *testing.T, store *repositories.Store, ownerID int, visibility string, songIDs ...int) int {
+	t.Helper()
+	ctx := context.Background()
+	id, err := store.Playlists.Create(ctx, models.Playlist{UserId: ownerID, Name: "Road trip", Visibility: visibility})
+	if err != nil {
+		t.Fatalf("Failed to seed playlist: %v", err)
+	}
+	for _, songID := range songIDs {
+		if _, err := store.Playlists.AddTrack(ctx, id, songID, 0); err != nil {
+			t.Fatalf("Failed to seed track: %v", err)
+		}
+	}
+	return id
+}
+
+func decodePlaylist(t *testing.T, w *httptest.ResponseRecorder) viewModels.DetailedPlaylistViewModel {
+	t.Helper()
+	var playlist viewModels.DetailedPlaylistViewModel
+	if err := json.Unmarshal(w.Body.Bytes(), &playlist); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return playlist
+}
+
+func TestPostPlaylist(t *testing.T) {
+	store, _ := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	playlists := services.NewPlaylistService(store)
+
+	w := httptest.NewRecorder()
+	playlists.PostPlaylist(w, asUser(userID, "POST", "/playlists", `{"name": "Road trip"}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	playlist := decodePlaylist(t, w)
+	assert.Equal(t, "Road trip", playlist.Name)
+	assert.Equal(t, userID, playlist.OwnerId)
+	assert.Equal(t, "private", playlist.Visibility)
+	assert.Empty(t, playlist.Tracks)
+
+	w = httptest.NewRecorder()
+	playlists.PostPlaylist(w, asUser(userID, "POST", "/playlists", `{"name": "", "visibility": "friends"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response problem.Problem
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []problem.FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "visibility", Rule: "oneof", Message: "must be one of public, private"},
+	}, response.Errors)
+}
+
+func TestGetPlaylistByID(t *testing.T) {
+	store, _ := newTestStore(t)
+	ownerID := seedUser(t, store, "alice", "password123")
+	otherID := seedUser(t, store, "bob", "password123")
+	yellow := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	trouble := seedSong(t, store, models.Song{Title: "Trouble", Length: 273, Price: 129})
+	private := seedPlaylist(t, store, ownerID, "private", yellow, trouble)
+	public := seedPlaylist(t, store, ownerID, "public", yellow)
+	playlists := services.NewPlaylistService(store)
+
+	t.Run("owner sees the tracks and total duration", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		playlists.GetPlaylistByID(w, asUser(ownerID, "GET", "/playlists/1", ""), private)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		playlist := decodePlaylist(t, w)
+		assert.Equal(t, 2, playlist.TrackCount)
+		assert.Equal(t, 539, playlist.TotalDuration)
+		if assert.Len(t, playlist.Tracks, 2) {
+			assert.Equal(t, 2, playlist.Tracks[1].Position)
+			assert.Equal(t, "Trouble", playlist.Tracks[1].Song.Title)
+		}
+	})
+
+	t.Run("private playlists are hidden from other users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		playlists.GetPlaylistByID(w, asUser(otherID, "GET", "/playlists/1", ""), private)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("public playlists are visible but read only", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		playlists.GetPlaylistByID(w, asUser(otherID, "GET", "/playlists/2", ""), public)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		playlists.AddTrack(w, asUser(otherID, "POST", "/playlists/2/tracks", `{"song_id": 2}`), public)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestPlaylistTrackEditing(t *testing.T) {
+	store, _ := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	yellow := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	trouble := seedSong(t, store, models.Song{Title: "Trouble", Length: 273, Price: 129})
+	shiver := seedSong(t, store, models.Song{Title: "Shiver", Length: 299, Price: 129})
+	playlistID := seedPlaylist(t, store, userID, "private", yellow, trouble)
+	playlists := services.NewPlaylistService(store)
+
+	titles := func(playlist viewModels.DetailedPlaylistViewModel) []string {
+		var titles []string
+		for _, track := range playlist.Tracks {
+			titles = append(titles, track.Song.Title)
+		}
+		return titles
+	}
+
+	w := httptest.NewRecorder()
+	playlists.AddTrack(w, asUser(userID, "POST", "/playlists/1/tracks", `{"song_id": 3, "position": 1}`), playlistID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []string{"Shiver", "Yellow", "Trouble"}, titles(decodePlaylist(t, w)))
+
+	w = httptest.NewRecorder()
+	playlists.MoveTrack(w, asUser(userID, "POST", "/playlists/1/tracks/move", `{"from": 1, "to": 3}`), playlistID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Yellow", "Trouble", "Shiver"}, titles(decodePlaylist(t, w)))
+
+	w = httptest.NewRecorder()
+	playlists.ReorderTracks(w, asUser(userID, "PUT", "/playlists/1/tracks", `{"order": [2, 3, 1]}`), playlistID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Trouble", "Shiver", "Yellow"}, titles(decodePlaylist(t, w)))
+
+	w = httptest.NewRecorder()
+	playlists.ReorderTracks(w, asUser(userID, "PUT", "/playlists/1/tracks", `{"order": [2, 1]}`), playlistID)
+	assert.Equal(t, http.StatusConflict, w.Code, "an order missing a track is stale")
+
+	w = httptest.NewRecorder()
+	playlists.RemoveTrack(w, asUser(userID, "DELETE", "/playlists/1/tracks/1", ""), playlistID, 1)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.AddTrack(w, asUser(userID, "POST", "/playlists/1/tracks", `{"song_id": 99}`), playlistID)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.GetPlaylistByID(w, asUser(userID, "GET", "/playlists/1", ""), playlistID)
+	playlist := decodePlaylist(t, w)
+	assert.Equal(t, []string{"Shiver", "Yellow"}, titles(playlist))
+	assert.Equal(t, shiver, *playlist.Tracks[0].Song.ID)
+	assert.Equal(t, 565, playlist.TotalDuration)
+}
+
+func TestPlaylistCollaboration(t *testing.T) {
+	store, _ := newTestStore(t)
+	ownerID := seedUser(t, store, "alice", "password123")
+	guestID := seedUser(t, store, "bob", "password123")
+	song := seedSong(t, store, models.Song{Title: "Yellow", Length: 266, Price: 129})
+	playlistID := seedPlaylist(t, store, ownerID, "private")
+	playlists := services.NewPlaylistService(store)
+
+	w := httptest.NewRecorder()
+	playlists.InviteCollaborator(w, asUser(guestID, "POST", "/playlists/1/collaborators", `{"username": "bob"}`), playlistID)
+	assert.Equal(t, http.StatusNotFound, w.Code, "only the owner can see the private playlist")
+
+	w = httptest.NewRecorder()
+	playlists.InviteCollaborator(w, asUser(ownerID, "POST", "/playlists/1/collaborators", `{"username": "carol"}`), playlistID)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.InviteCollaborator(w, asUser(ownerID, "POST", "/playlists/1/collaborators", `{"username": "bob"}`), playlistID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []viewModels.PlaylistCollaboratorViewModel{{UserId: guestID, Username: "bob"}}, decodePlaylist(t, w).Collaborators)
+
+	w = httptest.NewRecorder()
+	playlists.AddTrack(w, asUser(guestID, "POST", "/playlists/1/tracks", `{"song_id": 1}`), playlistID)
+	assert.Equal(t, http.StatusNotFound, w.Code, "a pending invitation grants no access")
+
+	w = httptest.NewRecorder()
+	playlists.GetInvitations(w, asUser(guestID, "GET", "/playlists/invitations", ""))
+	assert.Contains(t, w.Body.String(), `"name":"Road trip"`)
+
+	w = httptest.NewRecorder()
+	playlists.AcceptInvitation(w, asUser(guestID, "POST", "/playlists/1/invitation", ""), playlistID)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.AddTrack(w, asUser(guestID, "POST", "/playlists/1/tracks", `{"song_id": 1}`), playlistID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, song, *decodePlaylist(t, w).Tracks[0].Song.ID)
+
+	w = httptest.NewRecorder()
+	playlists.UpdatePlaylistByID(w, asUser(guestID, "PUT", "/playlists/1", `{"name": "Mine now"}`), playlistID)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.RemoveCollaborator(w, asUser(guestID, "DELETE", "/playlists/1/collaborators/2", ""), playlistID, guestID)
+	assert.Equal(t, http.StatusNoContent, w.Code, "collaborators can leave")
+
+	w = httptest.NewRecorder()
+	playlists.GetPlaylistByID(w, asUser(guestID, "GET", "/playlists/1", ""), playlistID)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateAndDeletePlaylist(t *testing.T) {
+	store, _ := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	playlistID := seedPlaylist(t, store, userID, "private")
+	playlists := services.NewPlaylistService(store)
+
+	w := httptest.NewRecorder()
+	playlists.UpdatePlaylistByID(w, asUser(userID, "PUT", "/playlists/1", `{"name": "Summer", "visibility": "public"}`), playlistID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	playlist := decodePlaylist(t, w)
+	assert.Equal(t, "Summer", playlist.Name)
+	assert.Equal(t, "public", playlist.Visibility)
+	assert.Equal(t, userID, playlist.OwnerId)
+
+	w = httptest.NewRecorder()
+	playlists.DeletePlaylistByID(w, asUser(userID, "DELETE", "/playlists/1", ""), playlistID)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	playlists.GetPlaylists(w, asUser(userID, "GET", "/playlists", ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
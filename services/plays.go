@@ -0,0 +1,162 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultStatsLimit = 10
+	maxStatsLimit     = 50
+)
+
+// statsWindows maps each ?window= to how far back it reaches. The windows
+// roll with the current time; all has no limit.
+var statsWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+type PlayService struct {
+	store *repositories.Store
+}
+
+func NewPlayService(store *repositories.Store) *PlayService {
+	return &PlayService{store: store}
+}
+
+// PostPlays records a batch of the user's plays. Plays repeating a client_id
+// the user already submitted are counted as duplicates rather than stored
+// again.
+func (s *PlayService) PostPlays(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	var req viewModels.PlayBatchRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	plays := make([]models.Play, 0, len(req.Plays))
+	for _, play := range req.Plays {
+		var clientID *string
+		if play.ClientId != "" {
+			clientID = &play.ClientId
+		}
+		plays = append(plays, models.Play{
+			UserId: userID, SongId: &play.SongId, PlayedAt: play.PlayedAt, Duration: play.Duration, ClientId: clientID,
+		})
+	}
+
+	recorded, err := s.store.Plays.Record(r.Context(), plays)
+	if err != nil {
+		writeRepositoryError(w, r, err, "play")
+		return
+	}
+	writeJSON(w, http.StatusCreated, viewModels.PlayBatchViewModel{Recorded: recorded, Duplicates: len(plays) - recorded})
+}
+
+// GetTopSongs ranks the songs the user played most in the window.
+func (s *PlayService) GetTopSongs(w http.ResponseWriter, r *http.Request) {
+	userID, window, since, limit, ok := parseStatsQuery(w, r)
+	if !ok {
+		return
+	}
+	counts, err := s.store.Plays.TopSongs(r.Context(), userID, sinceTime(since), limit)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	songs, err := viewModels.GetTopSongViewModels(r.Context(), s.store, counts)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.TopListViewModel[viewModels.TopSongViewModel]{Window: window, Since: since, Items: songs})
+}
+
+// GetTopArtists ranks the artists and bands whose songs the user played most
+// in the window. A play counts for every performer credited on the song.
+func (s *PlayService) GetTopArtists(w http.ResponseWriter, r *http.Request) {
+	userID, window, since, limit, ok := parseStatsQuery(w, r)
+	if !ok {
+		return
+	}
+	counts, err := s.store.Plays.TopPerformers(r.Context(), userID, sinceTime(since), limit)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	artists, err := viewModels.GetTopArtistViewModels(r.Context(), s.store, counts)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.TopListViewModel[viewModels.TopArtistViewModel]{Window: window, Since: since, Items: artists})
+}
+
+// GetListeningTime totals the user's plays in the window, and per day.
+func (s *PlayService) GetListeningTime(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	window, since, ok := parseWindow(w, r)
+	if !ok {
+		return
+	}
+	days, err := s.store.Plays.ListeningTime(r.Context(), userID, sinceTime(since))
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetListeningTimeViewModel(window, since, days))
+}
+
+// parseStatsQuery reads the user, window and limit of a top list.
+func parseStatsQuery(w http.ResponseWriter, r *http.Request) (userID int, window string, since *time.Time, limit int, ok bool) {
+	if userID, ok = requestUserID(w, r); !ok {
+		return
+	}
+	if window, since, ok = parseWindow(w, r); !ok {
+		return
+	}
+	limit, ok = parseLimit(w, r, defaultStatsLimit, maxStatsLimit)
+	return
+}
+
+// parseWindow reads ?window=, defaulting to all, and returns when it starts,
+// or nil for all time.
+func parseWindow(w http.ResponseWriter, r *http.Request) (string, *time.Time, bool) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "all"
+	}
+	length, ok := statsWindows[window]
+	if !ok {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "window must be one of day, week, month or all")
+		return "", nil, false
+	}
+	if length == 0 {
+		return window, nil, true
+	}
+	since := time.Now().UTC().Add(-length).Truncate(time.Second)
+	return window, &since, true
+}
+
+// sinceTime turns an open start into the zero time, which precedes every
+// play.
+func sinceTime(since *time.Time) time.Time {
+	if since == nil {
+		return time.Time{}
+	}
+	return *since
+}
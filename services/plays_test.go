@@ -0,0 +1,144 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostPlays(t *testing.T) {
+	store, _ := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	songID := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 99})
+	plays := services.NewPlayService(store)
+
+	body := `{"plays": [
+		{"song_id": 1, "played_at": "2024-03-01T20:00:00Z", "duration": 284, "client_id": "phone-1"},
+		{"song_id": 1, "played_at": "2024-03-01T20:05:00Z", "duration": 30, "client_id": "phone-2"}
+	]}`
+	w := httptest.NewRecorder()
+	plays.PostPlays(w, asUser(userID, "POST", "/me/plays", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"recorded": 2, "duplicates": 0}`, w.Body.String())
+
+	t.Run("retried batch", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.PostPlays(w, asUser(userID, "POST", "/me/plays", body))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"recorded": 0, "duplicates": 2}`, w.Body.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		for _, body := range []string{
+			`{"plays": []}`,
+			`{"plays": [{"song_id": 1, "played_at": "` + future + `", "duration": 10}]}`,
+			`{"plays": [{"song_id": 1, "played_at": "2024-03-01T20:00:00Z", "duration": -1}]}`,
+		} {
+			w := httptest.NewRecorder()
+			plays.PostPlays(w, asUser(userID, "POST", "/me/plays", body))
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	t.Run("unknown song", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.PostPlays(w, asUser(userID, "POST", "/me/plays", `{"plays": [{"song_id": 99, "played_at": "2024-03-01T20:00:00Z", "duration": 10}]}`))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	top, _ := store.Plays.TopSongs(context.Background(), userID, time.Time{}, 10)
+	assert.Equal(t, []models.SongPlays{{SongId: songID, Plays: 2, Seconds: 314}}, top)
+}
+
+func TestStats(t *testing.T) {
+	store, _ := newTestStore(t)
+	userID := seedUser(t, store, "alice", "password123")
+	bandID := seedBand(t, store, models.Band{Name: "Radiohead", Nationality: "British", DateFormed: "1985-09-01"})
+	airbag := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 99})
+	creep := seedSong(t, store, models.Song{Title: "Creep", Length: 238, Price: 99})
+	seedCredit(t, store, models.Credit{SongId: &airbag, BandId: &bandID, Role: models.RolePerformer})
+	seedCredit(t, store, models.Credit{SongId: &creep, BandId: &bandID, Role: models.RolePerformer})
+
+	now := time.Now()
+	_, err := store.Plays.Record(context.Background(), []models.Play{
+		{UserId: userID, SongId: &airbag, PlayedAt: now.Add(-time.Hour), Duration: 284},
+		{UserId: userID, SongId: &creep, PlayedAt: now.Add(-3 * 24 * time.Hour), Duration: 238},
+		{UserId: userID, SongId: &creep, PlayedAt: now.Add(-60 * 24 * time.Hour), Duration: 200},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plays := services.NewPlayService(store)
+
+	t.Run("top songs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.GetTopSongs(w, asUser(userID, "GET", "/me/stats/top-songs?window=week", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var top viewModels.TopListViewModel[viewModels.TopSongViewModel]
+		if err := json.Unmarshal(w.Body.Bytes(), &top); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "week", top.Window)
+		assert.NotNil(t, top.Since)
+		if assert.Len(t, top.Items, 2) {
+			assert.Equal(t, "Airbag", top.Items[0].Song.Title)
+			assert.Equal(t, 1, top.Items[0].Plays)
+			assert.Equal(t, "Creep", top.Items[1].Song.Title)
+		}
+
+		w = httptest.NewRecorder()
+		plays.GetTopSongs(w, asUser(userID, "GET", "/me/stats/top-songs?limit=1", ""))
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &top))
+		assert.Equal(t, "all", top.Window)
+		assert.Nil(t, top.Since)
+		if assert.Len(t, top.Items, 1) {
+			assert.Equal(t, "Creep", top.Items[0].Song.Title)
+			assert.Equal(t, 2, top.Items[0].Plays)
+		}
+	})
+
+	t.Run("top artists", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.GetTopArtists(w, asUser(userID, "GET", "/me/stats/top-artists?window=month", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var top struct {
+			Items []struct {
+				Type  string         `json:"type"`
+				Item  map[string]any `json:"item"`
+				Plays int            `json:"plays"`
+			} `json:"items"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &top))
+		if assert.Len(t, top.Items, 1) {
+			assert.Equal(t, "band", top.Items[0].Type)
+			assert.Equal(t, "Radiohead", top.Items[0].Item["name"])
+			assert.Equal(t, 2, top.Items[0].Plays)
+		}
+	})
+
+	t.Run("listening time", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.GetListeningTime(w, asUser(userID, "GET", "/me/stats/listening-time?window=day", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var listening viewModels.ListeningTimeViewModel
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listening))
+		assert.Equal(t, 1, listening.Plays)
+		assert.Equal(t, 284, listening.Seconds)
+		assert.Len(t, listening.Days, 1)
+	})
+
+	t.Run("unknown window", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		plays.GetListeningTime(w, asUser(userID, "GET", "/me/stats/listening-time?window=year", ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
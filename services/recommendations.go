@@ -0,0 +1,66 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/recommend"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+const (
+	defaultRecommendationLimit = 20
+	maxRecommendationLimit     = 50
+)
+
+type RecommendationService struct {
+	store *repositories.Store
+}
+
+func NewRecommendationService(store *repositories.Store) *RecommendationService {
+	return &RecommendationService{store: store}
+}
+
+// GetRecommendations suggests songs for the user from what they have played
+// and bought.
+func (s *RecommendationService) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(w, r, defaultRecommendationLimit, maxRecommendationLimit)
+	if !ok {
+		return
+	}
+	recommendations, err := recommend.ForUser(r.Context(), s.store, userID, limit)
+	s.writeRecommendations(w, r, recommendations, err)
+}
+
+// GetSimilarSongs suggests songs like the given one.
+func (s *RecommendationService) GetSimilarSongs(w http.ResponseWriter, r *http.Request, id int) {
+	limit, ok := parseLimit(w, r, defaultRecommendationLimit, maxRecommendationLimit)
+	if !ok {
+		return
+	}
+	song, err := s.store.Songs.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return
+	}
+	recommendations, err := recommend.Similar(r.Context(), s.store, song, limit)
+	s.writeRecommendations(w, r, recommendations, err)
+}
+
+func (s *RecommendationService) writeRecommendations(w http.ResponseWriter, r *http.Request, recommendations []models.Recommendation, err error) {
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	vms, err := viewModels.GetRecommendationViewModels(r.Context(), s.store, recommendations)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, vms)
+}
@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/recommend"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendations(t *testing.T) {
+	store, _ := newTestStore(t)
+	alice := seedUser(t, store, "alice", "password123")
+	bob := seedUser(t, store, "bob", "password123")
+	karma := seedSong(t, store, models.Song{Title: "Karma Police", Length: 264, Price: 99})
+	roads := seedSong(t, store, models.Song{Title: "Roads", Length: 305, Price: 99})
+	ctx := context.Background()
+	if _, err := store.Plays.Record(ctx, []models.Play{
+		{UserId: alice, SongId: &karma, Duration: 264},
+		{UserId: alice, SongId: &roads, Duration: 305},
+		{UserId: bob, SongId: &karma, Duration: 264},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recommend.Rebuild(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	recommendations := services.NewRecommendationService(store)
+
+	t.Run("for the user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		recommendations.GetRecommendations(w, asUser(bob, "GET", "/me/recommendations", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []viewModels.RecommendationViewModel
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, "Roads", got[0].Song.Title)
+			assert.Equal(t, "because you played Karma Police", got[0].Reason)
+			assert.Greater(t, got[0].Score, 0.0)
+		}
+	})
+
+	t.Run("similar songs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		recommendations.GetSimilarSongs(w, httptest.NewRequest("GET", "/songs/2/similar", nil), roads)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []viewModels.RecommendationViewModel
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, "Karma Police", got[0].Song.Title)
+		}
+	})
+
+	t.Run("missing song", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		recommendations.GetSimilarSongs(w, httptest.NewRequest("GET", "/songs/99/similar", nil), 99)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("bad limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		recommendations.GetRecommendations(w, asUser(bob, "GET", "/me/recommendations?limit=500", ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
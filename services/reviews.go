@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"goMusic/constants"
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+)
+
+type ReviewService struct {
+	store *repositories.Store
+}
+
+func NewReviewService(store *repositories.Store) *ReviewService {
+	return &ReviewService{store: store}
+}
+
+// GetAlbumReviews pages through an album's reviews.
+func (s *ReviewService) GetAlbumReviews(w http.ResponseWriter, r *http.Request, albumID int) {
+	if _, err := s.store.Albums.GetByID(r.Context(), albumID); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return
+	}
+	s.writeReviews(w, r, func(q query.Query) (query.Page[models.Review], error) {
+		return s.store.Reviews.ListByAlbumID(r.Context(), albumID, q)
+	})
+}
+
+// GetSongReviews pages through a song's reviews.
+func (s *ReviewService) GetSongReviews(w http.ResponseWriter, r *http.Request, songID int) {
+	if _, err := s.store.Songs.GetByID(r.Context(), songID); err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return
+	}
+	s.writeReviews(w, r, func(q query.Query) (query.Page[models.Review], error) {
+		return s.store.Reviews.ListBySongID(r.Context(), songID, q)
+	})
+}
+
+func (s *ReviewService) writeReviews(w http.ResponseWriter, r *http.Request, list func(query.Query) (query.Page[models.Review], error)) {
+	q, ok := parseQuery(w, r, repositories.ReviewQuery)
+	if !ok {
+		return
+	}
+	page, err := list(q)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, query.WithItems(page, viewModels.GetReviewViewModels(page.Items)))
+}
+
+// PostAlbumReview adds the user's review of an album.
+func (s *ReviewService) PostAlbumReview(w http.ResponseWriter, r *http.Request, albumID int) {
+	if _, err := s.store.Albums.GetByID(r.Context(), albumID); err != nil {
+		writeRepositoryError(w, r, err, "album")
+		return
+	}
+	s.postReview(w, r, models.Review{AlbumId: &albumID}, "album")
+}
+
+// PostSongReview adds the user's review of a song.
+func (s *ReviewService) PostSongReview(w http.ResponseWriter, r *http.Request, songID int) {
+	if _, err := s.store.Songs.GetByID(r.Context(), songID); err != nil {
+		writeRepositoryError(w, r, err, "song")
+		return
+	}
+	s.postReview(w, r, models.Review{SongId: &songID}, "song")
+}
+
+// postReview creates a review of the album or song set on review. Each user
+// may review an item once; they edit that review to change their mind.
+func (s *ReviewService) postReview(w http.ResponseWriter, r *http.Request, review models.Review, noun string) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	var req viewModels.ReviewRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	review.UserId, review.Rating, review.Body = userID, req.Rating, req.Body
+	id, err := s.store.Reviews.Create(r.Context(), review)
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "You have already reviewed this "+noun+"; edit that review instead.")
+		return
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "review")
+		return
+	}
+	s.writeReview(w, r, id, http.StatusCreated)
+}
+
+// UpdateReview changes a review's rating and text. Only its author and
+// moderators may do so.
+func (s *ReviewService) UpdateReview(w http.ResponseWriter, r *http.Request, id int) {
+	if _, ok := s.loadOwnReview(w, r, id); !ok {
+		return
+	}
+	var req viewModels.ReviewRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if err := s.store.Reviews.Update(r.Context(), id, models.Review{Rating: req.Rating, Body: req.Body}); err != nil {
+		writeRepositoryError(w, r, err, "review")
+		return
+	}
+	s.writeReview(w, r, id, http.StatusOK)
+}
+
+// DeleteReview removes a review. Only its author and moderators may do so.
+func (s *ReviewService) DeleteReview(w http.ResponseWriter, r *http.Request, id int) {
+	if _, ok := s.loadOwnReview(w, r, id); !ok {
+		return
+	}
+	if err := s.store.Reviews.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "review")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadOwnReview fetches a review the user may change: their own, or any
+// review when they are a moderator or admin.
+func (s *ReviewService) loadOwnReview(w http.ResponseWriter, r *http.Request, id int) (models.Review, bool) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return models.Review{}, false
+	}
+	review, err := s.store.Reviews.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "review")
+		return models.Review{}, false
+	}
+
+	role, _ := r.Context().Value("role").(string)
+	if review.UserId != userID && role != constants.Moderator.String() && role != constants.Admin.String() {
+		problem.Error(w, r, http.StatusForbidden, problem.Forbidden, "Only the author and moderators can change this review.")
+		return models.Review{}, false
+	}
+	return review, true
+}
+
+func (s *ReviewService) writeReview(w http.ResponseWriter, r *http.Request, id int, status int) {
+	review, err := s.store.Reviews.GetByID(r.Context(), id)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, status, viewModels.GetReviewViewModel(review))
+}
@@ -0,0 +1,138 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/query"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// asRole builds a request carrying the user ID and role AuthMiddleware
+// would set.
+func asRole(userID int, role, method, target, body string) *http.Request {
+	req := asUser(userID, method, target, body)
+	return req.WithContext(context.WithValue(req.Context(), "role", role))
+}
+
+func decodeReview(t *testing.T, w *httptest.ResponseRecorder) viewModels.ReviewViewModel {
+	t.Helper()
+	var review viewModels.ReviewViewModel
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return review
+}
+
+func TestPostAlbumReview(t *testing.T) {
+	store, _ := newTestStore(t)
+	alice := seedUser(t, store, "alice", "password123")
+	bob := seedUser(t, store, "bob", "password123")
+	albumID := seedAlbum(t, store, models.Album{Title: "OK Computer", Price: 999})
+	reviews := services.NewReviewService(store)
+
+	w := httptest.NewRecorder()
+	reviews.PostAlbumReview(w, asUser(alice, "POST", "/albums/1/reviews", `{"rating": 5, "body": "A classic."}`), albumID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	review := decodeReview(t, w)
+	assert.Equal(t, "alice", review.Username)
+	assert.Equal(t, &albumID, review.AlbumId)
+	assert.Nil(t, review.SongId)
+
+	w = httptest.NewRecorder()
+	reviews.PostAlbumReview(w, asUser(bob, "POST", "/albums/1/reviews", `{"rating": 4}`), albumID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("once per user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		reviews.PostAlbumReview(w, asUser(alice, "POST", "/albums/1/reviews", `{"rating": 1}`), albumID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("rating out of range", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		reviews.PostAlbumReview(w, asUser(alice, "POST", "/albums/1/reviews", `{"rating": 6}`), albumID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing album", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		reviews.PostAlbumReview(w, asUser(alice, "POST", "/albums/99/reviews", `{"rating": 3}`), 99)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("aggregate on the album", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		services.NewAlbumService(store).GetAlbumByID(w, httptest.NewRequest("GET", "/albums/1", nil), albumID)
+		var album viewModels.DetailedAlbumViewModel
+		if err := json.Unmarshal(w.Body.Bytes(), &album); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, album.RatingCount)
+		if assert.NotNil(t, album.AverageRating) {
+			assert.Equal(t, 4.5, *album.AverageRating)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		reviews.GetAlbumReviews(w, httptest.NewRequest("GET", "/albums/1/reviews?rating_gte=5", nil), albumID)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page query.Page[viewModels.ReviewViewModel]
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, page.Items, 1) {
+			assert.Equal(t, review.Id, page.Items[0].Id)
+		}
+	})
+}
+
+func TestChangeReview(t *testing.T) {
+	store, _ := newTestStore(t)
+	alice := seedUser(t, store, "alice", "password123")
+	bob := seedUser(t, store, "bob", "password123")
+	carol := seedUser(t, store, "carol", "password123")
+	songID := seedSong(t, store, models.Song{Title: "Airbag", Length: 284, Price: 99})
+	reviews := services.NewReviewService(store)
+
+	w := httptest.NewRecorder()
+	reviews.PostSongReview(w, asUser(alice, "POST", "/songs/1/reviews", `{"rating": 2}`), songID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	id := decodeReview(t, w).Id
+
+	w = httptest.NewRecorder()
+	reviews.UpdateReview(w, asRole(bob, "viewer", "PUT", "/reviews/1", `{"rating": 1, "body": "Not mine to change."}`), id)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	reviews.UpdateReview(w, asRole(alice, "viewer", "PUT", "/reviews/1", `{"rating": 4, "body": "It grew on me."}`), id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 4, decodeReview(t, w).Rating)
+
+	w = httptest.NewRecorder()
+	reviews.UpdateReview(w, asRole(carol, "moderator", "PUT", "/reviews/1", `{"rating": 4, "body": ""}`), id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", decodeReview(t, w).Body)
+
+	w = httptest.NewRecorder()
+	reviews.DeleteReview(w, asRole(bob, "editor", "DELETE", "/reviews/1", ""), id)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	reviews.DeleteReview(w, asRole(carol, "moderator", "DELETE", "/reviews/1", ""), id)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	ratings, _ := store.Reviews.GetSongRatings(context.Background(), []int{songID})
+	assert.Equal(t, models.Rating{}, ratings[songID])
+
+	w = httptest.NewRecorder()
+	reviews.DeleteReview(w, asRole(alice, "viewer", "DELETE", "/reviews/1", ""), id)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"encoding/json"
+	"goMusic/problem"
+	"goMusic/repositories"
+	viewModelSearch "goMusic/viewModels"
+	"net/http"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+type SearchService struct {
+	store *repositories.Store
+}
+
+func NewSearchService(store *repositories.Store) *SearchService {
+	return &SearchService{store: store}
+}
+
+// Search answers GET /search?q= with ranked hits across albums, artists,
+// bands and songs.
+func (s *SearchService) Search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	text := r.URL.Query().Get("q")
+	if text == "" {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "q is required")
+		return
+	}
+
+	limit, ok := parseLimit(w, r, defaultSearchLimit, maxSearchLimit)
+	if !ok {
+		return
+	}
+
+	hits, err := s.store.Search.Search(r.Context(), text, limit)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	results, err := viewModelSearch.GetSearchResultViewModels(r.Context(), s.store, hits)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	store, _ := newTestStore(t)
+	bandID := seedBand(t, store, newTestBand())
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	seedCredit(t, store, models.Credit{AlbumId: &albumID, BandId: &bandID, Role: models.RolePerformer})
+	seedSong(t, store, models.Song{Title: "Coloratura", Length: 617, Price: 129})
+
+	req := httptest.NewRequest("GET", "/search?q=col", nil)
+	rr := httptest.NewRecorder()
+
+	services.NewSearchService(store).Search(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var results []struct {
+		Type    string          `json:"type"`
+		Snippet string          `json:"snippet"`
+		Item    json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Type != "band" || results[1].Type != "song" {
+		t.Fatalf("Wrong results: got %+v", results)
+	}
+	if results[0].Snippet != "<mark>Coldplay</mark>" {
+		t.Errorf("Wrong snippet: got %q", results[0].Snippet)
+	}
+
+	var band struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(results[0].Item, &band); err != nil || band.Id != bandID || band.Name != "Coldplay" {
+		t.Errorf("Wrong band item: got %s", results[0].Item)
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	for _, target := range []string{"/search", "/search?q=col&limit=0", "/search?q=col&limit=many"} {
+		req := httptest.NewRequest("GET", target, nil)
+		rr := httptest.NewRecorder()
+
+		services.NewSearchService(store).Search(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: wrong status code: got %v want %v", target, status, http.StatusBadRequest)
+		}
+	}
+}
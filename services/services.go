@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// writeRepositoryError maps a repository failure on the named resource to a
+// problem response: missing records are a 404, duplicates a 409 and dangling
+// references a 422. Anything else is logged and reported as a bare 500.
+func writeRepositoryError(w http.ResponseWriter, r *http.Request, err error, resource string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		problem.Error(w, r, http.StatusNotFound, problem.NotFound, resource+" not found")
+	case errors.Is(err, repositories.ErrConflict):
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, resource+" already exists")
+	case errors.Is(err, repositories.ErrInvalidReference):
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.InvalidReference, resource+" refers to a record that does not exist")
+	default:
+		problem.InternalError(w, r, err)
+	}
+}
+
+// parseQuery reads the collection parameters for a spec, responding with a
+// 400 problem when they are invalid
+func parseQuery[T any](w http.ResponseWriter, r *http.Request, spec query.Spec[T]) (query.Query, bool) {
+	q, err := spec.Parse(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, err.Error())
+		return query.Query{}, false
+	}
+	return q, true
+}
+
+// writeJSON sends v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// parseDateParam reads an optional YYYY-MM-DD query parameter, responding
+// with a 400 problem when it is not a real date.
+func parseDateParam(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return "", true
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, name+" must be a date formatted like 2006-01-02")
+		return "", false
+	}
+	return value, true
+}
+
+// datesInOrder responds with a 400 problem when an end date in a request
+// body falls before its start date. Either date may be absent.
+func datesInOrder(w http.ResponseWriter, r *http.Request, startField string, start *string, endField string, end *string) bool {
+	if start == nil || end == nil || *end >= *start {
+		return true
+	}
+	p := problem.New(http.StatusBadRequest, problem.ValidationFailed, "The request body failed validation.")
+	p.Errors = []problem.FieldError{{Field: endField, Rule: "gtefield", Message: "must not be before " + startField}}
+	problem.Write(w, r, p)
+	return false
+}
+
+// parseLimit reads an optional limit query parameter between 1 and max,
+// responding with a 400 problem when it is out of range.
+func parseLimit(w http.ResponseWriter, r *http.Request, fallback, max int) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return fallback, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > max {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "limit must be between 1 and "+strconv.Itoa(max))
+		return 0, false
+	}
+	return limit, true
+}
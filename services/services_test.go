@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"goMusic/authentication"
+	"goMusic/models"
+	"goMusic/repositories"
+	"os"
+	"testing"
+)
+
+var errDatabase = errors.New("database connection lost")
+
+// TestMain gives the package a signing key so the user tests can issue tokens.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "goMusic-keys")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := authentication.GenerateKeyFile(dir, "EdDSA"); err != nil {
+		panic(err)
+	}
+	keys, err := authentication.LoadKeySet(dir, authentication.DefaultGracePeriod)
+	if err != nil {
+		panic(err)
+	}
+	authentication.UseKeys(keys)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// newTestStore returns an in-memory store together with its backing database
+// so tests can seed join-table rows.
+func newTestStore(t *testing.T) (*repositories.Store, *repositories.Memory) {
+	t.Helper()
+	mem := repositories.NewMemory()
+	return mem.Store(), mem
+}
+
+func seedAlbum(t *testing.T, store *repositories.Store, album models.Album) int {
+	t.Helper()
+	id, err := store.Albums.Create(context.Background(), album)
+	if err != nil {
+		t.Fatalf("Failed to seed album: %v", err)
+	}
+	return id
+}
+
+func seedArtist(t *testing.T, store *repositories.Store, artist models.Artist) int {
+	t.Helper()
+	id, err := store.Artists.Create(context.Background(), artist)
+	if err != nil {
+		t.Fatalf("Failed to seed artist: %v", err)
+	}
+	return id
+}
+
+func seedBand(t *testing.T, store *repositories.Store, band models.Band) int {
+	t.Helper()
+	id, err := store.Bands.Create(context.Background(), band)
+	if err != nil {
+		t.Fatalf("Failed to seed band: %v", err)
+	}
+	return id
+}
+
+func seedSong(t *testing.T, store *repositories.Store, song models.Song) int {
+	t.Helper()
+	id, err := store.Songs.Create(context.Background(), song)
+	if err != nil {
+		t.Fatalf("Failed to seed song: %v", err)
+	}
+	return id
+}
+
+// seedCredit credits an artist or band on an album or song.
+func seedCredit(t *testing.T, store *repositories.Store, credit models.Credit) int {
+	t.Helper()
+	id, err := store.Credits.Create(context.Background(), credit)
+	if err != nil {
+		t.Fatalf("Failed to seed credit: %v", err)
+	}
+	return id
+}
+
+// failingAlbumRepository simulates a storage outage on reads.
+type failingAlbumRepository struct {
+	repositories.AlbumRepository
+}
+
+func (failingAlbumRepository) GetAll(ctx context.Context) ([]models.Album, error) {
+	return nil, errDatabase
+}
+
+func (failingAlbumRepository) GetByID(ctx context.Context, id int) (models.Album, error) {
+	return models.Album{}, errDatabase
+}
@@ -2,111 +2,87 @@ package services
 
 import (
 	"encoding/json"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
+	"goMusic/repositories"
 	"goMusic/utils"
 	viewModelSong "goMusic/viewModels"
 	"net/http"
 )
 
-func GetSongs(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	rows, err := db.DB.Query("SELECT id, title, length, price FROM songs")
-	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var songs []models.Song
-
-	for rows.Next() {
-		var song models.Song
+type SongService struct {
+	store *repositories.Store
+}
 
-		err := rows.Scan(&song.Id, &song.Title, &song.Length, &song.Price)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+func NewSongService(store *repositories.Store) *SongService {
+	return &SongService{store: store}
+}
 
-		songs = append(songs, song)
+func (s *SongService) GetSongs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q, ok := parseQuery(w, r, repositories.SongQuery)
+	if !ok {
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		http.Error(w, "Error iterating rows: "+err.Error(), http.StatusInternalServerError)
+	page, err := s.store.Songs.List(r.Context(), q)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	songVMs, err := viewModelSong.GetSongViewModels(songs)
+	songVMs, err := viewModelSong.GetSongViewModels(r.Context(), s.store, page.Items)
 	if err != nil {
-		http.Error(w, "Error generating view models: "+err.Error(), http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(songVMs)
+	json.NewEncoder(w).Encode(query.WithItems(page, songVMs))
 }
 
-func GetSongByID(w http.ResponseWriter, id int) {
+func (s *SongService) GetSongByID(w http.ResponseWriter, r *http.Request, id int) {
 	w.Header().Set("Content-Type", "application/json")
-	row, err := db.DB.Query("SELECT id, title, length, price FROM songs WHERE id = ?", id)
+	song, err := s.store.Songs.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, r, err, "song")
 		return
 	}
 
-	if row.Next() {
-		var song models.Song
-
-		err := row.Scan(&song.Id, &song.Title, &song.Length, &song.Price)
-		if err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		songVM, err := viewModelSong.GetSongViewModel(song)
-		if err != nil {
-			http.Error(w, "Error generating view model: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		json.NewEncoder(w).Encode(songVM)
+	songVM, err := viewModelSong.GetSongViewModel(r.Context(), s.store, song)
+	if err != nil {
+		problem.InternalError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{"message": "band not found"})
+
+	json.NewEncoder(w).Encode(songVM)
 }
 
-func PostSong(w http.ResponseWriter, r *http.Request) {
+func (s *SongService) PostSong(w http.ResponseWriter, r *http.Request) {
 	var newSong models.Song
 
 	if !utils.DecodeAndValidate(w, r, &newSong) {
 		return
 	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"INSERT INTO songs (title, length, price, album_id, artist_id, band_id) VALUES (?, ?, ?, ?, ?, ?)",
-		newSong.Title, newSong.Length, newSong.Price, newSong.AlbumId, newSong.ArtistId, newSong.BandId)
-
-	if !success {
+	if _, err := s.store.Songs.Create(r.Context(), newSong); err != nil {
+		writeRepositoryError(w, r, err, "song")
 		return
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 }
 
-func UpdateSongByID(w http.ResponseWriter, r *http.Request, id int) bool {
+func (s *SongService) UpdateSongByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	var updatedSong models.Song
 
 	if !utils.DecodeAndValidate(w, r, &updatedSong) {
 		return false
 	}
 
-	success := utils.ExecuteWithTransaction(w,
-		"UPDATE songs SET title = ?, length = ?, price = ?, artist_id = ?, album_id = ?, band_id = ? WHERE id = ?",
-		updatedSong.Title, updatedSong.Length, updatedSong.Price,
-		updatedSong.ArtistId, updatedSong.AlbumId, updatedSong.BandId, id)
-
-	if !success {
+	if err := s.store.Songs.Update(r.Context(), id, updatedSong); err != nil {
+		writeRepositoryError(w, r, err, "song")
 		return false
 	}
 
@@ -115,12 +91,9 @@ func UpdateSongByID(w http.ResponseWriter, r *http.Request, id int) bool {
 	return true
 }
 
-func DeleteSongByID(w http.ResponseWriter, id int) bool {
-	success := utils.ExecuteWithTransaction(w,
-		"DELETE FROM songs WHERE id = ?",
-		id)
-
-	if !success {
+func (s *SongService) DeleteSongByID(w http.ResponseWriter, r *http.Request, id int) bool {
+	if err := s.store.Songs.Delete(r.Context(), id); err != nil {
+		writeRepositoryError(w, r, err, "song")
 		return false
 	}
 
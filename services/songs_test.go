@@ -2,56 +2,27 @@ package services_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"github.com/DATA-DOG/go-sqlmock"
-	"goMusic/db"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/query"
 	"goMusic/services"
+	"goMusic/viewModels"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 )
 
 func TestGetSongs(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	rows := sqlmock.NewRows([]string{"id", "title", "length", "price"}).
-		AddRow(1, "Yellow", 431, 1.29)
-
-	mock.ExpectQuery("SELECT id, title, length, price FROM songs").
-		WillReturnRows(rows)
-
-	albumRows := sqlmock.NewRows([]string{"id", "title", "price"}).
-		AddRow(1, "Parachutes", 29.99)
-
-	mock.ExpectQuery("SELECT a.id, a.title, a.price FROM albums a JOIN album_songs sa ON a.id = sa.album_id WHERE sa.song_id = ?").
-		WithArgs(1).
-		WillReturnRows(albumRows)
-
-	artistRows := sqlmock.NewRows([]string{"id", "first_name", "last_name"}).
-		AddRow(1, "Chris", "Martin")
-
-	mock.ExpectQuery("SELECT a.id, a.first_name, a.last_name FROM artists a JOIN artist_songs sa ON a.id = sa.artist_id LEFT JOIN sexes s ON a.sex_id = s.id LEFT JOIN titles t ON a.title_id = t.id WHERE sa.song_id = ?").
-		WithArgs(1).
-		WillReturnRows(artistRows)
-
-	bandRows := sqlmock.NewRows([]string{"id", "name"}).
-		AddRow(1, "Coldplay")
-
-	mock.ExpectQuery("SELECT b.id, b.name FROM bands b JOIN band_songs sb ON b.id = sb.band_id WHERE sb.song_id = ?").
-		WithArgs(1).
-		WillReturnRows(bandRows)
+	store, mem := newTestStore(t)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 2999})
+	artistID := seedArtist(t, store, models.Artist{FirstName: "Chris", LastName: "Martin", Nationality: "British", BirthDate: "1977-03-02"})
+	bandID := seedBand(t, store, newTestBand())
+	songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 431, Price: 129})
+	mem.LinkAlbumSong(albumID, songID)
+	seedCredit(t, store, models.Credit{SongId: &songID, ArtistId: &artistID, Role: models.RoleComposer})
+	seedCredit(t, store, models.Credit{SongId: &songID, BandId: &bandID, Role: models.RolePerformer})
 
 	req, err := http.NewRequest("GET", "/songs", nil)
 	if err != nil {
@@ -59,149 +30,101 @@ func TestGetSongs(t *testing.T) {
 	}
 	rr := httptest.NewRecorder()
 
-	services.GetSongs(rr, req)
+	services.NewSongService(store).GetSongs(rr, req)
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	var page query.Page[viewModels.SongViewModel]
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
-}
 
-func TestGetSongByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+	songs := page.Items
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	if len(songs) != 1 || songs[0].Title != "Yellow" {
+		t.Fatalf("Wrong song data: got %+v", songs)
 	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
+	if songs[0].Albums == nil || (*songs[0].Albums)[0].Title != "Parachutes" {
+		t.Errorf("Missing album data: got %+v", songs[0].Albums)
+	}
+	credits := songs[0].Credits
+	if len(credits) != 2 || credits[0].Role != models.RoleComposer || credits[0].Artist == nil || credits[0].Artist.LastName != "Martin" {
+		t.Errorf("Missing artist credit: got %+v", credits)
+	}
+	if len(credits) != 2 || credits[1].Role != models.RolePerformer || credits[1].Band == nil || credits[1].Band.Name != "Coldplay" {
+		t.Errorf("Missing band credit: got %+v", credits)
+	}
+}
 
+func TestGetSongByID(t *testing.T) {
 	t.Run("Song found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "title", "length", "price"}).
-			AddRow(1, "Yellow", 431, 1.29)
-
-		mock.ExpectQuery("SELECT id, title, length, price FROM songs WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(rows)
-
-		albumRows := sqlmock.NewRows([]string{"id", "title", "price", "artist_id", "band_id"}).
-			AddRow(1, "Album Title", 9.99, 2, 3)
-
-		mock.ExpectQuery("SELECT a.id, a.title, a.price, a.artist_id, a.band_id FROM albums a JOIN album_songs sa ON a.id = sa.album_id WHERE sa.song_id = ?").
-			WithArgs(1).
-			WillReturnRows(albumRows)
-
-		artistRows := sqlmock.NewRows([]string{"first_name", "last_name"}).
-			AddRow("Chris", "Martin")
-
-		mock.ExpectQuery("SELECT a.first_name, a.last_name FROM artists a WHERE a.id=?").
-			WithArgs(2).
-			WillReturnRows(artistRows)
-
-		artistRows2 := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "age", "alive", "sex_id", "title_id", "band_id"}).
-			AddRow(1, "Chris", "Martin", "British", "1977-03-02", 44, true, 1, 1, nil)
-
-		mock.ExpectQuery("SELECT a.* FROM artists a JOIN artist_songs sa ON a.id = sa.artist_id WHERE sa.song_id = ?").
-			WithArgs(1).
-			WillReturnRows(artistRows2)
-
-		sexRows := sqlmock.NewRows([]string{"name"}).
-			AddRow("Male")
-
-		mock.ExpectQuery("SELECT name FROM sexes WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(sexRows)
-
-		titleRows := sqlmock.NewRows([]string{"name"}).
-			AddRow("Mr")
-
-		mock.ExpectQuery("SELECT name FROM titles WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(titleRows)
-
-		bandRows := sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "age", "active"}).
-			AddRow(1, "Coldplay", "British", 4, "1996-01-16", 25, true)
-
-		mock.ExpectQuery("SELECT b.* FROM bands b JOIN band_songs sb ON b.id = sb.band_id WHERE sb.song_id = ?").
-			WithArgs(1).
-			WillReturnRows(bandRows)
-
-		_, err := http.NewRequest("GET", "/songs/1", nil)
-		if err != nil {
-			t.Fatal(err)
-		}
+		store, mem := newTestStore(t)
+		artistID := seedArtist(t, store, models.Artist{
+			FirstName: "Chris", LastName: "Martin", Nationality: "British", BirthDate: "1977-03-02",
+			SexId: intPtr(1), TitleId: intPtr(1),
+		})
+		bandID := seedBand(t, store, newTestBand())
+		albumID := seedAlbum(t, store, models.Album{Title: "Album Title", Price: 999})
+		seedCredit(t, store, models.Credit{AlbumId: &albumID, BandId: &bandID, Role: models.RolePerformer})
+		songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 431, Price: 129})
+		mem.LinkAlbumSong(albumID, songID)
+		seedCredit(t, store, models.Credit{SongId: &songID, ArtistId: &artistID, Role: models.RoleLyricist})
+
+		req := httptest.NewRequest("GET", "/songs/1", nil)
 		rr := httptest.NewRecorder()
 
-		services.GetSongByID(rr, 1)
+		services.NewSongService(store).GetSongByID(rr, req, songID)
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		var song viewModels.DetailedSongViewModel
+		if err := json.Unmarshal(rr.Body.Bytes(), &song); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if song.Albums == nil || len((*song.Albums)[0].Credits) != 1 || (*song.Albums)[0].Credits[0].Band == nil {
+			t.Errorf("Missing album relationships: got %+v", song.Albums)
+		}
+		if len(song.Credits) != 1 || song.Credits[0].Role != models.RoleLyricist || song.Credits[0].Artist == nil {
+			t.Errorf("Missing artist credit: got %+v", song.Credits)
 		}
 	})
 
 	t.Run("Song not found", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "title", "length", "price"})
-
-		mock.ExpectQuery("SELECT id, title, length, price FROM songs WHERE id = ?").
-			WithArgs(999).
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
 
-		_, err := http.NewRequest("GET", "/songs/999", nil)
-		if err != nil {
-			t.Fatal(err)
-		}
+		req := httptest.NewRequest("GET", "/songs/999", nil)
 		rr := httptest.NewRecorder()
 
-		services.GetSongByID(rr, 999)
+		services.NewSongService(store).GetSongByID(rr, req, 999)
 
 		if status := rr.Code; status != http.StatusNotFound {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+		var response problem.Problem
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Code != problem.NotFound || response.Detail != "song not found" {
+			t.Errorf("Wrong problem: got %+v", response)
 		}
 	})
 }
 
 func TestPostSong(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
+	store, _ := newTestStore(t)
 
 	song := models.Song{
 		Title:  "Yellow",
 		Length: 431,
-		Price:  1.29,
+		Price:  129,
 	}
 
-	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO songs").
-		WithArgs(song.Title, song.Length, song.Price, nil, nil, nil).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
-
 	songJSON, _ := json.Marshal(song)
 	req, err := http.NewRequest("POST", "/songs", bytes.NewBuffer(songJSON))
 	if err != nil {
@@ -210,43 +133,63 @@ func TestPostSong(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	services.PostSong(rr, req)
+	services.NewSongService(store).PostSong(rr, req)
 
 	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	stored, err := store.Songs.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Song was not stored: %v", err)
+	}
+	if stored.Title != song.Title || stored.Length != song.Length {
+		t.Errorf("Wrong song stored: got %+v", stored)
 	}
 }
 
-func TestUpdateSongByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
+func TestPostSongValidation(t *testing.T) {
+	store, _ := newTestStore(t)
 
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	req := httptest.NewRequest("POST", "/songs", bytes.NewBufferString(`{"title": "", "length": 431}`))
+	rr := httptest.NewRecorder()
+
+	services.NewSongService(store).PostSong(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != problem.ContentType {
+		t.Errorf("Wrong content type: got %q want %q", contentType, problem.ContentType)
 	}
-	defer mockDB.Close()
 
-	db.DB = mockDB
+	var response problem.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Code != problem.ValidationFailed {
+		t.Errorf("Wrong problem code: got %q", response.Code)
+	}
+
+	want := []problem.FieldError{
+		{Field: "title", Rule: "required", Message: "is required"},
+		{Field: "price", Rule: "required", Message: "is required"},
+	}
+	if len(response.Errors) != len(want) || response.Errors[0] != want[0] || response.Errors[1] != want[1] {
+		t.Errorf("Wrong field errors: got %+v want %+v", response.Errors, want)
+	}
+}
+
+func TestUpdateSongByID(t *testing.T) {
+	store, _ := newTestStore(t)
+	songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 431, Price: 129})
 
 	song := models.Song{
 		Title:  "Yellow (2023 Remix)",
 		Length: 445,
-		Price:  1.49,
+		Price:  149,
 	}
 
-	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE songs SET").
-		WithArgs(song.Title, song.Length, song.Price, nil, nil, nil, 1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
-
 	songJSON, _ := json.Marshal(song)
 	req, err := http.NewRequest("PUT", "/songs/1", bytes.NewBuffer(songJSON))
 	if err != nil {
@@ -255,7 +198,7 @@ func TestUpdateSongByID(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	result := services.UpdateSongByID(rr, req, 1)
+	result := services.NewSongService(store).UpdateSongByID(rr, req, songID)
 
 	if !result {
 		t.Errorf("UpdateSongByID returned false, expected true")
@@ -265,34 +208,22 @@ func TestUpdateSongByID(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	stored, _ := store.Songs.GetByID(context.Background(), songID)
+	if stored.Title != song.Title {
+		t.Errorf("Song was not updated: got %+v", stored)
 	}
 }
 
 func TestDeleteSongByID(t *testing.T) {
-	originalDB := db.DB
-	defer func() {
-		db.DB = originalDB
-	}()
-
-	mockDB, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mockDB.Close()
-
-	db.DB = mockDB
-
-	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM songs WHERE id = ?").
-		WithArgs(1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
+	store, mem := newTestStore(t)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 2999})
+	songID := seedSong(t, store, models.Song{Title: "Yellow", Length: 431, Price: 129})
+	mem.LinkAlbumSong(albumID, songID)
 
+	req := httptest.NewRequest("DELETE", "/songs/1", nil)
 	rr := httptest.NewRecorder()
 
-	result := services.DeleteSongByID(rr, 1)
+	result := services.NewSongService(store).DeleteSongByID(rr, req, songID)
 
 	if !result {
 		t.Errorf("DeleteSongByID returned false, expected true")
@@ -302,7 +233,8 @@ func TestDeleteSongByID(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("there were unfulfilled expectations: %s", err)
+	albums, _ := store.Albums.GetBySongIDs(context.Background(), []int{songID})
+	if len(albums[songID]) != 0 {
+		t.Errorf("album_songs rows were not removed: got %+v", albums)
 	}
 }
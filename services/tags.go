@@ -0,0 +1,111 @@
+package services
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
+	"goMusic/viewModels"
+	"net/http"
+	"strings"
+)
+
+type TagService struct {
+	store *repositories.Store
+}
+
+func NewTagService(store *repositories.Store) *TagService {
+	return &TagService{store: store}
+}
+
+// GetTags lists the tags in use with their usage counts, most used first.
+// ?type= counts only albums, songs, artists or bands.
+func (s *TagService) GetTags(w http.ResponseWriter, r *http.Request) {
+	entityType := r.URL.Query().Get("type")
+	switch entityType {
+	case "", models.TagAlbum, models.TagSong, models.TagArtist, models.TagBand:
+	default:
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidQuery, "type must be album, song, artist or band")
+		return
+	}
+
+	tags, err := s.store.Tags.List(r.Context(), entityType)
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewModels.GetTagCountViewModels(tags))
+}
+
+// AddTag attaches a tag to an album, song, artist or band and responds with
+// the entry's tags.
+func (s *TagService) AddTag(w http.ResponseWriter, r *http.Request, entityType string, id int) bool {
+	if !s.exists(w, r, entityType, id) {
+		return false
+	}
+
+	var req viewModels.TagRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return false
+	}
+	name := normalizeTag(req.Name)
+	if name == "" {
+		problem.Error(w, r, http.StatusUnprocessableEntity, problem.ValidationFailed, "Tag names cannot be blank.")
+		return false
+	}
+
+	if err := s.store.Tags.Add(r.Context(), entityType, id, name); err != nil {
+		writeRepositoryError(w, r, err, entityType)
+		return false
+	}
+	return s.writeTags(w, r, entityType, id)
+}
+
+// RemoveTag detaches a tag and responds with the entry's remaining tags.
+func (s *TagService) RemoveTag(w http.ResponseWriter, r *http.Request, entityType string, id int, name string) bool {
+	if !s.exists(w, r, entityType, id) {
+		return false
+	}
+	if err := s.store.Tags.Remove(r.Context(), entityType, id, normalizeTag(name)); err != nil {
+		writeRepositoryError(w, r, err, "tag")
+		return false
+	}
+	return s.writeTags(w, r, entityType, id)
+}
+
+// normalizeTag lower-cases a tag name and collapses its whitespace, so
+// "Post  Rock" and "post rock" are the same tag.
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// exists responds with a 404 unless the tagged entry exists.
+func (s *TagService) exists(w http.ResponseWriter, r *http.Request, entityType string, id int) bool {
+	var err error
+	ctx := r.Context()
+	switch entityType {
+	case models.TagAlbum:
+		_, err = s.store.Albums.GetByID(ctx, id)
+	case models.TagSong:
+		_, err = s.store.Songs.GetByID(ctx, id)
+	case models.TagArtist:
+		_, err = s.store.Artists.GetByID(ctx, id)
+	case models.TagBand:
+		_, err = s.store.Bands.GetByID(ctx, id)
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, entityType)
+		return false
+	}
+	return true
+}
+
+func (s *TagService) writeTags(w http.ResponseWriter, r *http.Request, entityType string, id int) bool {
+	tags, err := s.store.Tags.GetByEntityIDs(r.Context(), entityType, []int{id})
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return false
+	}
+	writeJSON(w, http.StatusOK, append([]string{}, tags[id]...))
+	return true
+}
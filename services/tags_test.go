@@ -0,0 +1,68 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"goMusic/models"
+	"goMusic/services"
+	"goMusic/viewModels"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	store, _ := newTestStore(t)
+	tags := services.NewTagService(store)
+	albumID := seedAlbum(t, store, models.Album{Title: "Parachutes", Price: 999})
+	artistID := seedArtist(t, store, models.Artist{FirstName: "Chris", LastName: "Martin", Nationality: "British", BirthDate: "1977-03-02"})
+
+	add := func(entityType string, id int, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tags.AddTag(w, httptest.NewRequest("POST", "/tags", bytes.NewBufferString(body)), entityType, id)
+		return w
+	}
+
+	w := add(models.TagAlbum, albumID, `{"name": "  Brit   Pop "}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	add(models.TagAlbum, albumID, `{"name": "debut"}`)
+	w = add(models.TagAlbum, albumID, `{"name": "britpop"}`)
+	var albumTags []string
+	json.Unmarshal(w.Body.Bytes(), &albumTags)
+	assert.Equal(t, []string{"brit pop", "britpop", "debut"}, albumTags)
+	add(models.TagArtist, artistID, `{"name": "Brit Pop"}`)
+
+	assert.Equal(t, http.StatusNotFound, add(models.TagBand, 99, `{"name": "ghost"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, add(models.TagAlbum, albumID, `{"name": "   "}`).Code)
+
+	w = httptest.NewRecorder()
+	tags.GetTags(w, httptest.NewRequest("GET", "/tags", nil))
+	var counts []viewModels.TagCountViewModel
+	json.Unmarshal(w.Body.Bytes(), &counts)
+	assert.Equal(t, viewModels.TagCountViewModel{Name: "brit pop", Count: 2}, counts[0])
+	assert.Len(t, counts, 3)
+
+	w = httptest.NewRecorder()
+	tags.GetTags(w, httptest.NewRequest("GET", "/tags?type=artist", nil))
+	json.Unmarshal(w.Body.Bytes(), &counts)
+	assert.Equal(t, []viewModels.TagCountViewModel{{Name: "brit pop", Count: 1}}, counts)
+
+	w = httptest.NewRecorder()
+	tags.GetTags(w, httptest.NewRequest("GET", "/tags?type=playlist", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	tags.RemoveTag(w, httptest.NewRequest("DELETE", "/albums/1/tags/Debut", nil), models.TagAlbum, albumID, "Debut")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	tags.RemoveTag(w, httptest.NewRequest("DELETE", "/albums/1/tags/debut", nil), models.TagAlbum, albumID, "debut")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	services.NewArtistService(store).GetArtistByID(w, httptest.NewRequest("GET", "/artists/1", nil), artistID)
+	var artist viewModels.ArtistViewModel
+	json.Unmarshal(w.Body.Bytes(), &artist)
+	assert.Equal(t, []string{"brit pop"}, artist.Tags)
+}
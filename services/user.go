@@ -1,118 +1,342 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"goMusic/authentication"
-	"goMusic/db"
+	"goMusic/constants"
 	"goMusic/models"
+	"goMusic/problem"
+	"goMusic/repositories"
+	"goMusic/utils"
 	"goMusic/viewModels"
+	"io"
 	"net/http"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
+type UserService struct {
+	store *repositories.Store
+}
+
+func NewUserService(store *repositories.Store) *UserService {
+	return &UserService{store: store}
+}
+
+func (s *UserService) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	var req viewModels.RegisterRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !utils.DecodeJSONBody(w, r, &req) {
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	result, err := db.DB.Exec(
-		"INSERT INTO users (username, password, email) VALUES (?, ?, ?)",
-		req.Username, string(hashedPassword), req.Email,
-	)
+	user := models.User{
+		Username: req.Username,
+		Password: string(hashedPassword),
+		Email:    req.Email,
+		Role:     constants.Viewer.String(),
+	}
+
+	id, err := s.store.Users.Create(r.Context(), user)
+	if errors.Is(err, repositories.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, problem.Conflict, "Username or email already exists")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Username or email already exists", http.StatusBadRequest)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	id, _ := result.LastInsertId()
+	user.Id = id
+	user.Password = ""
 
-	token, err := authentication.GenerateToken(int(id))
+	response, err := s.issueTokens(r.Context(), user, nil)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	user := models.User{
-		Id:       int(id),
-		Username: req.Username,
-		Email:    req.Email,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(viewModels.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
-func LoginUser(w http.ResponseWriter, r *http.Request) {
+func (s *UserService) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var req viewModels.LoginRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
+	if !utils.DecodeJSONBody(w, r, &req) {
+		return
+	}
+
+	user, err := s.store.Users.GetByUsername(r.Context(), req.Username)
+	if errors.Is(err, repositories.ErrNotFound) {
+		problem.Error(w, r, http.StatusUnauthorized, problem.InvalidCredentials, "Invalid credentials")
+		return
+	}
+	if err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if err != nil {
+		problem.Error(w, r, http.StatusUnauthorized, problem.InvalidCredentials, "Invalid credentials")
+		return
+	}
+	user.Password = ""
+
+	response, err := s.issueTokens(r.Context(), user, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	var user models.User
-	var hashedPassword string
-	err = db.DB.QueryRow(
-		"SELECT id, username, password, email FROM users WHERE username = ?",
-		req.Username,
-	).Scan(&user.Id, &user.Username, &hashedPassword, &user.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token works once: presenting one that was
+// already exchanged means it leaked, so every token descended from the same
+// login is revoked.
+func (s *UserService) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req viewModels.RefreshRequest
+	if !utils.DecodeJSONBody(w, r, &req) {
+		return
+	}
 
+	stored, err := s.store.Tokens.GetRefreshToken(r.Context(), authentication.HashRefreshToken(req.RefreshToken))
+	if errors.Is(err, repositories.ErrNotFound) {
+		problem.Error(w, r, http.StatusUnauthorized, problem.InvalidRefreshToken, "Invalid refresh token")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	if stored.RevokedAt != nil {
+		s.revokeFamily(w, r, stored.FamilyId)
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		problem.Error(w, r, http.StatusUnauthorized, problem.InvalidRefreshToken, "Invalid refresh token")
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(req.Password))
+	user, err := s.store.Users.GetByID(r.Context(), stored.UserId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		problem.Error(w, r, http.StatusUnauthorized, problem.InvalidRefreshToken, "Invalid refresh token")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		problem.InternalError(w, r, err)
 		return
 	}
 
-	token, err := authentication.GenerateToken(user.Id)
+	response, err := s.issueTokens(r.Context(), user, &stored)
+	if errors.Is(err, repositories.ErrConflict) {
+		// Another request exchanged the same token first.
+		s.revokeFamily(w, r, stored.FamilyId)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		problem.InternalError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(viewModels.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	json.NewEncoder(w).Encode(response)
+}
+
+// revokeFamily handles a reused refresh token by revoking its whole family.
+func (s *UserService) revokeFamily(w http.ResponseWriter, r *http.Request, familyID string) {
+	if err := s.store.Tokens.RevokeRefreshTokenFamily(r.Context(), familyID); err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+	problem.Error(w, r, http.StatusUnauthorized, problem.RefreshTokenReused, "Refresh token has already been used")
+}
+
+// Logout revokes the access token used for the request and, when one is
+// given, the refresh token from the same login.
+func (s *UserService) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*authentication.Claims)
+	if !ok {
+		problem.InternalError(w, r, errors.New("request has no user context"))
+		return
+	}
+
+	var req viewModels.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "The request body is not valid JSON.")
+		return
+	}
+
+	if err := s.store.Tokens.RevokeAccessToken(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		problem.InternalError(w, r, err)
+		return
+	}
+
+	if req.RefreshToken != "" {
+		stored, err := s.store.Tokens.GetRefreshToken(r.Context(), authentication.HashRefreshToken(req.RefreshToken))
+		if err == nil && stored.UserId == claims.UserID {
+			err = s.store.Tokens.RevokeRefreshTokenFamily(r.Context(), stored.FamilyId)
+		}
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			problem.InternalError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll revokes every refresh token the user holds and every access token
+// issued to them so far, signing them out on all devices.
+func (s *UserService) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*authentication.Claims)
+	if !ok {
+		problem.InternalError(w, r, errors.New("request has no user context"))
+		return
+	}
+
+	ctx := r.Context()
+	err := s.store.Tokens.RevokeRefreshTokens(ctx, claims.UserID)
+	if err == nil {
+		err = s.store.Tokens.RevokeAccessTokens(ctx, claims.UserID, time.Now())
+	}
+	if err == nil {
+		// Tokens issued within the current second survive the cutoff, so
+		// the one used for this request is denied explicitly.
+		err = s.store.Tokens.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	}
+	if err != nil {
+		writeRepositoryError(w, r, err, "user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueTokens signs an access token for the user and stores a new refresh
+// token. A fresh login passes a nil previous token and starts a new family;
+// a refresh rotates previous out in favour of the new token.
+func (s *UserService) issueTokens(ctx context.Context, user models.User, previous *models.RefreshToken) (viewModels.AuthResponse, error) {
+	var familyID string
+	if previous != nil {
+		familyID = previous.FamilyId
+	} else {
+		var err error
+		if familyID, err = authentication.NewFamilyID(); err != nil {
+			return viewModels.AuthResponse{}, err
+		}
+	}
+
+	refreshToken, hash, err := authentication.NewRefreshToken()
+	if err != nil {
+		return viewModels.AuthResponse{}, err
+	}
+	stored := models.RefreshToken{
+		UserId:    user.Id,
+		TokenHash: hash,
+		FamilyId:  familyID,
+		ExpiresAt: time.Now().Add(authentication.RefreshTokenTTL),
+	}
+	if previous != nil {
+		_, err = s.store.Tokens.RotateRefreshToken(ctx, previous.Id, stored)
+	} else {
+		_, err = s.store.Tokens.CreateRefreshToken(ctx, stored)
+	}
+	if err != nil {
+		return viewModels.AuthResponse{}, err
+	}
+
+	token, err := authentication.GenerateToken(user.Id, user.Role)
+	if err != nil {
+		return viewModels.AuthResponse{}, err
+	}
+
+	return viewModels.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(authentication.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
 }
 
-func GetProfile(w http.ResponseWriter, r *http.Request) {
+func (s *UserService) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("userID").(int)
 	if !ok {
-		http.Error(w, "Invalid user context", http.StatusInternalServerError)
+		problem.InternalError(w, r, errors.New("request has no user context"))
+		return
+	}
+
+	user, err := s.store.Users.GetByID(r.Context(), userID)
+	if err != nil {
+		writeRepositoryError(w, r, err, "user")
 		return
 	}
 
-	var user models.User
-	err := db.DB.QueryRow(
-		"SELECT id, username, email FROM users WHERE id = ?",
-		userID,
-	).Scan(&user.Id, &user.Username, &user.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
 
+// GetUsers lists every user and their role. It is limited to admins.
+func (s *UserService) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.store.Users.GetAll(r.Context())
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		problem.InternalError(w, r, err)
 		return
 	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// UpdateUserRole changes a user's role. It is limited to admins. Access
+// tokens carry the role, so the ones already issued are revoked and the user
+// logs in or refreshes to get the new one.
+func (s *UserService) UpdateUserRole(w http.ResponseWriter, r *http.Request, id int) bool {
+	var req viewModels.UpdateRoleRequest
+	if !utils.DecodeJSONBody(w, r, &req) {
+		return false
+	}
+
+	role, err := constants.ParseRole(req.Role)
+	if err != nil {
+		p := problem.New(http.StatusBadRequest, problem.ValidationFailed, "The request body failed validation.")
+		p.Errors = []problem.FieldError{{Field: "role", Rule: "oneof", Message: "must be admin, moderator, editor or viewer"}}
+		problem.Write(w, r, p)
+		return false
+	}
+
+	if err := s.store.Users.UpdateRole(r.Context(), id, role); err != nil {
+		writeRepositoryError(w, r, err, "user")
+		return false
+	}
+	if err := s.store.Tokens.RevokeAccessTokens(r.Context(), id, time.Now()); err != nil {
+		writeRepositoryError(w, r, err, "user")
+		return false
+	}
+
+	user, err := s.store.Users.GetByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, r, err, "user")
+		return false
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
+	return true
 }
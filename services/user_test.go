@@ -3,39 +3,39 @@ package services_test
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
-	"goMusic/db"
+	"goMusic/authentication"
+	"goMusic/constants"
 	"goMusic/models"
+	"goMusic/repositories"
 	"goMusic/services"
 	"goMusic/viewModels"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func setupMockDB(t *testing.T) sqlmock.Sqlmock {
-	mockDB, mock, err := sqlmock.New()
+func seedUser(t *testing.T, store *repositories.Store, username, password string) int {
+	t.Helper()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	id, err := store.Users.Create(context.Background(), models.User{
+		Username: username,
+		Password: string(hashedPassword),
+		Email:    username + "@example.com",
+	})
 	if err != nil {
-		t.Fatalf("Error creating mock database: %v", err)
+		t.Fatalf("Failed to seed user: %v", err)
 	}
-
-	db.DB = mockDB
-	return mock
+	return id
 }
 
 func TestRegisterUser(t *testing.T) {
 	t.Run("successful registration", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		mock.ExpectExec("INSERT INTO users").
-			WithArgs("testuser", sqlmock.AnyArg(), "test@example.com").
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		store, _ := newTestStore(t)
 
 		reqBody := viewModels.RegisterRequest{
 			Username: "testuser",
@@ -46,35 +46,37 @@ func TestRegisterUser(t *testing.T) {
 		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqJSON))
 		w := httptest.NewRecorder()
 
-		services.RegisterUser(w, req)
+		services.NewUserService(store).RegisterUser(w, req)
 
 		assert.Equal(t, http.StatusCreated, w.Code)
 
 		var response viewModels.AuthResponse
 		json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NotEmpty(t, response.Token)
+		assert.NotEmpty(t, response.RefreshToken)
 		assert.Equal(t, 1, response.User.Id)
 		assert.Equal(t, "testuser", response.User.Username)
+		assert.Equal(t, "viewer", response.User.Role)
+
+		stored, err := store.Users.GetByUsername(context.Background(), "testuser")
+		assert.NoError(t, err)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("password123")))
 	})
 
 	t.Run("invalid request body", func(t *testing.T) {
-		defer db.DB.Close()
+		store, _ := newTestStore(t)
 
 		req := httptest.NewRequest("POST", "/register", bytes.NewBufferString("invalid json"))
 		w := httptest.NewRecorder()
 
-		services.RegisterUser(w, req)
+		services.NewUserService(store).RegisterUser(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("username already exists", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		mock.ExpectExec("INSERT INTO users").
-			WithArgs("existinguser", sqlmock.AnyArg(), "test@example.com").
-			WillReturnError(sql.ErrNoRows)
+		store, _ := newTestStore(t)
+		seedUser(t, store, "existinguser", "password123")
 
 		reqBody := viewModels.RegisterRequest{
 			Username: "existinguser",
@@ -85,25 +87,16 @@ func TestRegisterUser(t *testing.T) {
 		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqJSON))
 		w := httptest.NewRecorder()
 
-		services.RegisterUser(w, req)
+		services.NewUserService(store).RegisterUser(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 }
 
 func TestLoginUser(t *testing.T) {
 	t.Run("successful login", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
-
-		rows := sqlmock.NewRows([]string{"id", "username", "password", "email"}).
-			AddRow(1, "testuser", string(hashedPassword), "test@example.com")
-
-		mock.ExpectQuery("SELECT id, username, password, email FROM users WHERE username = ?").
-			WithArgs("testuser").
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
+		seedUser(t, store, "testuser", "password123")
 
 		reqBody := viewModels.LoginRequest{
 			Username: "testuser",
@@ -113,23 +106,23 @@ func TestLoginUser(t *testing.T) {
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqJSON))
 		w := httptest.NewRecorder()
 
-		services.LoginUser(w, req)
+		services.NewUserService(store).LoginUser(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response viewModels.AuthResponse
 		json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NotEmpty(t, response.Token)
+		assert.NotEmpty(t, response.RefreshToken)
 		assert.Equal(t, "testuser", response.User.Username)
+
+		stored, err := store.Tokens.GetRefreshToken(context.Background(), authentication.HashRefreshToken(response.RefreshToken))
+		assert.NoError(t, err)
+		assert.Equal(t, response.User.Id, stored.UserId)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		mock.ExpectQuery("SELECT id, username, password, email FROM users WHERE username = ?").
-			WithArgs("nonexistentuser").
-			WillReturnError(sql.ErrNoRows)
+		store, _ := newTestStore(t)
 
 		reqBody := viewModels.LoginRequest{
 			Username: "nonexistentuser",
@@ -139,23 +132,14 @@ func TestLoginUser(t *testing.T) {
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqJSON))
 		w := httptest.NewRecorder()
 
-		services.LoginUser(w, req)
+		services.NewUserService(store).LoginUser(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 
 	t.Run("invalid password", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
-
-		rows := sqlmock.NewRows([]string{"id", "username", "password", "email"}).
-			AddRow(1, "testuser", string(hashedPassword), "test@example.com")
-
-		mock.ExpectQuery("SELECT id, username, password, email FROM users WHERE username = ?").
-			WithArgs("testuser").
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
+		seedUser(t, store, "testuser", "correctpassword")
 
 		reqBody := viewModels.LoginRequest{
 			Username: "testuser",
@@ -165,7 +149,7 @@ func TestLoginUser(t *testing.T) {
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqJSON))
 		w := httptest.NewRecorder()
 
-		services.LoginUser(w, req)
+		services.NewUserService(store).LoginUser(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
@@ -173,23 +157,16 @@ func TestLoginUser(t *testing.T) {
 
 func TestGetProfile(t *testing.T) {
 	t.Run("successfully get profile", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		rows := sqlmock.NewRows([]string{"id", "username", "email"}).
-			AddRow(1, "testuser", "test@example.com")
-
-		mock.ExpectQuery("SELECT id, username, email FROM users WHERE id = ?").
-			WithArgs(1).
-			WillReturnRows(rows)
+		store, _ := newTestStore(t)
+		userID := seedUser(t, store, "testuser", "password123")
 
 		req := httptest.NewRequest("GET", "/profile", nil)
-		ctx := context.WithValue(req.Context(), "userID", 1)
+		ctx := context.WithValue(req.Context(), "userID", userID)
 		req = req.WithContext(ctx)
 
 		w := httptest.NewRecorder()
 
-		services.GetProfile(w, req)
+		services.NewUserService(store).GetProfile(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
@@ -197,16 +174,11 @@ func TestGetProfile(t *testing.T) {
 		json.Unmarshal(w.Body.Bytes(), &user)
 		assert.Equal(t, 1, user.Id)
 		assert.Equal(t, "testuser", user.Username)
-		assert.Equal(t, "test@example.com", user.Email)
+		assert.Equal(t, "testuser@example.com", user.Email)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
-		mock := setupMockDB(t)
-		defer db.DB.Close()
-
-		mock.ExpectQuery("SELECT id, username, email FROM users WHERE id = ?").
-			WithArgs(999).
-			WillReturnError(sql.ErrNoRows)
+		store, _ := newTestStore(t)
 
 		req := httptest.NewRequest("GET", "/profile", nil)
 		ctx := context.WithValue(req.Context(), "userID", 999)
@@ -214,18 +186,213 @@ func TestGetProfile(t *testing.T) {
 
 		w := httptest.NewRecorder()
 
-		services.GetProfile(w, req)
+		services.NewUserService(store).GetProfile(w, req)
 
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
 	t.Run("invalid context", func(t *testing.T) {
+		store, _ := newTestStore(t)
 		req := httptest.NewRequest("GET", "/profile", nil)
 
 		w := httptest.NewRecorder()
 
-		services.GetProfile(w, req)
+		services.NewUserService(store).GetProfile(w, req)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
 }
+
+func TestGetUsers(t *testing.T) {
+	store, _ := newTestStore(t)
+	seedUser(t, store, "alice", "password123")
+	seedUser(t, store, "bob", "password123")
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	w := httptest.NewRecorder()
+
+	services.NewUserService(store).GetUsers(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var users []models.User
+	json.Unmarshal(w.Body.Bytes(), &users)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Username)
+	assert.Equal(t, "viewer", users[0].Role)
+	assert.NotContains(t, w.Body.String(), "password")
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	t.Run("promote to editor", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		userID := seedUser(t, store, "alice", "password123")
+
+		req := httptest.NewRequest("PUT", "/users/1/role", bytes.NewBufferString(`{"role": "editor"}`))
+		w := httptest.NewRecorder()
+
+		assert.True(t, services.NewUserService(store).UpdateUserRole(w, req, userID))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		stored, err := store.Users.GetByID(context.Background(), userID)
+		assert.NoError(t, err)
+		assert.Equal(t, "editor", stored.Role)
+	})
+
+	t.Run("demotion revokes issued tokens", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		userID := seedUser(t, store, "alice", "password123")
+		assert.NoError(t, store.Users.UpdateRole(context.Background(), userID, constants.Editor))
+		session := login(t, store, "alice", "password123")
+		users := services.NewUserService(store)
+		// Revocation has whole second resolution, like the token's iat.
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+		req := httptest.NewRequest("PUT", "/users/1/role", bytes.NewBufferString(`{"role": "viewer"}`))
+		assert.True(t, users.UpdateUserRole(httptest.NewRecorder(), req, userID))
+
+		assert.Equal(t, http.StatusUnauthorized, authenticated(t, store, users.GetProfile, session.Token, ""))
+		assert.Equal(t, http.StatusOK, authenticated(t, store, users.GetProfile, login(t, store, "alice", "password123").Token, ""))
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		userID := seedUser(t, store, "alice", "password123")
+
+		req := httptest.NewRequest("PUT", "/users/1/role", bytes.NewBufferString(`{"role": "owner"}`))
+		w := httptest.NewRecorder()
+
+		assert.False(t, services.NewUserService(store).UpdateUserRole(w, req, userID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		store, _ := newTestStore(t)
+
+		req := httptest.NewRequest("PUT", "/users/999/role", bytes.NewBufferString(`{"role": "admin"}`))
+		w := httptest.NewRecorder()
+
+		assert.False(t, services.NewUserService(store).UpdateUserRole(w, req, 999))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func login(t *testing.T, store *repositories.Store, username, password string) viewModels.AuthResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(viewModels.LoginRequest{Username: username, Password: password})
+	w := httptest.NewRecorder()
+	services.NewUserService(store).LoginUser(w, httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqJSON)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Login failed: %d %s", w.Code, w.Body.String())
+	}
+
+	var response viewModels.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response
+}
+
+func refresh(store *repositories.Store, refreshToken string) *httptest.ResponseRecorder {
+	reqJSON, _ := json.Marshal(viewModels.RefreshRequest{RefreshToken: refreshToken})
+	w := httptest.NewRecorder()
+	services.NewUserService(store).RefreshToken(w, httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqJSON)))
+	return w
+}
+
+// authenticated serves a request through AuthMiddleware, checking tokens
+// against the store's denylist.
+func authenticated(t *testing.T, store *repositories.Store, handler http.HandlerFunc, token, body string) int {
+	t.Helper()
+	authentication.UseDenylist(store.Tokens)
+	t.Cleanup(func() { authentication.UseDenylist(nil) })
+
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	authentication.AuthMiddleware(handler).ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		seedUser(t, store, "testuser", "password123")
+		session := login(t, store, "testuser", "password123")
+
+		w := refresh(store, session.RefreshToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response viewModels.AuthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NotEmpty(t, response.Token)
+		assert.NotEmpty(t, response.RefreshToken)
+		assert.NotEqual(t, session.RefreshToken, response.RefreshToken)
+		assert.Equal(t, "testuser", response.User.Username)
+
+		assert.Equal(t, http.StatusOK, refresh(store, response.RefreshToken).Code)
+	})
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		seedUser(t, store, "testuser", "password123")
+		session := login(t, store, "testuser", "password123")
+		other := login(t, store, "testuser", "password123")
+
+		var rotated viewModels.AuthResponse
+		json.Unmarshal(refresh(store, session.RefreshToken).Body.Bytes(), &rotated)
+
+		assert.Equal(t, http.StatusUnauthorized, refresh(store, session.RefreshToken).Code)
+		assert.Equal(t, http.StatusUnauthorized, refresh(store, rotated.RefreshToken).Code)
+		assert.Equal(t, http.StatusOK, refresh(store, other.RefreshToken).Code, "other logins should be unaffected")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		store, _ := newTestStore(t)
+
+		assert.Equal(t, http.StatusUnauthorized, refresh(store, "not-a-token").Code)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		store, _ := newTestStore(t)
+		userID := seedUser(t, store, "testuser", "password123")
+		token, hash, _ := authentication.NewRefreshToken()
+		store.Tokens.CreateRefreshToken(context.Background(), models.RefreshToken{
+			UserId: userID, TokenHash: hash, FamilyId: "family", ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, refresh(store, token).Code)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	store, _ := newTestStore(t)
+	seedUser(t, store, "testuser", "password123")
+	session := login(t, store, "testuser", "password123")
+	other := login(t, store, "testuser", "password123")
+	users := services.NewUserService(store)
+
+	body := `{"refresh_token": "` + session.RefreshToken + `"}`
+	assert.Equal(t, http.StatusNoContent, authenticated(t, store, users.Logout, session.Token, body))
+
+	assert.Equal(t, http.StatusUnauthorized, authenticated(t, store, users.GetProfile, session.Token, ""))
+	assert.Equal(t, http.StatusUnauthorized, refresh(store, session.RefreshToken).Code)
+
+	assert.Equal(t, http.StatusOK, authenticated(t, store, users.GetProfile, other.Token, ""))
+	assert.Equal(t, http.StatusNoContent, authenticated(t, store, users.Logout, other.Token, ""), "the body is optional")
+}
+
+func TestLogoutAll(t *testing.T) {
+	store, _ := newTestStore(t)
+	seedUser(t, store, "testuser", "password123")
+	seedUser(t, store, "otheruser", "password123")
+	session := login(t, store, "testuser", "password123")
+	second := login(t, store, "testuser", "password123")
+	bystander := login(t, store, "otheruser", "password123")
+	users := services.NewUserService(store)
+
+	assert.Equal(t, http.StatusNoContent, authenticated(t, store, users.LogoutAll, session.Token, ""))
+
+	assert.Equal(t, http.StatusUnauthorized, authenticated(t, store, users.GetProfile, session.Token, ""))
+	assert.Equal(t, http.StatusUnauthorized, refresh(store, session.RefreshToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, refresh(store, second.RefreshToken).Code)
+	assert.Equal(t, http.StatusOK, authenticated(t, store, users.GetProfile, bystander.Token, ""))
+}
@@ -1,55 +1,27 @@
 package utils
 
 import (
-	"context"
 	"encoding/json"
-	"goMusic/db"
+	"goMusic/problem"
 	"goMusic/validation"
 	"net/http"
-	"time"
 )
 
 // DecodeJSONBody decodes a JSON request body into the provided struct
 func DecodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "invalid request"})
+		problem.Error(w, r, http.StatusBadRequest, problem.InvalidRequest, "The request body is not valid JSON.")
 		return false
 	}
 	return true
 }
 
-// ExecuteWithTransaction executes a SQL query with parameters inside a transaction with timeout
-func ExecuteWithTransaction(w http.ResponseWriter, query string, args ...interface{}) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	tx, err := db.DB.BeginTx(ctx, nil)
-	if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		return false
-	}
-
-	_, err = tx.ExecContext(ctx, query, args...)
-	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		return false
-	}
-
-	if err = tx.Commit(); err != nil {
-		http.Error(w, "Commit error: "+err.Error(), http.StatusInternalServerError)
-		return false
-	}
-
-	return true
-}
-
 // ValidateRequestBody validates the provided struct and handles error responses
-func ValidateRequestBody(w http.ResponseWriter, v interface{}) bool {
+func ValidateRequestBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
 	if err := validation.ValidateStruct(v); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		p := problem.New(http.StatusBadRequest, problem.ValidationFailed, "The request body failed validation.")
+		p.Errors = validation.FieldErrors(err)
+		problem.Write(w, r, p)
 		return false
 	}
 	return true
@@ -57,5 +29,5 @@ func ValidateRequestBody(w http.ResponseWriter, v interface{}) bool {
 
 // DecodeAndValidate combines both operations for common use case
 func DecodeAndValidate(w http.ResponseWriter, r *http.Request, v interface{}) bool {
-	return DecodeJSONBody(w, r, v) && ValidateRequestBody(w, v)
+	return DecodeJSONBody(w, r, v) && ValidateRequestBody(w, r, v)
 }
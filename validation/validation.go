@@ -1,6 +1,12 @@
 package validation
 
 import (
+	"errors"
+	"goMusic/problem"
+	"reflect"
+	"strings"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -9,12 +15,69 @@ var validate = validator.New()
 func init() {
 	validate.RegisterValidation("validSex", validateSex)
 	validate.RegisterValidation("validTitle", validateTitle)
+	validate.RegisterValidation("notFuture", validateNotFuture)
+	validate.RegisterValidation("barcode", validateBarcode)
+	// Report fields by the names clients send them as.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 }
 
 func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
 
+// FieldErrors describes each field a ValidateStruct error complains about.
+func FieldErrors(err error) []problem.FieldError {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+
+	fields := make([]problem.FieldError, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = problem.FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: message(fe)}
+	}
+	return fields
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters long"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters long"
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "datetime":
+		return "must be a date formatted like " + fe.Param()
+	case "unique":
+		return "must not contain duplicates"
+	case "validSex":
+		return "is not a known sex"
+	case "validTitle":
+		return "is not a known title"
+	case "notFuture":
+		return "must not be in the future"
+	case "barcode":
+		return "must be a 12 digit UPC or 13 digit EAN with a valid check digit"
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
 func validateSex(fl validator.FieldLevel) bool {
 	value := fl.Field().Int()
 	return value >= 1 && value <= 3
@@ -24,3 +87,33 @@ func validateTitle(fl validator.FieldLevel) bool {
 	value := fl.Field().Int()
 	return value >= 1 && value <= 4
 }
+
+// clockSkew is how far ahead of the server's clock a client's may run.
+const clockSkew = 5 * time.Minute
+
+func validateNotFuture(fl validator.FieldLevel) bool {
+	value, ok := fl.Field().Interface().(time.Time)
+	return ok && !value.After(time.Now().Add(clockSkew))
+}
+
+// validateBarcode accepts a UPC-A or EAN-13 whose last digit is the check
+// digit: counting from the right, the other digits alternate weights 3 and 1
+// and the check digit tops their weighted sum up to a multiple of 10.
+func validateBarcode(fl validator.FieldLevel) bool {
+	code := fl.Field().String()
+	if len(code) != 12 && len(code) != 13 {
+		return false
+	}
+	sum := 0
+	for i := len(code) - 1; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if (len(code)-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
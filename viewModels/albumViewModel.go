@@ -1,183 +1,175 @@
 package viewModels
 
 import (
-	"database/sql"
-	"goMusic/db"
+	"context"
 	"goMusic/models"
+	"goMusic/repositories"
+	"strconv"
 )
 
 type DetailedAlbumViewModel struct {
-	Id     *int                 `json:"id,omitempty"`
-	Title  string               `json:"title"`
-	Price  float64              `json:"price"`
-	Artist *ArtistViewModel     `json:"artist,omitempty"`
-	Band   *BandViewModel       `json:"band,omitempty"`
-	Songs  []BasicSongViewModel `json:"songs,omitempty"`
+	Id            *int                  `json:"id,omitempty"`
+	Title         string                `json:"title"`
+	Price         int64                 `json:"price"`
+	Label         *BasicLabelViewModel  `json:"label,omitempty"`
+	ReleaseDate   *string               `json:"release_date,omitempty"`
+	CatalogNumber *string               `json:"catalog_number,omitempty"`
+	Barcode       *string               `json:"barcode,omitempty"`
+	Format        *string               `json:"format,omitempty"`
+	Credits       []CreditViewModel     `json:"credits"`
+	Songs         []BasicSongViewModel  `json:"songs,omitempty"`
+	CoverURLs     map[string]string     `json:"cover_urls,omitempty"`
+	Genres        []BasicGenreViewModel `json:"genres"`
+	Tags          []string              `json:"tags"`
+	// AverageRating is null until the album has been rated.
+	AverageRating *float64 `json:"average_rating"`
+	RatingCount   int      `json:"rating_count"`
 }
 
 type AlbumViewModel struct {
-	Id     *int                  `json:"id,omitempty"`
-	Title  string                `json:"title"`
-	Price  float64               `json:"price"`
-	Artist *BasicArtistViewModel `json:"artist,omitempty"`
-	Band   *BasicBandViewModel   `json:"band,omitempty"`
-	Songs  []BasicSongViewModel  `json:"songs,omitempty"`
+	Id            *int                  `json:"id,omitempty"`
+	Title         string                `json:"title"`
+	Price         int64                 `json:"price"`
+	Label         *BasicLabelViewModel  `json:"label,omitempty"`
+	ReleaseDate   *string               `json:"release_date,omitempty"`
+	CatalogNumber *string               `json:"catalog_number,omitempty"`
+	Barcode       *string               `json:"barcode,omitempty"`
+	Format        *string               `json:"format,omitempty"`
+	Credits       []CreditViewModel     `json:"credits"`
+	Songs         []BasicSongViewModel  `json:"songs,omitempty"`
+	CoverURLs     map[string]string     `json:"cover_urls,omitempty"`
+	Genres        []BasicGenreViewModel `json:"genres"`
+	Tags          []string              `json:"tags"`
+	// AverageRating is null until the album has been rated.
+	AverageRating *float64 `json:"average_rating"`
+	RatingCount   int      `json:"rating_count"`
 }
 
 type BasicAlbumViewModel struct {
-	Id    *int    `json:"id,omitempty"`
-	Title string  `json:"title"`
-	Price float64 `json:"price"`
+	Id    *int   `json:"id,omitempty"`
+	Title string `json:"title"`
+	Price int64  `json:"price"`
 }
 
-func GetAlbumViewModels(albums []models.Album) ([]AlbumViewModel, error) {
-	result := make([]AlbumViewModel, 0, len(albums))
+func GetAlbumViewModels(ctx context.Context, store *repositories.Store, albums []models.Album) ([]AlbumViewModel, error) {
+	albumIDs := make([]int, len(albums))
+	for i, album := range albums {
+		albumIDs[i] = album.Id
+	}
+	credits, err := loadAlbumCredits(ctx, store, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	covers, err := store.Covers.GetByAlbumIDs(ctx, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	classes, err := loadClassification(ctx, store, models.TagAlbum, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := store.Reviews.GetAlbumRatings(ctx, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := loadAlbumLabels(ctx, store, albums)
+	if err != nil {
+		return nil, err
+	}
 
+	result := make([]AlbumViewModel, 0, len(albums))
 	for _, album := range albums {
 		vm := AlbumViewModel{
 			Id:    &album.Id,
 			Title: album.Title,
 			Price: album.Price,
-			Songs: []BasicSongViewModel{},
-		}
-
-		if album.ArtistId != nil {
-			var artist BasicArtistViewModel
-			err := db.DB.QueryRow(`
-			SELECT 
-			  a.first_name, 
-			  a.last_name
-			FROM artists a
-			LEFT JOIN sexes s ON a.sex_id = s.id
-			LEFT JOIN titles t ON a.title_id = t.id
-			LEFT JOIN bands b ON a.band_id = b.id
-			WHERE a.id = ?`, *album.ArtistId,
-			).Scan(
-				&artist.FirstName,
-				&artist.LastName,
-			)
-
-			if err != nil && err != sql.ErrNoRows {
-				return nil, err
-			}
-
-			if err != sql.ErrNoRows {
-				vm.Artist = &artist
-			}
-		}
 
-		if album.BandId != nil {
-			var band BasicBandViewModel
-			err := db.DB.QueryRow(
-				"SELECT name FROM bands WHERE id = ?",
-				*album.BandId,
-			).Scan(&band.Name)
+			Label:         albumLabel(album, labels),
+			ReleaseDate:   album.ReleaseDate,
+			CatalogNumber: album.CatalogNumber,
+			Barcode:       album.Barcode,
+			Format:        album.Format,
 
-			if err != nil && err != sql.ErrNoRows {
-				return nil, err
-			}
+			Credits:   creditList(credits[album.Id]),
+			Songs:     []BasicSongViewModel{},
+			CoverURLs: GetCoverURLs(covers[album.Id]),
+			Genres:    GetBasicGenreViewModels(classes.genres[album.Id]),
+			Tags:      tagList(classes.tags[album.Id]),
 
-			if err != sql.ErrNoRows {
-				vm.Band = &band
-			}
+			AverageRating: ratings[album.Id].Average(),
+			RatingCount:   ratings[album.Id].Count,
 		}
-
 		result = append(result, vm)
 	}
 
 	return result, nil
 }
 
-func GetAlbumViewModel(album models.Album) (DetailedAlbumViewModel, error) {
+func GetAlbumViewModel(ctx context.Context, store *repositories.Store, album models.Album) (DetailedAlbumViewModel, error) {
 	vm := DetailedAlbumViewModel{
 		Id:    &album.Id,
 		Title: album.Title,
 		Price: album.Price,
-	}
-
-	if album.ArtistId != nil {
-		var artist ArtistViewModel
-		var bandName sql.NullString
-		err := db.DB.QueryRow(`
-		SELECT
-			a.first_name,
-			a.last_name,
-			a.nationality,
-			a.birth_date,
-			a.age,
-			a.alive,
-			s.name,
-			t.name,
-			b.name
-		FROM artists a
-		LEFT JOIN sexes s ON a.sex_id = s.id
-		LEFT JOIN titles t ON a.title_id = t.id
-		LEFT JOIN bands b ON a.band_id = b.id
-		WHERE a.id = ?`, *album.ArtistId,
-		).Scan(
-			&artist.FirstName,
-			&artist.LastName,
-			&artist.Nationality,
-			&artist.BirthDate,
-			&artist.Age,
-			&artist.Alive,
-			&artist.Sex,
-			&artist.Title,
-			&bandName,
-		)
-
-		if err != nil && err != sql.ErrNoRows {
-			return DetailedAlbumViewModel{}, err
-		}
 
-		if err != sql.ErrNoRows {
-			vm.Artist = &artist
-		}
+		ReleaseDate:   album.ReleaseDate,
+		CatalogNumber: album.CatalogNumber,
+		Barcode:       album.Barcode,
+		Format:        album.Format,
 	}
 
-	if album.BandId != nil {
-		var band BandViewModel
-		err := db.DB.QueryRow(
-			"SELECT name, nationality, number_of_members, date_formed, age, active FROM bands WHERE id = ?",
-			*album.BandId,
-		).Scan(&band.Name, &band.Nationality, &band.NumberOfMembers, &band.DateFormed, &band.Age, &band.Active)
-
-		if err != nil && err != sql.ErrNoRows {
-			return DetailedAlbumViewModel{}, err
-		}
+	labels, err := loadAlbumLabels(ctx, store, []models.Album{album})
+	if err != nil {
+		return DetailedAlbumViewModel{}, err
+	}
+	vm.Label = albumLabel(album, labels)
 
-		if err != sql.ErrNoRows {
-			vm.Band = &band
-		}
+	credits, err := loadAlbumCredits(ctx, store, []int{album.Id})
+	if err != nil {
+		return DetailedAlbumViewModel{}, err
 	}
+	vm.Credits = creditList(credits[album.Id])
 
-	rows, err := db.DB.Query("SELECT id, title, length, price FROM songs WHERE album_id = ?", album.Id)
+	songs, err := store.Songs.GetByAlbumID(ctx, album.Id)
 	if err != nil {
 		return DetailedAlbumViewModel{}, err
 	}
-	defer rows.Close()
 
 	vm.Songs = []BasicSongViewModel{}
-	for rows.Next() {
-		var song BasicSongViewModel
-		var songID int
-
-		if err := rows.Scan(&songID, &song.Title, &song.Length, &song.Price); err != nil {
-			return DetailedAlbumViewModel{}, err
-		}
+	for _, song := range songs {
+		vm.Songs = append(vm.Songs, GetBasicSongViewModel(song))
+	}
 
-		song.ID = &songID
+	covers, err := store.Covers.GetByAlbumIDs(ctx, []int{album.Id})
+	if err != nil {
+		return DetailedAlbumViewModel{}, err
+	}
+	vm.CoverURLs = GetCoverURLs(covers[album.Id])
 
-		vm.Songs = append(vm.Songs, song)
+	classes, err := loadClassification(ctx, store, models.TagAlbum, []int{album.Id})
+	if err != nil {
+		return DetailedAlbumViewModel{}, err
 	}
+	vm.Genres = GetBasicGenreViewModels(classes.genres[album.Id])
+	vm.Tags = tagList(classes.tags[album.Id])
 
-	if err := rows.Err(); err != nil {
+	ratings, err := store.Reviews.GetAlbumRatings(ctx, []int{album.Id})
+	if err != nil {
 		return DetailedAlbumViewModel{}, err
 	}
+	vm.AverageRating = ratings[album.Id].Average()
+	vm.RatingCount = ratings[album.Id].Count
 
 	return vm, nil
 }
 
+func loadAlbumCredits(ctx context.Context, store *repositories.Store, albumIDs []int) (map[int][]CreditViewModel, error) {
+	credits, err := store.Credits.GetByAlbumIDs(ctx, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	return loadCredits(ctx, store, credits)
+}
+
 func GetBasicAlbumViewModel(album models.Album) BasicAlbumViewModel {
 	return BasicAlbumViewModel{
 		Id:    &album.Id,
@@ -185,3 +177,26 @@ func GetBasicAlbumViewModel(album models.Album) BasicAlbumViewModel {
 		Price: album.Price,
 	}
 }
+
+// GetCoverURLs maps each cover variant to its URL. The URLs carry a prefix
+// of the image's digest, so they change whenever a new cover is uploaded and
+// can be cached indefinitely.
+func GetCoverURLs(covers []models.CoverImage) map[string]string {
+	if len(covers) == 0 {
+		return nil
+	}
+	urls := make(map[string]string, len(covers))
+	for _, cover := range covers {
+		urls[cover.Variant] = CoverURL(cover)
+	}
+	return urls
+}
+
+func CoverURL(cover models.CoverImage) string {
+	return "/albums/" + strconv.Itoa(cover.AlbumId) + "/cover/" + cover.Variant + "?v=" + CoverVersion(cover.Digest)
+}
+
+// CoverVersion is the cache-busting version of an image with this digest.
+func CoverVersion(digest string) string {
+	return digest[:min(16, len(digest))]
+}
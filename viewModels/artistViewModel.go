@@ -1,21 +1,23 @@
 package viewModels
 
 import (
-	"database/sql"
-	"goMusic/db"
+	"context"
 	"goMusic/models"
+	"goMusic/repositories"
 )
 
 type ArtistViewModel struct {
-	Id          *int   `json:"id,omitempty"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	Nationality string `json:"nationality"`
-	BirthDate   string `json:"birth_date"`
-	Age         int    `json:"age"`
-	Alive       bool   `json:"alive"`
-	Sex         string `json:"sex"`
-	Title       string `json:"title"`
+	Id          *int     `json:"id,omitempty"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	Nationality string   `json:"nationality"`
+	BirthDate   string   `json:"birth_date"`
+	DeathDate   *string  `json:"death_date,omitempty"`
+	Age         int      `json:"age"`
+	Alive       bool     `json:"alive"`
+	Sex         string   `json:"sex"`
+	Title       string   `json:"title"`
+	Tags        []string `json:"tags"`
 }
 
 type BasicArtistViewModel struct {
@@ -24,9 +26,28 @@ type BasicArtistViewModel struct {
 	LastName  string `json:"last_name"`
 }
 
-func GetArtistViewModels(artists []models.Artist) ([]ArtistViewModel, error) {
-	result := make([]ArtistViewModel, 0, len(artists))
+// GetArtistViewModels computes each artist's age and status as of the given
+// date, or today when asOf is empty.
+func GetArtistViewModels(ctx context.Context, store *repositories.Store, artists []models.Artist, asOf string) ([]ArtistViewModel, error) {
+	if asOf == "" {
+		asOf = models.Today()
+	}
 
+	sexes, titles, err := loadArtistLookups(ctx, store, artists)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(artists))
+	for _, artist := range artists {
+		ids = append(ids, artist.Id)
+	}
+	classes, err := loadClassification(ctx, store, models.TagArtist, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ArtistViewModel, 0, len(artists))
 	for _, artist := range artists {
 		vm := ArtistViewModel{
 			Id:          &artist.Id,
@@ -34,30 +55,18 @@ func GetArtistViewModels(artists []models.Artist) ([]ArtistViewModel, error) {
 			LastName:    artist.LastName,
 			Nationality: artist.Nationality,
 			BirthDate:   artist.BirthDate,
-			Age:         artist.Age,
-			Alive:       artist.Alive,
+			DeathDate:   artist.DeathDate,
+			Age:         artist.AgeOn(asOf),
+			Alive:       artist.AliveOn(asOf),
+			Tags:        tagList(classes.tags[artist.Id]),
 		}
 
 		if artist.SexId != nil {
-			var sexName string
-			err := db.DB.QueryRow("SELECT name FROM sexes WHERE id = ?", *artist.SexId).Scan(&sexName)
-			if err != nil && err != sql.ErrNoRows {
-				return nil, err
-			}
-			if err != sql.ErrNoRows {
-				vm.Sex = sexName
-			}
+			vm.Sex = sexes[*artist.SexId]
 		}
 
 		if artist.TitleId != nil {
-			var titleName string
-			err := db.DB.QueryRow("SELECT name FROM titles WHERE id = ?", *artist.TitleId).Scan(&titleName)
-			if err != nil && err != sql.ErrNoRows {
-				return nil, err
-			}
-			if err != sql.ErrNoRows {
-				vm.Title = titleName
-			}
+			vm.Title = titles[*artist.TitleId]
 		}
 
 		result = append(result, vm)
@@ -66,40 +75,12 @@ func GetArtistViewModels(artists []models.Artist) ([]ArtistViewModel, error) {
 	return result, nil
 }
 
-func GetArtistViewModel(artist models.Artist) (ArtistViewModel, error) {
-	vm := ArtistViewModel{
-		Id:          &artist.Id,
-		FirstName:   artist.FirstName,
-		LastName:    artist.LastName,
-		Nationality: artist.Nationality,
-		BirthDate:   artist.BirthDate,
-		Age:         artist.Age,
-		Alive:       artist.Alive,
+func GetArtistViewModel(ctx context.Context, store *repositories.Store, artist models.Artist, asOf string) (ArtistViewModel, error) {
+	result, err := GetArtistViewModels(ctx, store, []models.Artist{artist}, asOf)
+	if err != nil {
+		return ArtistViewModel{}, err
 	}
-
-	if artist.SexId != nil {
-		var sexName string
-		err := db.DB.QueryRow("SELECT name FROM sexes WHERE id = ?", *artist.SexId).Scan(&sexName)
-		if err != nil && err != sql.ErrNoRows {
-			return ArtistViewModel{}, err
-		}
-		if err != sql.ErrNoRows {
-			vm.Sex = sexName
-		}
-	}
-
-	if artist.TitleId != nil {
-		var titleName string
-		err := db.DB.QueryRow("SELECT name FROM titles WHERE id = ?", *artist.TitleId).Scan(&titleName)
-		if err != nil && err != sql.ErrNoRows {
-			return ArtistViewModel{}, err
-		}
-		if err != sql.ErrNoRows {
-			vm.Title = titleName
-		}
-	}
-
-	return vm, nil
+	return result[0], nil
 }
 
 func GetBasicArtistViewModel(artist models.Artist) BasicArtistViewModel {
@@ -0,0 +1,53 @@
+package viewModels
+
+import (
+	"goMusic/audio"
+	"goMusic/models"
+	"strconv"
+	"time"
+)
+
+// AudioViewModel describes a song's audio file. Length is in seconds.
+type AudioViewModel struct {
+	URL        string    `json:"url"`
+	Format     string    `json:"format"`
+	MIMEType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Length     int       `json:"length"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// TagSuggestionsViewModel is what the file's tags say about the song.
+// ArtistId and BandId are set when the artist tag names exactly one artist
+// or band in the catalog.
+type TagSuggestionsViewModel struct {
+	Title       string `json:"title,omitempty"`
+	Artist      string `json:"artist,omitempty"`
+	ArtistId    *int   `json:"artist_id,omitempty"`
+	BandId      *int   `json:"band_id,omitempty"`
+	TrackNumber int    `json:"track_number,omitempty"`
+	Length      int    `json:"length,omitempty"`
+}
+
+type AudioUploadViewModel struct {
+	Song        models.Song             `json:"song"`
+	Audio       AudioViewModel          `json:"audio"`
+	Suggestions TagSuggestionsViewModel `json:"suggestions"`
+	// Applied lists what was filled in from the file: the length and title
+	// fields, and "credits" when the suggested artist or band was credited
+	// as the performer.
+	Applied []string `json:"applied"`
+}
+
+func GetAudioViewModel(record models.SongAudio) AudioViewModel {
+	return AudioViewModel{
+		URL:        "/songs/" + strconv.Itoa(record.SongId) + "/audio",
+		Format:     record.Format,
+		MIMEType:   audio.Format(record.Format).MIMEType(),
+		Size:       record.Size,
+		SHA256:     record.Digest,
+		Length:     record.Length,
+		UploadedAt: record.UploadedAt.UTC(),
+	}
+}
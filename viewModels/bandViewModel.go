@@ -1,17 +1,21 @@
 package viewModels
 
 import (
+	"context"
 	"goMusic/models"
+	"goMusic/repositories"
 )
 
 type BandViewModel struct {
-	Id              *int   `json:"id,omitempty"`
-	Name            string `json:"name"`
-	Nationality     string `json:"nationality"`
-	NumberOfMembers int    `json:"number_of_members"`
-	DateFormed      string `json:"date_formed"`
-	Age             int    `json:"age"`
-	Active          bool   `json:"active"`
+	Id              *int     `json:"id,omitempty"`
+	Name            string   `json:"name"`
+	Nationality     string   `json:"nationality"`
+	NumberOfMembers int      `json:"number_of_members"`
+	DateFormed      string   `json:"date_formed"`
+	DisbandedDate   *string  `json:"disbanded_date,omitempty"`
+	Age             int      `json:"age"`
+	Active          bool     `json:"active"`
+	Tags            []string `json:"tags"`
 }
 
 type BasicBandViewModel struct {
@@ -19,7 +23,22 @@ type BasicBandViewModel struct {
 	Name string `json:"name"`
 }
 
-func GetBandViewModels(bands []models.Band) ([]BandViewModel, error) {
+// GetBandViewModels computes each band's age and status as of the given date,
+// or today when asOf is empty.
+func GetBandViewModels(ctx context.Context, store *repositories.Store, bands []models.Band, asOf string) ([]BandViewModel, error) {
+	if asOf == "" {
+		asOf = models.Today()
+	}
+
+	ids := make([]int, 0, len(bands))
+	for _, band := range bands {
+		ids = append(ids, band.Id)
+	}
+	classes, err := loadClassification(ctx, store, models.TagBand, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]BandViewModel, 0, len(bands))
 	for _, band := range bands {
 		vm := BandViewModel{
@@ -28,25 +47,22 @@ func GetBandViewModels(bands []models.Band) ([]BandViewModel, error) {
 			Nationality:     band.Nationality,
 			NumberOfMembers: band.NumberOfMembers,
 			DateFormed:      band.DateFormed,
-			Age:             band.Age,
-			Active:          band.Active,
+			DisbandedDate:   band.DisbandedDate,
+			Age:             band.AgeOn(asOf),
+			Active:          band.ActiveOn(asOf),
+			Tags:            tagList(classes.tags[band.Id]),
 		}
 		result = append(result, vm)
 	}
 	return result, nil
 }
 
-func GetBandViewModel(band models.Band) (BandViewModel, error) {
-	vm := BandViewModel{
-		Id:              &band.Id,
-		Name:            band.Name,
-		Nationality:     band.Nationality,
-		NumberOfMembers: band.NumberOfMembers,
-		DateFormed:      band.DateFormed,
-		Age:             band.Age,
-		Active:          band.Active,
+func GetBandViewModel(ctx context.Context, store *repositories.Store, band models.Band, asOf string) (BandViewModel, error) {
+	result, err := GetBandViewModels(ctx, store, []models.Band{band}, asOf)
+	if err != nil {
+		return BandViewModel{}, err
 	}
-	return vm, nil
+	return result[0], nil
 }
 
 func GetBasicBandViewModel(band models.Band) BasicBandViewModel {
@@ -0,0 +1,93 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+// ChartViewModel is a song or album chart. A provisional chart is today's,
+// which changes until the day is over.
+type ChartViewModel[T any] struct {
+	Period      string                   `json:"period"`
+	Date        string                   `json:"date"`
+	Provisional bool                     `json:"provisional"`
+	Entries     []ChartEntryViewModel[T] `json:"entries"`
+}
+
+// ChartEntryViewModel is a place in a chart. Movement is up, down, same or
+// new when the item wasn't in the previous chart; change is how many places
+// it moved, positive when up.
+type ChartEntryViewModel[T any] struct {
+	Position         int     `json:"position"`
+	PreviousPosition *int    `json:"previous_position"`
+	Movement         string  `json:"movement"`
+	Change           *int    `json:"change,omitempty"`
+	Score            float64 `json:"score"`
+	Item             T       `json:"item"`
+}
+
+// GetSongChartViewModel loads the songs of a chart, leaving out those since
+// deleted, and up to limit entries.
+func GetSongChartViewModel(ctx context.Context, store *repositories.Store, chart models.Chart, provisional bool, limit int) (ChartViewModel[BasicSongViewModel], error) {
+	entries := chart.Entries[:min(len(chart.Entries), limit)]
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ItemId)
+	}
+	songs, err := store.Songs.GetByIDs(ctx, ids)
+	if err != nil {
+		return ChartViewModel[BasicSongViewModel]{}, err
+	}
+	byID := map[int]BasicSongViewModel{}
+	for _, song := range songs {
+		byID[song.Id] = GetBasicSongViewModel(song)
+	}
+	return getChartViewModel(chart, provisional, entries, byID), nil
+}
+
+// GetAlbumChartViewModel loads the albums of a chart, leaving out those
+// since deleted, and up to limit entries.
+func GetAlbumChartViewModel(ctx context.Context, store *repositories.Store, chart models.Chart, provisional bool, limit int) (ChartViewModel[BasicAlbumViewModel], error) {
+	entries := chart.Entries[:min(len(chart.Entries), limit)]
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ItemId)
+	}
+	albums, err := store.Albums.GetByIDs(ctx, ids)
+	if err != nil {
+		return ChartViewModel[BasicAlbumViewModel]{}, err
+	}
+	byID := map[int]BasicAlbumViewModel{}
+	for _, album := range albums {
+		byID[album.Id] = GetBasicAlbumViewModel(album)
+	}
+	return getChartViewModel(chart, provisional, entries, byID), nil
+}
+
+func getChartViewModel[T any](chart models.Chart, provisional bool, entries []models.ChartEntry, items map[int]T) ChartViewModel[T] {
+	vm := ChartViewModel[T]{Period: chart.Period, Date: chart.Date, Provisional: provisional, Entries: []ChartEntryViewModel[T]{}}
+	for _, entry := range entries {
+		item, ok := items[entry.ItemId]
+		if !ok {
+			continue
+		}
+		entryVM := ChartEntryViewModel[T]{
+			Position: entry.Position, PreviousPosition: entry.PreviousPosition, Movement: "new", Score: entry.Score, Item: item,
+		}
+		if entry.PreviousPosition != nil {
+			change := *entry.PreviousPosition - entry.Position
+			entryVM.Change = &change
+			switch {
+			case change > 0:
+				entryVM.Movement = "up"
+			case change < 0:
+				entryVM.Movement = "down"
+			default:
+				entryVM.Movement = "same"
+			}
+		}
+		vm.Entries = append(vm.Entries, entryVM)
+	}
+	return vm
+}
@@ -0,0 +1,157 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+// CreditViewModel is a credit as listed on an album or song: who, and in
+// which role. Exactly one of Artist and Band is set.
+type CreditViewModel struct {
+	Id     int                   `json:"id"`
+	Role   string                `json:"role"`
+	Artist *BasicArtistViewModel `json:"artist,omitempty"`
+	Band   *BasicBandViewModel   `json:"band,omitempty"`
+}
+
+// CreditedWorkViewModel is a credit as listed on an artist or band: what
+// they worked on, and in which role. Exactly one of Album and Song is set.
+type CreditedWorkViewModel struct {
+	Id    int                  `json:"id"`
+	Role  string               `json:"role"`
+	Album *BasicAlbumViewModel `json:"album,omitempty"`
+	Song  *BasicSongViewModel  `json:"song,omitempty"`
+}
+
+// CreditRequest credits either an artist or a band on an album or song.
+type CreditRequest struct {
+	ArtistId *int   `json:"artist_id,omitempty" validate:"required_without=BandId,excluded_with=BandId"`
+	BandId   *int   `json:"band_id,omitempty" validate:"required_without=ArtistId"`
+	Role     string `json:"role" validate:"required,oneof=performer featured composer lyricist producer"`
+}
+
+// AlbumSongsRequest replaces the tracks of an album. An empty list clears
+// them.
+type AlbumSongsRequest struct {
+	SongIds []int `json:"song_ids" validate:"unique,dive,min=1"`
+}
+
+// loadCredits resolves the artists and bands of batches of credits, keyed
+// by the album or song they belong to, with one query each.
+func loadCredits(ctx context.Context, store *repositories.Store, credits map[int][]models.Credit) (map[int][]CreditViewModel, error) {
+	var artistIDs, bandIDs []int
+	for _, owned := range credits {
+		for _, credit := range owned {
+			if credit.ArtistId != nil {
+				artistIDs = append(artistIDs, *credit.ArtistId)
+			}
+			if credit.BandId != nil {
+				bandIDs = append(bandIDs, *credit.BandId)
+			}
+		}
+	}
+
+	artistRows, err := store.Artists.GetByIDs(ctx, artistIDs)
+	if err != nil {
+		return nil, err
+	}
+	artists := map[int]BasicArtistViewModel{}
+	for _, artist := range artistRows {
+		artists[artist.Id] = GetBasicArtistViewModel(artist)
+	}
+
+	bandRows, err := store.Bands.GetByIDs(ctx, bandIDs)
+	if err != nil {
+		return nil, err
+	}
+	bands := map[int]BasicBandViewModel{}
+	for _, band := range bandRows {
+		bands[band.Id] = GetBasicBandViewModel(band)
+	}
+
+	result := make(map[int][]CreditViewModel, len(credits))
+	for ownerID, owned := range credits {
+		for _, credit := range owned {
+			vm := CreditViewModel{Id: credit.Id, Role: credit.Role}
+			if artist, ok := artists[nullableID(credit.ArtistId)]; ok {
+				vm.Artist = &artist
+			}
+			if band, ok := bands[nullableID(credit.BandId)]; ok {
+				vm.Band = &band
+			}
+			result[ownerID] = append(result[ownerID], vm)
+		}
+	}
+	return result, nil
+}
+
+// GetCreditViewModels resolves the artists and bands of one album's or
+// song's credits.
+func GetCreditViewModels(ctx context.Context, store *repositories.Store, credits []models.Credit) ([]CreditViewModel, error) {
+	vms, err := loadCredits(ctx, store, map[int][]models.Credit{0: credits})
+	return creditList(vms[0]), err
+}
+
+// GetCreditedWorkViewModels resolves the albums and songs of an artist's or
+// band's credits.
+func GetCreditedWorkViewModels(ctx context.Context, store *repositories.Store, credits []models.Credit) ([]CreditedWorkViewModel, error) {
+	var albumIDs, songIDs []int
+	for _, credit := range credits {
+		if credit.AlbumId != nil {
+			albumIDs = append(albumIDs, *credit.AlbumId)
+		}
+		if credit.SongId != nil {
+			songIDs = append(songIDs, *credit.SongId)
+		}
+	}
+
+	albumRows, err := store.Albums.GetByIDs(ctx, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	albums := map[int]BasicAlbumViewModel{}
+	for _, album := range albumRows {
+		albums[album.Id] = GetBasicAlbumViewModel(album)
+	}
+
+	songRows, err := store.Songs.GetByIDs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+	songs := map[int]BasicSongViewModel{}
+	for _, song := range songRows {
+		songs[song.Id] = GetBasicSongViewModel(song)
+	}
+
+	result := make([]CreditedWorkViewModel, 0, len(credits))
+	for _, credit := range credits {
+		vm := CreditedWorkViewModel{Id: credit.Id, Role: credit.Role}
+		if album, ok := albums[nullableID(credit.AlbumId)]; ok {
+			vm.Album = &album
+		}
+		if song, ok := songs[nullableID(credit.SongId)]; ok {
+			vm.Song = &song
+		}
+		result = append(result, vm)
+	}
+	return result, nil
+}
+
+// creditList is the credits of one album or song, never nil so it encodes
+// as an empty list.
+func creditList(credits []CreditViewModel) []CreditViewModel {
+	if credits == nil {
+		return []CreditViewModel{}
+	}
+	return credits
+}
+
+// nullableID unwraps an optional ID, returning 0, which no row has, when it
+// is unset.
+func nullableID(id *int) int {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
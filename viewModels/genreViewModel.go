@@ -0,0 +1,93 @@
+package viewModels
+
+import "goMusic/models"
+
+// GenreViewModel is a genre as listed by GET /genres. Top-level genres have
+// no parent_id.
+type GenreViewModel struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentId *int   `json:"parent_id,omitempty"`
+}
+
+type DetailedGenreViewModel struct {
+	Id        int                   `json:"id"`
+	Name      string                `json:"name"`
+	Parent    *BasicGenreViewModel  `json:"parent,omitempty"`
+	Subgenres []BasicGenreViewModel `json:"subgenres"`
+}
+
+type BasicGenreViewModel struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// GenresRequest replaces the genres of an album or song. An empty list
+// clears them.
+type GenresRequest struct {
+	GenreIds []int `json:"genre_ids" validate:"unique,dive,min=1"`
+}
+
+// TagRequest attaches a tag. Names are trimmed and lower-cased.
+type TagRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+type TagCountViewModel struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func GetGenreViewModels(genres []models.Genre) []GenreViewModel {
+	result := make([]GenreViewModel, 0, len(genres))
+	for _, genre := range genres {
+		result = append(result, GenreViewModel{Id: genre.Id, Name: genre.Name, ParentId: genre.ParentId})
+	}
+	return result
+}
+
+// GetGenreViewModel describes a genre with its parent and direct subgenres,
+// picked out of all genres.
+func GetGenreViewModel(genre models.Genre, all []models.Genre) DetailedGenreViewModel {
+	vm := DetailedGenreViewModel{Id: genre.Id, Name: genre.Name, Subgenres: []BasicGenreViewModel{}}
+	for _, other := range all {
+		if genre.ParentId != nil && other.Id == *genre.ParentId {
+			parent := GetBasicGenreViewModel(other)
+			vm.Parent = &parent
+		}
+		if other.ParentId != nil && *other.ParentId == genre.Id {
+			vm.Subgenres = append(vm.Subgenres, GetBasicGenreViewModel(other))
+		}
+	}
+	return vm
+}
+
+func GetBasicGenreViewModel(genre models.Genre) BasicGenreViewModel {
+	return BasicGenreViewModel{Id: genre.Id, Name: genre.Name}
+}
+
+// GetBasicGenreViewModels always returns a list, so entries without genres
+// show an empty one.
+func GetBasicGenreViewModels(genres []models.Genre) []BasicGenreViewModel {
+	result := make([]BasicGenreViewModel, 0, len(genres))
+	for _, genre := range genres {
+		result = append(result, GetBasicGenreViewModel(genre))
+	}
+	return result
+}
+
+func GetTagCountViewModels(tags []models.TagCount) []TagCountViewModel {
+	result := make([]TagCountViewModel, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, TagCountViewModel{Name: tag.Name, Count: tag.Count})
+	}
+	return result
+}
+
+// tagList always returns a list, so untagged entries show an empty one.
+func tagList(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,31 @@
+package viewModels
+
+import (
+	"goMusic/models"
+	"goMusic/problem"
+)
+
+// ImportReport is the outcome of an import: how many records of each kind
+// it created, or would create on a dry run, and the problems found.
+type ImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Artists int                  `json:"artists"`
+	Bands   int                  `json:"bands"`
+	Albums  int                  `json:"albums"`
+	Songs   int                  `json:"songs"`
+	Errors  []problem.FieldError `json:"errors"`
+}
+
+func GetImportReport(batch models.CatalogImport, problems []problem.FieldError, dryRun bool) ImportReport {
+	if problems == nil {
+		problems = []problem.FieldError{}
+	}
+	return ImportReport{
+		DryRun:  dryRun,
+		Artists: len(batch.Artists),
+		Bands:   len(batch.Bands),
+		Albums:  len(batch.Albums),
+		Songs:   len(batch.Songs),
+		Errors:  problems,
+	}
+}
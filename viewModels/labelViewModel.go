@@ -0,0 +1,65 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+type LabelViewModel struct {
+	Id      int     `json:"id"`
+	Name    string  `json:"name"`
+	Country *string `json:"country,omitempty"`
+}
+
+type BasicLabelViewModel struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func GetLabelViewModel(label models.Label) LabelViewModel {
+	return LabelViewModel{Id: label.Id, Name: label.Name, Country: label.Country}
+}
+
+func GetLabelViewModels(labels []models.Label) []LabelViewModel {
+	result := make([]LabelViewModel, 0, len(labels))
+	for _, label := range labels {
+		result = append(result, GetLabelViewModel(label))
+	}
+	return result
+}
+
+// loadAlbumLabels resolves the labels of a batch of albums, keyed by label
+// ID.
+func loadAlbumLabels(ctx context.Context, store *repositories.Store, albums []models.Album) (map[int]BasicLabelViewModel, error) {
+	var ids []int
+	for _, album := range albums {
+		if album.LabelId != nil {
+			ids = append(ids, *album.LabelId)
+		}
+	}
+	result := map[int]BasicLabelViewModel{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	labels, err := store.Labels.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		result[label.Id] = BasicLabelViewModel{Id: label.Id, Name: label.Name}
+	}
+	return result, nil
+}
+
+// albumLabel picks the album's label out of loaded labels.
+func albumLabel(album models.Album, labels map[int]BasicLabelViewModel) *BasicLabelViewModel {
+	if album.LabelId == nil {
+		return nil
+	}
+	label, ok := labels[*album.LabelId]
+	if !ok {
+		return nil
+	}
+	return &label
+}
@@ -0,0 +1,101 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+// songRelations holds the albums and credits of a batch of songs, their
+// rating totals, and their genres and tags, keyed by song ID.
+type songRelations struct {
+	albums  map[int][]models.Album
+	credits map[int][]CreditViewModel
+	ratings map[int]models.Rating
+	classification
+}
+
+// loadSongRelations fetches every relationship of the given songs with one
+// query per table, however many songs there are.
+func loadSongRelations(ctx context.Context, store *repositories.Store, songs []models.Song) (songRelations, error) {
+	ids := make([]int, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.Id)
+	}
+
+	var relations songRelations
+	var err error
+
+	if relations.albums, err = store.Albums.GetBySongIDs(ctx, ids); err != nil {
+		return songRelations{}, err
+	}
+	credits, err := store.Credits.GetBySongIDs(ctx, ids)
+	if err != nil {
+		return songRelations{}, err
+	}
+	if relations.credits, err = loadCredits(ctx, store, credits); err != nil {
+		return songRelations{}, err
+	}
+	if relations.ratings, err = store.Reviews.GetSongRatings(ctx, ids); err != nil {
+		return songRelations{}, err
+	}
+	if relations.classification, err = loadClassification(ctx, store, models.TagSong, ids); err != nil {
+		return songRelations{}, err
+	}
+
+	return relations, nil
+}
+
+// loadArtistLookups resolves the sex and title names of a batch of artists.
+func loadArtistLookups(ctx context.Context, store *repositories.Store, artists []models.Artist) (map[int]string, map[int]string, error) {
+	var sexIDs, titleIDs []int
+	for _, artist := range artists {
+		if artist.SexId != nil {
+			sexIDs = append(sexIDs, *artist.SexId)
+		}
+		if artist.TitleId != nil {
+			titleIDs = append(titleIDs, *artist.TitleId)
+		}
+	}
+
+	sexes, err := store.Artists.GetSexNames(ctx, sexIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	titles, err := store.Artists.GetTitleNames(ctx, titleIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sexes, titles, nil
+}
+
+// classification holds the genres and tags of a batch of entries of one
+// kind, keyed by entry ID. Artists and bands have no genres.
+type classification struct {
+	genres map[int][]models.Genre
+	tags   map[int][]string
+}
+
+// loadClassification fetches the genres and tags of a batch of entries of
+// the given kind with one query each.
+func loadClassification(ctx context.Context, store *repositories.Store, entityType string, ids []int) (classification, error) {
+	var c classification
+	var err error
+
+	switch entityType {
+	case models.TagAlbum:
+		c.genres, err = store.Genres.GetByAlbumIDs(ctx, ids)
+	case models.TagSong:
+		c.genres, err = store.Genres.GetBySongIDs(ctx, ids)
+	}
+	if err != nil {
+		return classification{}, err
+	}
+
+	if c.tags, err = store.Tags.GetByEntityIDs(ctx, entityType, ids); err != nil {
+		return classification{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,89 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+// BandMemberViewModel is a membership as listed on a band.
+type BandMemberViewModel struct {
+	Id         int                  `json:"id"`
+	Artist     BasicArtistViewModel `json:"artist"`
+	Role       string               `json:"role"`
+	DateJoined *string              `json:"date_joined"`
+	DateLeft   *string              `json:"date_left"`
+}
+
+// ArtistBandViewModel is a membership as listed on an artist.
+type ArtistBandViewModel struct {
+	Id         int                `json:"id"`
+	Band       BasicBandViewModel `json:"band"`
+	Role       string             `json:"role"`
+	DateJoined *string            `json:"date_joined"`
+	DateLeft   *string            `json:"date_left"`
+}
+
+// MembershipRequest adds an artist to a band or changes their membership.
+// Leaving out date_joined means since the band formed, and leaving out
+// date_left means they are still a member.
+type MembershipRequest struct {
+	ArtistId   int     `json:"artist_id" validate:"required,min=1"`
+	Role       string  `json:"role" validate:"max=100"`
+	DateJoined *string `json:"date_joined,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	DateLeft   *string `json:"date_left,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+func GetBandMemberViewModels(ctx context.Context, store *repositories.Store, memberships []models.BandMembership) ([]BandMemberViewModel, error) {
+	ids := make([]int, 0, len(memberships))
+	for _, membership := range memberships {
+		ids = append(ids, membership.ArtistId)
+	}
+	artistRows, err := store.Artists.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	artists := map[int]BasicArtistViewModel{}
+	for _, artist := range artistRows {
+		artists[artist.Id] = GetBasicArtistViewModel(artist)
+	}
+
+	result := make([]BandMemberViewModel, 0, len(memberships))
+	for _, membership := range memberships {
+		result = append(result, BandMemberViewModel{
+			Id:         membership.Id,
+			Artist:     artists[membership.ArtistId],
+			Role:       membership.Role,
+			DateJoined: membership.DateJoined,
+			DateLeft:   membership.DateLeft,
+		})
+	}
+	return result, nil
+}
+
+func GetArtistBandViewModels(ctx context.Context, store *repositories.Store, memberships []models.BandMembership) ([]ArtistBandViewModel, error) {
+	ids := make([]int, 0, len(memberships))
+	for _, membership := range memberships {
+		ids = append(ids, membership.BandId)
+	}
+	bandRows, err := store.Bands.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	bands := map[int]BasicBandViewModel{}
+	for _, band := range bandRows {
+		bands[band.Id] = GetBasicBandViewModel(band)
+	}
+
+	result := make([]ArtistBandViewModel, 0, len(memberships))
+	for _, membership := range memberships {
+		result = append(result, ArtistBandViewModel{
+			Id:         membership.Id,
+			Band:       bands[membership.BandId],
+			Role:       membership.Role,
+			DateJoined: membership.DateJoined,
+			DateLeft:   membership.DateLeft,
+		})
+	}
+	return result, nil
+}
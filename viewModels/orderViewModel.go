@@ -0,0 +1,123 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"time"
+)
+
+type CartItemRequest struct {
+	Type   string `json:"type" validate:"required,oneof=album song"`
+	ItemId int    `json:"item_id" validate:"required,min=1"`
+}
+
+// CartViewModel prices the cart at the current catalog prices. Amounts are
+// in minor units (cents).
+type CartViewModel struct {
+	Items      []CartItemViewModel `json:"items"`
+	TotalCents int64               `json:"total_cents"`
+}
+
+type CartItemViewModel struct {
+	Id         int    `json:"id"`
+	Type       string `json:"type"`
+	ItemId     int    `json:"item_id"`
+	Title      string `json:"title"`
+	PriceCents int64  `json:"price_cents"`
+	// Covered items are already owned, or are songs on an album in the same
+	// cart. They are not charged for and are dropped at checkout.
+	Covered bool `json:"covered"`
+}
+
+type OrderViewModel struct {
+	Id         int                  `json:"id"`
+	CreatedAt  time.Time            `json:"created_at"`
+	TotalCents int64                `json:"total_cents"`
+	Items      []OrderItemViewModel `json:"items"`
+}
+
+type OrderItemViewModel struct {
+	Type string `json:"type"`
+	// ItemId is null once the album or song has been deleted from the
+	// catalog.
+	ItemId     *int   `json:"item_id"`
+	Title      string `json:"title"`
+	PriceCents int64  `json:"price_cents"`
+}
+
+type LibraryViewModel struct {
+	Albums []BasicAlbumViewModel `json:"albums"`
+	Songs  []BasicSongViewModel  `json:"songs"`
+}
+
+func GetCartItemViewModel(id int, item models.OrderItem, covered bool) CartItemViewModel {
+	vm := CartItemViewModel{
+		Id:         id,
+		Type:       item.Type,
+		ItemId:     *orderItemID(item),
+		Title:      item.Title,
+		PriceCents: item.Price,
+		Covered:    covered,
+	}
+	if covered {
+		vm.PriceCents = 0
+	}
+	return vm
+}
+
+func GetOrderViewModels(orders []models.Order) []OrderViewModel {
+	result := make([]OrderViewModel, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, GetOrderViewModel(order))
+	}
+	return result
+}
+
+func GetOrderViewModel(order models.Order) OrderViewModel {
+	vm := OrderViewModel{
+		Id:         order.Id,
+		CreatedAt:  order.CreatedAt.UTC(),
+		TotalCents: order.Total,
+		Items:      make([]OrderItemViewModel, 0, len(order.Items)),
+	}
+	for _, item := range order.Items {
+		vm.Items = append(vm.Items, OrderItemViewModel{
+			Type:       item.Type,
+			ItemId:     orderItemID(item),
+			Title:      item.Title,
+			PriceCents: item.Price,
+		})
+	}
+	return vm
+}
+
+func GetLibraryViewModel(ctx context.Context, store *repositories.Store, library models.Library) (LibraryViewModel, error) {
+	albums, err := store.Albums.GetByIDs(ctx, library.AlbumIds)
+	if err != nil {
+		return LibraryViewModel{}, err
+	}
+	songs, err := store.Songs.GetByIDs(ctx, library.SongIds)
+	if err != nil {
+		return LibraryViewModel{}, err
+	}
+
+	vm := LibraryViewModel{
+		Albums: make([]BasicAlbumViewModel, 0, len(albums)),
+		Songs:  make([]BasicSongViewModel, 0, len(songs)),
+	}
+	for _, album := range albums {
+		vm.Albums = append(vm.Albums, GetBasicAlbumViewModel(album))
+	}
+	for _, song := range songs {
+		vm.Songs = append(vm.Songs, GetBasicSongViewModel(song))
+	}
+	return vm, nil
+}
+
+func orderItemID(item models.OrderItem) *int {
+	if item.Type == models.ItemAlbum {
+		return item.AlbumId
+	}
+	return item.SongId
+}
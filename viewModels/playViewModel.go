@@ -0,0 +1,158 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"time"
+)
+
+// PlayRequest reports one play. Duration is the number of seconds listened
+// to. ClientId is optional; plays submitted again with the same one are
+// ignored, so clients can safely retry a batch recorded offline.
+type PlayRequest struct {
+	SongId   int       `json:"song_id" validate:"required"`
+	PlayedAt time.Time `json:"played_at" validate:"required,notFuture"`
+	Duration int       `json:"duration" validate:"min=0,max=86400"`
+	ClientId string    `json:"client_id" validate:"max=100"`
+}
+
+type PlayBatchRequest struct {
+	Plays []PlayRequest `json:"plays" validate:"required,min=1,max=500,dive"`
+}
+
+// PlayBatchViewModel says how many of the submitted plays were new and how
+// many had already been recorded.
+type PlayBatchViewModel struct {
+	Recorded   int `json:"recorded"`
+	Duplicates int `json:"duplicates"`
+}
+
+// TopListViewModel ranks what the user listened to most in a window of their
+// history. Since is null for the all-time window.
+type TopListViewModel[T any] struct {
+	Window string     `json:"window"`
+	Since  *time.Time `json:"since"`
+	Items  []T        `json:"items"`
+}
+
+type TopSongViewModel struct {
+	Song    SongViewModel `json:"song"`
+	Plays   int           `json:"plays"`
+	Seconds int           `json:"seconds"`
+}
+
+// TopArtistViewModel is an artist or band, as named by Type, with the plays
+// of the songs it performs. Item holds its ArtistViewModel or BandViewModel.
+type TopArtistViewModel struct {
+	Type    string      `json:"type"`
+	Item    interface{} `json:"item"`
+	Plays   int         `json:"plays"`
+	Seconds int         `json:"seconds"`
+}
+
+// ListeningTimeViewModel totals the user's plays in a window of their
+// history, and for each day in it with plays. Since is null for the all-time
+// window.
+type ListeningTimeViewModel struct {
+	Window  string                  `json:"window"`
+	Since   *time.Time              `json:"since"`
+	Plays   int                     `json:"plays"`
+	Seconds int                     `json:"seconds"`
+	Days    []ListeningDayViewModel `json:"days"`
+}
+
+type ListeningDayViewModel struct {
+	Date    string `json:"date"`
+	Plays   int    `json:"plays"`
+	Seconds int    `json:"seconds"`
+}
+
+// GetTopSongViewModels loads the songs behind the counts, keeping their
+// order.
+func GetTopSongViewModels(ctx context.Context, store *repositories.Store, counts []models.SongPlays) ([]TopSongViewModel, error) {
+	ids := make([]int, 0, len(counts))
+	for _, count := range counts {
+		ids = append(ids, count.SongId)
+	}
+	songs, err := store.Songs.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	vms, err := GetSongViewModels(ctx, store, songs)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]SongViewModel{}
+	for _, vm := range vms {
+		byID[*vm.ID] = vm
+	}
+
+	result := make([]TopSongViewModel, 0, len(counts))
+	for _, count := range counts {
+		if song, ok := byID[count.SongId]; ok {
+			result = append(result, TopSongViewModel{Song: song, Plays: count.Plays, Seconds: count.Seconds})
+		}
+	}
+	return result, nil
+}
+
+// GetTopArtistViewModels loads the artists and bands behind the counts,
+// keeping their order.
+func GetTopArtistViewModels(ctx context.Context, store *repositories.Store, counts []models.PerformerPlays) ([]TopArtistViewModel, error) {
+	var artistIDs, bandIDs []int
+	for _, count := range counts {
+		if count.ArtistId != nil {
+			artistIDs = append(artistIDs, *count.ArtistId)
+		} else {
+			bandIDs = append(bandIDs, *count.BandId)
+		}
+	}
+
+	artists, err := store.Artists.GetByIDs(ctx, artistIDs)
+	if err != nil {
+		return nil, err
+	}
+	artistVMs, err := GetArtistViewModels(ctx, store, artists, "")
+	if err != nil {
+		return nil, err
+	}
+	bands, err := store.Bands.GetByIDs(ctx, bandIDs)
+	if err != nil {
+		return nil, err
+	}
+	bandVMs, err := GetBandViewModels(ctx, store, bands, "")
+	if err != nil {
+		return nil, err
+	}
+
+	items := map[string]map[int]interface{}{"artist": {}, "band": {}}
+	for _, vm := range artistVMs {
+		items["artist"][*vm.Id] = vm
+	}
+	for _, vm := range bandVMs {
+		items["band"][*vm.Id] = vm
+	}
+
+	result := make([]TopArtistViewModel, 0, len(counts))
+	for _, count := range counts {
+		kind, id := "artist", count.ArtistId
+		if id == nil {
+			kind, id = "band", count.BandId
+		}
+		if item, ok := items[kind][*id]; ok {
+			result = append(result, TopArtistViewModel{Type: kind, Item: item, Plays: count.Plays, Seconds: count.Seconds})
+		}
+	}
+	return result, nil
+}
+
+func GetListeningTimeViewModel(window string, since *time.Time, days []models.ListeningDay) ListeningTimeViewModel {
+	vm := ListeningTimeViewModel{Window: window, Since: since, Days: make([]ListeningDayViewModel, 0, len(days))}
+	for _, day := range days {
+		vm.Plays += day.Plays
+		vm.Seconds += day.Seconds
+		vm.Days = append(vm.Days, ListeningDayViewModel{Date: day.Date, Plays: day.Plays, Seconds: day.Seconds})
+	}
+	return vm
+}
@@ -0,0 +1,161 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+type PlaylistRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=200"`
+	Description string `json:"description" validate:"max=1000"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=public private"`
+}
+
+// AddTrackRequest adds a song at position, or at the end when position is
+// left out.
+type AddTrackRequest struct {
+	SongId   int `json:"song_id" validate:"required,min=1"`
+	Position int `json:"position" validate:"min=0"`
+}
+
+type MoveTrackRequest struct {
+	From int `json:"from" validate:"required,min=1"`
+	To   int `json:"to" validate:"required,min=1"`
+}
+
+// ReorderTracksRequest lists every current position in the new order, e.g.
+// [3, 1, 2] moves the third track to the top.
+type ReorderTracksRequest struct {
+	Order []int `json:"order" validate:"required,unique,dive,min=1"`
+}
+
+type InviteRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type PlaylistViewModel struct {
+	Id          int    `json:"id"`
+	OwnerId     int    `json:"owner_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+	TrackCount  int    `json:"track_count"`
+	// TotalDuration is the sum of the tracks' lengths, in seconds.
+	TotalDuration int `json:"total_duration"`
+}
+
+type DetailedPlaylistViewModel struct {
+	PlaylistViewModel
+	Tracks        []PlaylistTrackViewModel        `json:"tracks"`
+	Collaborators []PlaylistCollaboratorViewModel `json:"collaborators"`
+}
+
+type PlaylistTrackViewModel struct {
+	Position int                `json:"position"`
+	Song     BasicSongViewModel `json:"song"`
+}
+
+type PlaylistCollaboratorViewModel struct {
+	UserId   int    `json:"user_id"`
+	Username string `json:"username"`
+	Accepted bool   `json:"accepted"`
+}
+
+func GetPlaylistViewModels(ctx context.Context, store *repositories.Store, playlists []models.Playlist) ([]PlaylistViewModel, error) {
+	tracks, err := loadPlaylistTracks(ctx, store, playlists)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PlaylistViewModel, 0, len(playlists))
+	for _, playlist := range playlists {
+		result = append(result, playlistSummary(playlist, tracks[playlist.Id]))
+	}
+	return result, nil
+}
+
+func GetPlaylistViewModel(ctx context.Context, store *repositories.Store, playlist models.Playlist) (DetailedPlaylistViewModel, error) {
+	tracks, err := loadPlaylistTracks(ctx, store, []models.Playlist{playlist})
+	if err != nil {
+		return DetailedPlaylistViewModel{}, err
+	}
+
+	collaborators, err := store.Playlists.GetCollaborators(ctx, []int{playlist.Id})
+	if err != nil {
+		return DetailedPlaylistViewModel{}, err
+	}
+
+	vm := DetailedPlaylistViewModel{
+		PlaylistViewModel: playlistSummary(playlist, tracks[playlist.Id]),
+		Tracks:            tracks[playlist.Id],
+		Collaborators:     make([]PlaylistCollaboratorViewModel, 0, len(collaborators[playlist.Id])),
+	}
+	if vm.Tracks == nil {
+		vm.Tracks = []PlaylistTrackViewModel{}
+	}
+	for _, c := range collaborators[playlist.Id] {
+		vm.Collaborators = append(vm.Collaborators, PlaylistCollaboratorViewModel{UserId: c.UserId, Username: c.Username, Accepted: c.Accepted})
+	}
+
+	return vm, nil
+}
+
+func playlistSummary(playlist models.Playlist, tracks []PlaylistTrackViewModel) PlaylistViewModel {
+	vm := PlaylistViewModel{
+		Id:          playlist.Id,
+		OwnerId:     playlist.UserId,
+		Name:        playlist.Name,
+		Description: playlist.Description,
+		Visibility:  playlist.Visibility,
+		TrackCount:  len(tracks),
+	}
+	for _, track := range tracks {
+		vm.TotalDuration += int(track.Song.Length)
+	}
+	return vm
+}
+
+// loadPlaylistTracks fetches the tracks of a batch of playlists and their
+// songs with one query each, keyed by playlist ID.
+func loadPlaylistTracks(ctx context.Context, store *repositories.Store, playlists []models.Playlist) (map[int][]PlaylistTrackViewModel, error) {
+	ids := make([]int, 0, len(playlists))
+	for _, playlist := range playlists {
+		ids = append(ids, playlist.Id)
+	}
+
+	tracks, err := store.Playlists.GetTracks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var songIDs []int
+	for _, playlistTracks := range tracks {
+		for _, track := range playlistTracks {
+			songIDs = append(songIDs, track.SongId)
+		}
+	}
+	songRows, err := store.Songs.GetByIDs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+	songs := map[int]models.Song{}
+	for _, song := range songRows {
+		songs[song.Id] = song
+	}
+
+	result := map[int][]PlaylistTrackViewModel{}
+	for playlistID, playlistTracks := range tracks {
+		for _, track := range playlistTracks {
+			song, ok := songs[track.SongId]
+			if !ok {
+				continue
+			}
+			result[playlistID] = append(result[playlistID], PlaylistTrackViewModel{
+				Position: track.Position,
+				Song:     GetBasicSongViewModel(song),
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+)
+
+// RecommendationViewModel is a suggested song. Score only orders the
+// suggestions, higher being a better match.
+type RecommendationViewModel struct {
+	Song   BasicSongViewModel `json:"song"`
+	Score  float64            `json:"score"`
+	Reason string             `json:"reason"`
+}
+
+// GetRecommendationViewModels loads the recommended songs, keeping their
+// order.
+func GetRecommendationViewModels(ctx context.Context, store *repositories.Store, recommendations []models.Recommendation) ([]RecommendationViewModel, error) {
+	ids := make([]int, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.SongId)
+	}
+	songs, err := store.Songs.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]models.Song{}
+	for _, song := range songs {
+		byID[song.Id] = song
+	}
+
+	result := make([]RecommendationViewModel, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		if song, ok := byID[recommendation.SongId]; ok {
+			result = append(result, RecommendationViewModel{
+				Song: GetBasicSongViewModel(song), Score: recommendation.Score, Reason: recommendation.Reason,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package viewModels
+
+import (
+	"goMusic/models"
+	"time"
+)
+
+// ReviewRequest rates an album or song, with optional text.
+type ReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Body   string `json:"body" validate:"max=10000"`
+}
+
+// ReviewViewModel is a review with its author. Exactly one of AlbumId and
+// SongId is set.
+type ReviewViewModel struct {
+	Id        int       `json:"id"`
+	UserId    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	AlbumId   *int      `json:"album_id,omitempty"`
+	SongId    *int      `json:"song_id,omitempty"`
+	Rating    int       `json:"rating"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func GetReviewViewModel(review models.Review) ReviewViewModel {
+	return ReviewViewModel{
+		Id:        review.Id,
+		UserId:    review.UserId,
+		Username:  review.Username,
+		AlbumId:   review.AlbumId,
+		SongId:    review.SongId,
+		Rating:    review.Rating,
+		Body:      review.Body,
+		CreatedAt: review.CreatedAt.UTC(),
+		UpdatedAt: review.UpdatedAt.UTC(),
+	}
+}
+
+func GetReviewViewModels(reviews []models.Review) []ReviewViewModel {
+	result := make([]ReviewViewModel, 0, len(reviews))
+	for _, review := range reviews {
+		result = append(result, GetReviewViewModel(review))
+	}
+	return result
+}
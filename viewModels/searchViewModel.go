@@ -0,0 +1,79 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/repositories"
+)
+
+// SearchResultViewModel is one search hit. Item holds the Basic*ViewModel of
+// the matched entity, as named by Type.
+type SearchResultViewModel struct {
+	Type    string      `json:"type"`
+	Score   float64     `json:"score"`
+	Snippet string      `json:"snippet"`
+	Item    interface{} `json:"item"`
+}
+
+// GetSearchResultViewModels loads the entities behind a set of hits with one
+// query per type. Hits whose entity no longer exists are dropped.
+func GetSearchResultViewModels(ctx context.Context, store *repositories.Store, hits []repositories.SearchHit) ([]SearchResultViewModel, error) {
+	ids := map[string][]int{}
+	for _, hit := range hits {
+		ids[hit.Type] = append(ids[hit.Type], hit.ID)
+	}
+
+	items := map[string]map[int]interface{}{
+		repositories.SearchAlbum:  {},
+		repositories.SearchArtist: {},
+		repositories.SearchBand:   {},
+		repositories.SearchSong:   {},
+	}
+
+	albums, err := store.Albums.GetByIDs(ctx, ids[repositories.SearchAlbum])
+	if err != nil {
+		return nil, err
+	}
+	for _, album := range albums {
+		items[repositories.SearchAlbum][album.Id] = GetBasicAlbumViewModel(album)
+	}
+
+	artists, err := store.Artists.GetByIDs(ctx, ids[repositories.SearchArtist])
+	if err != nil {
+		return nil, err
+	}
+	for _, artist := range artists {
+		items[repositories.SearchArtist][artist.Id] = GetBasicArtistViewModel(artist)
+	}
+
+	bands, err := store.Bands.GetByIDs(ctx, ids[repositories.SearchBand])
+	if err != nil {
+		return nil, err
+	}
+	for _, band := range bands {
+		items[repositories.SearchBand][band.Id] = GetBasicBandViewModel(band)
+	}
+
+	songs, err := store.Songs.GetByIDs(ctx, ids[repositories.SearchSong])
+	if err != nil {
+		return nil, err
+	}
+	for _, song := range songs {
+		items[repositories.SearchSong][song.Id] = GetBasicSongViewModel(song)
+	}
+
+	result := make([]SearchResultViewModel, 0, len(hits))
+	for _, hit := range hits {
+		item, ok := items[hit.Type][hit.ID]
+		if !ok {
+			continue
+		}
+		result = append(result, SearchResultViewModel{
+			Type:    hit.Type,
+			Score:   hit.Score,
+			Snippet: hit.Snippet,
+			Item:    item,
+		})
+	}
+
+	return result, nil
+}
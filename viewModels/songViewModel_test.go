@@ -0,0 +1,277 @@
+package viewModels_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"goMusic/db"
+	"goMusic/models"
+	"goMusic/repositories"
+	"goMusic/viewModels"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSongViewModelsUsesFixedQueryCount(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	songs := []models.Song{
+		{Id: 1, Title: "Yellow", Length: 266, Price: 129},
+		{Id: 2, Title: "Trouble", Length: 273, Price: 129},
+		{Id: 3, Title: "Creep", Length: 238, Price: 99},
+	}
+
+	// One query per join table, whatever the number of songs.
+	mock.ExpectQuery(`FROM albums a JOIN album_songs sa ON a.id = sa.album_id WHERE sa.song_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"song_id", "id", "title", "price", "label_id", "release_date", "catalog_number", "barcode", "format"}).
+			AddRow(1, 1, "Parachutes", 999, nil, nil, nil, nil, nil).
+			AddRow(2, 1, "Parachutes", 999, nil, nil, nil, nil, nil).
+			AddRow(3, 2, "Pablo Honey", 899, nil, nil, nil, nil, nil))
+	mock.ExpectQuery(`FROM credits WHERE song_id IN \(\?, \?, \?\) ORDER BY song_id, id`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "album_id", "song_id", "artist_id", "band_id", "role"}).
+			AddRow(1, nil, 1, nil, 1, "performer").
+			AddRow(2, nil, 2, nil, 1, "performer").
+			AddRow(3, nil, 3, nil, 2, "performer").
+			AddRow(4, nil, 3, 4, nil, "lyricist"))
+	// The credited artists and bands are fetched once for the whole batch.
+	mock.ExpectQuery(`FROM artists WHERE id IN \(\?\)`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "death_date", "sex_id", "title_id"}).
+			AddRow(4, "Thom", "Yorke", "British", "1968-10-07", nil, 1, 1))
+	mock.ExpectQuery(`FROM bands WHERE id IN \(\?, \?\)`).
+		WithArgs(models.Today(), models.Today(), 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "disbanded_date"}).
+			AddRow(1, "Coldplay", "British", 4, "1996-01-16", nil).
+			AddRow(2, "Radiohead", "British", 5, "1985-01-01", nil))
+	mock.ExpectQuery(`SELECT id, rating_count, rating_sum FROM songs WHERE id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rating_count", "rating_sum"}).
+			AddRow(1, 0, 0).
+			AddRow(2, 3, 11).
+			AddRow(3, 0, 0))
+	mock.ExpectQuery(`FROM genres g JOIN song_genres l ON g.id = l.genre_id WHERE l.song_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"song_id", "id", "name", "parent_id"}).
+			AddRow(3, 7, "Alternative Rock", 6))
+	mock.ExpectQuery(`FROM tags t JOIN song_tags l ON t.id = l.tag_id WHERE l.song_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"song_id", "name"}).
+			AddRow(1, "britpop"))
+
+	result, err := viewModels.GetSongViewModels(context.Background(), repositories.NewSQLiteStore(mockDB), songs)
+	if err != nil {
+		t.Fatalf("GetSongViewModels returned error: %v", err)
+	}
+
+	if len(result) != 3 {
+		t.Fatalf("expected 3 view models, got %d", len(result))
+	}
+	if result[0].Albums == nil || (*result[0].Albums)[0].Title != "Parachutes" ||
+		len(result[0].Credits) != 1 || result[0].Credits[0].Band == nil || result[0].Credits[0].Band.Name != "Coldplay" {
+		t.Errorf("wrong relationships for song 1: %+v", result[0])
+	}
+	if credits := result[2].Credits; len(credits) != 2 || credits[0].Band.Name != "Radiohead" ||
+		credits[1].Artist == nil || credits[1].Artist.LastName != "Yorke" || credits[1].Role != "lyricist" {
+		t.Errorf("wrong relationships for song 3: %+v", result[2])
+	}
+	if len(result[2].Genres) != 1 || result[2].Genres[0].Name != "Alternative Rock" || len(result[2].Tags) != 0 {
+		t.Errorf("wrong genres and tags for song 3: %+v", result[2])
+	}
+	if len(result[0].Tags) != 1 || result[0].Tags[0] != "britpop" || result[0].Genres == nil {
+		t.Errorf("wrong genres and tags for song 1: %+v", result[0])
+	}
+	if result[0].AverageRating != nil || result[1].RatingCount != 3 || result[1].AverageRating == nil || *result[1].AverageRating != 3.67 {
+		t.Errorf("wrong ratings: %v, %d, %v", result[0].AverageRating, result[1].RatingCount, result[1].AverageRating)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAlbumViewModelsBatchesRelations(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	parlophone, xl := 7, 8
+	albums := []models.Album{
+		{Id: 1, Title: "Parachutes", Price: 999, LabelId: &parlophone},
+		{Id: 2, Title: "The Eraser", Price: 999, LabelId: &xl},
+		{Id: 3, Title: "OK Computer", Price: 999, LabelId: &parlophone},
+	}
+
+	mock.ExpectQuery(`FROM credits WHERE album_id IN \(\?, \?, \?\) ORDER BY album_id, id`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "album_id", "song_id", "artist_id", "band_id", "role"}).
+			AddRow(1, 1, nil, nil, 2, "performer").
+			AddRow(2, 2, nil, 4, nil, "performer").
+			AddRow(3, 3, nil, nil, 2, "performer"))
+	mock.ExpectQuery(`FROM artists WHERE id IN \(\?\)`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "nationality", "birth_date", "death_date", "sex_id", "title_id"}).
+			AddRow(4, "Thom", "Yorke", "British", "1968-10-07", nil, 1, 1))
+	mock.ExpectQuery(`FROM bands WHERE id IN \(\?\)`).
+		WithArgs(models.Today(), models.Today(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "nationality", "number_of_members", "date_formed", "disbanded_date"}).
+			AddRow(2, "Radiohead", "British", 5, "1985-01-01", nil))
+	mock.ExpectQuery(`FROM album_covers WHERE album_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"album_id", "variant", "digest", "mime_type", "width", "height", "uploaded_at"}).
+			AddRow(3, "original", "0123456789abcdef0123", "image/png", 500, 500, 1700000000))
+	mock.ExpectQuery(`FROM genres g JOIN album_genres l ON g.id = l.genre_id WHERE l.album_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"album_id", "id", "name", "parent_id"}))
+	mock.ExpectQuery(`FROM tags t JOIN album_tags l ON t.id = l.tag_id WHERE l.album_id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"album_id", "name"}))
+	mock.ExpectQuery(`SELECT id, rating_count, rating_sum FROM albums WHERE id IN \(\?, \?, \?\)`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rating_count", "rating_sum"}).
+			AddRow(1, 0, 0).
+			AddRow(2, 0, 0).
+			AddRow(3, 2, 9))
+	mock.ExpectQuery(`FROM labels WHERE id IN \(\?, \?\)`).
+		WithArgs(7, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "country"}).
+			AddRow(7, "Parlophone", "United Kingdom").
+			AddRow(8, "XL Recordings", nil))
+
+	result, err := viewModels.GetAlbumViewModels(context.Background(), repositories.NewSQLiteStore(mockDB), albums)
+	if err != nil {
+		t.Fatalf("GetAlbumViewModels returned error: %v", err)
+	}
+
+	if len(result[0].Credits) != 1 || result[0].Credits[0].Band.Name != "Radiohead" || len(result[2].Credits) != 1 {
+		t.Errorf("bands were not credited: %+v", result)
+	}
+	if len(result[1].Credits) != 1 || result[1].Credits[0].Artist == nil || result[1].Credits[0].Artist.LastName != "Yorke" {
+		t.Errorf("artist was not credited: %+v", result[1])
+	}
+	if result[0].CoverURLs != nil || result[2].CoverURLs["original"] != "/albums/3/cover/original?v=0123456789abcdef" {
+		t.Errorf("covers were not attached: %+v", result)
+	}
+	if result[0].AverageRating != nil || result[2].RatingCount != 2 || *result[2].AverageRating != 4.5 {
+		t.Errorf("wrong ratings: %+v", result)
+	}
+	if result[0].Label == nil || result[0].Label.Name != "Parlophone" || result[1].Label.Name != "XL Recordings" || result[2].Label.Id != 7 {
+		t.Errorf("labels were not attached: %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// openBenchmarkDB migrates a temporary SQLite database and fills it with
+// songCount songs, each on an album and credited to an artist and a band.
+func openBenchmarkDB(b *testing.B, songCount int) (*sql.DB, []models.Song) {
+	b.Helper()
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(b.TempDir(), "bench.db")+"?_foreign_keys=on")
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	b.Cleanup(func() { sqlDB.Close() })
+
+	migrations, err := db.Migrations()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := db.NewMigrator(sqlDB, migrations).Up(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO sexes (id, name) VALUES (1, 'Male')"); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO titles (id, name) VALUES (1, 'Mr.')"); err != nil {
+		b.Fatal(err)
+	}
+	statements := []string{
+		"INSERT INTO bands (id, name, nationality, date_formed) VALUES (?, ?, 'British', '1996-01-16')",
+		"INSERT INTO artists (id, first_name, last_name, nationality, birth_date, sex_id, title_id) VALUES (?, ?, 'Surname', 'British', '1977-03-02', 1, 1)",
+		"INSERT INTO albums (id, title, price) VALUES (?, ?, 999)",
+		"INSERT INTO songs (id, title, length, price) VALUES (?, ?, 200, 99)",
+		"INSERT INTO album_songs (album_id, song_id) VALUES (?, ?)",
+		"INSERT INTO credits (artist_id, song_id, role) VALUES (?, ?, 'performer')",
+		"INSERT INTO credits (band_id, song_id, role) VALUES (?, ?, 'performer')",
+	}
+	songs := make([]models.Song, 0, songCount)
+	for id := 1; id <= songCount; id++ {
+		name := fmt.Sprintf("Name %d", id)
+		for i, statement := range statements {
+			args := []interface{}{id, name}
+			if i >= 4 {
+				args = []interface{}{id, id}
+			}
+			if _, err := tx.Exec(statement, args...); err != nil {
+				b.Fatal(err)
+			}
+		}
+		songs = append(songs, models.Song{Id: id, Title: name, Length: 200, Price: 99})
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	return sqlDB, songs
+}
+
+func BenchmarkGetSongViewModels(b *testing.B) {
+	sqlDB, songs := openBenchmarkDB(b, 1000)
+	store := repositories.NewSQLiteStore(sqlDB)
+	ctx := context.Background()
+
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := viewModels.GetSongViewModels(ctx, store, songs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// per-song issues the relationship queries for every song, the way the
+	// view models were built before batching.
+	b.Run("per-song", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, song := range songs {
+				ids := []int{song.Id}
+				if _, err := store.Albums.GetBySongIDs(ctx, ids); err != nil {
+					b.Fatal(err)
+				}
+				credits, err := store.Credits.GetBySongIDs(ctx, ids)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, credit := range credits[song.Id] {
+					if credit.ArtistId != nil {
+						if _, err := store.Artists.GetByID(ctx, *credit.ArtistId); err != nil {
+							b.Fatal(err)
+						}
+					}
+					if credit.BandId != nil {
+						if _, err := store.Bands.GetByID(ctx, *credit.BandId); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			}
+		}
+	})
+}
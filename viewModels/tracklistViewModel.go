@@ -0,0 +1,125 @@
+package viewModels
+
+import (
+	"context"
+	"goMusic/models"
+	"goMusic/repositories"
+	"strings"
+)
+
+// GetAlbumTracklist lists an album's songs for export, credited to their
+// performers.
+func GetAlbumTracklist(ctx context.Context, store *repositories.Store, album models.Album) (models.Tracklist, error) {
+	credits, err := loadAlbumCredits(ctx, store, []int{album.Id})
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	songs, err := store.Songs.GetByAlbumID(ctx, album.Id)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	tracks, err := tracklistTracks(ctx, store, songs)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+
+	for i := range tracks {
+		tracks[i].Album = album.Title
+	}
+	return models.Tracklist{Title: album.Title, Creator: performerNames(credits[album.Id]), Tracks: tracks}, nil
+}
+
+// GetPlaylistTracklist lists a playlist's songs for export in position
+// order. Each song is given the first album it appears on, and the
+// playlist is credited to its owner.
+func GetPlaylistTracklist(ctx context.Context, store *repositories.Store, playlist models.Playlist) (models.Tracklist, error) {
+	owner, err := store.Users.GetByID(ctx, playlist.UserId)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	playlistTracks, err := store.Playlists.GetTracks(ctx, []int{playlist.Id})
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+
+	var songIDs []int
+	for _, track := range playlistTracks[playlist.Id] {
+		songIDs = append(songIDs, track.SongId)
+	}
+	songRows, err := store.Songs.GetByIDs(ctx, songIDs)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	byID := map[int]models.Song{}
+	for _, song := range songRows {
+		byID[song.Id] = song
+	}
+	var songs []models.Song
+	for _, id := range songIDs {
+		if song, ok := byID[id]; ok {
+			songs = append(songs, song)
+		}
+	}
+
+	tracks, err := tracklistTracks(ctx, store, songs)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	albums, err := store.Albums.GetBySongIDs(ctx, songIDs)
+	if err != nil {
+		return models.Tracklist{}, err
+	}
+	for i := range tracks {
+		if onAlbums := albums[tracks[i].SongId]; len(onAlbums) > 0 {
+			tracks[i].Album = onAlbums[0].Title
+		}
+	}
+	return models.Tracklist{Title: playlist.Name, Creator: owner.Username, Tracks: tracks}, nil
+}
+
+// tracklistTracks numbers the songs from 1 and credits each to its
+// performers.
+func tracklistTracks(ctx context.Context, store *repositories.Store, songs []models.Song) ([]models.TracklistTrack, error) {
+	ids := make([]int, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.Id)
+	}
+	credits, err := store.Credits.GetBySongIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := loadCredits(ctx, store, credits)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]models.TracklistTrack, 0, len(songs))
+	for i, song := range songs {
+		tracks = append(tracks, models.TracklistTrack{
+			SongId:   song.Id,
+			Title:    song.Title,
+			Creator:  performerNames(resolved[song.Id]),
+			Length:   song.Length,
+			TrackNum: i + 1,
+		})
+	}
+	return tracks, nil
+}
+
+// performerNames joins the names of the artists and bands credited as
+// performers.
+func performerNames(credits []CreditViewModel) string {
+	var names []string
+	for _, credit := range credits {
+		if credit.Role != models.RolePerformer {
+			continue
+		}
+		if credit.Artist != nil {
+			names = append(names, credit.Artist.FirstName+" "+credit.Artist.LastName)
+		}
+		if credit.Band != nil {
+			names = append(names, credit.Band.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
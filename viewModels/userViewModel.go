@@ -13,7 +13,17 @@ type RegisterRequest struct {
 	Email    string `json:"email"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    int         `json:"expires_in"`
+	User         models.User `json:"user"`
 }